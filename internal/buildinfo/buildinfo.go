@@ -0,0 +1,56 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// version can be stamped at link time with
+// -ldflags "-X github.com/fpp-125/metaclaw/internal/buildinfo.version=v1.2.3";
+// otherwise the module version from the build info is used.
+var version string
+
+type Info struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"goVersion"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	Modified  bool   `json:"modified"`
+}
+
+// Read reports the running binary's version and VCS metadata. Fields the
+// toolchain did not record (e.g. `go run` without VCS stamping) are "unknown"
+// or empty.
+func Read() Info {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fromBuildInfo(nil)
+	}
+	return fromBuildInfo(bi)
+}
+
+func fromBuildInfo(bi *debug.BuildInfo) Info {
+	info := Info{Version: "unknown", GoVersion: runtime.Version()}
+	if bi != nil {
+		if bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+		if bi.GoVersion != "" {
+			info.GoVersion = bi.GoVersion
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				info.Commit = s.Value
+			case "vcs.time":
+				info.BuildTime = s.Value
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	if version != "" {
+		info.Version = version
+	}
+	return info
+}
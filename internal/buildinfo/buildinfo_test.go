@@ -0,0 +1,26 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.25.7",
+		Main:      debug.Module{Path: "github.com/fpp-125/metaclaw", Version: "v0.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2026-02-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+	got := fromBuildInfo(bi)
+	want := Info{Version: "v0.4.0", GoVersion: "go1.25.7", Commit: "abc123", BuildTime: "2026-02-01T10:00:00Z", Modified: true}
+	if got != want {
+		t.Fatalf("fromBuildInfo() = %+v, want %+v", got, want)
+	}
+	if got := fromBuildInfo(nil); got.Version != "unknown" || got.GoVersion == "" {
+		t.Fatalf("fromBuildInfo(nil) = %+v", got)
+	}
+}
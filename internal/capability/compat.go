@@ -0,0 +1,119 @@
+package capability
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed MAJOR.MINOR.PATCH[-PRERELEASE] version. Build metadata
+// is dropped since it carries no precedence.
+type semver struct {
+	major, minor, patch int
+	pre                 []string
+}
+
+func parseSemver(v string) (semver, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	core, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semver{}, fmt.Errorf("invalid semantic version %q (want x.y.z)", v)
+	}
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (len(p) > 1 && p[0] == '0') {
+			return semver{}, fmt.Errorf("invalid semantic version %q (want x.y.z)", v)
+		}
+		nums[i] = n
+	}
+	out := semver{major: nums[0], minor: nums[1], patch: nums[2]}
+	if hasPre {
+		if pre == "" {
+			return semver{}, fmt.Errorf("invalid semantic version %q: empty prerelease", v)
+		}
+		out.pre = strings.Split(pre, ".")
+	}
+	return out, nil
+}
+
+// compare orders versions by semver precedence: a prerelease sorts before the
+// release it precedes, and numeric identifiers compare numerically.
+func (a semver) compare(b semver) int {
+	for _, d := range []int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case len(a.pre) == 0 && len(b.pre) == 0:
+		return 0
+	case len(a.pre) == 0:
+		return 1
+	case len(b.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.pre) && i < len(b.pre); i++ {
+		x, y := a.pre[i], b.pre[i]
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil:
+			if xn != yn {
+				return sign(xn - yn)
+			}
+		case xErr == nil:
+			return -1
+		case yErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(a.pre) - len(b.pre))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// parseMinVersion accepts ">=x.y.z" or a bare "x.y.z" (read as a minimum).
+func parseMinVersion(constraint string) (semver, error) {
+	c := strings.TrimSpace(constraint)
+	c = strings.TrimSpace(strings.TrimPrefix(c, ">="))
+	return parseSemver(c)
+}
+
+// ValidateCompatibility checks compatibility.minMetaclawVersion against the
+// running engine version. Engines without a release version (e.g. "unknown"
+// or "(devel)" from a source build) are not held to the minimum.
+func ValidateCompatibility(c Contract, engineVersion string) error {
+	constraint := strings.TrimSpace(c.Compatibility.MinMetaclawVersion)
+	if constraint == "" {
+		return nil
+	}
+	min, err := parseMinVersion(constraint)
+	if err != nil {
+		return fmt.Errorf("compatibility.minMetaclawVersion: %w", err)
+	}
+	engine, err := parseSemver(engineVersion)
+	if err != nil {
+		return nil
+	}
+	if engine.compare(min) < 0 {
+		return fmt.Errorf("skill requires metaclaw %s but this engine is %s", constraint, engineVersion)
+	}
+	return nil
+}
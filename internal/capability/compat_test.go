@@ -0,0 +1,58 @@
+package capability
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateCompatibilityMinMetaclawVersion(t *testing.T) {
+	c := Contract{Compatibility: Compatibility{MinMetaclawVersion: ">=0.5.0"}}
+	cases := []struct {
+		engine string
+		ok     bool
+	}{
+		{"v0.5.0", true},
+		{"v0.10.0", true},
+		{"v1.0.0-rc.1", true},
+		{"v0.4.9", false},
+		{"v0.5.0-rc.1", false},
+		{"v0.5.0+dirty", true},
+		{"(devel)", true},
+		{"unknown", true},
+	}
+	for _, tc := range cases {
+		err := ValidateCompatibility(c, tc.engine)
+		if (err == nil) != tc.ok {
+			t.Fatalf("ValidateCompatibility(%s) error = %v, want ok=%v", tc.engine, err, tc.ok)
+		}
+		if err != nil && !strings.Contains(err.Error(), "requires metaclaw >=0.5.0") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := ValidateCompatibility(Contract{Compatibility: Compatibility{MinMetaclawVersion: "0.5.0"}}, "v0.4.0"); err == nil {
+		t.Fatal("expected a bare version to act as a minimum")
+	}
+}
+
+func TestSemverPrereleasePrecedence(t *testing.T) {
+	order := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0"}
+	for i := 1; i < len(order); i++ {
+		a, _ := parseSemver(order[i-1])
+		b, _ := parseSemver(order[i])
+		if a.compare(b) >= 0 {
+			t.Fatalf("expected %s < %s", order[i-1], order[i])
+		}
+	}
+}
+
+func TestValidateRejectsMalformedMinMetaclawVersion(t *testing.T) {
+	c := Contract{
+		APIVersion:    ContractAPIVersion,
+		Kind:          ContractKind,
+		Metadata:      Metadata{Name: "s", Version: "v1.0.0"},
+		Compatibility: Compatibility{MinMetaclawVersion: ">=0.5"},
+	}
+	if err := Validate(c); err == nil || !strings.Contains(err.Error(), "minMetaclawVersion") {
+		t.Fatalf("expected minMetaclawVersion error, got %v", err)
+	}
+}
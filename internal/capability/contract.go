@@ -109,20 +109,29 @@ func LoadFromSkillPath(skillPath string) (Contract, string, error) {
 	if !ok {
 		return Contract{}, "", fmt.Errorf("missing capability contract (expected capability.contract.yaml|yml|json)")
 	}
+	c, err := LoadFile(contractPath)
+	if err != nil {
+		return Contract{}, "", err
+	}
+	return c, contractPath, nil
+}
+
+// LoadFile parses and validates a contract file directly, without skill-dir discovery.
+func LoadFile(contractPath string) (Contract, error) {
 	b, err := os.ReadFile(contractPath)
 	if err != nil {
-		return Contract{}, "", fmt.Errorf("read capability contract: %w", err)
+		return Contract{}, fmt.Errorf("read capability contract: %w", err)
 	}
 	var c Contract
 	dec := yaml.NewDecoder(bytes.NewReader(b))
 	dec.KnownFields(true)
 	if err := dec.Decode(&c); err != nil {
-		return Contract{}, "", fmt.Errorf("parse capability contract (%s): %w", filepath.Base(contractPath), err)
+		return Contract{}, fmt.Errorf("parse capability contract (%s): %w", filepath.Base(contractPath), err)
 	}
 	if err := Validate(c); err != nil {
-		return Contract{}, "", err
+		return Contract{}, err
 	}
-	return c, contractPath, nil
+	return c, nil
 }
 
 func Validate(c Contract) error {
@@ -177,6 +186,11 @@ func Validate(c Contract) error {
 		return err
 	}
 
+	if v := strings.TrimSpace(c.Compatibility.MinMetaclawVersion); v != "" {
+		if _, err := parseMinVersion(v); err != nil {
+			return fmt.Errorf("capability contract compatibility.minMetaclawVersion: %w", err)
+		}
+	}
 	for _, rt := range c.Compatibility.RuntimeTargets {
 		rt = strings.TrimSpace(rt)
 		if rt == "" {
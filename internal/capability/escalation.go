@@ -0,0 +1,74 @@
+package capability
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Escalation is a permission the newer contract grants that the older one did not.
+type Escalation struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// Escalations lists security-relevant widenings between two contract versions:
+// a higher network rank, mounts that are new or turned read-write, and new
+// secret or env names.
+func Escalations(from, to Contract) []Escalation {
+	var out []Escalation
+
+	oldNet, newNet := contractNetwork(from), contractNetwork(to)
+	if networkRank(newNet) > networkRank(oldNet) {
+		out = append(out, Escalation{Kind: "network", Detail: fmt.Sprintf("%s -> %s", oldNet, newNet)})
+	}
+
+	oldMounts := make(map[string]string, len(from.Permissions.Mounts))
+	for _, m := range from.Permissions.Mounts {
+		oldMounts[strings.TrimSpace(m.Target)] = strings.TrimSpace(m.Access)
+	}
+	for _, m := range to.Permissions.Mounts {
+		target := strings.TrimSpace(m.Target)
+		access := strings.TrimSpace(m.Access)
+		prev, existed := oldMounts[target]
+		switch {
+		case !existed:
+			out = append(out, Escalation{Kind: "mount", Detail: fmt.Sprintf("new %s mount %s", access, target)})
+		case prev != "rw" && access == "rw":
+			out = append(out, Escalation{Kind: "mount", Detail: fmt.Sprintf("mount %s changed %s -> rw", target, prev)})
+		}
+	}
+
+	for _, name := range addedNames(from.Permissions.Secrets, to.Permissions.Secrets) {
+		out = append(out, Escalation{Kind: "secret", Detail: "new secret " + name})
+	}
+	for _, name := range addedNames(from.Permissions.Env, to.Permissions.Env) {
+		out = append(out, Escalation{Kind: "env", Detail: "new env " + name})
+	}
+	return out
+}
+
+func contractNetwork(c Contract) string {
+	if n := strings.TrimSpace(c.Permissions.Network); n != "" {
+		return n
+	}
+	return "none"
+}
+
+func addedNames(before, after []string) []string {
+	seen := make(map[string]struct{}, len(before))
+	for _, v := range before {
+		seen[strings.TrimSpace(v)] = struct{}{}
+	}
+	var out []string
+	for _, v := range after {
+		v = strings.TrimSpace(v)
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,36 @@
+package capability
+
+import "testing"
+
+func TestEscalations(t *testing.T) {
+	from := Contract{Permissions: Permissions{
+		Network: "none",
+		Mounts:  []MountPermission{{Target: "/vault", Access: "ro"}, {Target: "/cache", Access: "rw"}},
+		Secrets: []string{"OPENAI_API_KEY"},
+	}}
+	to := Contract{Permissions: Permissions{
+		Network: "outbound",
+		Mounts:  []MountPermission{{Target: "/vault", Access: "rw"}, {Target: "/cache", Access: "rw"}, {Target: "/out", Access: "ro"}},
+		Env:     []string{"VAULT_DIR"},
+		Secrets: []string{"OPENAI_API_KEY", "TAVILY_API_KEY"},
+	}}
+	got := Escalations(from, to)
+	want := []Escalation{
+		{Kind: "network", Detail: "none -> outbound"},
+		{Kind: "mount", Detail: "mount /vault changed ro -> rw"},
+		{Kind: "mount", Detail: "new ro mount /out"},
+		{Kind: "secret", Detail: "new secret TAVILY_API_KEY"},
+		{Kind: "env", Detail: "new env VAULT_DIR"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Escalations() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Escalations()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if esc := Escalations(to, from); len(esc) != 0 {
+		t.Fatalf("narrowing must not report escalations, got %+v", esc)
+	}
+}
@@ -0,0 +1,32 @@
+package capability
+
+import "strings"
+
+// LintWarning is a non-fatal finding about an otherwise valid contract.
+type LintWarning struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+const (
+	LintEmptyInterface        = "empty_interface"
+	LintMissingRequiredEvents = "missing_required_events"
+	LintMissingDescription    = "missing_description"
+)
+
+// Lint reports gaps that Validate accepts but that leave a contract hard to
+// consume: no declared inputs/outputs, no required events, no description.
+func Lint(c Contract) []LintWarning {
+	var out []LintWarning
+	if strings.TrimSpace(c.Metadata.Description) == "" {
+		out = append(out, LintWarning{Code: LintMissingDescription, Field: "metadata.description", Message: "metadata.description is empty"})
+	}
+	if len(c.Interface.Inputs) == 0 && len(c.Interface.Outputs) == 0 {
+		out = append(out, LintWarning{Code: LintEmptyInterface, Field: "interface", Message: "interface declares no inputs or outputs"})
+	}
+	if len(c.Observability.RequiredEvents) == 0 {
+		out = append(out, LintWarning{Code: LintMissingRequiredEvents, Field: "observability.requiredEvents", Message: "observability.requiredEvents is empty; runs cannot be checked for expected events"})
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package capability
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RegistryEntryPath returns the directory holding skill id at version in a
+// local skill registry laid out as <registryDir>/<id>@<version>/.
+func RegistryEntryPath(registryDir, id, version string) (string, error) {
+	id, version = strings.TrimSpace(id), strings.TrimSpace(version)
+	if id == "" || version == "" {
+		return "", fmt.Errorf("skill registry lookup needs both id and version")
+	}
+	name := id + "@" + version
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("skill id %q escapes the skill registry", id)
+	}
+	return filepath.Join(registryDir, clean), nil
+}
+
+// LoadFromRegistry locates id@version in a local skill registry and loads its
+// capability contract. It returns the entry directory alongside the contract.
+func LoadFromRegistry(registryDir, id, version string) (Contract, string, error) {
+	entry, err := RegistryEntryPath(registryDir, id, version)
+	if err != nil {
+		return Contract{}, "", err
+	}
+	st, err := os.Stat(entry)
+	if err != nil || !st.IsDir() {
+		return Contract{}, "", fmt.Errorf("skill %s@%s not found in registry %s", id, version, registryDir)
+	}
+	c, _, err := LoadFromSkillPath(entry)
+	if err != nil {
+		return Contract{}, "", err
+	}
+	return c, entry, nil
+}
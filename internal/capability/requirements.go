@@ -0,0 +1,90 @@
+package capability
+
+import (
+	"sort"
+	"strings"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+)
+
+// Requirements is the union of permissions demanded by a set of skill contracts.
+type Requirements struct {
+	Skills  []string          `json:"skills"`
+	Network string            `json:"network"`
+	Mounts  []MountPermission `json:"mounts"`
+	Env     []string          `json:"env"`
+	Secrets []string          `json:"secrets"`
+}
+
+// Combine merges contracts into one set of requirements: the highest network
+// rank wins, a mount is rw and required if any contract asks for that, and env
+// and secret names are unioned.
+func Combine(contracts []Contract) Requirements {
+	out := Requirements{Skills: []string{}, Network: "none", Mounts: []MountPermission{}, Env: []string{}, Secrets: []string{}}
+	mounts := map[string]MountPermission{}
+	env := map[string]struct{}{}
+	secrets := map[string]struct{}{}
+	for _, c := range contracts {
+		out.Skills = append(out.Skills, c.Metadata.Name+"@"+c.Metadata.Version)
+		if n := contractNetwork(c); networkRank(n) > networkRank(out.Network) {
+			out.Network = n
+		}
+		for _, m := range c.Permissions.Mounts {
+			target := strings.TrimSpace(m.Target)
+			merged, ok := mounts[target]
+			if !ok {
+				merged = MountPermission{Target: target, Access: "ro"}
+			}
+			if strings.TrimSpace(m.Access) == "rw" {
+				merged.Access = "rw"
+			}
+			merged.Required = merged.Required || m.Required
+			mounts[target] = merged
+		}
+		for _, k := range c.Permissions.Env {
+			env[strings.TrimSpace(k)] = struct{}{}
+		}
+		for _, k := range c.Permissions.Secrets {
+			secrets[strings.TrimSpace(k)] = struct{}{}
+		}
+	}
+	for _, m := range mounts {
+		out.Mounts = append(out.Mounts, m)
+	}
+	sort.Slice(out.Mounts, func(i, j int) bool { return out.Mounts[i].Target < out.Mounts[j].Target })
+	out.Env = sortedKeys(env)
+	out.Secrets = sortedKeys(secrets)
+	return out
+}
+
+// Unsatisfied checks every combined requirement against the agent grants and
+// returns one reason per requirement the agent does not meet.
+func (r Requirements) Unsatisfied(agent v1.AgentSpec) []string {
+	var probes []Permissions
+	probes = append(probes, Permissions{Network: r.Network})
+	for _, m := range r.Mounts {
+		probes = append(probes, Permissions{Mounts: []MountPermission{m}})
+	}
+	for _, k := range r.Env {
+		probes = append(probes, Permissions{Env: []string{k}})
+	}
+	for _, k := range r.Secrets {
+		probes = append(probes, Permissions{Secrets: []string{k}})
+	}
+	out := []string{}
+	for _, p := range probes {
+		if err := ValidateAgainstAgent(Contract{Permissions: p}, agent); err != nil {
+			out = append(out, err.Error())
+		}
+	}
+	return out
+}
+
+func sortedKeys(in map[string]struct{}) []string {
+	out := make([]string, 0, len(in))
+	for k := range in {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
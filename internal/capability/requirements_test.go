@@ -0,0 +1,51 @@
+package capability
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+)
+
+func TestCombineAndUnsatisfied(t *testing.T) {
+	a := Contract{
+		Metadata:    Metadata{Name: "notes", Version: "v1"},
+		Permissions: Permissions{Network: "none", Mounts: []MountPermission{{Target: "/vault", Access: "ro"}}, Env: []string{"VAULT_DIR"}},
+	}
+	b := Contract{
+		Metadata: Metadata{Name: "fetch", Version: "v2"},
+		Permissions: Permissions{
+			Network: "outbound",
+			Mounts:  []MountPermission{{Target: "/vault", Access: "rw", Required: true}},
+			Secrets: []string{"API_TOKEN"},
+		},
+	}
+	req := Combine([]Contract{a, b})
+	if req.Network != "outbound" {
+		t.Fatalf("network = %q, want outbound", req.Network)
+	}
+	if len(req.Mounts) != 1 || req.Mounts[0].Access != "rw" || !req.Mounts[0].Required {
+		t.Fatalf("mounts = %+v, want one required rw /vault", req.Mounts)
+	}
+	if strings.Join(req.Skills, ",") != "notes@v1,fetch@v2" || strings.Join(req.Secrets, ",") != "API_TOKEN" {
+		t.Fatalf("unexpected requirements: %+v", req)
+	}
+
+	agent := v1.AgentSpec{
+		Habitat: v1.HabitatSpec{
+			Network: v1.NetworkSpec{Mode: "none"},
+			Mounts:  []v1.MountSpec{{Source: "/tmp/vault", Target: "/vault", ReadOnly: true}},
+			Env:     map[string]string{"VAULT_DIR": "/vault"},
+		},
+	}
+	got := req.Unsatisfied(agent)
+	if len(got) != 3 {
+		t.Fatalf("expected network, mount and secret gaps, got %v", got)
+	}
+	agent.Habitat.Network.Mode = "outbound"
+	agent.Habitat.Mounts[0].ReadOnly = false
+	agent.Habitat.Env["API_TOKEN"] = ""
+	if got := req.Unsatisfied(agent); len(got) != 0 {
+		t.Fatalf("expected all requirements satisfied, got %v", got)
+	}
+}
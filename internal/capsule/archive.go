@@ -0,0 +1,215 @@
+package capsule
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// archiveLimits bound what an untrusted tarball may unpack.
+type archiveLimits struct {
+	entryBytes int64
+	totalBytes int64
+	entries    int
+}
+
+var defaultArchiveLimits = archiveLimits{
+	entryBytes: 256 << 20,
+	totalBytes: 1 << 30,
+	entries:    10000,
+}
+
+// ExtractArchive unpacks a capsule tarball (gzip-compressed or plain) into dst
+// and returns the directory holding manifest.json. The archive may contain the
+// capsule files at its root or under a single top-level directory.
+func ExtractArchive(archivePath string, dst string) (string, error) {
+	if err := ExtractTarball(archivePath, dst); err != nil {
+		return "", err
+	}
+	return FindArchiveRoot(dst, "manifest.json")
+}
+
+// ExtractTarball unpacks a tarball (gzip-compressed or plain) into dst. Only
+// regular files and directories are accepted; links, absolute names and
+// entries escaping dst are rejected, as are archives with more than 10000
+// entries, a file over 256 MiB or more than 1 GiB of file data in total.
+func ExtractTarball(archivePath string, dst string) error {
+	return extractTarball(archivePath, dst, defaultArchiveLimits)
+}
+
+func extractTarball(archivePath string, dst string, limits archiveLimits) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("open gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	var entries int
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		if entries++; entries > limits.entries {
+			return fmt.Errorf("archive has more than %d entries", limits.entries)
+		}
+		rel, err := archiveEntryPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		if rel == "" {
+			continue
+		}
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if hdr.Size > limits.entryBytes {
+				return fmt.Errorf("archive entry %q exceeds %d bytes", hdr.Name, limits.entryBytes)
+			}
+			if total += hdr.Size; total > limits.totalBytes {
+				return fmt.Errorf("archive contents exceed %d bytes", limits.totalBytes)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			// Keep the executable bit so extracted scripts still run; all
+			// other permission bits are normalized.
+			mode := os.FileMode(0o644)
+			if hdr.Mode&0o111 != 0 {
+				mode = 0o755
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_EXCL, mode)
+			if err != nil {
+				return fmt.Errorf("extract %q: %w", hdr.Name, err)
+			}
+			if _, err := io.CopyN(out, tr, hdr.Size); err != nil {
+				_ = out.Close()
+				return fmt.Errorf("extract %q: %w", hdr.Name, err)
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return fmt.Errorf("archive entry %q has unsupported type %q", hdr.Name, string(hdr.Typeflag))
+		}
+	}
+	return nil
+}
+
+func archiveEntryPath(name string) (string, error) {
+	if strings.HasPrefix(name, "/") || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("archive entry %q must be a relative path", name)
+	}
+	clean := path.Clean(name)
+	if clean == "." {
+		return "", nil
+	}
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("archive entry %q escapes extraction dir", name)
+	}
+	return clean, nil
+}
+
+// FindArchiveRoot returns dir, or its single top-level subdirectory, as
+// long as it holds marker.
+func FindArchiveRoot(dir, marker string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		sub := filepath.Join(dir, entries[0].Name())
+		if _, err := os.Stat(filepath.Join(sub, marker)); err == nil {
+			return sub, nil
+		}
+	}
+	return "", fmt.Errorf("archive does not contain %s", marker)
+}
+
+// WriteArchive writes the capsule directory capPath to w as a gzip-compressed
+// tarball rooted at "cap_<id>/".
+func WriteArchive(capPath string, w io.Writer) error {
+	m, err := Load(capPath)
+	if err != nil {
+		return err
+	}
+	return WriteTarball(capPath, "cap_"+m.CapsuleID, w)
+}
+
+// WriteTarball writes dir to w as a gzip-compressed tarball whose entries sit
+// under prefix. Entries are sorted and carry fixed modes, owners and mtimes so
+// the same tree always produces the same bytes.
+func WriteTarball(dir, prefix string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	epoch := time.Unix(0, 0).UTC()
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := prefix
+		if rel != "." {
+			name = path.Join(prefix, filepath.ToSlash(rel))
+		}
+		switch {
+		case d.IsDir():
+			return tw.WriteHeader(&tar.Header{Name: name + "/", Typeflag: tar.TypeDir, Mode: 0o755, ModTime: epoch, Format: tar.FormatPAX})
+		case d.Type().IsRegular():
+			b, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(b)), ModTime: epoch, Format: tar.FormatPAX}); err != nil {
+				return err
+			}
+			_, err = tw.Write(b)
+			return err
+		default:
+			return fmt.Errorf("archive entry %q is not a regular file", rel)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
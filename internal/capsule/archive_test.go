@@ -0,0 +1,163 @@
+package capsule
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/locks"
+	"github.com/fpp-125/metaclaw/internal/policy"
+)
+
+func TestExtractArchiveRoundTripsCapsule(t *testing.T) {
+	lk := locks.BundleLocks{
+		Deps:   locks.DepsLock{Version: "metaclaw.depslock/v1", Skills: []locks.SkillLock{}},
+		Image:  locks.ImageLock{Version: "metaclaw.imagelock/v1", Image: "alpine@sha256:test", Digest: "sha256:test"},
+		Source: locks.SourceLock{Version: "metaclaw.sourcelock/v1", Files: []locks.FileHash{}},
+	}
+	pol := policy.Policy{Version: "metaclaw.policy/v1", Network: policy.NetworkPolicy{Mode: "none"}}
+	cap, err := Write(t.TempDir(), "agent.claw", nil, map[string]any{"hello": "world"}, pol, lk)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "cap.tar.gz")
+	entries := map[string]string{}
+	err = filepath.Walk(cap.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(cap.Path, p)
+		b, err := os.ReadFile(p)
+		entries["cap_"+cap.ID+"/"+filepath.ToSlash(rel)] = string(b)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk capsule: %v", err)
+	}
+	writeTestArchive(t, archive, entries)
+
+	root, err := ExtractArchive(archive, t.TempDir())
+	if err != nil {
+		t.Fatalf("ExtractArchive() error = %v", err)
+	}
+	m, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load() extracted capsule: %v", err)
+	}
+	if m.CapsuleID != cap.ID {
+		t.Fatalf("capsule id = %q, want %q", m.CapsuleID, cap.ID)
+	}
+}
+
+func TestExtractArchiveRejectsTraversal(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil.tar.gz")
+	writeTestArchive(t, archive, map[string]string{"../escape.json": "{}"})
+	_, err := ExtractArchive(archive, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "escapes extraction dir") {
+		t.Fatalf("expected traversal error, got %v", err)
+	}
+}
+
+func TestExtractTarballEnforcesLimits(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "big.tar.gz")
+	writeTestArchive(t, archive, map[string]string{"a.txt": "0123456789", "b.txt": "0123456789", "c.txt": "0123456789"})
+	cases := []struct {
+		name   string
+		limits archiveLimits
+		want   string
+	}{
+		{name: "entry size", limits: archiveLimits{entryBytes: 5, totalBytes: 100, entries: 10}, want: "exceeds 5 bytes"},
+		{name: "total size", limits: archiveLimits{entryBytes: 10, totalBytes: 25, entries: 10}, want: "contents exceed 25 bytes"},
+		{name: "entry count", limits: archiveLimits{entryBytes: 10, totalBytes: 100, entries: 2}, want: "more than 2 entries"},
+	}
+	for _, tc := range cases {
+		err := extractTarball(archive, t.TempDir(), tc.limits)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected %q error, got %v", tc.name, tc.want, err)
+		}
+	}
+	if err := extractTarball(archive, t.TempDir(), archiveLimits{entryBytes: 10, totalBytes: 30, entries: 3}); err != nil {
+		t.Fatalf("archive at the limits should extract: %v", err)
+	}
+}
+
+func writeTestArchive(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, body := range entries {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("write body: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+}
+
+func TestWriteArchiveIsDeterministicAndRoundTrips(t *testing.T) {
+	lk := locks.BundleLocks{
+		Deps:   locks.DepsLock{Version: "metaclaw.depslock/v1", Skills: []locks.SkillLock{}},
+		Image:  locks.ImageLock{Version: "metaclaw.imagelock/v1", Image: "alpine@sha256:test", Digest: "sha256:test"},
+		Source: locks.SourceLock{Version: "metaclaw.sourcelock/v1", Files: []locks.FileHash{}},
+	}
+	pol := policy.Policy{Version: "metaclaw.policy/v1", Network: policy.NetworkPolicy{Mode: "none"}}
+	cap, err := Write(t.TempDir(), "agent.claw", []byte("apiVersion: metaclaw/v1\n"), map[string]any{"hello": "world"}, pol, lk)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var first, second bytes.Buffer
+	if err := WriteArchive(cap.Path, &first); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+	now := time.Now().Add(time.Hour)
+	_ = os.Chtimes(filepath.Join(cap.Path, "ir.json"), now, now)
+	if err := WriteArchive(cap.Path, &second); err != nil {
+		t.Fatalf("WriteArchive() second error = %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatal("expected identical archive bytes regardless of file mtimes")
+	}
+
+	archive := filepath.Join(t.TempDir(), "cap.tar.gz")
+	if err := os.WriteFile(archive, first.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root, err := ExtractArchive(archive, t.TempDir())
+	if err != nil {
+		t.Fatalf("ExtractArchive() error = %v", err)
+	}
+	if filepath.Base(root) != "cap_"+cap.ID {
+		t.Fatalf("extracted root = %s, want cap_%s", root, cap.ID)
+	}
+	m, err := Load(root)
+	if err != nil {
+		t.Fatalf("Load() extracted capsule: %v", err)
+	}
+	if err := VerifyID(m); err != nil {
+		t.Fatalf("VerifyID() error = %v", err)
+	}
+	m.CapsuleID = "0000000000000000"
+	if err := VerifyID(m); err == nil {
+		t.Fatal("expected VerifyID to reject a forged capsule id")
+	}
+}
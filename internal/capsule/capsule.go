@@ -18,6 +18,7 @@ type Manifest struct {
 	Version              string            `json:"version"`
 	CapsuleID            string            `json:"capsuleId"`
 	SourceClawfile       string            `json:"sourceClawfile"`
+	EmbeddedSource       string            `json:"embeddedSource,omitempty"`
 	Digests              map[string]string `json:"digests"`
 	RuntimeCompatibility RuntimeContract   `json:"runtimeCompatibility"`
 	Locks                LockManifest      `json:"locks"`
@@ -67,12 +68,17 @@ type Capsule struct {
 	Manifest
 }
 
-func Write(outputDir string, sourceClawfile string, ir any, pol policy.Policy, lk locks.BundleLocks) (Capsule, error) {
+// EmbeddedSourcePath is where Write stores the normalized clawfile text.
+const EmbeddedSourcePath = "source/agent.claw"
+
+// Write materializes a capsule under outputDir. When source is non-empty it is
+// stored at EmbeddedSourcePath and covered by the "clawfile" digest.
+func Write(outputDir string, sourceClawfile string, source []byte, ir any, pol policy.Policy, lk locks.BundleLocks) (Capsule, error) {
 	if outputDir == "" {
 		outputDir = "."
 	}
 
-	irJSON, err := canonicalJSON(ir)
+	irJSON, err := MarshalIR(ir)
 	if err != nil {
 		return Capsule{}, fmt.Errorf("marshal ir: %w", err)
 	}
@@ -100,6 +106,9 @@ func Write(outputDir string, sourceClawfile string, ir any, pol policy.Policy, l
 		"image":  digest(imageJSON),
 		"source": digest(sourceJSON),
 	}
+	if len(source) > 0 {
+		digests["clawfile"] = digest(source)
+	}
 	capsuleID := makeCapsuleID(digests)
 
 	manifest := Manifest{
@@ -108,15 +117,18 @@ func Write(outputDir string, sourceClawfile string, ir any, pol policy.Policy, l
 		SourceClawfile: filepath.Base(sourceClawfile),
 		Digests:        digests,
 		RuntimeCompatibility: RuntimeContract{
-			Targets:   []string{"podman", "apple_container", "docker"},
+			Targets:   []string{"podman", "apple_container", "docker", "nerdctl"},
 			Semantics: []string{"detach", "env", "volume", "workdir"},
 		},
 		Locks: LockManifest{
-			Dependency: "locks/deps.lock.json",
-			Image:      "locks/image.lock.json",
-			Source:     "locks/source.lock.json",
+			Dependency: "locks/" + locks.DepsLockFile,
+			Image:      "locks/" + locks.ImageLockFile,
+			Source:     "locks/" + locks.SourceLockFile,
 		},
 	}
+	if len(source) > 0 {
+		manifest.EmbeddedSource = EmbeddedSourcePath
+	}
 	manifestJSON, err := canonicalJSON(manifest)
 	if err != nil {
 		return Capsule{}, fmt.Errorf("marshal manifest: %w", err)
@@ -135,21 +147,35 @@ func Write(outputDir string, sourceClawfile string, ir any, pol policy.Policy, l
 	if err := writeFile(filepath.Join(capPath, "policy.json"), policyJSON); err != nil {
 		return Capsule{}, err
 	}
-	if err := writeFile(filepath.Join(capPath, "locks", "deps.lock.json"), depsJSON); err != nil {
+	if err := writeFile(filepath.Join(capPath, "locks", locks.DepsLockFile), depsJSON); err != nil {
 		return Capsule{}, err
 	}
-	if err := writeFile(filepath.Join(capPath, "locks", "image.lock.json"), imageJSON); err != nil {
+	if err := writeFile(filepath.Join(capPath, "locks", locks.ImageLockFile), imageJSON); err != nil {
 		return Capsule{}, err
 	}
-	if err := writeFile(filepath.Join(capPath, "locks", "source.lock.json"), sourceJSON); err != nil {
+	if err := writeFile(filepath.Join(capPath, "locks", locks.SourceLockFile), sourceJSON); err != nil {
 		return Capsule{}, err
 	}
+	if len(source) > 0 {
+		if err := os.MkdirAll(filepath.Join(capPath, "source"), 0o755); err != nil {
+			return Capsule{}, err
+		}
+		if err := writeFile(filepath.Join(capPath, filepath.FromSlash(EmbeddedSourcePath)), source); err != nil {
+			return Capsule{}, err
+		}
+	}
 	portable := map[string]any{
 		"version": "metaclaw.portable/v1",
 		"image":   lk.Image.Image,
 		"network": pol.Network.Mode,
 		"mounts":  pol.Mounts,
 	}
+	if len(pol.Tmpfs) > 0 {
+		portable["tmpfs"] = pol.Tmpfs
+	}
+	if len(pol.Ports) > 0 {
+		portable["ports"] = pol.Ports
+	}
 	portableJSON, err := canonicalJSON(portable)
 	if err != nil {
 		return Capsule{}, fmt.Errorf("marshal portable spec: %w", err)
@@ -164,6 +190,34 @@ func Write(outputDir string, sourceClawfile string, ir any, pol policy.Policy, l
 	return Capsule{ID: capsuleID, Path: capPath, Manifest: manifest}, nil
 }
 
+// WriteLocks writes the three lock files into dir in the same canonical form
+// Write uses inside a capsule and returns their paths.
+func WriteLocks(dir string, lk locks.BundleLocks) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create lock dir: %w", err)
+	}
+	var paths []string
+	for _, f := range []struct {
+		name string
+		v    any
+	}{
+		{locks.DepsLockFile, lk.Deps},
+		{locks.ImageLockFile, lk.Image},
+		{locks.SourceLockFile, lk.Source},
+	} {
+		b, err := canonicalJSON(f.v)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", f.name, err)
+		}
+		p := filepath.Join(dir, f.name)
+		if err := writeFile(p, b); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
 func Load(path string) (Manifest, error) {
 	b, err := os.ReadFile(filepath.Join(path, "manifest.json"))
 	if err != nil {
@@ -186,6 +240,11 @@ func writeFile(path string, b []byte) error {
 	return nil
 }
 
+// MarshalIR returns ir exactly as Write stores it in ir.json.
+func MarshalIR(ir any) ([]byte, error) {
+	return canonicalJSON(ir)
+}
+
 func canonicalJSON(v any) ([]byte, error) {
 	b, err := json.Marshal(v)
 	if err != nil {
@@ -217,10 +276,60 @@ func makeCapsuleID(digests map[string]string) string {
 	return hex.EncodeToString(h.Sum(nil))[:16]
 }
 
+// VerifyID checks that the manifest's capsuleId is the one derived from its
+// digests, so a manifest cannot claim another capsule's identity.
+func VerifyID(m Manifest) error {
+	if got := makeCapsuleID(m.Digests); got != m.CapsuleID {
+		return fmt.Errorf("capsule id mismatch: manifest says %s, digests give %s", m.CapsuleID, got)
+	}
+	return nil
+}
+
 func verifyManifest(basePath string, m Manifest) error {
 	if m.CapsuleID == "" {
 		return fmt.Errorf("capsule manifest missing capsuleId")
 	}
+	for _, a := range checkArtifacts(basePath, m) {
+		if a.err != nil {
+			return a.err
+		}
+	}
+	return nil
+}
+
+// ArtifactStatus is the digest check of one file listed in a capsule
+// manifest.
+type ArtifactStatus struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+
+	err error
+}
+
+// Verify reads the manifest at path and checks every digested artifact,
+// reporting each one instead of stopping at the first mismatch. The error is
+// only for a manifest that cannot be read at all.
+func Verify(path string) (Manifest, []ArtifactStatus, error) {
+	b, err := os.ReadFile(filepath.Join(path, "manifest.json"))
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return Manifest{}, nil, fmt.Errorf("parse capsule manifest: %w", err)
+	}
+	if m.CapsuleID == "" {
+		return m, nil, fmt.Errorf("capsule manifest missing capsuleId")
+	}
+	return m, checkArtifacts(path, m), nil
+}
+
+// checkArtifacts checks the manifest digests in name order.
+func checkArtifacts(basePath string, m Manifest) []ArtifactStatus {
 	required := map[string]string{
 		"ir":     "ir.json",
 		"policy": "policy.json",
@@ -228,25 +337,45 @@ func verifyManifest(basePath string, m Manifest) error {
 		"image":  m.Locks.Image,
 		"source": m.Locks.Source,
 	}
-	for key, relPath := range required {
-		expected, ok := m.Digests[key]
-		if !ok || expected == "" {
-			return fmt.Errorf("capsule manifest missing digest for %s", key)
-		}
-		absPath, err := resolveCapsulePath(basePath, relPath)
-		if err != nil {
-			return fmt.Errorf("capsule manifest path for %s is invalid: %w", key, err)
-		}
-		b, err := os.ReadFile(absPath)
-		if err != nil {
-			return fmt.Errorf("read capsule %s: %w", relPath, err)
-		}
-		got := digest(b)
-		if got != expected {
-			return fmt.Errorf("capsule digest mismatch for %s: expected %s, got %s", key, expected, got)
+	// Capsules written before the clawfile was embedded carry no source entry.
+	if m.EmbeddedSource != "" {
+		required["clawfile"] = m.EmbeddedSource
+	}
+	keys := make([]string, 0, len(required))
+	for k := range required {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]ArtifactStatus, 0, len(keys))
+	for _, key := range keys {
+		relPath := required[key]
+		a := ArtifactStatus{Name: key, Path: relPath, Expected: m.Digests[key]}
+		a.err = checkArtifact(basePath, key, relPath, &a)
+		a.OK = a.err == nil
+		if a.err != nil {
+			a.Error = a.err.Error()
 		}
+		out = append(out, a)
 	}
+	return out
+}
 
+func checkArtifact(basePath, key, relPath string, a *ArtifactStatus) error {
+	if a.Expected == "" {
+		return fmt.Errorf("capsule manifest missing digest for %s", key)
+	}
+	absPath, err := resolveCapsulePath(basePath, relPath)
+	if err != nil {
+		return fmt.Errorf("capsule manifest path for %s is invalid: %w", key, err)
+	}
+	b, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("read capsule %s: %w", relPath, err)
+	}
+	a.Actual = digest(b)
+	if a.Actual != a.Expected {
+		return fmt.Errorf("capsule digest mismatch for %s: expected %s, got %s", key, a.Expected, a.Actual)
+	}
 	return nil
 }
 
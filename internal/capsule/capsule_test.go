@@ -31,7 +31,7 @@ func TestLoadVerifiesManifestAndPayloadDigests(t *testing.T) {
 		Version: "metaclaw.policy/v1",
 		Network: policy.NetworkPolicy{Mode: "none", Allowed: false},
 	}
-	cap, err := Write(root, "agent.claw", map[string]any{"hello": "world"}, pol, lk)
+	cap, err := Write(root, "agent.claw", nil, map[string]any{"hello": "world"}, pol, lk)
 	if err != nil {
 		t.Fatalf("Write() error = %v", err)
 	}
@@ -74,7 +74,7 @@ func TestLoadRejectsPathTraversalInLockManifest(t *testing.T) {
 		Version: "metaclaw.policy/v1",
 		Network: policy.NetworkPolicy{Mode: "none", Allowed: false},
 	}
-	cap, err := Write(root, "agent.claw", map[string]any{"hello": "world"}, pol, lk)
+	cap, err := Write(root, "agent.claw", nil, map[string]any{"hello": "world"}, pol, lk)
 	if err != nil {
 		t.Fatalf("Write() error = %v", err)
 	}
@@ -98,3 +98,31 @@ func TestLoadRejectsPathTraversalInLockManifest(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestWriteEmbedsSourceUnderDigest(t *testing.T) {
+	lk := locks.BundleLocks{
+		Deps:   locks.DepsLock{Version: "metaclaw.depslock/v1", Skills: []locks.SkillLock{}},
+		Image:  locks.ImageLock{Version: "metaclaw.imagelock/v1", Image: "alpine@sha256:test", Digest: "sha256:test"},
+		Source: locks.SourceLock{Version: "metaclaw.sourcelock/v1", Files: []locks.FileHash{}},
+	}
+	pol := policy.Policy{Version: "metaclaw.policy/v1", Network: policy.NetworkPolicy{Mode: "none"}}
+	source := []byte("apiVersion: metaclaw/v1\nkind: Agent\n")
+	cap, err := Write(t.TempDir(), "agent.claw", source, map[string]any{"hello": "world"}, pol, lk)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if cap.EmbeddedSource != EmbeddedSourcePath || cap.Digests["clawfile"] == "" {
+		t.Fatalf("manifest does not reference embedded source: %+v", cap.Manifest)
+	}
+	b, err := os.ReadFile(filepath.Join(cap.Path, "source", "agent.claw"))
+	if err != nil || string(b) != string(source) {
+		t.Fatalf("embedded source = %q, %v", b, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(cap.Path, "source", "agent.claw"), []byte("kind: Tampered\n"), 0o644); err != nil {
+		t.Fatalf("tamper source: %v", err)
+	}
+	if _, err := Load(cap.Path); err == nil || !strings.Contains(err.Error(), "capsule digest mismatch for clawfile") {
+		t.Fatalf("expected clawfile digest mismatch, got %v", err)
+	}
+}
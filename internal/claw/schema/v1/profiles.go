@@ -1,5 +1,15 @@
 package v1
 
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// builtinSpecies lists the built-in species in size order.
+var builtinSpecies = []Species{SpeciesNano, SpeciesMicro, SpeciesMega}
+
 var speciesProfiles = map[Species]SpeciesProfile{
 	SpeciesNano: {
 		Name:         SpeciesNano,
@@ -11,6 +21,7 @@ var speciesProfiles = map[Species]SpeciesProfile{
 			AllowResourceOverride: true,
 			AllowImageOverride:    true,
 		},
+		DefaultCapDrop: []string{"ALL"},
 	},
 	SpeciesMicro: {
 		Name:         SpeciesMicro,
@@ -22,6 +33,7 @@ var speciesProfiles = map[Species]SpeciesProfile{
 			AllowResourceOverride: true,
 			AllowImageOverride:    true,
 		},
+		DefaultCapDrop: []string{"ALL"},
 	},
 	SpeciesMega: {
 		Name:         SpeciesMega,
@@ -33,10 +45,59 @@ var speciesProfiles = map[Species]SpeciesProfile{
 			AllowResourceOverride: true,
 			AllowImageOverride:    true,
 		},
+		DefaultCapDrop: []string{"ALL"},
 	},
 }
 
+// customSpecies holds profiles added at startup from a species registry file.
+var (
+	customMu      sync.RWMutex
+	customSpecies = map[Species]SpeciesProfile{}
+)
+
 func SpeciesProfileFor(s Species) (SpeciesProfile, bool) {
-	p, ok := speciesProfiles[s]
+	if p, ok := speciesProfiles[s]; ok {
+		return p, true
+	}
+	customMu.RLock()
+	defer customMu.RUnlock()
+	p, ok := customSpecies[s]
 	return p, ok
 }
+
+// RegisterSpecies adds custom species profiles next to the built-ins.
+// Registering an identical profile again is a no-op; redefining a built-in
+// or an already registered species with different values is an error.
+func RegisterSpecies(profiles ...SpeciesProfile) error {
+	customMu.Lock()
+	defer customMu.Unlock()
+	for _, p := range profiles {
+		if _, ok := speciesProfiles[p.Name]; ok {
+			return fmt.Errorf("species %s is built in and cannot be redefined", p.Name)
+		}
+		if prev, ok := customSpecies[p.Name]; ok && !reflect.DeepEqual(prev, p) {
+			return fmt.Errorf("species %s is already registered with a different profile", p.Name)
+		}
+	}
+	for _, p := range profiles {
+		customSpecies[p.Name] = p
+	}
+	return nil
+}
+
+// SpeciesNames lists the built-in species in size order followed by the
+// registered custom species sorted by name.
+func SpeciesNames() []string {
+	out := make([]string, 0, len(builtinSpecies))
+	for _, s := range builtinSpecies {
+		out = append(out, string(s))
+	}
+	customMu.RLock()
+	custom := make([]string, 0, len(customSpecies))
+	for s := range customSpecies {
+		custom = append(custom, string(s))
+	}
+	customMu.RUnlock()
+	sort.Strings(custom)
+	return append(out, custom...)
+}
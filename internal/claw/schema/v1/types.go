@@ -1,6 +1,9 @@
 package v1
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Species string
 
@@ -8,6 +11,7 @@ type LifecycleMode string
 
 type RuntimeTarget string
 type LLMProvider string
+type RestartMode string
 
 const (
 	SpeciesNano  Species = "nano"
@@ -22,9 +26,16 @@ const (
 )
 
 const (
-	RuntimePodman RuntimeTarget = "podman"
-	RuntimeApple  RuntimeTarget = "apple_container"
-	RuntimeDocker RuntimeTarget = "docker"
+	RestartNever     RestartMode = "never"
+	RestartOnFailure RestartMode = "on-failure"
+	RestartAlways    RestartMode = "always"
+)
+
+const (
+	RuntimePodman  RuntimeTarget = "podman"
+	RuntimeApple   RuntimeTarget = "apple_container"
+	RuntimeDocker  RuntimeTarget = "docker"
+	RuntimeNerdctl RuntimeTarget = "nerdctl"
 )
 
 const (
@@ -52,15 +63,29 @@ type AgentSpec struct {
 }
 
 type HabitatSpec struct {
-	Network NetworkSpec       `yaml:"network,omitempty" json:"network,omitempty"`
-	Mounts  []MountSpec       `yaml:"mounts,omitempty" json:"mounts,omitempty"`
-	Env     map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
-	Workdir string            `yaml:"workdir,omitempty" json:"workdir,omitempty"`
-	User    string            `yaml:"user,omitempty" json:"user,omitempty"`
+	Network        NetworkSpec       `yaml:"network,omitempty" json:"network,omitempty"`
+	Mounts         []MountSpec       `yaml:"mounts,omitempty" json:"mounts,omitempty"`
+	Tmpfs          []TmpfsSpec       `yaml:"tmpfs,omitempty" json:"tmpfs,omitempty"`
+	Ports          []PortMapping     `yaml:"ports,omitempty" json:"ports,omitempty"`
+	Env            map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	SecretFiles    map[string]string `yaml:"secretFiles,omitempty" json:"secretFiles,omitempty"`
+	ExtraHosts     []string          `yaml:"extraHosts,omitempty" json:"extraHosts,omitempty"`
+	Workdir        string            `yaml:"workdir,omitempty" json:"workdir,omitempty"`
+	User           string            `yaml:"user,omitempty" json:"user,omitempty"`
+	ReadOnlyRootfs bool              `yaml:"readOnlyRootfs,omitempty" json:"readOnlyRootfs,omitempty"`
+	Capabilities   CapabilitiesSpec  `yaml:"capabilities,omitempty" json:"capabilities,omitempty"`
+}
+
+// CapabilitiesSpec adjusts the container's Linux capability set. Drop
+// defaults to the species' defaultCapDrop ([ALL] for the built-in species).
+type CapabilitiesSpec struct {
+	Drop []string `yaml:"drop,omitempty" json:"drop,omitempty"`
+	Add  []string `yaml:"add,omitempty" json:"add,omitempty"`
 }
 
 type NetworkSpec struct {
-	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Mode           string   `yaml:"mode,omitempty" json:"mode,omitempty"`
+	AllowedDomains []string `yaml:"allowedDomains,omitempty" json:"allowedDomains,omitempty"`
 }
 
 type MountSpec struct {
@@ -69,11 +94,35 @@ type MountSpec struct {
 	ReadOnly bool   `yaml:"readOnly,omitempty" json:"readOnly,omitempty"`
 }
 
+// TmpfsSpec is an in-memory scratch mount. Size takes a byte count with an
+// optional k/m/g suffix and Mode an octal permission such as 1777.
+type TmpfsSpec struct {
+	Target string `yaml:"target" json:"target"`
+	Size   string `yaml:"size,omitempty" json:"size,omitempty"`
+	Mode   string `yaml:"mode,omitempty" json:"mode,omitempty"`
+}
+
+// PortMapping publishes a container port on the host. Protocol defaults to tcp
+// and HostIP to 127.0.0.1, so ports are only reachable locally unless widened.
+type PortMapping struct {
+	HostPort      int    `yaml:"hostPort" json:"hostPort"`
+	ContainerPort int    `yaml:"containerPort" json:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+	HostIP        string `yaml:"hostIP,omitempty" json:"hostIP,omitempty"`
+}
+
+// SoulSpec gives the agent its persona and memory. Each is either inline
+// text or a path (relative to the clawfile) that is mounted read-only under
+// SoulDir; MemoryWritable lets the agent update a file-backed memory.
 type SoulSpec struct {
-	Persona string `yaml:"persona,omitempty" json:"persona,omitempty"`
-	Memory  string `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Persona        string `yaml:"persona,omitempty" json:"persona,omitempty"`
+	Memory         string `yaml:"memory,omitempty" json:"memory,omitempty"`
+	MemoryWritable bool   `yaml:"memoryWritable,omitempty" json:"memoryWritable,omitempty"`
 }
 
+// SoulDir is the container directory reserved for file-backed soul entries.
+const SoulDir = "/metaclaw/soul"
+
 type SkillRef struct {
 	Path    string `yaml:"path,omitempty" json:"path,omitempty"`
 	ID      string `yaml:"id,omitempty" json:"id,omitempty"`
@@ -85,6 +134,28 @@ type RuntimeSpec struct {
 	Target    RuntimeTarget `yaml:"target,omitempty" json:"target,omitempty"`
 	Image     string        `yaml:"image,omitempty" json:"image,omitempty"`
 	Resources ResourceSpec  `yaml:"resources,omitempty" json:"resources,omitempty"`
+	// CapAdd and CapDrop are the older spelling of habitat.capabilities;
+	// validation moves them there.
+	CapAdd        []string         `yaml:"capAdd,omitempty" json:"capAdd,omitempty"`
+	CapDrop       []string         `yaml:"capDrop,omitempty" json:"capDrop,omitempty"`
+	RestartPolicy *RestartPolicy   `yaml:"restartPolicy,omitempty" json:"restartPolicy,omitempty"`
+	Healthcheck   *HealthcheckSpec `yaml:"healthcheck,omitempty" json:"healthcheck,omitempty"`
+}
+
+// HealthcheckSpec is a native container healthcheck. Durations use Go syntax
+// (e.g. "5s"); empty fields keep the runtime defaults.
+type HealthcheckSpec struct {
+	Command     string `yaml:"command" json:"command"`
+	Interval    string `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Retries     int    `yaml:"retries,omitempty" json:"retries,omitempty"`
+	StartPeriod string `yaml:"startPeriod,omitempty" json:"startPeriod,omitempty"`
+}
+
+// RestartPolicy tells the manager whether to relaunch a daemon container that
+// exits. MaxRetries of zero means no limit.
+type RestartPolicy struct {
+	Mode       RestartMode `yaml:"mode,omitempty" json:"mode,omitempty"`
+	MaxRetries int         `yaml:"maxRetries,omitempty" json:"maxRetries,omitempty"`
 }
 
 type LLMSpec struct {
@@ -100,31 +171,40 @@ type ResourceSpec struct {
 }
 
 type SpeciesProfile struct {
-	Name         Species      `json:"name"`
-	DefaultImage string       `json:"defaultImage"`
-	DefaultCPU   string       `json:"defaultCPU"`
-	DefaultMem   string       `json:"defaultMemory"`
-	RuntimeHints []string     `json:"runtimeHints"`
-	Allowed      AllowedPatch `json:"allowedOverrides"`
+	Name         Species      `yaml:"name" json:"name"`
+	DefaultImage string       `yaml:"defaultImage" json:"defaultImage"`
+	DefaultCPU   string       `yaml:"defaultCPU" json:"defaultCPU"`
+	DefaultMem   string       `yaml:"defaultMemory" json:"defaultMemory"`
+	RuntimeHints []string     `yaml:"runtimeHints,omitempty" json:"runtimeHints"`
+	Allowed      AllowedPatch `yaml:"allowedOverrides" json:"allowedOverrides"`
+	// DefaultCapDrop is used when habitat.capabilities.drop is unset.
+	DefaultCapDrop []string `yaml:"defaultCapDrop,omitempty" json:"defaultCapDrop,omitempty"`
 }
 
 type AllowedPatch struct {
-	AllowResourceOverride bool `json:"allowResourceOverride"`
-	AllowImageOverride    bool `json:"allowImageOverride"`
+	AllowResourceOverride bool `yaml:"allowResourceOverride" json:"allowResourceOverride"`
+	AllowImageOverride    bool `yaml:"allowImageOverride" json:"allowImageOverride"`
 }
 
+// Valid reports whether s is a built-in species or one added with
+// RegisterSpecies.
 func (s Species) Valid() bool {
-	switch s {
-	case SpeciesNano, SpeciesMicro, SpeciesMega:
+	_, ok := SpeciesProfileFor(s)
+	return ok
+}
+
+func (l LifecycleMode) Valid() bool {
+	switch l {
+	case LifecycleEphemeral, LifecycleDaemon, LifecycleDebug:
 		return true
 	default:
 		return false
 	}
 }
 
-func (l LifecycleMode) Valid() bool {
-	switch l {
-	case LifecycleEphemeral, LifecycleDaemon, LifecycleDebug:
+func (r RestartMode) Valid() bool {
+	switch r {
+	case RestartNever, RestartOnFailure, RestartAlways:
 		return true
 	default:
 		return false
@@ -133,7 +213,7 @@ func (l LifecycleMode) Valid() bool {
 
 func (r RuntimeTarget) Valid() bool {
 	switch r {
-	case RuntimePodman, RuntimeApple, RuntimeDocker, "":
+	case RuntimePodman, RuntimeApple, RuntimeDocker, RuntimeNerdctl, "":
 		return true
 	default:
 		return false
@@ -160,13 +240,13 @@ func (c Clawfile) ValidateBasics() error {
 		return fmt.Errorf("agent.name is required")
 	}
 	if !c.Agent.Species.Valid() {
-		return fmt.Errorf("agent.species must be one of nano,micro,mega")
+		return fmt.Errorf("agent.species must be one of %s", strings.Join(SpeciesNames(), ","))
 	}
 	if c.Agent.Lifecycle != "" && !c.Agent.Lifecycle.Valid() {
 		return fmt.Errorf("agent.lifecycle must be one of ephemeral,daemon,debug")
 	}
 	if !c.Agent.Runtime.Target.Valid() {
-		return fmt.Errorf("agent.runtime.target must be one of podman,apple_container,docker")
+		return fmt.Errorf("agent.runtime.target must be one of podman,apple_container,docker,nerdctl")
 	}
 	if !c.Agent.LLM.Provider.Valid() {
 		return fmt.Errorf("agent.llm.provider must be one of openai_compatible,gemini_openai,anthropic")
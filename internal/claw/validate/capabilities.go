@@ -0,0 +1,94 @@
+package validate
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+)
+
+// linuxCapabilities is the set of names accepted by docker/podman --cap-add and
+// --cap-drop, without the CAP_ prefix.
+var linuxCapabilities = map[string]struct{}{
+	"AUDIT_CONTROL": {}, "AUDIT_READ": {}, "AUDIT_WRITE": {}, "BLOCK_SUSPEND": {},
+	"BPF": {}, "CHECKPOINT_RESTORE": {}, "CHOWN": {}, "DAC_OVERRIDE": {},
+	"DAC_READ_SEARCH": {}, "FOWNER": {}, "FSETID": {}, "IPC_LOCK": {},
+	"IPC_OWNER": {}, "KILL": {}, "LEASE": {}, "LINUX_IMMUTABLE": {},
+	"MAC_ADMIN": {}, "MAC_OVERRIDE": {}, "MKNOD": {}, "NET_ADMIN": {},
+	"NET_BIND_SERVICE": {}, "NET_BROADCAST": {}, "NET_RAW": {}, "PERFMON": {},
+	"SETFCAP": {}, "SETGID": {}, "SETPCAP": {}, "SETUID": {},
+	"SYSLOG": {}, "SYS_ADMIN": {}, "SYS_BOOT": {}, "SYS_CHROOT": {},
+	"SYS_MODULE": {}, "SYS_NICE": {}, "SYS_PACCT": {}, "SYS_PTRACE": {},
+	"SYS_RAWIO": {}, "SYS_RESOURCE": {}, "SYS_TIME": {}, "SYS_TTY_CONFIG": {},
+	"WAKE_ALARM": {},
+}
+
+// normalizeCapabilities canonicalizes habitat.capabilities (upper case, no
+// CAP_ prefix, sorted, deduplicated) and defaults drop to the species'
+// defaultCapDrop. The older runtime.capAdd/capDrop fields are moved into the
+// block. SYS_ADMIN is close to root on the host, so granting it needs the
+// explicit allowSysAdmin override.
+func normalizeCapabilities(agent *v1.AgentSpec, profile v1.SpeciesProfile, allowSysAdmin bool) error {
+	caps := &agent.Habitat.Capabilities
+	rt := &agent.Runtime
+	if len(rt.CapAdd) > 0 || len(rt.CapDrop) > 0 {
+		if len(caps.Add) > 0 || len(caps.Drop) > 0 {
+			return fmt.Errorf("agent.runtime.capAdd/capDrop cannot be combined with agent.habitat.capabilities")
+		}
+		caps.Add, caps.Drop = rt.CapAdd, rt.CapDrop
+		rt.CapAdd, rt.CapDrop = nil, nil
+	}
+	drop, err := normalizeCapList("agent.habitat.capabilities.drop", caps.Drop, true)
+	if err != nil {
+		return err
+	}
+	add, err := normalizeCapList("agent.habitat.capabilities.add", caps.Add, false)
+	if err != nil {
+		return err
+	}
+	if len(drop) == 0 {
+		drop = append([]string(nil), profile.DefaultCapDrop...)
+	}
+	dropSet := make(map[string]struct{}, len(drop))
+	for _, c := range drop {
+		dropSet[c] = struct{}{}
+	}
+	for _, c := range add {
+		if _, ok := dropSet[c]; ok {
+			return fmt.Errorf("agent.habitat.capabilities: %s is listed in both add and drop", c)
+		}
+	}
+	if _, ok := slices.BinarySearch(add, "SYS_ADMIN"); ok && !allowSysAdmin {
+		return fmt.Errorf("agent.habitat.capabilities.add SYS_ADMIN requires the --allow-sys-admin override")
+	}
+	caps.Drop = drop
+	caps.Add = add
+	return nil
+}
+
+func normalizeCapList(field string, in []string, allowAll bool) ([]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]struct{}, len(in))
+	out := make([]string, 0, len(in))
+	for _, raw := range in {
+		c := strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(raw)), "CAP_")
+		if c == "ALL" {
+			if !allowAll {
+				return nil, fmt.Errorf("%s cannot contain ALL", field)
+			}
+		} else if _, ok := linuxCapabilities[c]; !ok {
+			return nil, fmt.Errorf("%s has unknown capability %q", field, raw)
+		}
+		if _, dup := seen[c]; dup {
+			continue
+		}
+		seen[c] = struct{}{}
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out, nil
+}
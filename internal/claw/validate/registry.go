@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultRegistry = "docker.io"
+
+// ImageRegistry returns the registry host of an image reference using the
+// Docker convention: the first path component is a registry only when it
+// contains a dot or a port, or is localhost; otherwise the image lives on
+// docker.io.
+func ImageRegistry(image string) string {
+	ref := strings.TrimSpace(image)
+	first, _, ok := strings.Cut(ref, "/")
+	if !ok || !(strings.ContainsAny(first, ".:") || first == "localhost") {
+		return defaultRegistry
+	}
+	host := strings.ToLower(first)
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		return defaultRegistry
+	}
+	return host
+}
+
+// NormalizeAllowedRegistries trims, lowercases the host part of, and drops
+// empty entries from a registry allowlist. Entries are a host
+// ("registry.corp.internal") or a host with a repository prefix
+// ("registry.corp.internal/team").
+func NormalizeAllowedRegistries(entries []string) ([]string, error) {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		e = strings.Trim(strings.TrimSpace(e), "/")
+		if e == "" {
+			continue
+		}
+		if strings.ContainsAny(e, "@ \t") {
+			return nil, fmt.Errorf("invalid allowed registry %q (want host or host/prefix)", e)
+		}
+		host, rest, _ := strings.Cut(e, "/")
+		host = strings.ToLower(host)
+		if host == "index.docker.io" || host == "registry-1.docker.io" {
+			host = defaultRegistry
+		}
+		if rest != "" {
+			host += "/" + rest
+		}
+		out = append(out, host)
+	}
+	return out, nil
+}
+
+// RegistryAllowed reports whether image comes from one of the normalized
+// allowlist entries. An empty allowlist allows every registry.
+func RegistryAllowed(image string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ref := strings.TrimSpace(image)
+	if i := strings.IndexByte(ref, '@'); i >= 0 {
+		ref = ref[:i]
+	}
+	host := ImageRegistry(ref)
+	repo := ref
+	if first, rest, ok := strings.Cut(ref, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		repo = rest
+	}
+	if host == defaultRegistry && !strings.Contains(repo, "/") {
+		repo = "library/" + repo
+	}
+	full := host + "/" + repo
+	for _, a := range allowed {
+		if a == host || strings.HasPrefix(full, a+"/") {
+			return true
+		}
+	}
+	return false
+}
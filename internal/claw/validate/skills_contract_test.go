@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"github.com/fpp-125/metaclaw/internal/locks"
 )
 
 func TestValidateSkillsWithCapabilityContract(t *testing.T) {
@@ -141,3 +142,112 @@ func TestValidateSkillsByIDRequireVersionAndDigest(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestValidateSkillsByIDAgainstRegistry(t *testing.T) {
+	root := t.TempDir()
+	registry := filepath.Join(root, "registry")
+	entry := filepath.Join(registry, "metaclaw", "obsidian-sync@v1.0.0")
+	if err := os.MkdirAll(entry, 0o755); err != nil {
+		t.Fatalf("mkdir registry entry: %v", err)
+	}
+	contract := `apiVersion: metaclaw.capability/v1
+kind: CapabilityContract
+metadata:
+  name: obsidian.sync
+  version: v1.0.0
+permissions:
+  network: outbound
+`
+	if err := os.WriteFile(filepath.Join(entry, "capability.contract.yaml"), []byte(contract), 0o644); err != nil {
+		t.Fatalf("write contract: %v", err)
+	}
+	digest, err := locks.SkillDigest(entry)
+	if err != nil {
+		t.Fatalf("SkillDigest() error = %v", err)
+	}
+
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: "outbound"}},
+			Skills: []v1.SkillRef{
+				{ID: "metaclaw/obsidian-sync", Version: "v1.0.0", Digest: digest},
+			},
+		},
+	}
+	opts := Options{SkillRegistry: registry}
+	if _, _, err := NormalizeAndValidateWithOptions(cfg, "agent.claw", opts); err != nil {
+		t.Fatalf("NormalizeAndValidateWithOptions() error = %v", err)
+	}
+
+	cfg.Agent.Skills[0].Digest = "sha256:" + strings.Repeat("0", 64)
+	if _, _, err := NormalizeAndValidateWithOptions(cfg, "agent.claw", opts); err == nil || !strings.Contains(err.Error(), "does not match clawfile digest") {
+		t.Fatalf("expected digest mismatch error, got %v", err)
+	}
+
+	cfg.Agent.Skills[0].Digest = digest
+	cfg.Agent.Habitat.Network.Mode = "none"
+	if _, _, err := NormalizeAndValidateWithOptions(cfg, "agent.claw", opts); err == nil || !strings.Contains(err.Error(), "requires network=outbound") {
+		t.Fatalf("expected contract permission error, got %v", err)
+	}
+
+	cfg.Agent.Skills[0].Version = "v2.0.0"
+	if _, _, err := NormalizeAndValidateWithOptions(cfg, "agent.claw", opts); err == nil || !strings.Contains(err.Error(), "not found in registry") {
+		t.Fatalf("expected missing entry error, got %v", err)
+	}
+}
+
+func TestValidateSkillsPathDriftSuggestsSkillDigest(t *testing.T) {
+	root := t.TempDir()
+	skillDir := filepath.Join(root, "skill")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatalf("mkdir skill dir: %v", err)
+	}
+	contract := `apiVersion: metaclaw.capability/v1
+kind: CapabilityContract
+metadata:
+  name: notes.reader
+  version: v1.1.0
+`
+	if err := os.WriteFile(filepath.Join(skillDir, "capability.contract.yaml"), []byte(contract), 0o644); err != nil {
+		t.Fatalf("write contract: %v", err)
+	}
+	digest, err := locks.SkillDigest(skillDir)
+	if err != nil {
+		t.Fatalf("SkillDigest() error = %v", err)
+	}
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			Skills:  []v1.SkillRef{{Path: "skill", Version: "v1.0.0"}},
+		},
+	}
+	claw := filepath.Join(root, "agent.claw")
+	_, err = NormalizeAndValidate(cfg, claw)
+	if err == nil {
+		t.Fatal("expected version mismatch error")
+	}
+	for _, want := range []string{"clawfile (v1.0.0)", "contract (v1.1.0)", "metaclaw skill digest skill"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not contain %q", err, want)
+		}
+	}
+
+	cfg.Agent.Skills = []v1.SkillRef{{Path: "skill", Version: "v1.1.0", Digest: digest}}
+	if _, err := NormalizeAndValidate(cfg, claw); err != nil {
+		t.Fatalf("matching digest should validate: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "run.sh"), []byte("#!/bin/sh\n"), 0o644); err != nil {
+		t.Fatalf("write skill file: %v", err)
+	}
+	_, err = NormalizeAndValidate(cfg, claw)
+	if err == nil || !strings.Contains(err.Error(), "digest drift") || !strings.Contains(err.Error(), digest) {
+		t.Fatalf("expected digest drift error, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// SpeciesFile is the name of the optional species registry in a state dir.
+const SpeciesFile = "species.yaml"
+
+var speciesNameRef = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// speciesRegistry is the on-disk layout of a species registry file:
+//
+//	species:
+//	  - name: gpu
+//	    defaultImage: registry.corp/agent@sha256:...
+//	    defaultCPU: "4"
+//	    defaultMemory: 8g
+//	    allowedOverrides:
+//	      allowResourceOverride: true
+type speciesRegistry struct {
+	Species []v1.SpeciesProfile `yaml:"species"`
+}
+
+// LoadSpeciesFile parses and checks a species registry file. Every profile
+// needs a lowercase name that is not a built-in species, a digest-pinned
+// default image, and default CPU and memory values; defaultCapDrop falls back
+// to [ALL] like the built-ins.
+func LoadSpeciesFile(path string) ([]v1.SpeciesProfile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read species file: %w", err)
+	}
+	var reg speciesRegistry
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&reg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse species file (%s): %w", filepath.Base(path), err)
+	}
+	seen := map[v1.Species]bool{}
+	for i, p := range reg.Species {
+		field := fmt.Sprintf("species[%d]", i)
+		if !speciesNameRef.MatchString(string(p.Name)) {
+			return nil, fmt.Errorf("%s.name %q must be lowercase letters, digits and dashes", field, p.Name)
+		}
+		field = fmt.Sprintf("species %s", p.Name)
+		if seen[p.Name] {
+			return nil, fmt.Errorf("%s is defined more than once", field)
+		}
+		seen[p.Name] = true
+		if isBuiltinSpecies(p.Name) {
+			return nil, fmt.Errorf("%s is built in and cannot be redefined", field)
+		}
+		if !IsDigestPinned(strings.TrimSpace(p.DefaultImage)) {
+			return nil, fmt.Errorf("%s: defaultImage must be digest-pinned (@sha256:...)", field)
+		}
+		if strings.TrimSpace(p.DefaultCPU) == "" {
+			return nil, fmt.Errorf("%s: defaultCPU is required", field)
+		}
+		if strings.TrimSpace(p.DefaultMem) == "" {
+			return nil, fmt.Errorf("%s: defaultMemory is required", field)
+		}
+		drop, err := normalizeCapList(field+": defaultCapDrop", p.DefaultCapDrop, true)
+		if err != nil {
+			return nil, err
+		}
+		if len(drop) == 0 {
+			drop = []string{"ALL"}
+		}
+		reg.Species[i].DefaultCapDrop = drop
+	}
+	return reg.Species, nil
+}
+
+// RegisterSpeciesFile loads a species registry file and registers its
+// profiles next to the built-in species.
+func RegisterSpeciesFile(path string) error {
+	profiles, err := LoadSpeciesFile(path)
+	if err != nil {
+		return err
+	}
+	return v1.RegisterSpecies(profiles...)
+}
+
+func isBuiltinSpecies(s v1.Species) bool {
+	switch s {
+	case v1.SpeciesNano, v1.SpeciesMicro, v1.SpeciesMega:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+)
+
+const testSpeciesImage = "registry.corp/agent@sha256:a4f4213abb84c497377b8544c81b3564f313746700372ec4fe84653e4fb03805"
+
+func writeSpeciesFile(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), SpeciesFile)
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRegisterSpeciesFile(t *testing.T) {
+	p := writeSpeciesFile(t, `species:
+  - name: gpu-test
+    defaultImage: `+testSpeciesImage+`
+    defaultCPU: "4"
+    defaultMemory: 8g
+    runtimeHints: [gpu]
+    allowedOverrides:
+      allowResourceOverride: true
+`)
+	if err := RegisterSpeciesFile(p); err != nil {
+		t.Fatalf("RegisterSpeciesFile() error = %v", err)
+	}
+	if err := RegisterSpeciesFile(p); err != nil {
+		t.Fatalf("re-registering the same file should be a no-op: %v", err)
+	}
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent:      v1.AgentSpec{Name: "a", Species: "gpu-test"},
+	}
+	got, err := NormalizeAndValidate(cfg, "agent.claw")
+	if err != nil {
+		t.Fatalf("NormalizeAndValidate() error = %v", err)
+	}
+	if got.Agent.Runtime.Image != testSpeciesImage || got.Agent.Runtime.Resources.CPU != "4" || got.Agent.Runtime.Resources.Memory != "8g" {
+		t.Fatalf("custom species defaults not applied: %+v", got.Agent.Runtime)
+	}
+	if strings.Join(got.Agent.Habitat.Capabilities.Drop, ",") != "ALL" {
+		t.Fatalf("expected custom species to drop ALL by default, got %v", got.Agent.Habitat.Capabilities.Drop)
+	}
+
+	cfg.Agent.Runtime.Image = "alpine:3.20@sha256:a4f4213abb84c497377b8544c81b3564f313746700372ec4fe84653e4fb03805"
+	if _, err := NormalizeAndValidate(cfg, "agent.claw"); err == nil || !strings.Contains(err.Error(), "does not allow overriding runtime.image") {
+		t.Fatalf("expected image override to be rejected, got %v", err)
+	}
+
+	cfg.Agent.Runtime.Image = ""
+	cfg.Agent.Species = "unknown-test"
+	if _, err := NormalizeAndValidate(cfg, "agent.claw"); err == nil || !strings.Contains(err.Error(), "gpu-test") {
+		t.Fatalf("expected unknown species error to list custom species, got %v", err)
+	}
+
+	conflict := writeSpeciesFile(t, `species:
+  - name: gpu-test
+    defaultImage: `+testSpeciesImage+`
+    defaultCPU: "8"
+    defaultMemory: 8g
+`)
+	if err := RegisterSpeciesFile(conflict); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("expected conflicting redefinition to fail, got %v", err)
+	}
+}
+
+func TestLoadSpeciesFileRejectsInvalidProfiles(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unpinned image", "species:\n  - {name: big, defaultImage: 'ubuntu:24.04', defaultCPU: '2', defaultMemory: 4g}\n", "digest-pinned"},
+		{"built in", "species:\n  - {name: micro, defaultImage: '" + testSpeciesImage + "', defaultCPU: '1', defaultMemory: 512m}\n", "built in"},
+		{"bad name", "species:\n  - {name: Big_One, defaultImage: '" + testSpeciesImage + "', defaultCPU: '2', defaultMemory: 4g}\n", "lowercase"},
+		{"duplicate", "species:\n  - {name: big, defaultImage: '" + testSpeciesImage + "', defaultCPU: '2', defaultMemory: 4g}\n  - {name: big, defaultImage: '" + testSpeciesImage + "', defaultCPU: '2', defaultMemory: 4g}\n", "more than once"},
+		{"missing cpu", "species:\n  - {name: big, defaultImage: '" + testSpeciesImage + "', defaultMemory: 4g}\n", "defaultCPU is required"},
+		{"missing memory", "species:\n  - {name: big, defaultImage: '" + testSpeciesImage + "', defaultCPU: '2'}\n", "defaultMemory is required"},
+		{"bad cap drop", "species:\n  - {name: big, defaultImage: '" + testSpeciesImage + "', defaultCPU: '2', defaultMemory: 4g, defaultCapDrop: [NOPE]}\n", "defaultCapDrop has unknown capability"},
+		{"unknown field", "species:\n  - {name: big, image: '" + testSpeciesImage + "'}\n", "parse species file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSpeciesFile(writeSpeciesFile(t, tt.body))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadSpeciesFile() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
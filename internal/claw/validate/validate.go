@@ -1,24 +1,80 @@
 package validate
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/fpp-125/metaclaw/internal/buildinfo"
 	"github.com/fpp-125/metaclaw/internal/capability"
 	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"github.com/fpp-125/metaclaw/internal/locks"
 )
 
 var digestRef = regexp.MustCompile(`.+@sha256:[a-fA-F0-9]{64}$`)
 var envNameRef = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var tmpfsSizeRef = regexp.MustCompile(`^[0-9]+[kmgKMG]?$`)
+var tmpfsModeRef = regexp.MustCompile(`^[0-7]{3,4}$`)
+var hostNameRef = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
 
+// IsDigestPinned reports whether ref carries an @sha256 digest.
+func IsDigestPinned(ref string) bool {
+	return digestRef.MatchString(ref)
+}
+
+// NormalizeAndValidate is NormalizeAndValidateWithWarnings for callers that
+// only care about hard errors.
 func NormalizeAndValidate(cfg v1.Clawfile, clawfilePath string) (v1.Clawfile, error) {
+	n, _, err := NormalizeAndValidateWithWarnings(cfg, clawfilePath)
+	return n, err
+}
+
+// NormalizeAndValidateWithWarnings fills defaults, rejects invalid configs,
+// and returns non-fatal findings alongside the normalized Clawfile.
+func NormalizeAndValidateWithWarnings(cfg v1.Clawfile, clawfilePath string) (v1.Clawfile, []Warning, error) {
+	return NormalizeAndValidateWithOptions(cfg, clawfilePath, Options{})
+}
+
+// Options tunes validation. SkillRegistry is a local directory of
+// <id>@<version>/ skill entries used to check id-based skills; when empty,
+// id-based skills are only checked for a pinned version and digest.
+// AllowedRegistries, when set, restricts agent.runtime.image to those
+// registry hosts (or host/prefix entries). CheckMounts stats every habitat
+// mount source on this host; AllowMissingMounts downgrades a source that does
+// not exist yet to a warning for workflows that create mounts lazily.
+// AllowSysAdmin permits habitat.capabilities.add to grant SYS_ADMIN.
+type Options struct {
+	SkillRegistry      string
+	AllowedRegistries  []string
+	CheckMounts        bool
+	AllowMissingMounts bool
+	AllowSysAdmin      bool
+}
+
+// NormalizeAndValidateWithOptions is NormalizeAndValidateWithWarnings with
+// explicit options.
+func NormalizeAndValidateWithOptions(cfg v1.Clawfile, clawfilePath string, opts Options) (v1.Clawfile, []Warning, error) {
+	n, errs := normalizeAndValidate(cfg, clawfilePath, opts)
+	if len(errs) > 0 {
+		return v1.Clawfile{}, nil, errs[0]
+	}
+	return n, collectWarnings(n, cfg, opts), nil
+}
+
+// normalizeAndValidate fills defaults and checks every section of cfg,
+// returning the first error of each failing section in declaration order.
+// Only a config that fails the basic shape or names an unknown species stops
+// early, since nothing after that can be checked meaningfully.
+func normalizeAndValidate(cfg v1.Clawfile, clawfilePath string, opts Options) (v1.Clawfile, []error) {
 	if err := cfg.ValidateBasics(); err != nil {
-		return v1.Clawfile{}, err
+		return v1.Clawfile{}, []error{err}
 	}
 
 	if cfg.Agent.Lifecycle == "" {
@@ -30,8 +86,19 @@ func NormalizeAndValidate(cfg v1.Clawfile, clawfilePath string) (v1.Clawfile, er
 
 	profile, ok := v1.SpeciesProfileFor(cfg.Agent.Species)
 	if !ok {
-		return v1.Clawfile{}, fmt.Errorf("unknown species: %s", cfg.Agent.Species)
+		return v1.Clawfile{}, []error{fmt.Errorf("unknown species: %s", cfg.Agent.Species)}
 	}
+
+	var errs []error
+	check := func(err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		return true
+	}
+
+	check(checkSpeciesOverrides(cfg.Agent, profile))
 	if cfg.Agent.Runtime.Image == "" {
 		cfg.Agent.Runtime.Image = profile.DefaultImage
 	}
@@ -44,28 +111,77 @@ func NormalizeAndValidate(cfg v1.Clawfile, clawfilePath string) (v1.Clawfile, er
 	if len(cfg.Agent.Command) == 0 {
 		cfg.Agent.Command = []string{"sh", "-lc", "echo MetaClaw agent started"}
 	}
-	if err := normalizeLLM(&cfg.Agent.LLM); err != nil {
-		return v1.Clawfile{}, err
-	}
+	check(normalizeLLM(&cfg.Agent.LLM))
+	check(normalizeCapabilities(&cfg.Agent, profile, opts.AllowSysAdmin))
+	check(validateRestartPolicy(cfg.Agent))
+	check(validateHealthcheck(cfg.Agent.Runtime.Healthcheck))
+	check(validateImage(cfg.Agent.Runtime.Image, opts.AllowedRegistries))
 
-	if !digestRef.MatchString(cfg.Agent.Runtime.Image) {
-		return v1.Clawfile{}, fmt.Errorf("agent.runtime.image must be digest-pinned (example: image@sha256:...)")
+	if check(validateNetwork(cfg.Agent.Habitat.Network.Mode)) {
+		domains, err := normalizeAllowedDomains(cfg.Agent.Habitat.Network)
+		if check(err) {
+			cfg.Agent.Habitat.Network.AllowedDomains = domains
+		}
 	}
-
-	if err := validateNetwork(cfg.Agent.Habitat.Network.Mode); err != nil {
-		return v1.Clawfile{}, err
+	if check(validateMounts(cfg.Agent.Habitat.Mounts)) && opts.CheckMounts {
+		check(checkMountSources(cfg.Agent.Habitat.Mounts, opts.AllowMissingMounts))
 	}
-	if err := validateMounts(cfg.Agent.Habitat.Mounts); err != nil {
-		return v1.Clawfile{}, err
+	check(validateTmpfs(cfg.Agent.Habitat))
+	ports, err := normalizePorts(cfg.Agent.Habitat)
+	if check(err) {
+		cfg.Agent.Habitat.Ports = ports
 	}
-	if err := validateSkills(cfg, filepath.Dir(clawfilePath)); err != nil {
-		return v1.Clawfile{}, err
+	check(validateReadOnlyRootfs(cfg.Agent.Habitat))
+	check(validateSecretFiles(cfg.Agent.Habitat))
+	check(validateExtraHosts(cfg.Agent.Habitat))
+	check(validateSkills(cfg, filepath.Dir(clawfilePath), opts.SkillRegistry))
+	check(normalizeSoul(&cfg.Agent.Soul, filepath.Dir(clawfilePath)))
+	check(validateSoulDirReserved(cfg.Agent.Habitat))
+	if len(errs) > 0 {
+		return v1.Clawfile{}, errs
 	}
 
 	cfg.Agent.Habitat.Env = sortedMap(cfg.Agent.Habitat.Env)
+	cfg.Agent.Habitat.SecretFiles = sortedMap(cfg.Agent.Habitat.SecretFiles)
 	return cfg, nil
 }
 
+// validateImage requires a digest-pinned image from an allowed registry.
+func validateImage(image string, allowedRegistries []string) error {
+	if !IsDigestPinned(image) {
+		return fmt.Errorf("agent.runtime.image must be digest-pinned (example: image@sha256:...)")
+	}
+	allowed, err := NormalizeAllowedRegistries(allowedRegistries)
+	if err != nil {
+		return err
+	}
+	if !RegistryAllowed(image, allowed) {
+		return fmt.Errorf("agent.runtime.image registry %s is not allowed (allowed: %s)", ImageRegistry(image), strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// checkSpeciesOverrides enforces the profile's allowed overrides on the
+// values the user wrote, before defaults are filled in. Restating a default is
+// not an override.
+func checkSpeciesOverrides(agent v1.AgentSpec, profile v1.SpeciesProfile) error {
+	res := agent.Runtime.Resources
+	if !profile.Allowed.AllowResourceOverride {
+		if cpu := strings.TrimSpace(res.CPU); cpu != "" && cpu != profile.DefaultCPU {
+			return fmt.Errorf("species %s does not allow overriding runtime.resources.cpu (default %s, got %s)", profile.Name, profile.DefaultCPU, cpu)
+		}
+		if mem := strings.TrimSpace(res.Memory); mem != "" && !strings.EqualFold(mem, profile.DefaultMem) {
+			return fmt.Errorf("species %s does not allow overriding runtime.resources.memory (default %s, got %s)", profile.Name, profile.DefaultMem, mem)
+		}
+	}
+	if !profile.Allowed.AllowImageOverride {
+		if img := strings.TrimSpace(agent.Runtime.Image); img != "" && img != profile.DefaultImage {
+			return fmt.Errorf("species %s does not allow overriding runtime.image (default %s)", profile.Name, profile.DefaultImage)
+		}
+	}
+	return nil
+}
+
 func normalizeLLM(spec *v1.LLMSpec) error {
 	if spec == nil {
 		return nil
@@ -98,6 +214,13 @@ func normalizeLLM(spec *v1.LLMSpec) error {
 		if spec.APIKeyEnv == "" {
 			spec.APIKeyEnv = "OPENAI_API_KEY"
 		}
+	case v1.LLMProviderAnthropic:
+		if spec.BaseURL == "" {
+			spec.BaseURL = "https://api.anthropic.com"
+		}
+		if spec.APIKeyEnv == "" {
+			spec.APIKeyEnv = "ANTHROPIC_API_KEY"
+		}
 	}
 	if !envNameRef.MatchString(spec.APIKeyEnv) {
 		return fmt.Errorf("agent.llm.apiKeyEnv must be a valid environment variable name")
@@ -105,6 +228,49 @@ func normalizeLLM(spec *v1.LLMSpec) error {
 	return nil
 }
 
+// validateRestartPolicy treats an empty mode as never and only allows restarts
+// for daemon agents, the one lifecycle the manager supervises.
+func validateRestartPolicy(agent v1.AgentSpec) error {
+	if agent.Runtime.RestartPolicy == nil {
+		return nil
+	}
+	rp := *agent.Runtime.RestartPolicy
+	if rp.Mode == "" {
+		rp.Mode = v1.RestartNever
+	}
+	if !rp.Mode.Valid() {
+		return fmt.Errorf("agent.runtime.restartPolicy.mode must be one of never,on-failure,always")
+	}
+	if rp.MaxRetries < 0 {
+		return fmt.Errorf("agent.runtime.restartPolicy.maxRetries must be >= 0")
+	}
+	if rp.Mode != v1.RestartNever && agent.Lifecycle != v1.LifecycleDaemon {
+		return fmt.Errorf("agent.runtime.restartPolicy.mode %s requires lifecycle daemon", rp.Mode)
+	}
+	return nil
+}
+
+func validateHealthcheck(hc *v1.HealthcheckSpec) error {
+	if hc == nil {
+		return nil
+	}
+	if strings.TrimSpace(hc.Command) == "" {
+		return fmt.Errorf("agent.runtime.healthcheck.command is required")
+	}
+	if hc.Retries < 0 {
+		return fmt.Errorf("agent.runtime.healthcheck.retries must be >= 0")
+	}
+	for _, f := range [][2]string{{"interval", hc.Interval}, {"startPeriod", hc.StartPeriod}} {
+		if f[1] == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(f[1]); err != nil || d <= 0 {
+			return fmt.Errorf("agent.runtime.healthcheck.%s must be a positive duration (example: 5s), got %q", f[0], f[1])
+		}
+	}
+	return nil
+}
+
 func validateNetwork(mode string) error {
 	switch mode {
 	case "none", "outbound", "all":
@@ -147,7 +313,195 @@ func validateMounts(mounts []v1.MountSpec) error {
 	return nil
 }
 
-func validateSkills(cfg v1.Clawfile, baseDir string) error {
+// checkMountSources requires every mount source to be an existing directory
+// on this host. With allowMissing, a source that does not exist yet passes
+// here and is reported by collectWarnings instead.
+func checkMountSources(mounts []v1.MountSpec, allowMissing bool) error {
+	for _, m := range mounts {
+		source := strings.TrimSpace(m.Source)
+		st, err := os.Stat(source)
+		if errors.Is(err, os.ErrNotExist) {
+			if allowMissing {
+				continue
+			}
+			return fmt.Errorf("habitat mount source does not exist: %s", source)
+		}
+		if err != nil {
+			return fmt.Errorf("habitat mount source %s: %w", source, err)
+		}
+		if !st.IsDir() {
+			return fmt.Errorf("habitat mount source is not a directory: %s", source)
+		}
+	}
+	return nil
+}
+
+// validateReadOnlyRootfs makes sure an agent with an immutable root filesystem
+// still has somewhere to write.
+func validateReadOnlyRootfs(h v1.HabitatSpec) error {
+	if !h.ReadOnlyRootfs {
+		return nil
+	}
+	if len(h.Tmpfs) > 0 {
+		return nil
+	}
+	for _, m := range h.Mounts {
+		if !m.ReadOnly {
+			return nil
+		}
+	}
+	return fmt.Errorf("agent.habitat.readOnlyRootfs requires a tmpfs or at least one writable habitat mount for scratch space")
+}
+
+// validateTmpfs applies the mount target rules to tmpfs targets; a tmpfs may not
+// share a target with a regular mount.
+func validateTmpfs(h v1.HabitatSpec) error {
+	seenTargets := make(map[string]struct{}, len(h.Mounts)+len(h.Tmpfs))
+	for _, m := range h.Mounts {
+		seenTargets[strings.TrimSpace(m.Target)] = struct{}{}
+	}
+	for _, t := range h.Tmpfs {
+		target := strings.TrimSpace(t.Target)
+		if !path.IsAbs(target) {
+			return fmt.Errorf("habitat tmpfs target must be an absolute container path (got %q)", t.Target)
+		}
+		cleanTarget := path.Clean(target)
+		if cleanTarget == "/" {
+			return fmt.Errorf("habitat tmpfs target cannot be root /")
+		}
+		if cleanTarget != target {
+			return fmt.Errorf("habitat tmpfs target must be normalized (got %q; want %q)", t.Target, cleanTarget)
+		}
+		if _, ok := seenTargets[target]; ok {
+			return fmt.Errorf("duplicate habitat mount target: %s", target)
+		}
+		seenTargets[target] = struct{}{}
+		if t.Size != "" && !tmpfsSizeRef.MatchString(t.Size) {
+			return fmt.Errorf("habitat tmpfs %s size must be a byte count with optional k/m/g suffix (got %q)", target, t.Size)
+		}
+		if t.Mode != "" && !tmpfsModeRef.MatchString(t.Mode) {
+			return fmt.Errorf("habitat tmpfs %s mode must be octal such as 1777 (got %q)", target, t.Mode)
+		}
+	}
+	return nil
+}
+
+// normalizePorts fills in the tcp protocol and loopback host IP defaults and
+// rejects out-of-range or duplicate host ports.
+func normalizePorts(h v1.HabitatSpec) ([]v1.PortMapping, error) {
+	if len(h.Ports) == 0 {
+		return nil, nil
+	}
+	if h.Network.Mode != "outbound" && h.Network.Mode != "all" {
+		return nil, fmt.Errorf("agent.habitat.ports requires network mode outbound or all")
+	}
+	seen := make(map[string]struct{}, len(h.Ports))
+	out := make([]v1.PortMapping, 0, len(h.Ports))
+	for _, pm := range h.Ports {
+		if pm.HostPort < 1 || pm.HostPort > 65535 || pm.ContainerPort < 1 || pm.ContainerPort > 65535 {
+			return nil, fmt.Errorf("habitat port %d:%d must use ports between 1 and 65535", pm.HostPort, pm.ContainerPort)
+		}
+		pm.Protocol = strings.ToLower(strings.TrimSpace(pm.Protocol))
+		if pm.Protocol == "" {
+			pm.Protocol = "tcp"
+		}
+		if pm.Protocol != "tcp" && pm.Protocol != "udp" {
+			return nil, fmt.Errorf("habitat port %d protocol must be tcp or udp (got %q)", pm.HostPort, pm.Protocol)
+		}
+		pm.HostIP = strings.TrimSpace(pm.HostIP)
+		if pm.HostIP == "" {
+			pm.HostIP = "127.0.0.1"
+		}
+		if net.ParseIP(pm.HostIP) == nil {
+			return nil, fmt.Errorf("habitat port %d hostIP must be an IP address (got %q)", pm.HostPort, pm.HostIP)
+		}
+		key := fmt.Sprintf("%d/%s", pm.HostPort, pm.Protocol)
+		if _, dup := seen[key]; dup {
+			return nil, fmt.Errorf("duplicate habitat host port: %s", key)
+		}
+		seen[key] = struct{}{}
+		out = append(out, pm)
+	}
+	return out, nil
+}
+
+func validateSecretFiles(h v1.HabitatSpec) error {
+	for name, p := range h.SecretFiles {
+		if !envNameRef.MatchString(name) {
+			return fmt.Errorf("habitat secretFiles name must be a valid environment variable name (got %q)", name)
+		}
+		if _, ok := h.Env[name]; ok {
+			return fmt.Errorf("habitat secretFiles %s is also declared in habitat.env", name)
+		}
+		source := strings.TrimSpace(p)
+		if !filepath.IsAbs(source) {
+			return fmt.Errorf("habitat secretFiles %s path must be an absolute path (got %q)", name, p)
+		}
+		if clean := filepath.Clean(source); clean != source {
+			return fmt.Errorf("habitat secretFiles %s path must be normalized (got %q; want %q)", name, p, clean)
+		}
+	}
+	return nil
+}
+
+// normalizeAllowedDomains lower-cases, sorts and deduplicates the outbound
+// domain allowlist. Entries are exact host names; wildcards cannot be pinned.
+func normalizeAllowedDomains(n v1.NetworkSpec) ([]string, error) {
+	if len(n.AllowedDomains) == 0 {
+		return nil, nil
+	}
+	if n.Mode != "outbound" {
+		return nil, fmt.Errorf("agent.habitat.network.allowedDomains requires network mode outbound (got %s)", n.Mode)
+	}
+	seen := make(map[string]struct{}, len(n.AllowedDomains))
+	out := make([]string, 0, len(n.AllowedDomains))
+	for _, d := range n.AllowedDomains {
+		name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+		if len(name) > 253 || !hostNameRef.MatchString(name) {
+			return nil, fmt.Errorf("agent.habitat.network.allowedDomains entry %q must be a plain host name (no scheme, port or wildcard)", d)
+		}
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// validateExtraHosts checks name:ip entries. The name ends at the first colon so
+// IPv6 addresses can be used unbracketed, matching docker --add-host.
+func validateExtraHosts(h v1.HabitatSpec) error {
+	if len(h.ExtraHosts) == 0 {
+		return nil
+	}
+	if h.Network.Mode == "none" {
+		return fmt.Errorf("agent.habitat.extraHosts requires network mode outbound or all")
+	}
+	seen := make(map[string]struct{}, len(h.ExtraHosts))
+	for _, entry := range h.ExtraHosts {
+		name, ip, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || ip == "" {
+			return fmt.Errorf("habitat extraHosts entry must be name:ip (got %q)", entry)
+		}
+		if len(name) > 253 || !hostNameRef.MatchString(name) {
+			return fmt.Errorf("habitat extraHosts entry %q has invalid hostname %q", entry, name)
+		}
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("habitat extraHosts entry %q has invalid ip %q", entry, ip)
+		}
+		key := strings.ToLower(name)
+		if _, dup := seen[key]; dup {
+			return fmt.Errorf("duplicate habitat extraHosts name: %s", name)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func validateSkills(cfg v1.Clawfile, baseDir, registryDir string) error {
+	engineVersion := buildinfo.Read().Version
 	for _, s := range cfg.Agent.Skills {
 		hasPath := s.Path != ""
 		hasID := s.ID != ""
@@ -167,18 +521,120 @@ func validateSkills(cfg v1.Clawfile, baseDir string) error {
 				return fmt.Errorf("skill %s: %w", s.Path, err)
 			}
 			if strings.TrimSpace(s.Version) != "" && strings.TrimSpace(s.Version) != strings.TrimSpace(contract.Metadata.Version) {
-				return fmt.Errorf("skill %s: version mismatch between clawfile (%s) and contract (%s)", s.Path, s.Version, contract.Metadata.Version)
+				return fmt.Errorf("skill %s: version mismatch between clawfile (%s) and contract (%s); set version: %s and refresh digest: with `metaclaw skill digest %s`", s.Path, s.Version, contract.Metadata.Version, contract.Metadata.Version, s.Path)
+			}
+			if want := strings.TrimSpace(s.Digest); want != "" {
+				got, err := locks.SkillDigest(resolved)
+				if err != nil {
+					return fmt.Errorf("skill %s: hash skill: %w", s.Path, err)
+				}
+				if got != want {
+					return fmt.Errorf("skill %s: digest drift: clawfile pins %s but the skill now hashes to %s; review the change, then update digest: (`metaclaw skill digest %s`)", s.Path, want, got, s.Path)
+				}
 			}
 			if err := capability.ValidateAgainstAgent(contract, cfg.Agent); err != nil {
 				return fmt.Errorf("skill %s contract (%s): %w", s.Path, filepath.Base(contractPath), err)
 			}
+			if err := capability.ValidateCompatibility(contract, engineVersion); err != nil {
+				return fmt.Errorf("skill %s contract (%s): %w", s.Path, filepath.Base(contractPath), err)
+			}
 			continue
 		}
 		if strings.TrimSpace(s.Version) == "" {
 			return fmt.Errorf("skill id %s requires version for reproducible resolution", s.ID)
 		}
 		if strings.TrimSpace(s.Digest) == "" {
-			return fmt.Errorf("skill id %s requires digest for reproducible resolution", s.ID)
+			return fmt.Errorf("skill id %s requires digest for reproducible resolution; compute it with `metaclaw skill digest <skill-dir>` on the %s@%s registry entry", s.ID, s.ID, s.Version)
+		}
+		if registryDir == "" {
+			continue
+		}
+		contract, entry, err := capability.LoadFromRegistry(registryDir, s.ID, s.Version)
+		if err != nil {
+			return fmt.Errorf("skill id %s: %w", s.ID, err)
+		}
+		if strings.TrimSpace(s.Version) != strings.TrimSpace(contract.Metadata.Version) {
+			return fmt.Errorf("skill id %s: version mismatch between clawfile (%s) and registry contract (%s)", s.ID, s.Version, contract.Metadata.Version)
+		}
+		got, err := locks.SkillDigest(entry)
+		if err != nil {
+			return fmt.Errorf("skill id %s: hash registry entry: %w", s.ID, err)
+		}
+		if got != strings.TrimSpace(s.Digest) {
+			return fmt.Errorf("skill id %s@%s: registry digest %s does not match clawfile digest %s; verify the entry with `metaclaw skill digest %s`", s.ID, s.Version, got, s.Digest, entry)
+		}
+		if err := capability.ValidateAgainstAgent(contract, cfg.Agent); err != nil {
+			return fmt.Errorf("skill id %s contract: %w", s.ID, err)
+		}
+		if err := capability.ValidateCompatibility(contract, engineVersion); err != nil {
+			return fmt.Errorf("skill id %s contract: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// normalizeSoul resolves file-backed soul entries against the clawfile
+// directory and checks that they exist; persona must be a file, memory may
+// also be a directory. A value is read as a path when it is a single word
+// that starts with /, ./ or ../; anything else is inline text and is left
+// alone.
+func normalizeSoul(s *v1.SoulSpec, baseDir string) error {
+	for _, f := range []struct {
+		field string
+		value *string
+		dirOK bool
+	}{
+		{"persona", &s.Persona, false},
+		{"memory", &s.Memory, true},
+	} {
+		raw := strings.TrimSpace(*f.value)
+		if !isSoulPath(raw) {
+			continue
+		}
+		resolved := raw
+		if !filepath.IsAbs(resolved) {
+			abs, err := filepath.Abs(filepath.Join(baseDir, raw))
+			if err != nil {
+				return fmt.Errorf("agent.soul.%s: %w", f.field, err)
+			}
+			resolved = abs
+		}
+		resolved = filepath.Clean(resolved)
+		st, err := os.Stat(resolved)
+		if err != nil {
+			return fmt.Errorf("agent.soul.%s not found: %s", f.field, raw)
+		}
+		if st.IsDir() && !f.dirOK {
+			return fmt.Errorf("agent.soul.%s must be a file, not a directory: %s", f.field, raw)
+		}
+		*f.value = resolved
+	}
+	if s.MemoryWritable && !filepath.IsAbs(s.Memory) {
+		return fmt.Errorf("agent.soul.memoryWritable requires agent.soul.memory to be a file path")
+	}
+	return nil
+}
+
+func isSoulPath(v string) bool {
+	if v == "" || strings.ContainsAny(v, " \t\r\n") {
+		return false
+	}
+	return filepath.IsAbs(v) || strings.HasPrefix(v, "./") || strings.HasPrefix(v, "../")
+}
+
+// validateSoulDirReserved keeps habitat mounts and tmpfs out of v1.SoulDir,
+// where file-backed soul entries are mounted.
+func validateSoulDirReserved(h v1.HabitatSpec) error {
+	targets := make([]string, 0, len(h.Mounts)+len(h.Tmpfs))
+	for _, m := range h.Mounts {
+		targets = append(targets, strings.TrimSpace(m.Target))
+	}
+	for _, t := range h.Tmpfs {
+		targets = append(targets, strings.TrimSpace(t.Target))
+	}
+	for _, t := range targets {
+		if t == v1.SoulDir || strings.HasPrefix(t, v1.SoulDir+"/") || strings.HasPrefix(v1.SoulDir, t+"/") {
+			return fmt.Errorf("habitat target %s overlaps %s, which is reserved for agent.soul", t, v1.SoulDir)
 		}
 	}
 	return nil
@@ -1,6 +1,8 @@
 package validate
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -73,6 +75,31 @@ func TestNormalizeLLMGeminiDefaults(t *testing.T) {
 	}
 }
 
+func TestNormalizeLLMAnthropicDefaults(t *testing.T) {
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			LLM: v1.LLMSpec{
+				Provider: v1.LLMProviderAnthropic,
+				Model:    "claude-sonnet-4-5",
+			},
+		},
+	}
+	got, err := NormalizeAndValidate(cfg, "agent.claw")
+	if err != nil {
+		t.Fatalf("NormalizeAndValidate() error = %v", err)
+	}
+	if got.Agent.LLM.APIKeyEnv != "ANTHROPIC_API_KEY" {
+		t.Fatalf("expected default apiKeyEnv ANTHROPIC_API_KEY, got %q", got.Agent.LLM.APIKeyEnv)
+	}
+	if got.Agent.LLM.BaseURL != "https://api.anthropic.com" {
+		t.Fatalf("expected default Anthropic baseURL, got %q", got.Agent.LLM.BaseURL)
+	}
+}
+
 func TestRejectLLMWithoutProvider(t *testing.T) {
 	cfg := v1.Clawfile{
 		APIVersion: "metaclaw/v1",
@@ -180,3 +207,594 @@ func TestRejectDuplicateMountTargets(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestValidateSecretFiles(t *testing.T) {
+	base := func(files map[string]string, env map[string]string) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Habitat: v1.HabitatSpec{Env: env, SecretFiles: files},
+			},
+		}
+	}
+	abs := filepath.Join(filepath.Clean(t.TempDir()), "token")
+	if _, err := NormalizeAndValidate(base(map[string]string{"GITHUB_TOKEN": abs}, nil), "agent.claw"); err != nil {
+		t.Fatalf("expected valid secretFiles, got %v", err)
+	}
+	cases := []struct {
+		files map[string]string
+		env   map[string]string
+		want  string
+	}{
+		{files: map[string]string{"BAD-NAME": abs}, want: "valid environment variable name"},
+		{files: map[string]string{"TOKEN": "secrets/token"}, want: "must be an absolute path"},
+		{files: map[string]string{"TOKEN": abs}, env: map[string]string{"TOKEN": ""}, want: "also declared in habitat.env"},
+	}
+	for _, tc := range cases {
+		_, err := NormalizeAndValidate(base(tc.files, tc.env), "agent.claw")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("secretFiles %v: expected error containing %q, got %v", tc.files, tc.want, err)
+		}
+	}
+}
+
+func TestValidateExtraHosts(t *testing.T) {
+	base := func(mode string, hosts ...string) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: mode}, ExtraHosts: hosts},
+			},
+		}
+	}
+	if _, err := NormalizeAndValidate(base("outbound", "db.internal:10.0.0.5", "v6-host:fd00::1"), "agent.claw"); err != nil {
+		t.Fatalf("expected valid extraHosts, got %v", err)
+	}
+	cases := []struct {
+		mode  string
+		hosts []string
+		want  string
+	}{
+		{mode: "none", hosts: []string{"db:10.0.0.5"}, want: "requires network mode"},
+		{mode: "outbound", hosts: []string{"db"}, want: "must be name:ip"},
+		{mode: "outbound", hosts: []string{"bad_name:10.0.0.5"}, want: "invalid hostname"},
+		{mode: "outbound", hosts: []string{"db:10.0.0.999"}, want: "invalid ip"},
+		{mode: "all", hosts: []string{"db:10.0.0.5", "DB:10.0.0.6"}, want: "duplicate habitat extraHosts"},
+	}
+	for _, tc := range cases {
+		_, err := NormalizeAndValidate(base(tc.mode, tc.hosts...), "agent.claw")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("extraHosts %v (mode %s): expected error containing %q, got %v", tc.hosts, tc.mode, tc.want, err)
+		}
+	}
+}
+
+func TestNormalizeCapabilities(t *testing.T) {
+	base := func(add, drop []string) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesMicro,
+				Habitat: v1.HabitatSpec{Capabilities: v1.CapabilitiesSpec{Add: add, Drop: drop}},
+			},
+		}
+	}
+	got, err := NormalizeAndValidate(base([]string{"cap_net_bind_service", "CHOWN", "chown"}, nil), "agent.claw")
+	if err != nil {
+		t.Fatalf("NormalizeAndValidate() error = %v", err)
+	}
+	if strings.Join(got.Agent.Habitat.Capabilities.Drop, ",") != "ALL" {
+		t.Fatalf("expected species default drop [ALL], got %v", got.Agent.Habitat.Capabilities.Drop)
+	}
+	if strings.Join(got.Agent.Habitat.Capabilities.Add, ",") != "CHOWN,NET_BIND_SERVICE" {
+		t.Fatalf("expected normalized add, got %v", got.Agent.Habitat.Capabilities.Add)
+	}
+
+	legacy := base(nil, nil)
+	legacy.Agent.Runtime = v1.RuntimeSpec{CapAdd: []string{"NET_RAW"}, CapDrop: []string{"mknod"}}
+	got, err = NormalizeAndValidate(legacy, "agent.claw")
+	if err != nil {
+		t.Fatalf("NormalizeAndValidate() legacy runtime caps error = %v", err)
+	}
+	if caps := got.Agent.Habitat.Capabilities; strings.Join(caps.Add, ",") != "NET_RAW" || strings.Join(caps.Drop, ",") != "MKNOD" || len(got.Agent.Runtime.CapAdd) != 0 {
+		t.Fatalf("expected runtime.capAdd/capDrop moved into habitat.capabilities, got %+v / %+v", caps, got.Agent.Runtime)
+	}
+	legacy.Agent.Habitat.Capabilities.Add = []string{"CHOWN"}
+	if _, err := NormalizeAndValidate(legacy, "agent.claw"); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("expected runtime caps plus habitat.capabilities to fail, got %v", err)
+	}
+
+	cases := []struct {
+		add, drop []string
+		want      string
+	}{
+		{add: []string{"NOT_A_CAP"}, want: "unknown capability"},
+		{add: []string{"ALL"}, want: "cannot contain ALL"},
+		{add: []string{"NET_RAW"}, drop: []string{"net_raw"}, want: "both add and drop"},
+		{add: []string{"cap_sys_admin"}, want: "requires the --allow-sys-admin override"},
+	}
+	for _, tc := range cases {
+		_, err := NormalizeAndValidate(base(tc.add, tc.drop), "agent.claw")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("add=%v drop=%v: expected error containing %q, got %v", tc.add, tc.drop, tc.want, err)
+		}
+	}
+
+	if _, _, err := NormalizeAndValidateWithOptions(base([]string{"SYS_ADMIN"}, nil), "agent.claw", Options{AllowSysAdmin: true}); err != nil {
+		t.Fatalf("expected SYS_ADMIN with the override to validate, got %v", err)
+	}
+}
+
+func TestNormalizeAndValidateWithWarnings(t *testing.T) {
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			Habitat: v1.HabitatSpec{
+				Network:    v1.NetworkSpec{Mode: "outbound"},
+				ExtraHosts: []string{"db:10.0.0.5"},
+			},
+			Soul:    v1.SoulSpec{Persona: "helpful"},
+			Command: []string{"python", "bot.py"},
+			Runtime: v1.RuntimeSpec{Target: v1.RuntimeApple, CapAdd: []string{"NET_RAW"}},
+		},
+	}
+	_, warnings, err := NormalizeAndValidateWithWarnings(cfg, "agent.claw")
+	if err != nil {
+		t.Fatalf("NormalizeAndValidateWithWarnings() error = %v", err)
+	}
+	if len(warnings) != 2 || warnings[0].Field != "agent.habitat.capabilities.add" || warnings[1].Field != "agent.habitat.extraHosts" {
+		t.Fatalf("unexpected warnings: %+v", warnings)
+	}
+	for _, w := range warnings {
+		if w.Code != WarnRuntimeUnsupported {
+			t.Fatalf("unexpected warning code: %+v", w)
+		}
+	}
+
+	cfg.Agent.Runtime.Target = v1.RuntimeDocker
+	if _, warnings, _ := NormalizeAndValidateWithWarnings(cfg, "agent.claw"); len(warnings) != 0 {
+		t.Fatalf("expected no warnings for docker, got %+v", warnings)
+	}
+}
+
+func TestNormalizeAndValidateReport(t *testing.T) {
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: "all"}},
+		},
+	}
+	rep := NormalizeAndValidateReport(cfg, "agent.claw", Options{})
+	if !rep.OK() {
+		t.Fatalf("unexpected errors: %v", rep.Errors)
+	}
+	var codes []string
+	for _, w := range rep.Warnings {
+		codes = append(codes, w.Code)
+	}
+	if strings.Join(codes, ",") != WarnDefaultCommand+","+WarnNetworkAll+","+WarnMissingSoul {
+		t.Fatalf("unexpected warnings: %+v", rep.Warnings)
+	}
+	if len(rep.Config.Agent.Command) == 0 {
+		t.Fatal("expected the normalized config in the report")
+	}
+
+	cfg.Agent.Habitat.Network.Mode = "bogus"
+	rep = NormalizeAndValidateReport(cfg, "agent.claw", Options{})
+	if rep.OK() || len(rep.Warnings) != 0 {
+		t.Fatalf("expected an error-only report, got %+v", rep)
+	}
+}
+
+func TestNormalizeAndValidateReportCollectsErrorsPerSection(t *testing.T) {
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			Habitat: v1.HabitatSpec{
+				Network: v1.NetworkSpec{Mode: "bogus"},
+				Mounts:  []v1.MountSpec{{Source: "relative/dir", Target: "/data"}},
+			},
+		},
+	}
+	rep := NormalizeAndValidateReport(cfg, "agent.claw", Options{})
+	if len(rep.Errors) != 2 {
+		t.Fatalf("expected one error per failing section, got %q", rep.Errors)
+	}
+	if !strings.Contains(rep.Errors[0], "network.mode") || !strings.Contains(rep.Errors[1], "mount source must be an absolute path") {
+		t.Fatalf("unexpected errors: %q", rep.Errors)
+	}
+
+	_, err := NormalizeAndValidate(cfg, "agent.claw")
+	if err == nil || err.Error() != rep.Errors[0] {
+		t.Fatalf("expected the fail-fast error to be the first reported one, got %v", err)
+	}
+}
+
+func TestValidateRestartPolicy(t *testing.T) {
+	base := func(lifecycle v1.LifecycleMode, rp *v1.RestartPolicy) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:      "a",
+				Species:   v1.SpeciesNano,
+				Lifecycle: lifecycle,
+				Runtime:   v1.RuntimeSpec{RestartPolicy: rp},
+			},
+		}
+	}
+	if _, err := NormalizeAndValidate(base(v1.LifecycleDaemon, &v1.RestartPolicy{Mode: v1.RestartOnFailure, MaxRetries: 3}), "agent.claw"); err != nil {
+		t.Fatalf("expected daemon on-failure policy to validate: %v", err)
+	}
+	if _, err := NormalizeAndValidate(base(v1.LifecycleEphemeral, &v1.RestartPolicy{Mode: v1.RestartNever}), "agent.claw"); err != nil {
+		t.Fatalf("expected never policy on ephemeral agent to validate: %v", err)
+	}
+	for name, cfg := range map[string]v1.Clawfile{
+		"bad mode":     base(v1.LifecycleDaemon, &v1.RestartPolicy{Mode: "sometimes"}),
+		"negative max": base(v1.LifecycleDaemon, &v1.RestartPolicy{Mode: v1.RestartAlways, MaxRetries: -1}),
+		"not a daemon": base(v1.LifecycleEphemeral, &v1.RestartPolicy{Mode: v1.RestartAlways}),
+	} {
+		if _, err := NormalizeAndValidate(cfg, "agent.claw"); err == nil || !strings.Contains(err.Error(), "restartPolicy") {
+			t.Fatalf("%s: expected restartPolicy error, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateHealthcheck(t *testing.T) {
+	base := func(hc *v1.HealthcheckSpec) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent:      v1.AgentSpec{Name: "a", Species: v1.SpeciesNano, Runtime: v1.RuntimeSpec{Healthcheck: hc}},
+		}
+	}
+	if _, err := NormalizeAndValidate(base(&v1.HealthcheckSpec{Command: "true", Interval: "5s", Retries: 2, StartPeriod: "30s"}), "agent.claw"); err != nil {
+		t.Fatalf("expected healthcheck to validate: %v", err)
+	}
+	for name, hc := range map[string]*v1.HealthcheckSpec{
+		"missing command":  {Interval: "5s"},
+		"bad interval":     {Command: "true", Interval: "soon"},
+		"zero startPeriod": {Command: "true", StartPeriod: "0s"},
+		"negative retries": {Command: "true", Retries: -1},
+	} {
+		if _, err := NormalizeAndValidate(base(hc), "agent.claw"); err == nil || !strings.Contains(err.Error(), "healthcheck") {
+			t.Fatalf("%s: expected healthcheck error, got %v", name, err)
+		}
+	}
+}
+
+func TestNormalizeAllowedDomains(t *testing.T) {
+	base := func(mode string, domains ...string) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: mode, AllowedDomains: domains}},
+			},
+		}
+	}
+	got, err := NormalizeAndValidate(base("outbound", "API.example.com.", "b.example.org", "api.example.com"), "agent.claw")
+	if err != nil {
+		t.Fatalf("NormalizeAndValidate() error = %v", err)
+	}
+	if d := got.Agent.Habitat.Network.AllowedDomains; len(d) != 2 || d[0] != "api.example.com" || d[1] != "b.example.org" {
+		t.Fatalf("AllowedDomains = %v", d)
+	}
+	for name, cfg := range map[string]v1.Clawfile{
+		"mode none": base("none", "api.example.com"),
+		"mode all":  base("all", "api.example.com"),
+		"wildcard":  base("outbound", "*.example.com"),
+		"url":       base("outbound", "https://api.example.com"),
+	} {
+		if _, err := NormalizeAndValidate(cfg, "agent.claw"); err == nil || !strings.Contains(err.Error(), "allowedDomains") {
+			t.Fatalf("%s: expected allowedDomains error, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateReadOnlyRootfsNeedsWritableMount(t *testing.T) {
+	base := func(mounts ...v1.MountSpec) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: "none"}, Mounts: mounts, ReadOnlyRootfs: true},
+			},
+		}
+	}
+	if _, err := NormalizeAndValidate(base(v1.MountSpec{Source: "/host/data", Target: "/data"}), "agent.claw"); err != nil {
+		t.Fatalf("expected read-only rootfs with writable mount to validate, got %v", err)
+	}
+	_, err := NormalizeAndValidate(base(v1.MountSpec{Source: "/host/vault", Target: "/vault", ReadOnly: true}), "agent.claw")
+	if err == nil || !strings.Contains(err.Error(), "readOnlyRootfs") {
+		t.Fatalf("expected readOnlyRootfs error without writable mount, got %v", err)
+	}
+}
+
+func TestValidateTmpfs(t *testing.T) {
+	base := func(tmpfs ...v1.TmpfsSpec) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Habitat: v1.HabitatSpec{
+					Network:        v1.NetworkSpec{Mode: "none"},
+					Mounts:         []v1.MountSpec{{Source: "/host/vault", Target: "/vault", ReadOnly: true}},
+					Tmpfs:          tmpfs,
+					ReadOnlyRootfs: true,
+				},
+			},
+		}
+	}
+	if _, err := NormalizeAndValidate(base(v1.TmpfsSpec{Target: "/tmp", Size: "64m", Mode: "1777"}), "agent.claw"); err != nil {
+		t.Fatalf("expected tmpfs to satisfy readOnlyRootfs scratch space, got %v", err)
+	}
+	cases := []struct {
+		tmpfs v1.TmpfsSpec
+		want  string
+	}{
+		{tmpfs: v1.TmpfsSpec{Target: "tmp"}, want: "absolute container path"},
+		{tmpfs: v1.TmpfsSpec{Target: "/tmp/../var"}, want: "must be normalized"},
+		{tmpfs: v1.TmpfsSpec{Target: "/vault"}, want: "duplicate habitat mount target"},
+		{tmpfs: v1.TmpfsSpec{Target: "/tmp", Size: "64MB"}, want: "size must be"},
+		{tmpfs: v1.TmpfsSpec{Target: "/tmp", Mode: "rwx"}, want: "mode must be octal"},
+	}
+	for _, tc := range cases {
+		_, err := NormalizeAndValidate(base(tc.tmpfs), "agent.claw")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("tmpfs %+v: expected error containing %q, got %v", tc.tmpfs, tc.want, err)
+		}
+	}
+}
+
+func TestNormalizePorts(t *testing.T) {
+	base := func(mode string, ports ...v1.PortMapping) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: mode}, Ports: ports},
+			},
+		}
+	}
+	got, err := NormalizeAndValidate(base("outbound", v1.PortMapping{HostPort: 8080, ContainerPort: 80}, v1.PortMapping{HostPort: 8080, ContainerPort: 80, Protocol: "UDP", HostIP: "0.0.0.0"}), "agent.claw")
+	if err != nil {
+		t.Fatalf("NormalizeAndValidate() error = %v", err)
+	}
+	if p := got.Agent.Habitat.Ports; len(p) != 2 || p[0].Protocol != "tcp" || p[0].HostIP != "127.0.0.1" || p[1].Protocol != "udp" || p[1].HostIP != "0.0.0.0" {
+		t.Fatalf("unexpected normalized ports: %+v", p)
+	}
+	cases := []struct {
+		mode  string
+		ports []v1.PortMapping
+		want  string
+	}{
+		{mode: "none", ports: []v1.PortMapping{{HostPort: 8080, ContainerPort: 80}}, want: "requires network mode"},
+		{mode: "outbound", ports: []v1.PortMapping{{HostPort: 0, ContainerPort: 80}}, want: "between 1 and 65535"},
+		{mode: "outbound", ports: []v1.PortMapping{{HostPort: 8080, ContainerPort: 70000}}, want: "between 1 and 65535"},
+		{mode: "outbound", ports: []v1.PortMapping{{HostPort: 8080, ContainerPort: 80, Protocol: "sctp"}}, want: "tcp or udp"},
+		{mode: "outbound", ports: []v1.PortMapping{{HostPort: 8080, ContainerPort: 80, HostIP: "localhost"}}, want: "hostIP"},
+		{mode: "all", ports: []v1.PortMapping{{HostPort: 8080, ContainerPort: 80}, {HostPort: 8080, ContainerPort: 81, Protocol: "tcp"}}, want: "duplicate habitat host port"},
+	}
+	for _, tc := range cases {
+		_, err := NormalizeAndValidate(base(tc.mode, tc.ports...), "agent.claw")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("ports %+v (mode %s): expected error containing %q, got %v", tc.ports, tc.mode, tc.want, err)
+		}
+	}
+}
+
+func TestAllowedRegistries(t *testing.T) {
+	const digest = "@sha256:a4f4213abb84c497377b8544c81b3564f313746700372ec4fe84653e4fb03805"
+	base := func(image string) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Runtime: v1.RuntimeSpec{Image: image},
+			},
+		}
+	}
+	opts := Options{AllowedRegistries: []string{" Registry.Corp.Internal ", "ghcr.io/acme/"}}
+	for _, image := range []string{
+		"registry.corp.internal/bots/agent:1" + digest,
+		"ghcr.io/acme/agent" + digest,
+	} {
+		if _, _, err := NormalizeAndValidateWithOptions(base(image), "agent.claw", opts); err != nil {
+			t.Fatalf("%s: unexpected error %v", image, err)
+		}
+	}
+	for _, image := range []string{
+		"alpine:3.20" + digest,
+		"docker.io/library/alpine" + digest,
+		"ghcr.io/other/agent" + digest,
+		"registry.corp.internal.evil.com/agent" + digest,
+		"registry.corp.internal:5000/agent" + digest,
+	} {
+		if _, _, err := NormalizeAndValidateWithOptions(base(image), "agent.claw", opts); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+			t.Fatalf("%s: expected registry rejection, got %v", image, err)
+		}
+	}
+	if _, err := NormalizeAndValidate(base("alpine:3.20"+digest), "agent.claw"); err != nil {
+		t.Fatalf("empty allowlist should allow docker.io: %v", err)
+	}
+	if got := ImageRegistry("localhost:5000/agent"); got != "localhost:5000" {
+		t.Fatalf("ImageRegistry(localhost:5000/agent) = %q", got)
+	}
+	if !RegistryAllowed("alpine:3.20"+digest, []string{"docker.io/library"}) {
+		t.Fatal("expected docker.io/library prefix to cover official images")
+	}
+}
+
+func TestCheckMountSources(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "vault")
+	if err := os.Mkdir(existing, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(root, "notes.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(root, "later")
+	base := func(sources ...string) v1.Clawfile {
+		cfg := v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent:      v1.AgentSpec{Name: "a", Species: v1.SpeciesNano},
+		}
+		for i, s := range sources {
+			cfg.Agent.Habitat.Mounts = append(cfg.Agent.Habitat.Mounts, v1.MountSpec{Source: s, Target: fmt.Sprintf("/m%d", i)})
+		}
+		return cfg
+	}
+	check := Options{CheckMounts: true}
+	if _, _, err := NormalizeAndValidateWithOptions(base(existing), "agent.claw", check); err != nil {
+		t.Fatalf("existing dir: %v", err)
+	}
+	if _, err := NormalizeAndValidate(base(missing), "agent.claw"); err != nil {
+		t.Fatalf("mount sources are not checked by default: %v", err)
+	}
+	if _, _, err := NormalizeAndValidateWithOptions(base(existing, missing), "agent.claw", check); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected missing source error, got %v", err)
+	}
+	if _, _, err := NormalizeAndValidateWithOptions(base(file), "agent.claw", check); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Fatalf("expected non-directory error, got %v", err)
+	}
+
+	lazy := Options{CheckMounts: true, AllowMissingMounts: true}
+	_, warnings, err := NormalizeAndValidateWithOptions(base(existing, missing), "agent.claw", lazy)
+	if err != nil {
+		t.Fatalf("allow missing: %v", err)
+	}
+	found := false
+	for _, w := range warnings {
+		if w.Code == WarnMountSourceMissing && w.Field == "agent.habitat.mounts[1].source" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected %s warning, got %+v", WarnMountSourceMissing, warnings)
+	}
+	if _, _, err := NormalizeAndValidateWithOptions(base(file), "agent.claw", lazy); err == nil {
+		t.Fatal("allow missing must still reject a non-directory source")
+	}
+}
+
+func TestNormalizeSoul(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "persona.md"), []byte("be kind"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, "memory"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	clawPath := filepath.Join(root, "agent.claw")
+	base := func(soul v1.SoulSpec) v1.Clawfile {
+		return v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent:      v1.AgentSpec{Name: "a", Species: v1.SpeciesNano, Soul: soul},
+		}
+	}
+
+	got, err := NormalizeAndValidate(base(v1.SoulSpec{Persona: "./persona.md", Memory: "./memory"}), clawPath)
+	if err != nil {
+		t.Fatalf("NormalizeAndValidate() error = %v", err)
+	}
+	if got.Agent.Soul.Persona != filepath.Join(root, "persona.md") || got.Agent.Soul.Memory != filepath.Join(root, "memory") {
+		t.Fatalf("expected soul paths resolved against the clawfile dir, got %+v", got.Agent.Soul)
+	}
+	for _, inline := range []string{"You are a careful research assistant.", "Concise.", "notes.md"} {
+		got, err = NormalizeAndValidate(base(v1.SoulSpec{Persona: inline, Memory: inline}), clawPath)
+		if err != nil || got.Agent.Soul.Persona != inline || got.Agent.Soul.Memory != inline {
+			t.Fatalf("inline soul %q should pass through: %+v (%v)", inline, got.Agent.Soul, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		soul v1.SoulSpec
+		want string
+	}{
+		{v1.SoulSpec{Persona: "./missing.md"}, "agent.soul.persona not found"},
+		{v1.SoulSpec{Memory: "./notes.json"}, "agent.soul.memory not found"},
+		{v1.SoulSpec{Memory: "remember me", MemoryWritable: true}, "memoryWritable requires"},
+		{v1.SoulSpec{Persona: "./memory"}, "must be a file"},
+	} {
+		if _, err := NormalizeAndValidate(base(tc.soul), clawPath); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("soul %+v: expected %q, got %v", tc.soul, tc.want, err)
+		}
+	}
+
+	reserved := base(v1.SoulSpec{})
+	reserved.Agent.Habitat.Tmpfs = []v1.TmpfsSpec{{Target: v1.SoulDir + "/scratch"}}
+	if _, err := NormalizeAndValidate(reserved, clawPath); err == nil || !strings.Contains(err.Error(), "reserved for agent.soul") {
+		t.Fatalf("expected reserved soul dir error, got %v", err)
+	}
+}
+
+func TestCheckSpeciesOverrides(t *testing.T) {
+	custom := v1.AgentSpec{Runtime: v1.RuntimeSpec{
+		Image:     "ghcr.io/acme/agent@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Resources: v1.ResourceSpec{CPU: "8", Memory: "16g"},
+	}}
+	for _, species := range []v1.Species{v1.SpeciesNano, v1.SpeciesMicro, v1.SpeciesMega} {
+		profile, ok := v1.SpeciesProfileFor(species)
+		if !ok {
+			t.Fatalf("missing profile for %s", species)
+		}
+		if err := checkSpeciesOverrides(custom, profile); err != nil {
+			t.Fatalf("%s: built-in species allow overrides, got %v", species, err)
+		}
+
+		locked := profile
+		locked.Allowed = v1.AllowedPatch{}
+		restated := v1.AgentSpec{Runtime: v1.RuntimeSpec{
+			Image:     profile.DefaultImage,
+			Resources: v1.ResourceSpec{CPU: profile.DefaultCPU, Memory: strings.ToUpper(profile.DefaultMem)},
+		}}
+		if err := checkSpeciesOverrides(restated, locked); err != nil {
+			t.Fatalf("%s: restating defaults is not an override, got %v", species, err)
+		}
+		if err := checkSpeciesOverrides(v1.AgentSpec{}, locked); err != nil {
+			t.Fatalf("%s: empty runtime must pass, got %v", species, err)
+		}
+		for field, agent := range map[string]v1.AgentSpec{
+			"runtime.resources.cpu":    {Runtime: v1.RuntimeSpec{Resources: v1.ResourceSpec{CPU: "8"}}},
+			"runtime.resources.memory": {Runtime: v1.RuntimeSpec{Resources: v1.ResourceSpec{Memory: "16g"}}},
+			"runtime.image":            {Runtime: v1.RuntimeSpec{Image: custom.Runtime.Image}},
+		} {
+			err := checkSpeciesOverrides(agent, locked)
+			if err == nil || !strings.Contains(err.Error(), "species "+string(species)+" does not allow overriding "+field) {
+				t.Fatalf("%s: expected %s override to be rejected, got %v", species, field, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+)
+
+// Warning is a non-fatal validation finding. Code is stable for tooling;
+// Field is the dotted Clawfile path the warning refers to, when there is one.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+const (
+	WarnRuntimeUnsupported = "runtime_unsupported"
+	WarnNetworkAll         = "network_all"
+	WarnMissingSoul        = "missing_soul"
+	WarnDefaultCommand     = "default_command"
+	WarnMountSourceMissing = "mount_source_missing"
+)
+
+// ValidationReport keeps hard errors and non-fatal warnings apart. Config is
+// the normalized Clawfile and is only meaningful when Errors is empty.
+type ValidationReport struct {
+	Config   v1.Clawfile `json:"clawfile"`
+	Errors   []string    `json:"errors"`
+	Warnings []Warning   `json:"warnings"`
+}
+
+// OK reports whether the Clawfile passed validation.
+func (r ValidationReport) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// NormalizeAndValidateReport validates cfg and returns every finding as a
+// report instead of an error. Errors holds the first error of each failing
+// section (mounts, network, ports, ...); warnings are only collected once the
+// Clawfile is valid.
+func NormalizeAndValidateReport(cfg v1.Clawfile, clawfilePath string, opts Options) ValidationReport {
+	rep := ValidationReport{Errors: []string{}, Warnings: []Warning{}}
+	n, errs := normalizeAndValidate(cfg, clawfilePath, opts)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			rep.Errors = append(rep.Errors, err.Error())
+		}
+		return rep
+	}
+	rep.Config = n
+	if warnings := collectWarnings(n, cfg, opts); warnings != nil {
+		rep.Warnings = warnings
+	}
+	return rep
+}
+
+// collectWarnings inspects an already normalized and validated Clawfile; raw
+// is the same Clawfile before defaults were filled in.
+func collectWarnings(cfg, raw v1.Clawfile, opts Options) []Warning {
+	var out []Warning
+	if cfg.Agent.Habitat.Network.Mode == "all" {
+		out = append(out, Warning{
+			Code:    WarnNetworkAll,
+			Message: "network mode all grants unrestricted network access; prefer outbound with allowedDomains",
+			Field:   "agent.habitat.network.mode",
+		})
+	}
+	if cfg.Agent.Soul.Persona == "" && cfg.Agent.Soul.Memory == "" {
+		out = append(out, Warning{
+			Code:    WarnMissingSoul,
+			Message: "no soul persona or memory is declared",
+			Field:   "agent.soul",
+		})
+	}
+	if len(raw.Agent.Command) == 0 {
+		out = append(out, Warning{
+			Code:    WarnDefaultCommand,
+			Message: "command is empty; the agent runs a placeholder that only prints a start message",
+			Field:   "agent.command",
+		})
+	}
+	if opts.CheckMounts && opts.AllowMissingMounts {
+		for i, m := range cfg.Agent.Habitat.Mounts {
+			if _, err := os.Stat(m.Source); errors.Is(err, os.ErrNotExist) {
+				out = append(out, Warning{
+					Code:    WarnMountSourceMissing,
+					Message: fmt.Sprintf("mount source %s does not exist yet", m.Source),
+					Field:   fmt.Sprintf("agent.habitat.mounts[%d].source", i),
+				})
+			}
+		}
+	}
+	if cfg.Agent.Runtime.Target == v1.RuntimeApple {
+		if len(cfg.Agent.Habitat.ExtraHosts) > 0 {
+			out = append(out, Warning{
+				Code:    WarnRuntimeUnsupported,
+				Message: "apple_container ignores habitat.extraHosts",
+				Field:   "agent.habitat.extraHosts",
+			})
+		}
+		if len(cfg.Agent.Habitat.Capabilities.Add) > 0 {
+			out = append(out, Warning{
+				Code:    WarnRuntimeUnsupported,
+				Message: "apple_container ignores habitat.capabilities.add",
+				Field:   "agent.habitat.capabilities.add",
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Field == out[j].Field {
+			return out[i].Code < out[j].Code
+		}
+		return out[i].Field < out[j].Field
+	})
+	return out
+}
@@ -0,0 +1,256 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fpp-125/metaclaw/internal/capability"
+)
+
+type capabilityDiffResult struct {
+	Left        capabilityDiffRef       `json:"left"`
+	Right       capabilityDiffRef       `json:"right"`
+	Sections    []sectionDiff           `json:"sections"`
+	Escalations []capability.Escalation `json:"escalations"`
+	Equal       bool                    `json:"equal"`
+}
+
+type capabilityDiffRef struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+func runCapability(args []string) int {
+	if len(args) == 0 {
+		printCapabilityUsage()
+		return 1
+	}
+	switch args[0] {
+	case "diff":
+		return runCapabilityDiff(args[1:])
+	case "lint":
+		return runCapabilityLint(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown capability subcommand: %s\n", args[0])
+		printCapabilityUsage()
+		return 1
+	}
+}
+
+func printCapabilityUsage() {
+	fmt.Print(`metaclaw capability commands:
+  capability diff <contract-or-skill-dir-a> <contract-or-skill-dir-b> [--json]
+  capability lint <skill-path-or-contract> [--json]
+`)
+}
+
+func runCapabilityDiff(args []string) int {
+	args = reorderFlags(args, map[string]bool{})
+	fs := flag.NewFlagSet("capability diff", flag.ContinueOnError)
+	var asJSON bool
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capability diff <contract-or-skill-dir-a> <contract-or-skill-dir-b> [--json]")
+		return 1
+	}
+
+	left, leftPath, err := loadContractRef(remaining[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load %q failed: %v\n", remaining[0], err)
+		return 1
+	}
+	right, rightPath, err := loadContractRef(remaining[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load %q failed: %v\n", remaining[1], err)
+		return 1
+	}
+	res, err := diffContracts(left, leftPath, right, rightPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "capability diff failed: %v\n", err)
+		return 1
+	}
+
+	if asJSON {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+		return 0
+	}
+
+	fmt.Printf("left:  %s\t%s\t%s\n", res.Left.Name, res.Left.Version, res.Left.Path)
+	fmt.Printf("right: %s\t%s\t%s\n", res.Right.Name, res.Right.Version, res.Right.Path)
+	for _, esc := range res.Escalations {
+		fmt.Printf("! escalation[%s]: %s\n", esc.Kind, esc.Detail)
+	}
+	for _, sec := range res.Sections {
+		if sec.Equal {
+			continue
+		}
+		fmt.Printf("[%s] added=%d removed=%d changed=%d\n", sec.Section, len(sec.Added), len(sec.Removed), len(sec.Changed))
+		for _, c := range sec.Added {
+			fmt.Printf("+ %s = %s\n", c.Path, renderJSONValue(c.New))
+		}
+		for _, c := range sec.Removed {
+			fmt.Printf("- %s = %s\n", c.Path, renderJSONValue(c.Old))
+		}
+		for _, c := range sec.Changed {
+			fmt.Printf("~ %s: %s -> %s\n", c.Path, renderJSONValue(c.Old), renderJSONValue(c.New))
+		}
+	}
+	if res.Equal {
+		fmt.Println("capability diff: contracts are identical")
+	}
+	return 0
+}
+
+// loadContractRef accepts either a contract file or a skill directory that
+// contains one.
+func loadContractRef(ref string) (capability.Contract, string, error) {
+	st, err := os.Stat(ref)
+	if err != nil {
+		return capability.Contract{}, "", err
+	}
+	if st.IsDir() {
+		return capability.LoadFromSkillPath(ref)
+	}
+	c, err := capability.LoadFile(ref)
+	return c, ref, err
+}
+
+func diffContracts(left capability.Contract, leftPath string, right capability.Contract, rightPath string) (capabilityDiffResult, error) {
+	l, err := contractSections(left)
+	if err != nil {
+		return capabilityDiffResult{}, err
+	}
+	r, err := contractSections(right)
+	if err != nil {
+		return capabilityDiffResult{}, err
+	}
+	res := capabilityDiffResult{
+		Left:        capabilityDiffRef{Path: leftPath, Name: left.Metadata.Name, Version: left.Metadata.Version},
+		Right:       capabilityDiffRef{Path: rightPath, Name: right.Metadata.Name, Version: right.Metadata.Version},
+		Escalations: capability.Escalations(left, right),
+		Equal:       true,
+	}
+	if res.Escalations == nil {
+		res.Escalations = []capability.Escalation{}
+	}
+	for _, name := range []string{"metadata", "interface", "permissions", "sideEffects", "compatibility", "observability"} {
+		d := diffJSONSection(name, l[name], r[name])
+		if !d.Equal {
+			res.Equal = false
+		}
+		res.Sections = append(res.Sections, d)
+	}
+	return res, nil
+}
+
+// contractSections round-trips c through JSON so diffJSONSection sees plain
+// maps and slices.
+func contractSections(c capability.Contract) (map[string]any, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// capabilityLintResult is the structured outcome of `capability lint`.
+type capabilityLintResult struct {
+	Path     string                   `json:"path"`
+	Name     string                   `json:"name,omitempty"`
+	Version  string                   `json:"version,omitempty"`
+	Valid    bool                     `json:"valid"`
+	Errors   []string                 `json:"errors"`
+	Warnings []capability.LintWarning `json:"warnings"`
+}
+
+func runCapabilityLint(args []string) int {
+	args = reorderFlags(args, map[string]bool{})
+	fs := flag.NewFlagSet("capability lint", flag.ContinueOnError)
+	var asJSON bool
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capability lint <skill-path-or-contract> [--json]")
+		return 1
+	}
+	res := lintContractRef(fs.Args()[0])
+	if asJSON {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		writeCapabilityLint(os.Stdout, res)
+	}
+	if !res.Valid {
+		return 1
+	}
+	return 0
+}
+
+// lintContractRef loads a contract file, or discovers the contract of a skill
+// directory or skill file, and collects validation errors and lint warnings.
+func lintContractRef(ref string) capabilityLintResult {
+	res := capabilityLintResult{Path: ref, Errors: []string{}, Warnings: []capability.LintWarning{}}
+	st, err := os.Stat(ref)
+	if err != nil {
+		res.Errors = append(res.Errors, err.Error())
+		return res
+	}
+	var c capability.Contract
+	switch ext := strings.ToLower(filepath.Ext(ref)); {
+	case !st.IsDir() && (ext == ".yaml" || ext == ".yml" || ext == ".json"):
+		c, err = capability.LoadFile(ref)
+	default:
+		// Report the discovered contract path even when it fails to parse.
+		if p, ok, derr := capability.DiscoverContractPath(ref); derr == nil && ok {
+			res.Path = p
+		}
+		c, _, err = capability.LoadFromSkillPath(ref)
+	}
+	if err != nil {
+		res.Errors = append(res.Errors, err.Error())
+		return res
+	}
+	res.Valid = true
+	res.Name = c.Metadata.Name
+	res.Version = c.Metadata.Version
+	if w := capability.Lint(c); w != nil {
+		res.Warnings = w
+	}
+	return res
+}
+
+func writeCapabilityLint(w io.Writer, res capabilityLintResult) {
+	fmt.Fprintf(w, "contract: %s\n", res.Path)
+	if res.Name != "" {
+		fmt.Fprintf(w, "name: %s\n", res.Name)
+		fmt.Fprintf(w, "version: %s\n", res.Version)
+	}
+	for _, e := range res.Errors {
+		fmt.Fprintf(w, "error: %s\n", e)
+	}
+	for _, wn := range res.Warnings {
+		fmt.Fprintf(w, "warning[%s] %s: %s\n", wn.Code, wn.Field, wn.Message)
+	}
+	if res.Valid {
+		fmt.Fprintf(w, "lint: OK (%d warnings)\n", len(res.Warnings))
+		return
+	}
+	fmt.Fprintln(w, "lint: FAILED")
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fpp-125/metaclaw/internal/capability"
+)
+
+func TestDiffContractsReportsPermissionChanges(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, network, access string) string {
+		p := filepath.Join(root, name)
+		body := `apiVersion: metaclaw.capability/v1
+kind: CapabilityContract
+metadata:
+  name: obsidian.ingest
+  version: ` + name + `
+permissions:
+  network: ` + network + `
+  mounts:
+    - target: /vault
+      access: ` + access + `
+`
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatalf("write contract: %v", err)
+		}
+		return p
+	}
+	a := write("v1.yaml", "none", "ro")
+	b := write("v2.yaml", "outbound", "rw")
+
+	left, leftPath, err := loadContractRef(a)
+	if err != nil {
+		t.Fatalf("load left: %v", err)
+	}
+	right, rightPath, err := loadContractRef(b)
+	if err != nil {
+		t.Fatalf("load right: %v", err)
+	}
+	res, err := diffContracts(left, leftPath, right, rightPath)
+	if err != nil {
+		t.Fatalf("diffContracts() error = %v", err)
+	}
+	if res.Equal || len(res.Escalations) != 2 {
+		t.Fatalf("expected network and mount escalations, got %+v", res.Escalations)
+	}
+	var perms sectionDiff
+	for _, sec := range res.Sections {
+		if sec.Section == "permissions" {
+			perms = sec
+		}
+	}
+	if len(perms.Changed) != 2 {
+		t.Fatalf("expected 2 changed permission paths, got %+v", perms)
+	}
+
+	same, err := diffContracts(left, leftPath, left, leftPath)
+	if err != nil || !same.Equal || len(same.Escalations) != 0 {
+		t.Fatalf("expected identical contracts to be equal, got %+v err=%v", same, err)
+	}
+}
+
+func TestLintContractRef(t *testing.T) {
+	root := t.TempDir()
+	skillDir := filepath.Join(root, "skill")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	body := `apiVersion: metaclaw.capability/v1
+kind: CapabilityContract
+metadata:
+  name: obsidian.ingest
+  version: v1.0.0
+permissions:
+  network: none
+`
+	if err := os.WriteFile(filepath.Join(skillDir, "capability.contract.yaml"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := lintContractRef(skillDir)
+	if !res.Valid || res.Path != filepath.Join(skillDir, "capability.contract.yaml") {
+		t.Fatalf("unexpected lint result: %+v", res)
+	}
+	codes := map[string]bool{}
+	for _, w := range res.Warnings {
+		codes[w.Code] = true
+	}
+	if !codes[capability.LintEmptyInterface] || !codes[capability.LintMissingRequiredEvents] {
+		t.Fatalf("expected interface and requiredEvents warnings, got %+v", res.Warnings)
+	}
+
+	bad := filepath.Join(root, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("apiVersion: metaclaw.capability/v1\nkind: CapabilityContract\nmetadata:\n  name: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if res := lintContractRef(bad); res.Valid || len(res.Errors) != 1 || !strings.Contains(res.Errors[0], "metadata.version") {
+		t.Fatalf("expected version error, got %+v", res)
+	}
+	if res := lintContractRef(root); res.Valid {
+		t.Fatalf("expected a dir without a contract to fail, got %+v", res)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -15,11 +16,13 @@ import (
 )
 
 type capsuleListItem struct {
-	ID             string    `json:"id"`
-	Path           string    `json:"path"`
-	AgentName      string    `json:"agentName"`
-	SourceClawfile string    `json:"sourceClawfile"`
-	CreatedAt      time.Time `json:"createdAt"`
+	ID                   string                   `json:"id"`
+	Path                 string                   `json:"path"`
+	AgentName            string                   `json:"agentName"`
+	SourceClawfile       string                   `json:"sourceClawfile"`
+	CreatedAt            time.Time                `json:"createdAt"`
+	Digests              map[string]string        `json:"digests,omitempty"`
+	RuntimeCompatibility *capsule.RuntimeContract `json:"runtimeCompatibility,omitempty"`
 }
 
 type capsuleMaterial struct {
@@ -71,6 +74,16 @@ func runCapsule(args []string) int {
 		return runCapsuleList(args[1:])
 	case "diff":
 		return runCapsuleDiff(args[1:])
+	case "import":
+		return runCapsuleImport(args[1:])
+	case "cat":
+		return runCapsuleCat(args[1:])
+	case "show":
+		return runCapsuleShow(args[1:])
+	case "export":
+		return runCapsuleExport(args[1:])
+	case "verify":
+		return runCapsuleVerify(args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown capsule subcommand: %s\n", args[0])
 		printCapsuleUsage()
@@ -94,17 +107,19 @@ func runCapsuleList(args []string) int {
 	var untilRaw string
 	var limit int
 	var asJSON bool
+	var detail bool
 	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
 	fs.StringVar(&agentFilter, "agent", "", "filter by agent name (contains, case-insensitive)")
 	fs.StringVar(&sinceRaw, "since", "", "created at lower bound (RFC3339 or YYYY-MM-DD)")
 	fs.StringVar(&untilRaw, "until", "", "created at upper bound (RFC3339 or YYYY-MM-DD)")
 	fs.IntVar(&limit, "limit", 100, "max rows")
 	fs.BoolVar(&asJSON, "json", false, "json output")
+	fs.BoolVar(&detail, "detail", false, "include manifest digests and runtime compatibility")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	if len(fs.Args()) != 0 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule list [--state-dir=.metaclaw] [--agent=...] [--since=...] [--until=...]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule list [--state-dir=.metaclaw] [--agent=...] [--since=...] [--until=...] [--json] [--detail]")
 		return 1
 	}
 
@@ -120,7 +135,7 @@ func runCapsuleList(args []string) int {
 	}
 
 	capsuleRoot := filepath.Join(stateDir, "capsules")
-	items, err := discoverCapsules(capsuleRoot)
+	items, err := discoverCapsules(capsuleRoot, detail)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "capsule list failed: %v\n", err)
 		return 1
@@ -137,6 +152,10 @@ func runCapsuleList(args []string) int {
 	}
 
 	for _, it := range items {
+		if it.RuntimeCompatibility != nil {
+			fmt.Printf("%s\t%s\t%s\t%s\t%s\n", it.ID, it.CreatedAt.Format(time.RFC3339), it.AgentName, strings.Join(it.RuntimeCompatibility.Targets, ","), it.Path)
+			continue
+		}
 		fmt.Printf("%s\t%s\t%s\t%s\n", it.ID, it.CreatedAt.Format(time.RFC3339), it.AgentName, it.Path)
 	}
 	return 0
@@ -148,14 +167,18 @@ func runCapsuleDiff(args []string) int {
 	fs := flag.NewFlagSet("capsule diff", flag.ContinueOnError)
 	var stateDir string
 	var asJSON bool
+	var summary bool
+	var ignoreOrder bool
 	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
 	fs.BoolVar(&asJSON, "json", false, "json output")
+	fs.BoolVar(&summary, "summary", false, "one line per section plus overall EQUAL/DIFFERS")
+	fs.BoolVar(&ignoreOrder, "ignore-order", false, "compare arrays by content so reordering alone is not a difference")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	remaining := fs.Args()
 	if len(remaining) != 2 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule diff <id-or-path-1> <id-or-path-2> [--state-dir=.metaclaw] [--json]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule diff <id-or-path-1> <id-or-path-2> [--state-dir=.metaclaw] [--summary] [--ignore-order] [--json]")
 		return 1
 	}
 
@@ -170,7 +193,11 @@ func runCapsuleDiff(args []string) int {
 		return 1
 	}
 
-	res := diffCapsules(left, right)
+	res := diffCapsules(left, right, ignoreOrder)
+	if summary {
+		writeCapsuleDiffSummary(os.Stdout, res)
+		return 0
+	}
 	if asJSON {
 		b, _ := json.MarshalIndent(res, "", "  ")
 		fmt.Println(string(b))
@@ -201,14 +228,427 @@ func runCapsuleDiff(args []string) int {
 	return 0
 }
 
+type capsuleImportResult struct {
+	CapsuleID        string `json:"capsuleId"`
+	Path             string `json:"path,omitempty"`
+	Verified         bool   `json:"verified"`
+	Installed        bool   `json:"installed"`
+	AlreadyInstalled bool   `json:"alreadyInstalled,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+func runCapsuleImport(args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+
+	fs := flag.NewFlagSet("capsule import", flag.ContinueOnError)
+	var stateDir string
+	var verifyOnly bool
+	var asJSON bool
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.BoolVar(&verifyOnly, "verify-only", false, "verify the archive without installing it")
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule import <file.tar.gz> [--state-dir=.metaclaw] [--verify-only] [--json]")
+		return 1
+	}
+
+	res, err := importCapsuleArchive(fs.Args()[0], filepath.Join(stateDir, "capsules"), verifyOnly)
+	if err != nil && res.Error == "" {
+		fmt.Fprintf(os.Stderr, "capsule import failed: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		if res.CapsuleID != "" {
+			fmt.Printf("capsule_id: %s\n", res.CapsuleID)
+		}
+		fmt.Printf("verified: %t\n", res.Verified)
+		if res.Error != "" {
+			fmt.Printf("error: %s\n", res.Error)
+		}
+		switch {
+		case res.Installed:
+			fmt.Printf("installed: %s\n", res.Path)
+		case res.AlreadyInstalled:
+			fmt.Printf("already installed: %s\n", res.Path)
+		}
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// importCapsuleArchive extracts archivePath, verifies the capsule digests, and
+// unless verifyOnly moves it into capsuleRoot. The extraction is always
+// discarded when it is not installed. Verification failures are reported in
+// the result (with a non-nil error); other failures only as the error.
+func importCapsuleArchive(archivePath, capsuleRoot string, verifyOnly bool) (capsuleImportResult, error) {
+	var res capsuleImportResult
+	var tmpDir string
+	var err error
+	if verifyOnly {
+		tmpDir, err = os.MkdirTemp("", "metaclaw-import-*")
+	} else {
+		if err = os.MkdirAll(capsuleRoot, 0o755); err != nil {
+			return res, err
+		}
+		// Extract next to the destination so the final rename stays on one filesystem.
+		tmpDir, err = os.MkdirTemp(capsuleRoot, ".import-*")
+	}
+	if err != nil {
+		return res, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	root, err := capsule.ExtractArchive(archivePath, tmpDir)
+	if err != nil {
+		return res, err
+	}
+	manifest, err := capsule.Load(root)
+	if err == nil {
+		err = capsule.VerifyID(manifest)
+	}
+	if err != nil {
+		res.CapsuleID = readArchivedCapsuleID(root)
+		res.Error = err.Error()
+		return res, err
+	}
+	res.CapsuleID = manifest.CapsuleID
+	res.Verified = true
+	if verifyOnly {
+		return res, nil
+	}
+
+	dest := filepath.Join(capsuleRoot, "cap_"+manifest.CapsuleID)
+	res.Path = dest
+	if _, err := os.Stat(dest); err == nil {
+		res.AlreadyInstalled = true
+		return res, nil
+	}
+	if err := os.Rename(root, dest); err != nil {
+		return res, fmt.Errorf("install capsule: %w", err)
+	}
+	res.Installed = true
+	return res, nil
+}
+
+func readArchivedCapsuleID(root string) string {
+	b, err := os.ReadFile(filepath.Join(root, "manifest.json"))
+	if err != nil {
+		return ""
+	}
+	var m struct {
+		CapsuleID string `json:"capsuleId"`
+	}
+	if json.Unmarshal(b, &m) != nil {
+		return ""
+	}
+	return m.CapsuleID
+}
+
+func runCapsuleCat(args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+	fs := flag.NewFlagSet("capsule cat", flag.ContinueOnError)
+	var stateDir string
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule cat <id-or-path> <source|ir|policy|manifest> [--state-dir=.metaclaw]")
+		return 1
+	}
+	mat, err := resolveCapsuleRef(stateDir, remaining[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resolve capsule %q failed: %v\n", remaining[0], err)
+		return 1
+	}
+	b, err := readCapsuleEntry(mat.Path, remaining[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "capsule cat failed: %v\n", err)
+		return 1
+	}
+	_, _ = os.Stdout.Write(b)
+	return 0
+}
+
+func runCapsuleShow(args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true, "--section": true})
+	fs := flag.NewFlagSet("capsule show", flag.ContinueOnError)
+	var stateDir string
+	var section string
+	var asJSON bool
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.StringVar(&section, "section", "", "only show one section: ir, policy, locks.deps, locks.image or locks.source")
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule show <id-or-path> [--section=ir|policy|locks.deps|locks.image|locks.source] [--state-dir=.metaclaw] [--json]")
+		return 1
+	}
+	mat, err := resolveCapsuleRef(stateDir, remaining[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resolve capsule %q failed: %v\n", remaining[0], err)
+		return 1
+	}
+	if err := writeCapsuleShow(os.Stdout, mat, section, asJSON); err != nil {
+		fmt.Fprintf(os.Stderr, "capsule show failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeCapsuleShow prints the requested section of a capsule, or all of them.
+// JSON output of a single section is the bare section value so it can be piped
+// into other tools unchanged.
+func writeCapsuleShow(w io.Writer, mat capsuleMaterial, section string, asJSON bool) error {
+	sections := mat.sections()
+	if section != "" {
+		var picked []capsuleSection
+		names := make([]string, 0, len(sections))
+		for _, s := range sections {
+			names = append(names, s.name)
+			if s.name == section {
+				picked = append(picked, s)
+			}
+		}
+		if len(picked) == 0 {
+			return fmt.Errorf("unknown section %q (want %s)", section, strings.Join(names, ", "))
+		}
+		sections = picked
+	}
+	if asJSON {
+		var v any = mat
+		if section != "" {
+			v = sections[0].value
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	if section == "" {
+		fmt.Fprintf(w, "capsule: %s\t%s\t%s\n", mat.ID, mat.AgentName, mat.Path)
+	}
+	for _, s := range sections {
+		b, err := json.MarshalIndent(s.value, "", "  ")
+		if err != nil {
+			return err
+		}
+		if section == "" {
+			fmt.Fprintf(w, "[%s]\n", s.name)
+		}
+		fmt.Fprintln(w, string(b))
+	}
+	return nil
+}
+
+// readCapsuleEntry returns the raw bytes of a named capsule file. The capsule
+// has already been digest-verified by resolveCapsuleRef.
+func readCapsuleEntry(capPath, entry string) ([]byte, error) {
+	var rel string
+	switch entry {
+	case "source":
+		m, err := capsule.Load(capPath)
+		if err != nil {
+			return nil, err
+		}
+		if m.EmbeddedSource == "" {
+			return nil, fmt.Errorf("capsule %s has no embedded clawfile source (compiled by an older metaclaw)", m.CapsuleID)
+		}
+		rel = m.EmbeddedSource
+	case "ir":
+		rel = "ir.json"
+	case "policy":
+		rel = "policy.json"
+	case "manifest":
+		rel = "manifest.json"
+	default:
+		return nil, fmt.Errorf("unknown capsule entry %q (want source, ir, policy or manifest)", entry)
+	}
+	return os.ReadFile(filepath.Join(capPath, filepath.FromSlash(rel)))
+}
+
 func printCapsuleUsage() {
 	fmt.Print(`metaclaw capsule commands:
-  capsule list [--state-dir=.metaclaw] [--agent=...] [--since=...] [--until=...] [--json]
-  capsule diff <id-or-path-1> <id-or-path-2> [--state-dir=.metaclaw] [--json]
+  capsule list [--state-dir=.metaclaw] [--agent=...] [--since=...] [--until=...] [--json] [--detail]
+  capsule diff <id-or-path-1> <id-or-path-2> [--state-dir=.metaclaw] [--summary] [--ignore-order] [--json]
+  capsule import <file.tar.gz> [--state-dir=.metaclaw] [--verify-only] [--json]
+  capsule cat <id-or-path> <source|ir|policy|manifest> [--state-dir=.metaclaw]
+  capsule show <id-or-path> [--section=ir|policy|locks.deps|locks.image|locks.source] [--state-dir=.metaclaw] [--json]
+  capsule export <id-or-path> [-o cap_<id>.tar.gz] [--state-dir=.metaclaw]
+  capsule verify <id-or-path> [--state-dir=.metaclaw] [--json]
 `)
 }
 
-func discoverCapsules(capsuleRoot string) ([]capsuleListItem, error) {
+type capsuleVerifyResult struct {
+	ID        string                   `json:"id"`
+	Path      string                   `json:"path"`
+	Artifacts []capsule.ArtifactStatus `json:"artifacts"`
+	IDMatches bool                     `json:"idMatches"`
+	IDError   string                   `json:"idError,omitempty"`
+	OK        bool                     `json:"ok"`
+}
+
+func runCapsuleVerify(args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+	fs := flag.NewFlagSet("capsule verify", flag.ContinueOnError)
+	var stateDir string
+	var asJSON bool
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule verify <id-or-path> [--state-dir=.metaclaw] [--json]")
+		return 1
+	}
+	capPath, err := resolveCapsuleDir(stateDir, remaining[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resolve capsule %q failed: %v\n", remaining[0], err)
+		return 1
+	}
+	res, err := verifyCapsuleDir(capPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "capsule verify failed: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		writeCapsuleVerify(os.Stdout, res)
+	}
+	if !res.OK {
+		return 1
+	}
+	return 0
+}
+
+// verifyCapsuleDir checks every artifact digest of the capsule at capPath and
+// that its id is the one the digests derive.
+func verifyCapsuleDir(capPath string) (capsuleVerifyResult, error) {
+	m, artifacts, err := capsule.Verify(capPath)
+	if err != nil {
+		return capsuleVerifyResult{}, err
+	}
+	res := capsuleVerifyResult{ID: m.CapsuleID, Path: capPath, Artifacts: artifacts, IDMatches: true, OK: true}
+	if err := capsule.VerifyID(m); err != nil {
+		res.IDMatches, res.IDError, res.OK = false, err.Error(), false
+	}
+	for _, a := range artifacts {
+		if !a.OK {
+			res.OK = false
+		}
+	}
+	return res, nil
+}
+
+func writeCapsuleVerify(w io.Writer, res capsuleVerifyResult) {
+	fmt.Fprintf(w, "capsule: %s\t%s\n", res.ID, res.Path)
+	for _, a := range res.Artifacts {
+		status := "ok"
+		if !a.OK {
+			status = "FAIL: " + a.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", a.Name, a.Path, status)
+	}
+	if res.IDMatches {
+		fmt.Fprintln(w, "capsule_id\tok")
+	} else {
+		fmt.Fprintf(w, "capsule_id\tFAIL: %s\n", res.IDError)
+	}
+	if res.OK {
+		fmt.Fprintln(w, "result: verified")
+	} else {
+		fmt.Fprintln(w, "result: FAILED")
+	}
+}
+
+func runCapsuleExport(args []string) int {
+	args = reorderFlags(args, map[string]bool{"-o": true, "--state-dir": true})
+	fs := flag.NewFlagSet("capsule export", flag.ContinueOnError)
+	var stateDir string
+	var out string
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.StringVar(&out, "o", "", "output tarball (default cap_<id>.tar.gz)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw capsule export <id-or-path> [-o cap_<id>.tar.gz] [--state-dir=.metaclaw]")
+		return 1
+	}
+	mat, err := resolveCapsuleRef(stateDir, remaining[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resolve capsule %q failed: %v\n", remaining[0], err)
+		return 1
+	}
+	if out == "" {
+		out = "cap_" + mat.ID + ".tar.gz"
+	}
+	if err := exportCapsuleArchive(mat.Path, out); err != nil {
+		fmt.Fprintf(os.Stderr, "capsule export failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("capsule_id: %s\n", mat.ID)
+	fmt.Printf("archive: %s\n", out)
+	return 0
+}
+
+// exportCapsuleArchive writes the tarball through a temp file in the target
+// directory so a failed export never leaves a truncated archive behind.
+func exportCapsuleArchive(capPath, out string) error {
+	if dir := filepath.Dir(out); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(out), ".export-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := capsule.WriteArchive(capPath, tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), out)
+}
+
+func writeCapsuleDiffSummary(w io.Writer, res capsuleDiffResult) {
+	for _, sec := range res.Sections {
+		fmt.Fprintf(w, "%s: ~%d +%d -%d\n", sec.Section, len(sec.Changed), len(sec.Added), len(sec.Removed))
+	}
+	if res.Equal {
+		fmt.Fprintln(w, "EQUAL")
+		return
+	}
+	fmt.Fprintln(w, "DIFFERS")
+}
+
+// discoverCapsules lists verified capsules under capsuleRoot, newest first.
+// With detail set each item also carries its manifest digests and runtime
+// compatibility.
+func discoverCapsules(capsuleRoot string, detail bool) ([]capsuleListItem, error) {
 	entries, err := os.ReadDir(capsuleRoot)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -237,13 +677,19 @@ func discoverCapsules(capsuleRoot string) ([]capsuleListItem, error) {
 			fmt.Fprintf(os.Stderr, "warning: stat failed for %s: %v\n", capPath, err)
 			continue
 		}
-		items = append(items, capsuleListItem{
+		item := capsuleListItem{
 			ID:             manifest.CapsuleID,
 			Path:           capPath,
 			AgentName:      agentName,
 			SourceClawfile: manifest.SourceClawfile,
 			CreatedAt:      st.ModTime().UTC(),
-		})
+		}
+		if detail {
+			compat := manifest.RuntimeCompatibility
+			item.Digests = manifest.Digests
+			item.RuntimeCompatibility = &compat
+		}
+		items = append(items, item)
 	}
 
 	sort.Slice(items, func(i, j int) bool {
@@ -294,8 +740,18 @@ func parseTimeFilter(raw string, endOfDayForDateOnly bool) (time.Time, bool, err
 }
 
 func resolveCapsuleRef(stateDir, ref string) (capsuleMaterial, error) {
+	capPath, err := resolveCapsuleDir(stateDir, ref)
+	if err != nil {
+		return capsuleMaterial{}, err
+	}
+	return loadCapsuleMaterial(capPath)
+}
+
+// resolveCapsuleDir maps a capsule directory, full id or unique id prefix to
+// the capsule's directory without loading or verifying it.
+func resolveCapsuleDir(stateDir, ref string) (string, error) {
 	if st, err := os.Stat(ref); err == nil && st.IsDir() {
-		return loadCapsuleMaterial(ref)
+		return ref, nil
 	}
 
 	capsuleRoot := filepath.Join(stateDir, "capsules")
@@ -306,16 +762,16 @@ func resolveCapsuleRef(stateDir, ref string) (capsuleMaterial, error) {
 	for _, name := range candidateNames {
 		candidatePath := filepath.Join(capsuleRoot, name)
 		if st, err := os.Stat(candidatePath); err == nil && st.IsDir() {
-			return loadCapsuleMaterial(candidatePath)
+			return candidatePath, nil
 		}
 	}
 
 	entries, err := os.ReadDir(capsuleRoot)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return capsuleMaterial{}, fmt.Errorf("capsule directory not found: %s", capsuleRoot)
+			return "", fmt.Errorf("capsule directory not found: %s", capsuleRoot)
 		}
-		return capsuleMaterial{}, err
+		return "", err
 	}
 
 	prefixes := []string{"cap_" + ref}
@@ -336,13 +792,13 @@ func resolveCapsuleRef(stateDir, ref string) (capsuleMaterial, error) {
 	}
 	sort.Strings(matches)
 	if len(matches) == 1 {
-		return loadCapsuleMaterial(matches[0])
+		return matches[0], nil
 	}
 	if len(matches) > 1 {
-		return capsuleMaterial{}, fmt.Errorf("ambiguous capsule reference %q; matches: %s", ref, strings.Join(matches, ", "))
+		return "", fmt.Errorf("ambiguous capsule reference %q; matches: %s", ref, strings.Join(matches, ", "))
 	}
 
-	return capsuleMaterial{}, fmt.Errorf("capsule %q not found in %s", ref, capsuleRoot)
+	return "", fmt.Errorf("capsule %q not found in %s", ref, capsuleRoot)
 }
 
 func loadCapsuleMaterial(capPath string) (capsuleMaterial, error) {
@@ -415,27 +871,39 @@ func readJSONFile(path string) (any, error) {
 	return out, nil
 }
 
-func diffCapsules(left, right capsuleMaterial) capsuleDiffResult {
-	sections := []struct {
-		name  string
-		left  any
-		right any
-	}{
-		{name: "ir", left: left.IR, right: right.IR},
-		{name: "policy", left: left.Policy, right: right.Policy},
-		{name: "locks.deps", left: left.Deps, right: right.Deps},
-		{name: "locks.image", left: left.Image, right: right.Image},
-		{name: "locks.source", left: left.Source, right: right.Source},
+type capsuleSection struct {
+	name  string
+	value any
+}
+
+// sections lists the comparable parts of a capsule in display order; the
+// names are shared by capsule diff and capsule show --section.
+func (m capsuleMaterial) sections() []capsuleSection {
+	return []capsuleSection{
+		{name: "ir", value: m.IR},
+		{name: "policy", value: m.Policy},
+		{name: "locks.deps", value: m.Deps},
+		{name: "locks.image", value: m.Image},
+		{name: "locks.source", value: m.Source},
 	}
+}
 
+// diffCapsules compares the ir, policy and lock sections of two capsules. With
+// ignoreOrder, array elements are matched by content instead of index.
+func diffCapsules(left, right capsuleMaterial, ignoreOrder bool) capsuleDiffResult {
+	leftSections, rightSections := left.sections(), right.sections()
 	res := capsuleDiffResult{
 		Left:     capsuleDiffRef{ID: left.ID, Path: left.Path, AgentName: left.AgentName},
 		Right:    capsuleDiffRef{ID: right.ID, Path: right.Path, AgentName: right.AgentName},
-		Sections: make([]sectionDiff, 0, len(sections)),
+		Sections: make([]sectionDiff, 0, len(leftSections)),
 		Equal:    true,
 	}
-	for _, s := range sections {
-		d := diffJSONSection(s.name, s.left, s.right)
+	for i, ls := range leftSections {
+		lv, rv := ls.value, rightSections[i].value
+		if ignoreOrder {
+			lv, rv = sortJSONArrays(lv), sortJSONArrays(rv)
+		}
+		d := diffJSONSection(ls.name, lv, rv)
 		if !d.Equal {
 			res.Equal = false
 		}
@@ -482,6 +950,37 @@ func diffJSONSection(name string, left any, right any) sectionDiff {
 	return out
 }
 
+// sortJSONArrays returns a copy of value with every array sorted by the
+// canonical JSON of its elements, so equal multisets flatten identically.
+func sortJSONArrays(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = sortJSONArrays(item)
+		}
+		return out
+	case []any:
+		type keyed struct {
+			key  string
+			item any
+		}
+		items := make([]keyed, 0, len(v))
+		for _, item := range v {
+			item = sortJSONArrays(item)
+			items = append(items, keyed{key: renderJSONValue(item), item: item})
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].key < items[j].key })
+		out := make([]any, 0, len(items))
+		for _, it := range items {
+			out = append(out, it.item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 func flattenJSON(path string, value any, out map[string]any) {
 	switch v := value.(type) {
 	case map[string]any:
@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -9,6 +12,10 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/fpp-125/metaclaw/internal/capsule"
+	"github.com/fpp-125/metaclaw/internal/locks"
+	"github.com/fpp-125/metaclaw/internal/policy"
 )
 
 func TestDiscoverCapsulesAndFilter(t *testing.T) {
@@ -31,13 +38,23 @@ func TestDiscoverCapsulesAndFilter(t *testing.T) {
 		t.Fatalf("chtimes second: %v", err)
 	}
 
-	items, err := discoverCapsules(capsuleRoot)
+	items, err := discoverCapsules(capsuleRoot, false)
 	if err != nil {
 		t.Fatalf("discoverCapsules() error = %v", err)
 	}
 	if len(items) != 2 {
 		t.Fatalf("expected 2 items, got %d", len(items))
 	}
+	if items[0].Digests != nil || items[0].RuntimeCompatibility != nil {
+		t.Fatalf("expected lean items without --detail, got %+v", items[0])
+	}
+	detailed, err := discoverCapsules(capsuleRoot, true)
+	if err != nil {
+		t.Fatalf("discoverCapsules(detail) error = %v", err)
+	}
+	if detailed[0].Digests["ir"] == "" || detailed[0].RuntimeCompatibility == nil || len(detailed[0].RuntimeCompatibility.Targets) == 0 {
+		t.Fatalf("expected digests and runtime compatibility with detail, got %+v", detailed[0])
+	}
 	if items[0].ID != "2222222222222222" {
 		t.Fatalf("expected newest capsule first, got %s", items[0].ID)
 	}
@@ -97,7 +114,7 @@ func TestResolveCapsuleRefAndDiff(t *testing.T) {
 		t.Fatalf("resolve right failed: %v", err)
 	}
 
-	res := diffCapsules(left, right)
+	res := diffCapsules(left, right, false)
 	if res.Equal {
 		t.Fatal("expected diff to detect section changes")
 	}
@@ -246,3 +263,241 @@ func TestDiffJSONSectionArrayAndFieldChanges(t *testing.T) {
 		t.Fatalf("missing expected diff signals: changed=%v removed=%v added=%v", foundChanged, foundRemoved, foundAdded)
 	}
 }
+
+func TestDiffCapsulesIgnoreOrder(t *testing.T) {
+	mounts := func(targets ...string) capsuleMaterial {
+		items := make([]any, 0, len(targets))
+		for _, target := range targets {
+			items = append(items, map[string]any{"target": target, "readOnly": true})
+		}
+		return capsuleMaterial{Policy: map[string]any{"mounts": items}}
+	}
+	left, right := mounts("/a", "/b"), mounts("/b", "/a")
+	if res := diffCapsules(left, right, false); res.Equal {
+		t.Fatal("expected positional diff to report reordered mounts")
+	}
+	if res := diffCapsules(left, right, true); !res.Equal {
+		t.Fatalf("expected reordered mounts to be equal with ignoreOrder: %+v", res.Sections)
+	}
+	if res := diffCapsules(left, mounts("/a", "/c"), true); res.Equal {
+		t.Fatal("expected content change to differ with ignoreOrder")
+	}
+}
+
+func TestWriteCapsuleDiffSummary(t *testing.T) {
+	res := capsuleDiffResult{
+		Sections: []sectionDiff{
+			{Section: "ir", Equal: true},
+			{
+				Section: "policy",
+				Added:   []jsonChange{{Path: "mounts[0]"}},
+				Changed: []jsonChange{{Path: "network.mode"}, {Path: "network.allowed"}, {Path: "workdir"}},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	writeCapsuleDiffSummary(&buf, res)
+	want := "ir: ~0 +0 -0\npolicy: ~3 +1 -0\nDIFFERS\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected summary:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	writeCapsuleDiffSummary(&buf, capsuleDiffResult{Sections: []sectionDiff{{Section: "ir", Equal: true}}, Equal: true})
+	if !strings.HasSuffix(buf.String(), "EQUAL\n") {
+		t.Fatalf("expected EQUAL, got %q", buf.String())
+	}
+}
+
+func TestImportCapsuleArchive(t *testing.T) {
+	root := t.TempDir()
+	lk := locks.BundleLocks{
+		Deps:   locks.DepsLock{Version: "metaclaw.depslock/v1", Skills: []locks.SkillLock{}},
+		Image:  locks.ImageLock{Version: "metaclaw.imagelock/v1", Image: "alpine@sha256:test", Digest: "sha256:test"},
+		Source: locks.SourceLock{Version: "metaclaw.sourcelock/v1", Files: []locks.FileHash{}},
+	}
+	pol := policy.Policy{Version: "metaclaw.policy/v1", Network: policy.NetworkPolicy{Mode: "none"}}
+	written, err := capsule.Write(filepath.Join(root, "src"), "agent.claw", nil, map[string]any{"hello": "world"}, pol, lk)
+	if err != nil {
+		t.Fatalf("capsule.Write() error = %v", err)
+	}
+	capPath, id := written.Path, written.ID
+	archive := filepath.Join(root, "cap.tar.gz")
+	writeTestCapsuleArchive(t, archive, capPath)
+	capsuleRoot := filepath.Join(root, "state", "capsules")
+
+	res, err := importCapsuleArchive(archive, capsuleRoot, true)
+	if err != nil {
+		t.Fatalf("verify-only import: %v", err)
+	}
+	if !res.Verified || res.Installed || res.CapsuleID != id {
+		t.Fatalf("unexpected verify-only result: %+v", res)
+	}
+	if _, err := os.Stat(capsuleRoot); !os.IsNotExist(err) {
+		t.Fatalf("verify-only must not touch capsule root, stat err = %v", err)
+	}
+
+	res, err = importCapsuleArchive(archive, capsuleRoot, false)
+	if err != nil || !res.Installed {
+		t.Fatalf("install import: res=%+v err=%v", res, err)
+	}
+	if _, err := os.Stat(filepath.Join(capsuleRoot, "cap_"+id, "manifest.json")); err != nil {
+		t.Fatalf("expected installed capsule: %v", err)
+	}
+	res, err = importCapsuleArchive(archive, capsuleRoot, false)
+	if err != nil || !res.AlreadyInstalled {
+		t.Fatalf("re-import: res=%+v err=%v", res, err)
+	}
+	entries, _ := os.ReadDir(capsuleRoot)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the installed capsule under %s, got %d entries", capsuleRoot, len(entries))
+	}
+
+	if err := os.WriteFile(filepath.Join(capPath, "ir.json"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("tamper ir.json: %v", err)
+	}
+	writeTestCapsuleArchive(t, archive, capPath)
+	res, err = importCapsuleArchive(archive, capsuleRoot, true)
+	if err == nil || res.Verified || !strings.Contains(res.Error, "digest mismatch") {
+		t.Fatalf("expected digest mismatch, res=%+v err=%v", res, err)
+	}
+	if res.CapsuleID != id {
+		t.Fatalf("expected capsule id from manifest on failure, got %q", res.CapsuleID)
+	}
+}
+
+func writeTestCapsuleArchive(t *testing.T, archivePath string, capPath string) {
+	t.Helper()
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("create archive: %v", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	base := filepath.Base(capPath)
+	err = filepath.Walk(capPath, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(capPath, p)
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{Name: base + "/" + filepath.ToSlash(rel), Mode: 0o644, Size: int64(len(b)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+}
+
+func TestReadCapsuleEntry(t *testing.T) {
+	capPath := filepath.Join(t.TempDir(), "cap_4444444444444444")
+	writeTestCapsule(t, capPath, "4444444444444444", "alpha")
+
+	b, err := readCapsuleEntry(capPath, "ir")
+	if err != nil || !strings.Contains(string(b), "alpha") {
+		t.Fatalf("readCapsuleEntry(ir) = %q, %v", b, err)
+	}
+	if _, err := readCapsuleEntry(capPath, "source"); err == nil || !strings.Contains(err.Error(), "no embedded clawfile source") {
+		t.Fatalf("expected missing source error, got %v", err)
+	}
+	if _, err := readCapsuleEntry(capPath, "bogus"); err == nil {
+		t.Fatal("expected unknown entry error")
+	}
+}
+
+func TestWriteCapsuleShow(t *testing.T) {
+	mat := capsuleMaterial{
+		ID:        "cap_1",
+		AgentName: "bot",
+		Path:      "/tmp/cap_1",
+		IR:        map[string]any{"k": "v"},
+		Policy:    map[string]any{"network": map[string]any{"mode": "none"}},
+	}
+	var buf bytes.Buffer
+	if err := writeCapsuleShow(&buf, mat, "policy", true); err != nil {
+		t.Fatalf("writeCapsuleShow() error = %v", err)
+	}
+	var pol map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &pol); err != nil || pol["network"] == nil {
+		t.Fatalf("expected bare policy json, got %q (%v)", buf.String(), err)
+	}
+	buf.Reset()
+	if err := writeCapsuleShow(&buf, mat, "", false); err != nil {
+		t.Fatalf("writeCapsuleShow() error = %v", err)
+	}
+	for _, want := range []string{"capsule: cap_1", "[ir]", "[policy]", "[locks.source]"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+	if err := writeCapsuleShow(&buf, mat, "locks", false); err == nil || !strings.Contains(err.Error(), "unknown section") {
+		t.Fatalf("expected unknown section error, got %v", err)
+	}
+}
+
+func TestVerifyCapsuleDir(t *testing.T) {
+	root := t.TempDir()
+	lk := locks.BundleLocks{
+		Deps:   locks.DepsLock{Version: "metaclaw.depslock/v1", Skills: []locks.SkillLock{}},
+		Image:  locks.ImageLock{Version: "metaclaw.imagelock/v1", Image: "alpine@sha256:test", Digest: "sha256:test"},
+		Source: locks.SourceLock{Version: "metaclaw.sourcelock/v1", Files: []locks.FileHash{}},
+	}
+	pol := policy.Policy{Version: "metaclaw.policy/v1", Network: policy.NetworkPolicy{Mode: "none"}}
+	written, err := capsule.Write(filepath.Join(root, "capsules"), "agent.claw", nil, map[string]any{"hello": "world"}, pol, lk)
+	if err != nil {
+		t.Fatalf("capsule.Write() error = %v", err)
+	}
+	capPath, err := resolveCapsuleDir(root, written.ID[:6])
+	if err != nil || capPath != written.Path {
+		t.Fatalf("resolveCapsuleDir() = %q, %v", capPath, err)
+	}
+
+	res, err := verifyCapsuleDir(capPath)
+	if err != nil || !res.OK || !res.IDMatches || res.ID != written.ID || len(res.Artifacts) != 5 {
+		t.Fatalf("expected a clean capsule to verify: %+v (%v)", res, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(capPath, "policy.json"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(capPath, "locks", "deps.lock.json")); err != nil {
+		t.Fatal(err)
+	}
+	res, err = verifyCapsuleDir(capPath)
+	if err != nil || res.OK {
+		t.Fatalf("expected tampered capsule to fail: %+v (%v)", res, err)
+	}
+	failed := map[string]string{}
+	for _, a := range res.Artifacts {
+		if !a.OK {
+			failed[a.Name] = a.Error
+		}
+	}
+	if len(failed) != 2 || !strings.Contains(failed["policy"], "digest mismatch") || !strings.Contains(failed["deps"], "read capsule") {
+		t.Fatalf("expected policy and deps failures only, got %v", failed)
+	}
+	var buf bytes.Buffer
+	writeCapsuleVerify(&buf, res)
+	for _, want := range []string{"ir\tir.json\tok", "policy\tpolicy.json\tFAIL: capsule digest mismatch", "result: FAILED"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected %q in output:\n%s", want, buf.String())
+		}
+	}
+}
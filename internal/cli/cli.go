@@ -1,18 +1,35 @@
 package cli
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"net"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
+	"github.com/fpp-125/metaclaw/internal/buildinfo"
 	"github.com/fpp-125/metaclaw/internal/capsule"
+	"github.com/fpp-125/metaclaw/internal/claw/validate"
 	"github.com/fpp-125/metaclaw/internal/compiler"
+	"github.com/fpp-125/metaclaw/internal/logs"
 	"github.com/fpp-125/metaclaw/internal/manager"
+	"github.com/fpp-125/metaclaw/internal/policy"
+	"github.com/fpp-125/metaclaw/internal/release"
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+	"github.com/fpp-125/metaclaw/internal/textdiff"
 )
 
 func Execute(args []string) int {
@@ -38,15 +55,27 @@ func Execute(args []string) int {
 	case "run":
 		return runRun(ctx, args[1:])
 	case "ps":
-		return runPS(args[1:])
+		return runPS(ctx, args[1:])
 	case "logs":
 		return runLogs(ctx, args[1:])
 	case "inspect":
 		return runInspect(ctx, args[1:])
+	case "stop":
+		return runStop(ctx, args[1:])
+	case "exec":
+		return runExec(ctx, args[1:])
+	case "rm":
+		return runRm(ctx, args[1:])
+	case "prune":
+		return runPrune(ctx, args[1:])
 	case "debug":
 		return runDebug(ctx, args[1:])
 	case "capsule":
 		return runCapsule(args[1:])
+	case "capability":
+		return runCapability(args[1:])
+	case "skill":
+		return runSkill(args[1:])
 	case "wizard":
 		return runWizard(args[1:])
 	case "quickstart":
@@ -57,6 +86,10 @@ func Execute(args []string) int {
 		return runDoctor(args[1:])
 	case "project":
 		return runProject(args[1:])
+	case "completion":
+		return runCompletion(args[1:])
+	case "version", "--version":
+		return runVersion(args[1:])
 	case "help", "-h", "--help":
 		printUsage()
 		return 0
@@ -67,6 +100,30 @@ func Execute(args []string) int {
 	}
 }
 
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	var asJSON bool
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	info := buildinfo.Read()
+	if asJSON {
+		b, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Println(string(b))
+		return 0
+	}
+	fmt.Printf("metaclaw %s\n", info.Version)
+	fmt.Printf("go: %s\n", info.GoVersion)
+	commit := orDash(info.Commit)
+	if info.Modified {
+		commit += " (modified)"
+	}
+	fmt.Printf("commit: %s\n", commit)
+	fmt.Printf("built: %s\n", orDash(info.BuildTime))
+	return 0
+}
+
 func runInit(args []string) int {
 	args = reorderFlags(args, map[string]bool{"--out": true, "-out": true})
 	fs := flag.NewFlagSet("init", flag.ContinueOnError)
@@ -88,7 +145,7 @@ agent:
     env: {}
   # Optional LLM contract (secret injected at run time)
   # llm:
-  #   provider: gemini_openai
+  #   provider: gemini_openai # or openai_compatible, anthropic
   #   model: gemini-2.5-pro
   #   # defaults to Google OpenAI-compatible endpoint for gemini_openai
   #   # baseURL: https://generativelanguage.googleapis.com/v1beta/openai/
@@ -111,75 +168,503 @@ agent:
 }
 
 func runValidate(args []string) int {
-	if len(args) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw validate <file.claw>")
+	args = reorderFlags(args, map[string]bool{"--skill-registry": true, "--allowed-registries": true, "--state-dir": true, "--species-file": true})
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	var stateDir string
+	var speciesFile string
+	var allowSysAdmin bool
+	var asJSON bool
+	var checkSkills bool
+	var skillRegistry string
+	var allowedRegistries string
+	var checkMounts bool
+	var allowMissingMounts bool
+	var quiet bool
+	fs.BoolVar(&asJSON, "json", false, "json output (normalized clawfile plus warnings)")
+	fs.BoolVar(&quiet, "quiet", false, "print only the verdict (and failures), not the normalized clawfile")
+	fs.BoolVar(&checkSkills, "check-skills-network", false, "report the combined network/mount/env/secret demands of all skills against the agent grants")
+	fs.StringVar(&skillRegistry, "skill-registry", "", "local skill registry dir (<id>@<version>/ entries) used to check id-based skills")
+	fs.StringVar(&allowedRegistries, "allowed-registries", "", "comma-separated registry hosts (or host/prefix) the runtime image must come from")
+	fs.BoolVar(&checkMounts, "check-mounts", false, "fail if a habitat mount source is missing or not a directory on this host")
+	fs.BoolVar(&allowMissingMounts, "allow-missing-mounts", false, "with --check-mounts, warn instead of failing on mount sources that do not exist yet")
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory (custom species are read from species.yaml here)")
+	fs.StringVar(&speciesFile, "species-file", "", "species registry file defining custom species (default: <state-dir>/species.yaml if present)")
+	fs.BoolVar(&allowSysAdmin, "allow-sys-admin", false, "permit habitat.capabilities.add to grant SYS_ADMIN (strict releases still fail runtime.no_sys_admin)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw validate <file.claw|dir>... [--json|--quiet] [--check-skills-network] [--skill-registry=dir] [--allowed-registries=host,...] [--check-mounts [--allow-missing-mounts]] [--state-dir=.metaclaw] [--species-file=path] [--allow-sys-admin]")
+		return 1
+	}
+	if quiet && asJSON {
+		fmt.Fprintln(os.Stderr, "validate failed: --quiet cannot be combined with --json")
+		return 1
+	}
+	if allowMissingMounts && !checkMounts {
+		fmt.Fprintln(os.Stderr, "validate failed: --allow-missing-mounts requires --check-mounts")
+		return 1
+	}
+	if err := loadSpeciesRegistry(stateDir, speciesFile); err != nil {
+		fmt.Fprintf(os.Stderr, "validate failed: %v\n", err)
 		return 1
 	}
-	cfg, err := compiler.LoadNormalize(args[0])
+	opts := compiler.Options{
+		SkillRegistry:      skillRegistry,
+		AllowedRegistries:  strings.Split(allowedRegistries, ","),
+		CheckMounts:        checkMounts,
+		AllowMissingMounts: allowMissingMounts,
+		AllowSysAdmin:      allowSysAdmin,
+	}
+	if st, err := os.Stat(fs.Args()[0]); len(fs.Args()) > 1 || (err == nil && st.IsDir()) {
+		if checkSkills {
+			fmt.Fprintln(os.Stderr, "validate failed: --check-skills-network takes a single clawfile")
+			return 1
+		}
+		return runValidateMany(fs.Args(), opts, asJSON, quiet)
+	}
+	var report *skillsNetworkReport
+	if checkSkills {
+		r, err := buildSkillsNetworkReport(fs.Args()[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "validate failed: %v\n", err)
+			return 1
+		}
+		report = &r
+	}
+	cfg, warnings, err := compiler.LoadNormalizeWithOptions(fs.Args()[0], opts)
 	if err != nil {
+		if report != nil {
+			if asJSON {
+				b, _ := json.MarshalIndent(map[string]any{"skillsNetwork": report}, "", "  ")
+				fmt.Println(string(b))
+			} else {
+				writeSkillsNetworkReport(os.Stdout, *report)
+			}
+		}
 		fmt.Fprintf(os.Stderr, "validate failed: %v\n", err)
 		return 1
 	}
-	b, _ := json.MarshalIndent(cfg, "", "  ")
-	fmt.Println(string(b))
+	if asJSON {
+		if warnings == nil {
+			warnings = []validate.Warning{}
+		}
+		out := map[string]any{"clawfile": cfg, "warnings": warnings}
+		if report != nil {
+			out["skillsNetwork"] = report
+		}
+		b, _ := json.MarshalIndent(out, "", "  ")
+		fmt.Println(string(b))
+		return 0
+	}
+	if !quiet {
+		b, _ := json.MarshalIndent(cfg, "", "  ")
+		fmt.Println(string(b))
+	}
+	printValidationWarnings(os.Stderr, warnings)
+	if report != nil {
+		writeSkillsNetworkReport(os.Stdout, *report)
+	}
 	fmt.Println("validation: OK")
 	return 0
 }
 
+// validateResult is one entry of a multi-file validate run.
+type validateResult struct {
+	Path     string             `json:"path"`
+	OK       bool               `json:"ok"`
+	Error    string             `json:"error,omitempty"`
+	Warnings []validate.Warning `json:"warnings"`
+}
+
+// runValidateMany validates every clawfile named by paths (directories are
+// walked for *.claw) and prints a per-file summary. Any failure makes the
+// exit code non-zero. With quiet only failures and the totals are printed.
+func runValidateMany(paths []string, opts compiler.Options, asJSON, quiet bool) int {
+	files, err := collectClawfiles(paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "validate failed: %v\n", err)
+		return 1
+	}
+	results := validateClawfiles(files, opts)
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+		}
+	}
+	if asJSON {
+		b, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(b))
+	} else {
+		for _, r := range results {
+			if r.OK {
+				if !quiet {
+					fmt.Printf("OK   %s\n", r.Path)
+				}
+				printValidationWarnings(os.Stderr, r.Warnings)
+				continue
+			}
+			fmt.Printf("FAIL %s: %s\n", r.Path, r.Error)
+		}
+		fmt.Printf("validation: %d ok, %d failed\n", len(results)-failed, failed)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func validateClawfiles(files []string, opts compiler.Options) []validateResult {
+	results := make([]validateResult, 0, len(files))
+	for _, f := range files {
+		rep := compiler.LoadNormalizeReport(f, opts)
+		r := validateResult{Path: f, OK: rep.OK(), Warnings: rep.Warnings}
+		if !rep.OK() {
+			r.Error = strings.Join(rep.Errors, "; ")
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// collectClawfiles expands directories into the *.claw files below them
+// (skipping hidden directories) and keeps file arguments as given.
+func collectClawfiles(paths []string) ([]string, error) {
+	var out []string
+	for _, p := range paths {
+		st, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !st.IsDir() {
+			out = append(out, p)
+			continue
+		}
+		var found []string
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if d.IsDir() {
+				if path != p && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(d.Name(), ".claw") {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no .claw files found under %s", p)
+		}
+		out = append(out, found...)
+	}
+	return out, nil
+}
+
+func printValidationWarnings(w io.Writer, warnings []validate.Warning) {
+	for _, wn := range warnings {
+		if wn.Field != "" {
+			fmt.Fprintf(w, "WARN [%s] %s: %s\n", wn.Code, wn.Field, wn.Message)
+			continue
+		}
+		fmt.Fprintf(w, "WARN [%s] %s\n", wn.Code, wn.Message)
+	}
+}
+
+// stateDirInUse reports whether compile may keep state under stateDir: the
+// user named it with --state-dir or it already exists. A plain compile then
+// leaves no .metaclaw directory behind.
+func stateDirInUse(fs *flag.FlagSet, stateDir string) bool {
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "state-dir" {
+			explicit = true
+		}
+	})
+	if explicit {
+		return true
+	}
+	st, err := os.Stat(stateDir)
+	return err == nil && st.IsDir()
+}
+
 func runCompile(args []string) int {
-	args = reorderFlags(args, map[string]bool{"-o": true})
+	args = reorderFlags(args, map[string]bool{"-o": true, "--state-dir": true, "--species-file": true, "--skill-registry": true, "--allowed-registries": true, "--runtime": true})
 	fs := flag.NewFlagSet("compile", flag.ContinueOnError)
 	var out string
+	var stateDir string
+	var speciesFile string
+	var allowSysAdmin bool
+	var noHashCache bool
+	var skillRegistry string
+	var allowedRegistries string
+	var checkMounts bool
+	var allowMissingMounts bool
+	var lockOnly bool
+	var emitIR bool
+	var resolveDigests bool
+	var runtimeOverride string
 	fs.StringVar(&out, "o", ".", "output directory")
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory (hosts the source hash cache when given or already present)")
+	fs.StringVar(&speciesFile, "species-file", "", "species registry file defining custom species (default: <state-dir>/species.yaml if present)")
+	fs.BoolVar(&noHashCache, "no-hash-cache", false, "re-hash every source file instead of using the hash cache")
+	fs.StringVar(&skillRegistry, "skill-registry", "", "local skill registry dir (<id>@<version>/ entries) used to check id-based skills")
+	fs.StringVar(&allowedRegistries, "allowed-registries", "", "comma-separated registry hosts (or host/prefix) the runtime image must come from")
+	fs.BoolVar(&checkMounts, "check-mounts", false, "fail if a habitat mount source is missing or not a directory on this host")
+	fs.BoolVar(&allowMissingMounts, "allow-missing-mounts", false, "with --check-mounts, warn instead of failing on mount sources that do not exist yet")
+	fs.BoolVar(&lockOnly, "lock-only", false, "only write deps/image/source lock files to the output directory (no capsule)")
+	fs.BoolVar(&emitIR, "emit-ir", false, "print the capsule's ir.json to stdout byte for byte and write nothing")
+	fs.BoolVar(&resolveDigests, "resolve-digests", false, "record the image manifest digest reported by the runtime in image.lock.json (falls back to the reference hash with a warning)")
+	fs.StringVar(&runtimeOverride, "runtime", "", "runtime used by --resolve-digests (podman|apple_container|docker|nerdctl; default: clawfile target or auto)")
+	fs.BoolVar(&allowSysAdmin, "allow-sys-admin", false, "permit habitat.capabilities.add to grant SYS_ADMIN (strict releases still fail runtime.no_sys_admin)")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	remaining := fs.Args()
 	if len(remaining) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw compile <file.claw> [-o dir]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw compile <file.claw> [-o dir] [--state-dir=.metaclaw] [--species-file=path] [--no-hash-cache] [--skill-registry=dir] [--allowed-registries=host,...] [--check-mounts [--allow-missing-mounts]] [--lock-only|--emit-ir] [--resolve-digests [--runtime=..]] [--allow-sys-admin]")
+		return 1
+	}
+	if runtimeOverride != "" && !resolveDigests {
+		fmt.Fprintln(os.Stderr, "compile failed: --runtime requires --resolve-digests")
+		return 1
+	}
+	if allowMissingMounts && !checkMounts {
+		fmt.Fprintln(os.Stderr, "compile failed: --allow-missing-mounts requires --check-mounts")
+		return 1
+	}
+	if emitIR && (lockOnly || resolveDigests) {
+		fmt.Fprintln(os.Stderr, "compile failed: --emit-ir cannot be combined with --lock-only or --resolve-digests")
 		return 1
 	}
-	res, err := compiler.Compile(remaining[0], out)
+	if err := loadSpeciesRegistry(stateDir, speciesFile); err != nil {
+		fmt.Fprintf(os.Stderr, "compile failed: %v\n", err)
+		return 1
+	}
+	opts := compiler.Options{
+		SkillRegistry:      skillRegistry,
+		AllowedRegistries:  strings.Split(allowedRegistries, ","),
+		CheckMounts:        checkMounts,
+		AllowMissingMounts: allowMissingMounts,
+		AllowSysAdmin:      allowSysAdmin,
+	}
+	if resolveDigests {
+		opts.ResolveImageDigest = imageDigestResolver(runtimeOverride)
+	}
+	if !noHashCache && stateDirInUse(fs, stateDir) {
+		opts.HashCacheDir = filepath.Join(stateDir, "hash-cache")
+	}
+	if emitIR {
+		b, warnings, err := compiler.EmitIR(remaining[0], opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "compile failed: %v\n", err)
+			return 1
+		}
+		printValidationWarnings(os.Stderr, warnings)
+		// No trailing newline: stdout must match ir.json exactly.
+		_, _ = os.Stdout.Write(b)
+		return 0
+	}
+	if lockOnly {
+		res, err := compiler.CompileLocks(remaining[0], out, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "compile failed: %v\n", err)
+			return 1
+		}
+		printValidationWarnings(os.Stderr, res.Warnings)
+		for _, p := range res.Paths {
+			fmt.Printf("lock: %s\n", p)
+		}
+		return 0
+	}
+	res, err := compiler.CompileWithOptions(remaining[0], out, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "compile failed: %v\n", err)
 		return 1
 	}
+	printValidationWarnings(os.Stderr, res.Warnings)
 	fmt.Printf("capsule: %s\n", res.Capsule.Path)
 	fmt.Printf("capsule_id: %s\n", res.Capsule.ID)
 	return 0
 }
 
+// imageDigestResolver looks up an image's manifest digest through a local
+// runtime: runtimeOverride if set, else the clawfile's target, else the first
+// healthy runtime.
+func imageDigestResolver(runtimeOverride string) func(image, target string) (string, error) {
+	return func(image, target string) (string, error) {
+		requested := runtimeOverride
+		if requested == "" {
+			requested = target
+		}
+		rt, bin, _, err := resolveRequestedRuntime(requested)
+		if err != nil {
+			return "", err
+		}
+		ref, err := resolvePinnedImageRef(rt, bin, image)
+		if err != nil {
+			return "", err
+		}
+		_, digest, ok := strings.Cut(ref, "@")
+		if !ok || !strings.HasPrefix(digest, "sha256:") {
+			return "", fmt.Errorf("%s returned no manifest digest for %s", rt, image)
+		}
+		return digest, nil
+	}
+}
+
 func runRun(ctx context.Context, args []string) int {
 	if err := IsSecurityOverrideFlag(args); err != nil {
 		fmt.Fprintf(os.Stderr, "run blocked: %v\n", err)
 		return 1
 	}
 	args = reorderFlags(args, map[string]bool{
-		"--runtime":         true,
-		"--state-dir":       true,
-		"--llm-api-key":     true,
-		"--llm-api-key-env": true,
-		"--secret-env":      true,
+		"--runtime":              true,
+		"--state-dir":            true,
+		"--species-file":         true,
+		"--llm-api-key":          true,
+		"--llm-api-key-env":      true,
+		"--secret-env":           true,
+		"--secret-file":          true,
+		"--sign-key":             true,
+		"--healthcheck-cmd":      true,
+		"--healthcheck-interval": true,
+		"--on-failure":           true,
+		"--log-format":           true,
+		"--timeout":              true,
+		"--name":                 true,
+		"--label":                true,
+		"--max-log-size":         true,
+		"--env-file":             true,
+		"--llm-model":            true,
+		"--llm-base-url":         true,
 	})
 	fs := flag.NewFlagSet("run", flag.ContinueOnError)
 	var detach bool
 	var runtimeOverride string
 	var stateDir string
+	var speciesFile string
 	var llmAPIKey string
 	var llmAPIKeyEnv string
 	var secretEnvNames stringListFlag
+	var secretFiles stringListFlag
+	var readOnlyMounts bool
+	var allowSysAdmin bool
+	var noRedact bool
+	var attach bool
+	var noHashCache bool
+	var compileOnly bool
+	var saveRelease bool
+	var strict bool
+	var signKey string
+	var healthcheckCmd string
+	var healthcheckInterval time.Duration
+	var onFailure string
+	var logFormat string
+	var timeout time.Duration
+	var runName string
+	var labelValues stringListFlag
+	var maxLogSizeRaw string
+	var envFile string
+	var llmModel string
+	var llmBaseURL string
 	fs.BoolVar(&detach, "detach", false, "run in background")
-	fs.StringVar(&runtimeOverride, "runtime", "", "runtime override (podman|apple_container|docker)")
+	fs.StringVar(&runtimeOverride, "runtime", "", "runtime override (podman|apple_container|docker|nerdctl)")
 	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.StringVar(&speciesFile, "species-file", "", "species registry file defining custom species (default: <state-dir>/species.yaml if present)")
 	fs.StringVar(&llmAPIKey, "llm-api-key", "", "LLM API key (prefer --llm-api-key-env for better secret hygiene)")
 	fs.StringVar(&llmAPIKeyEnv, "llm-api-key-env", "", "host env variable name to read LLM API key from")
 	fs.Var(&secretEnvNames, "secret-env", "host env variable to inject securely at runtime (repeatable)")
+	fs.Var(&secretFiles, "secret-file", "NAME=path: inject the file's contents as secret env NAME without exporting it (repeatable)")
+	fs.BoolVar(&readOnlyMounts, "read-only-mounts", false, "force every habitat mount read-only for this run")
+	fs.BoolVar(&allowSysAdmin, "allow-sys-admin", false, "permit habitat.capabilities.add to grant SYS_ADMIN when compiling a clawfile")
+	fs.BoolVar(&noHashCache, "no-hash-cache", false, "re-hash every source file instead of using the hash cache")
+	fs.BoolVar(&compileOnly, "compile-only", false, "compile and register the capsule in the state store without running it")
+	fs.BoolVar(&saveRelease, "save-release", false, "create a signed release from the capsule after a foreground run succeeds")
+	fs.BoolVar(&strict, "strict", false, "enforce strict release checks (with --save-release)")
+	fs.StringVar(&signKey, "sign-key", "", "ed25519 private key path for --save-release; auto-generated if absent")
+	fs.StringVar(&healthcheckCmd, "healthcheck-cmd", "", "container healthcheck command for detached/daemon runs; unhealthy marks the run failed")
+	fs.DurationVar(&healthcheckInterval, "healthcheck-interval", 0, "healthcheck interval (runtime default when 0)")
+	fs.StringVar(&logFormat, "log-format", manager.LogFormatRaw, "container output format for foreground runs (raw|json: NDJSON records on stdout)")
+	fs.StringVar(&onFailure, "on-failure", "", "action when a foreground run fails (debug: keep the container and open a shell in it)")
+	fs.DurationVar(&timeout, "timeout", 0, "fail a foreground run with status timed_out if it has not finished after this long (0 disables)")
+	fs.StringVar(&runName, "name", "", "human-friendly run name ([A-Za-z0-9_-]+) accepted wherever a run id is")
+	fs.StringVar(&maxLogSizeRaw, "max-log-size", "", "roll stdout.log/stderr.log to .1, .2, ... past this size (e.g. 10MB; bytes without a suffix)")
+	fs.StringVar(&llmModel, "llm-model", "", "override agent.llm.model for this run only")
+	fs.StringVar(&llmBaseURL, "llm-base-url", "", "override agent.llm.baseURL for this run only")
+	fs.StringVar(&envFile, "env-file", "", "dotenv file of KEY=VALUE overrides for env declared in agent.habitat.env (lower precedence than --secret-env and LLM env)")
+	fs.Var(&labelValues, "label", "key=value label recorded on the run for ps --filter label=key=value (repeatable)")
+	fs.BoolVar(&noRedact, "no-redact", false, "store output and events without masking secret values (debugging only)")
+	fs.BoolVar(&attach, "attach", false, "stream a foreground run's stdout/stderr to this terminal as it is produced")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	remaining := fs.Args()
 	if len(remaining) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw run <file.claw|capsule_dir> [--detach] [--runtime=..] [--state-dir=.metaclaw] [--llm-api-key=..|--llm-api-key-env=..] [--secret-env=NAME ...]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw run <file.claw|capsule_dir> [--detach] [--runtime=..] [--state-dir=.metaclaw] [--species-file=path] [--llm-api-key=..|--llm-api-key-env=..] [--llm-model=..] [--llm-base-url=..] [--secret-env=NAME ...] [--secret-file=NAME=path ...] [--read-only-mounts] [--no-hash-cache] [--compile-only] [--save-release [--strict] [--sign-key=path]] [--healthcheck-cmd=.. [--healthcheck-interval=5s]] [--on-failure=debug] [--log-format=raw|json] [--timeout=10m] [--name=NAME] [--label key=value ...] [--max-log-size=10MB] [--env-file=path] [--no-redact] [--attach] [--allow-sys-admin]")
+		return 1
+	}
+	if logFormat != manager.LogFormatRaw && logFormat != manager.LogFormatJSON {
+		fmt.Fprintf(os.Stderr, "run failed: unsupported --log-format value %q (supported: raw, json)\n", logFormat)
+		return 1
+	}
+	if logFormat == manager.LogFormatJSON && detach {
+		fmt.Fprintln(os.Stderr, "run failed: --log-format=json requires a foreground run")
+		return 1
+	}
+	if attach && (detach || compileOnly || logFormat == manager.LogFormatJSON) {
+		fmt.Fprintln(os.Stderr, "run failed: --attach requires a foreground run without --log-format=json")
+		return 1
+	}
+	if onFailure != "" && onFailure != "debug" {
+		fmt.Fprintf(os.Stderr, "run failed: unsupported --on-failure value %q (supported: debug)\n", onFailure)
+		return 1
+	}
+	if onFailure == "debug" && (detach || compileOnly) {
+		fmt.Fprintln(os.Stderr, "run failed: --on-failure=debug requires a foreground run")
+		return 1
+	}
+	if timeout < 0 {
+		fmt.Fprintln(os.Stderr, "run failed: --timeout must not be negative")
+		return 1
+	}
+	if timeout > 0 && (detach || compileOnly) {
+		fmt.Fprintln(os.Stderr, "run failed: --timeout requires a foreground run")
+		return 1
+	}
+	if healthcheckInterval < 0 || (healthcheckInterval > 0 && strings.TrimSpace(healthcheckCmd) == "") {
+		fmt.Fprintln(os.Stderr, "run failed: --healthcheck-interval must be positive and requires --healthcheck-cmd")
+		return 1
+	}
+	if (strict || signKey != "") && !saveRelease {
+		fmt.Fprintln(os.Stderr, "run failed: --strict and --sign-key require --save-release")
+		return 1
+	}
+	if saveRelease && (compileOnly || detach) {
+		fmt.Fprintln(os.Stderr, "run failed: --save-release requires a foreground run (not --compile-only or --detach)")
+		return 1
+	}
+	labels, err := parseLabels(labelValues.Values())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run failed: %v\n", err)
+		return 1
+	}
+	var maxLogSize int64
+	if maxLogSizeRaw != "" {
+		if maxLogSize, err = parseByteSize(maxLogSizeRaw); err != nil {
+			fmt.Fprintf(os.Stderr, "run failed: --max-log-size: %v\n", err)
+			return 1
+		}
+	}
+	if err := loadSpeciesRegistry(stateDir, speciesFile); err != nil {
+		fmt.Fprintf(os.Stderr, "run failed: %v\n", err)
 		return 1
 	}
 	m, err := manager.New(stateDir)
@@ -189,50 +674,169 @@ func runRun(ctx context.Context, args []string) int {
 	}
 	defer m.Close()
 
-	r, err := m.Run(ctx, manager.RunOptions{
-		InputPath:       remaining[0],
-		Detach:          detach,
-		RuntimeOverride: runtimeOverride,
-		LLMAPIKey:       llmAPIKey,
-		LLMAPIKeyEnv:    llmAPIKeyEnv,
-		SecretEnvs:      secretEnvNames.Values(),
-	})
+	runOpts := manager.RunOptions{
+		InputPath:           remaining[0],
+		Detach:              detach,
+		RuntimeOverride:     runtimeOverride,
+		LLMAPIKey:           llmAPIKey,
+		LLMAPIKeyEnv:        llmAPIKeyEnv,
+		SecretEnvs:          secretEnvNames.Values(),
+		SecretFiles:         secretFiles.Values(),
+		ReadOnlyMounts:      readOnlyMounts,
+		NoHashCache:         noHashCache,
+		AllowSysAdmin:       allowSysAdmin,
+		HealthcheckCmd:      healthcheckCmd,
+		HealthcheckInterval: healthcheckInterval,
+		PreserveOnFailure:   onFailure == "debug",
+		LogFormat:           logFormat,
+		Name:                runName,
+		Labels:              labels,
+		MaxLogSize:          maxLogSize,
+		EnvFile:             envFile,
+		LLMModel:            llmModel,
+		LLMBaseURL:          llmBaseURL,
+		NoRedact:            noRedact,
+	}
+	if attach {
+		runOpts.AttachStdout = os.Stdout
+		runOpts.AttachStderr = os.Stderr
+	}
+	if compileOnly {
+		c, err := m.RegisterCapsule(runOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "run failed: %v\n", err)
+			return 1
+		}
+		fmt.Printf("capsule_id: %s\n", c.CapsuleID)
+		fmt.Printf("capsule: %s\n", c.CapsulePath)
+		return 0
+	}
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	r, err := m.Run(runCtx, runOpts)
+	// With --log-format=json stdout carries only NDJSON output records, so the
+	// run summary moves to stderr.
+	info := io.Writer(os.Stdout)
+	if logFormat == manager.LogFormatJSON {
+		info = os.Stderr
+		if r.RunID != "" {
+			if out, err := readRunOutput(stateDir, r.RunID, manager.OutputNDJSONFile); err == nil {
+				fmt.Print(out)
+			}
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "run failed: %v\n", err)
 		if r.RunID != "" {
-			fmt.Printf("run_id: %s\n", r.RunID)
+			fmt.Fprintf(info, "run_id: %s\n", r.RunID)
 		}
 		if r.Status != "" {
-			fmt.Printf("status: %s\n", r.Status)
+			fmt.Fprintf(info, "status: %s\n", r.Status)
+		}
+		if onFailure == "debug" && r.Status == "failed_paused" {
+			fmt.Fprintf(os.Stderr, "opening debug shell in %s (exit the shell to return)\n", r.ContainerID)
+			if err := m.DebugShell(ctx, r.RunID); err != nil {
+				fmt.Fprintf(os.Stderr, "debug shell failed: %v\n", err)
+			}
 		}
 		return 1
 	}
-	fmt.Printf("run_id: %s\n", r.RunID)
-	fmt.Printf("status: %s\n", r.Status)
-	fmt.Printf("runtime: %s\n", r.RuntimeTarget)
-	fmt.Printf("container: %s\n", r.ContainerID)
+	fmt.Fprintf(info, "run_id: %s\n", r.RunID)
+	if r.Name != "" {
+		fmt.Fprintf(info, "name: %s\n", r.Name)
+	}
+	fmt.Fprintf(info, "status: %s\n", r.Status)
+	fmt.Fprintf(info, "runtime: %s\n", r.RuntimeTarget)
+	fmt.Fprintf(info, "container: %s\n", r.ContainerID)
+	if saveRelease {
+		// Daemon agents return while still running; only release a capsule
+		// whose run actually finished cleanly.
+		if r.Status != "succeeded" {
+			fmt.Fprintf(os.Stderr, "release failed: --save-release needs a succeeded run, got status %s\n", r.Status)
+			return 1
+		}
+		rel, err := release.Create(release.CreateOptions{
+			InputPath:      r.CapsulePath,
+			StateDir:       stateDir,
+			Strict:         strict,
+			PrivateKeyPath: signKey,
+			Passphrase:     keyPassphrase,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "release failed: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(info, "release_id: %s\n", rel.ReleaseID)
+		fmt.Fprintf(info, "release_dir: %s\n", rel.ReleaseDir)
+	}
 	return 0
 }
 
-func runPS(args []string) int {
-	args = reorderFlags(args, map[string]bool{"--state-dir": true, "--limit": true})
+func runPS(ctx context.Context, args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true, "--limit": true, "--output": true, "--filter": true})
 	fs := flag.NewFlagSet("ps", flag.ContinueOnError)
 	var stateDir string
 	var limit int
 	var asJSON bool
+	var wide bool
+	var output string
+	var quiet bool
+	var filters stringListFlag
+	var watch watchFlag
 	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
 	fs.IntVar(&limit, "limit", 50, "max rows")
+	fs.Var(&filters, "filter", "key=value filter on status, runtime, lifecycle, capsule or label=key=value (repeatable; same key ORs, different keys and labels AND)")
 	fs.BoolVar(&asJSON, "json", false, "json output")
+	fs.BoolVar(&wide, "wide", false, "include container, image, exit code, and last error columns")
+	fs.StringVar(&output, "output", "", "output mode (ids: one run id per line)")
+	fs.BoolVar(&quiet, "quiet", false, "alias for --output=ids")
+	fs.Var(&watch, "watch", "redraw the table every interval until interrupted (--watch or --watch=5s)")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
+	if quiet {
+		output = "ids"
+	}
+	if watch.enabled && (asJSON || output == "ids") {
+		fmt.Fprintln(os.Stderr, "ps failed: --watch cannot be combined with --json or --output=ids")
+		return 1
+	}
+	if output != "" && output != "ids" {
+		fmt.Fprintf(os.Stderr, "ps failed: unsupported --output value %q (supported: ids)\n", output)
+		return 1
+	}
+	if output == "ids" && (asJSON || wide) {
+		fmt.Fprintln(os.Stderr, "ps failed: --output=ids cannot be combined with --json or --wide")
+		return 1
+	}
+	filter, err := parseRunFilters(filters.Values())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ps failed: %v\n", err)
+		return 1
+	}
 	m, err := manager.New(stateDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "open manager: %v\n", err)
 		return 1
 	}
 	defer m.Close()
-	runs, err := m.ListRuns(limit)
+	if watch.enabled {
+		render := writePSTable
+		if wide {
+			render = writeWidePS
+		}
+		list := func() ([]store.RunRecord, error) { return m.ListRunsFiltered(filter, limit) }
+		if err := watchPS(ctx, os.Stdout, watch.interval, list, render); err != nil {
+			fmt.Fprintf(os.Stderr, "ps failed: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	runs, err := m.ListRunsFiltered(filter, limit)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ps failed: %v\n", err)
 		return 1
@@ -242,28 +846,214 @@ func runPS(args []string) int {
 		fmt.Println(string(b))
 		return 0
 	}
-	for _, r := range runs {
-		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", r.RunID, r.Status, r.RuntimeTarget, r.Lifecycle, r.CapsuleID)
+	if output == "ids" {
+		for _, r := range runs {
+			fmt.Println(r.RunID)
+		}
+		return 0
+	}
+	if wide {
+		writeWidePS(os.Stdout, runs)
+		return 0
 	}
+	writePSTable(os.Stdout, runs)
 	return 0
 }
 
+func writePSTable(w io.Writer, runs []store.RunRecord) {
+	for _, r := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.RunID, r.Status, r.RuntimeTarget, r.Lifecycle, r.CapsuleID)
+	}
+}
+
+// parseRunFilters turns repeated --filter key=value flags into a store filter.
+func parseRunFilters(values []string) (store.RunFilter, error) {
+	var f store.RunFilter
+	for _, raw := range values {
+		key, value, ok := strings.Cut(raw, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" {
+			return store.RunFilter{}, fmt.Errorf("invalid --filter %q (want key=value)", raw)
+		}
+		switch key {
+		case "status":
+			f.Status = append(f.Status, value)
+		case "runtime":
+			f.RuntimeTarget = append(f.RuntimeTarget, value)
+		case "lifecycle":
+			f.Lifecycle = append(f.Lifecycle, value)
+		case "capsule":
+			f.CapsuleID = append(f.CapsuleID, value)
+		case "label":
+			lk, lv, ok := strings.Cut(value, "=")
+			lk, lv = strings.TrimSpace(lk), strings.TrimSpace(lv)
+			if !ok || lk == "" || lv == "" {
+				return store.RunFilter{}, fmt.Errorf("invalid --filter %q (want label=key=value)", raw)
+			}
+			f.Labels = append(f.Labels, store.Label{Key: lk, Value: lv})
+		default:
+			return store.RunFilter{}, fmt.Errorf("unsupported --filter key %q (supported: status, runtime, lifecycle, capsule, label)", key)
+		}
+	}
+	return f, nil
+}
+
+// parseByteSize accepts a byte count with an optional K, KB, M, MB, G or GB
+// suffix (powers of 1024).
+func parseByteSize(raw string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(raw))
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"G", 1 << 30}, {"MB", 1 << 20}, {"M", 1 << 20}, {"KB", 1 << 10}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(v, u.suffix) {
+			v, mult = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q (want a positive byte count like 1048576 or 10MB)", raw)
+	}
+	return n * mult, nil
+}
+
+// parseLabels turns repeated --label key=value flags into a map. Key and
+// value rules are enforced by the manager.
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(values))
+	for _, raw := range values {
+		key, value, ok := strings.Cut(raw, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return nil, fmt.Errorf("invalid --label %q (want key=value)", raw)
+		}
+		if _, dup := out[key]; dup {
+			return nil, fmt.Errorf("duplicate --label key %q", key)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+const (
+	psContainerIDWidth = 12
+	psImageWidth       = 40
+	psLastErrorWidth   = 48
+)
+
+func writeWidePS(w io.Writer, runs []store.RunRecord) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN ID\tNAME\tSTATUS\tRUNTIME\tLIFECYCLE\tCAPSULE\tCONTAINER\tIMAGE\tEXIT\tLAST ERROR")
+	for _, r := range runs {
+		image := r.ImageRef
+		if image == "" {
+			// Runs recorded before the image was stored on the run.
+			image, _ = readCapsuleImage(r.CapsulePath)
+		}
+		exit := "-"
+		if r.ExitCode != nil {
+			exit = strconv.Itoa(*r.ExitCode)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.RunID,
+			orDash(r.Name),
+			r.Status,
+			r.RuntimeTarget,
+			r.Lifecycle,
+			r.CapsuleID,
+			orDash(shortContainerID(r.ContainerID)),
+			orDash(truncateCell(image, psImageWidth)),
+			exit,
+			orDash(truncateCell(r.LastError, psLastErrorWidth)),
+		)
+	}
+	_ = tw.Flush()
+}
+
+// truncateCell collapses whitespace so multi-line errors stay on one row, then
+// trims the value to max runes with a trailing ellipsis.
+func truncateCell(v string, max int) string {
+	v = strings.Join(strings.Fields(v), " ")
+	r := []rune(v)
+	if max <= 0 || len(r) <= max {
+		return v
+	}
+	if max <= 3 {
+		return string(r[:max])
+	}
+	return string(r[:max-3]) + "..."
+}
+
+func shortContainerID(id string) string {
+	id = strings.TrimSpace(id)
+	if len(id) > psContainerIDWidth {
+		return id[:psContainerIDWidth]
+	}
+	return id
+}
+
+func orDash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+func readCapsuleImage(capPath string) (string, error) {
+	if strings.TrimSpace(capPath) == "" {
+		return "", fmt.Errorf("capsule path is empty")
+	}
+	b, err := os.ReadFile(filepath.Join(capPath, "ir.json"))
+	if err != nil {
+		return "", err
+	}
+	var ir struct {
+		Runtime struct {
+			Image string `json:"image"`
+		} `json:"runtime"`
+	}
+	if err := json.Unmarshal(b, &ir); err != nil {
+		return "", err
+	}
+	return ir.Runtime.Image, nil
+}
+
 func runLogs(ctx context.Context, args []string) int {
-	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+	args = reorderFlags(args, map[string]bool{"--state-dir": true, "--tail": true, "--since": true})
 	fs := flag.NewFlagSet("logs", flag.ContinueOnError)
 	var stateDir string
 	var follow bool
+	var diff bool
+	var asJSON bool
+	var tail int
+	var sinceRaw string
 	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
 	fs.BoolVar(&follow, "follow", false, "follow runtime logs")
+	fs.IntVar(&tail, "tail", -1, "only print the last N lines of runtime, stdout and stderr output (-1 prints everything)")
+	fs.StringVar(&sinceRaw, "since", "", "only print events at or after this time (duration like 10m, or RFC3339)")
+	fs.BoolVar(&diff, "diff", false, "print a unified diff of stdout between two runs")
+	fs.BoolVar(&asJSON, "json", false, "one JSON object per line tagged with its source (event|runtime|stdout|stderr)")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	remaining := fs.Args()
-	if len(remaining) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw logs <run-id> [--follow]")
+	if (!diff && len(remaining) != 1) || (diff && len(remaining) != 2) || (diff && (asJSON || tail >= 0 || sinceRaw != "")) || tail < -1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw logs <run-id> [--follow] [--json] [--tail=N] [--since=10m|RFC3339] | metaclaw logs --diff <run-id-a> <run-id-b>")
 		return 1
 	}
-	runID := remaining[0]
+	var since time.Time
+	if sinceRaw != "" {
+		var err error
+		if since, err = parseLogsSince(sinceRaw, time.Now()); err != nil {
+			fmt.Fprintf(os.Stderr, "logs failed: %v\n", err)
+			return 1
+		}
+	}
 	m, err := manager.New(stateDir)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "open manager: %v\n", err)
@@ -271,55 +1061,205 @@ func runLogs(ctx context.Context, args []string) int {
 	}
 	defer m.Close()
 
+	if diff {
+		return runLogsDiff(m, stateDir, remaining[0], remaining[1])
+	}
+	r, err := m.GetRun(remaining[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run not found: %v\n", err)
+		return 1
+	}
+	runID := r.RunID
+
 	events, err := m.ReadEvents(runID)
 	if err == nil {
-		for _, line := range events {
-			fmt.Println(line)
+		if !since.IsZero() {
+			events = filterEventsSince(events, since)
+		}
+		if asJSON {
+			writeEventLogRecords(os.Stdout, runID, events)
+		} else {
+			for _, line := range events {
+				fmt.Println(line)
+			}
 		}
 	}
 
-	r, err := m.GetRun(runID)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "run not found: %v\n", err)
-		return 1
+	emit := func(source, text string) {
+		if asJSON {
+			writeTextLogRecords(os.Stdout, source, runID, text)
+			return
+		}
+		fmt.Print(text)
 	}
 	logsText, err := m.RuntimeLogs(ctx, r, follow)
 	if err == nil && strings.TrimSpace(logsText) != "" {
-		fmt.Print(logsText)
+		emit("runtime", tailLines(logsText, tail))
 	}
-	stdoutPath := filepath.Join(stateDir, "runs", runID, "stdout.log")
-	stderrPath := filepath.Join(stateDir, "runs", runID, "stderr.log")
-	if b, err := os.ReadFile(stdoutPath); err == nil && len(b) > 0 {
-		fmt.Print(string(b))
+	if out, err := readRunOutputTail(stateDir, runID, "stdout.log", tail); err == nil && len(out) > 0 {
+		emit("stdout", out)
 	}
-	if b, err := os.ReadFile(stderrPath); err == nil && len(b) > 0 {
-		fmt.Print(string(b))
+	if out, err := readRunOutputTail(stateDir, runID, "stderr.log", tail); err == nil && len(out) > 0 {
+		emit("stderr", out)
 	}
 	return 0
 }
 
+// logRecord is one line of `logs --json` output. Event records also carry the
+// original event object.
+type logRecord struct {
+	Source    string          `json:"source"`
+	RunID     string          `json:"runId"`
+	Timestamp string          `json:"timestamp,omitempty"`
+	Message   string          `json:"message"`
+	Event     json.RawMessage `json:"event,omitempty"`
+}
+
+func writeEventLogRecords(w io.Writer, runID string, events []string) {
+	for _, line := range events {
+		rec := logRecord{Source: "event", RunID: runID, Message: line}
+		var e logs.Event
+		if err := json.Unmarshal([]byte(line), &e); err == nil {
+			rec.Timestamp = e.Timestamp
+			rec.Message = e.Message
+			if e.Error != "" {
+				rec.Message += ": " + e.Error
+			}
+			rec.Event = json.RawMessage(line)
+		}
+		writeLogRecord(w, rec)
+	}
+}
+
+func writeTextLogRecords(w io.Writer, source, runID, text string) {
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		writeLogRecord(w, logRecord{Source: source, RunID: runID, Message: strings.TrimSuffix(line, "\r")})
+	}
+}
+
+func writeLogRecord(w io.Writer, rec logRecord) {
+	b, _ := json.Marshal(rec)
+	fmt.Fprintln(w, string(b))
+}
+
+func runLogsDiff(m *manager.Manager, stateDir, runA, runB string) int {
+	outputs := make([]string, 2)
+	for i, ref := range []string{runA, runB} {
+		r, err := m.GetRun(ref)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "run not found: %v\n", err)
+			return 1
+		}
+		id := r.RunID
+		out, err := readRunOutput(stateDir, id, "stdout.log")
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "logs diff failed: %v\n", err)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "warning: no stdout.log for run %s (treating as empty)\n", id)
+		}
+		outputs[i] = out
+	}
+	d := textdiff.Unified(runA+"/stdout.log", runB+"/stdout.log", outputs[0], outputs[1], 3)
+	if d == "" {
+		fmt.Println("logs diff: stdout identical")
+		return 0
+	}
+	fmt.Print(d)
+	return 0
+}
+
+// readRunOutput reads a captured run output file across its rotated set
+// (name.N ... name.1, name), falling back to a gzip-compressed copy
+// (name + ".gz") when the plain file is absent.
+func readRunOutput(stateDir, runID, name string) (string, error) {
+	p := filepath.Join(stateDir, "runs", runID, name)
+	out, err := logs.ReadRotated(p)
+	if err == nil {
+		return out, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	f, gzErr := os.Open(p + ".gz")
+	if gzErr != nil {
+		if errors.Is(gzErr, os.ErrNotExist) {
+			return "", err
+		}
+		return "", gzErr
+	}
+	defer f.Close()
+	zr, gzErr := gzip.NewReader(f)
+	if gzErr != nil {
+		return "", fmt.Errorf("read %s.gz: %w", p, gzErr)
+	}
+	defer zr.Close()
+	b, gzErr := io.ReadAll(zr)
+	if gzErr != nil {
+		return "", fmt.Errorf("read %s.gz: %w", p, gzErr)
+	}
+	return string(b), nil
+}
+
 func runInspect(ctx context.Context, args []string) int {
-	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+	args = reorderFlags(args, map[string]bool{"--state-dir": true, "--timeout": true, "--interval": true, "--format": true})
 	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
 	var stateDir string
+	var format string
 	var asJSON bool
+	var followStatus bool
+	var timeout time.Duration
+	var interval time.Duration
 	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
 	fs.BoolVar(&asJSON, "json", false, "json output")
+	fs.StringVar(&format, "format", "", "render output with a Go template (e.g. '{{.run.Status}}')")
+	fs.BoolVar(&followStatus, "follow-status", false, "block until the run reaches a terminal state; exit with the run's exit code")
+	fs.DurationVar(&timeout, "timeout", 0, "give up waiting after this long with --follow-status (0 waits forever)")
+	fs.DurationVar(&interval, "interval", 2*time.Second, "status poll interval with --follow-status")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	remaining := fs.Args()
 	if len(remaining) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw inspect <run-id|capsule-dir> [--json]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw inspect <run-id|capsule-dir> [--json|--format=TEMPLATE] [--follow-status [--timeout=10m] [--interval=2s]]")
+		return 1
+	}
+	if interval <= 0 {
+		fmt.Fprintln(os.Stderr, "inspect failed: --interval must be positive")
 		return 1
 	}
+	if asJSON && format != "" {
+		fmt.Fprintln(os.Stderr, "inspect failed: --json and --format are mutually exclusive")
+		return 1
+	}
+	var tmpl *template.Template
+	if format != "" {
+		var err error
+		tmpl, err = parseInspectTemplate(format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "inspect failed: %v\n", err)
+			return 1
+		}
+	}
 	target := remaining[0]
 	if st, err := os.Stat(target); err == nil && st.IsDir() {
+		if followStatus {
+			fmt.Fprintln(os.Stderr, "inspect failed: --follow-status requires a run id")
+			return 1
+		}
 		m, err := capsule.Load(target)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "inspect capsule failed: %v\n", err)
 			return 1
 		}
+		if tmpl != nil {
+			if err := executeInspectTemplate(os.Stdout, tmpl, m); err != nil {
+				fmt.Fprintf(os.Stderr, "inspect failed: %v\n", err)
+				return 1
+			}
+			return 0
+		}
 		if asJSON {
 			b, _ := json.MarshalIndent(m, "", "  ")
 			fmt.Println(string(b))
@@ -336,31 +1276,310 @@ func runInspect(ctx context.Context, args []string) int {
 		return 1
 	}
 	defer m.Close()
-	r, err := m.GetRun(target)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "inspect run failed: %v\n", err)
-		return 1
+	exitCode := 0
+	var r store.RunRecord
+	if followStatus {
+		var timedOut bool
+		r, timedOut, err = waitForTerminalRun(ctx, m.GetRun, target, interval, timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "inspect run failed: %v\n", err)
+			return 1
+		}
+		exitCode = runExitCode(r)
+		if timedOut {
+			fmt.Fprintf(os.Stderr, "inspect: timed out after %s waiting for run %s (status %s)\n", timeout, r.RunID, r.Status)
+			exitCode = inspectTimeoutExitCode
+		}
+	} else {
+		r, err = m.GetRun(target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "inspect run failed: %v\n", err)
+			return 1
+		}
 	}
 	rt, inspectErr := m.RuntimeInspect(ctx, r)
 	payload := map[string]any{"run": r, "runtimeInspect": rt}
 	if inspectErr != nil {
 		payload["runtimeInspectError"] = inspectErr.Error()
 	}
+	// The effective policy comes from the run's capsule so users can compare
+	// what MetaClaw enforced with what the runtime reports. A run whose capsule
+	// was pruned shows a policyError instead.
+	pol, polErr := m.RunPolicy(r)
+	if polErr != nil {
+		payload["policyError"] = polErr.Error()
+	} else {
+		payload["policy"] = pol
+	}
+	if len(pol.Ports) > 0 {
+		payload["ports"] = pol.Ports
+	}
+	if tmpl != nil {
+		if err := executeInspectTemplate(os.Stdout, tmpl, templatePayload(payload)); err != nil {
+			fmt.Fprintf(os.Stderr, "inspect failed: %v\n", err)
+			return 1
+		}
+		return exitCode
+	}
 	if asJSON {
 		b, _ := json.MarshalIndent(payload, "", "  ")
 		fmt.Println(string(b))
-		return 0
+		return exitCode
 	}
 	fmt.Printf("run_id: %s\n", r.RunID)
+	if r.Name != "" {
+		fmt.Printf("name: %s\n", r.Name)
+	}
 	fmt.Printf("status: %s\n", r.Status)
 	fmt.Printf("runtime: %s\n", r.RuntimeTarget)
 	fmt.Printf("container: %s\n", r.ContainerID)
+	if r.ImageRef != "" {
+		fmt.Printf("image: %s\n", r.ImageRef)
+	}
+	if r.LLMModel != "" {
+		fmt.Printf("llm_model: %s\n", r.LLMModel)
+	}
+	if len(r.InjectedEnvKeys) > 0 {
+		fmt.Printf("env_keys: %s\n", strings.Join(r.InjectedEnvKeys, ","))
+	}
+	labelKeys := make([]string, 0, len(r.Labels))
+	for k := range r.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		fmt.Printf("label: %s=%s\n", k, r.Labels[k])
+	}
+	if r.RestartCount > 0 {
+		fmt.Printf("restarts: %d\n", r.RestartCount)
+	}
+	if polErr == nil {
+		fmt.Printf("network: %s\n", pol.Network.Mode)
+		if len(pol.Network.AllowedDomains) > 0 {
+			fmt.Printf("allowed_domains: %s\n", strings.Join(pol.Network.AllowedDomains, ","))
+		}
+		for _, mp := range pol.Mounts {
+			fmt.Printf("mount: %s\n", formatMountPolicy(mp))
+		}
+	}
+	for _, pm := range pol.Ports {
+		fmt.Printf("port: %s\n", formatPortMapping(pm))
+	}
+	if followStatus && r.ExitCode != nil {
+		fmt.Printf("exit_code: %d\n", *r.ExitCode)
+	}
 	if inspectErr != nil {
 		fmt.Printf("runtime inspect error: %v\n", inspectErr)
 	}
+	return exitCode
+}
+
+// parseInspectTemplate compiles an inspect --format template. Like docker
+// inspect, a json function is available for dumping nested values.
+func parseInspectTemplate(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("parse --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// executeInspectTemplate renders data and terminates the output with a newline
+// so single-field templates compose in shell pipelines.
+func executeInspectTemplate(w io.Writer, tmpl *template.Template, data any) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("execute --format template: %w", err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// templatePayload decodes runtimeInspect into structured data when it is JSON
+// so templates can reach into it (e.g. {{(index .runtimeInspect 0).State}}).
+func templatePayload(payload map[string]any) map[string]any {
+	out := make(map[string]any, len(payload))
+	for k, v := range payload {
+		out[k] = v
+	}
+	if raw, ok := payload["runtimeInspect"].(string); ok {
+		var decoded any
+		if err := json.Unmarshal([]byte(raw), &decoded); err == nil {
+			out["runtimeInspect"] = decoded
+		}
+	}
+	return out
+}
+
+// formatPortMapping renders a published port as host address -> container port.
+func formatPortMapping(p policy.PortPolicy) string {
+	return fmt.Sprintf("%s -> %d/%s", net.JoinHostPort(p.HostIP, strconv.Itoa(p.HostPort)), p.ContainerPort, p.Protocol)
+}
+
+// formatMountPolicy renders an enforced mount as source -> target (ro|rw).
+func formatMountPolicy(mp policy.MountPolicy) string {
+	mode := "rw"
+	if mp.ReadOnly {
+		mode = "ro"
+	}
+	return fmt.Sprintf("%s -> %s (%s)", mp.Source, mp.Target, mode)
+}
+
+// inspectTimeoutExitCode matches timeout(1) so scripts can tell a slow run
+// apart from a failed one.
+const inspectTimeoutExitCode = 124
+
+// waitForTerminalRun polls get until the run leaves the running state. It
+// returns the last record seen and whether the timeout elapsed first.
+func waitForTerminalRun(ctx context.Context, get func(string) (store.RunRecord, error), runID string, interval, timeout time.Duration) (store.RunRecord, bool, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r, err := get(runID)
+		if err != nil {
+			return r, false, err
+		}
+		if r.Status != "running" {
+			return r, false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return r, false, ctx.Err()
+		case <-deadline:
+			return r, true, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// runExitCode maps a terminal run to a process exit code: 0 for success, the
+// container exit code when known, otherwise 1.
+func runExitCode(r store.RunRecord) int {
+	if r.Status == "succeeded" {
+		return 0
+	}
+	if r.ExitCode != nil && *r.ExitCode > 0 && *r.ExitCode < 256 {
+		return *r.ExitCode
+	}
+	return 1
+}
+
+func runStop(ctx context.Context, args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true, "--timeout": true})
+	fs := flag.NewFlagSet("stop", flag.ContinueOnError)
+	var stateDir string
+	var timeout time.Duration
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.DurationVar(&timeout, "timeout", 0, "graceful stop window before the runtime kills the container (runtime default when 0)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 1 || timeout < 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw stop <run-id> [--timeout=10s] [--state-dir=.metaclaw]")
+		return 1
+	}
+	m, err := manager.New(stateDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open manager: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+	r, err := m.Stop(ctx, remaining[0], timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "stop failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("run_id: %s\n", r.RunID)
+	fmt.Printf("status: %s\n", r.Status)
 	return 0
 }
 
+func runExec(ctx context.Context, args []string) int {
+	var command []string
+	for i, a := range args {
+		if a == "--" {
+			command = args[i+1:]
+			args = args[:i]
+			break
+		}
+	}
+	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	var stateDir string
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 1 || len(command) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw exec <run-id> [--state-dir=.metaclaw] -- <cmd...>")
+		return 1
+	}
+	m, err := manager.New(stateDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open manager: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+	res, err := m.Exec(ctx, remaining[0], command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "exec failed: %v\n", err)
+		return 1
+	}
+	fmt.Print(res.Stdout)
+	fmt.Fprint(os.Stderr, res.Stderr)
+	return res.ExitCode
+}
+
+func runRm(ctx context.Context, args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+	fs := flag.NewFlagSet("rm", flag.ContinueOnError)
+	var stateDir string
+	var force bool
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.BoolVar(&force, "force", false, "also remove running runs (their containers are removed too)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw rm <run-id>... [--force] [--state-dir=.metaclaw]")
+		return 1
+	}
+	m, err := manager.New(stateDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open manager: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+	code := 0
+	for _, runID := range remaining {
+		if err := m.RemoveRun(ctx, runID, force); err != nil {
+			fmt.Fprintf(os.Stderr, "rm %s failed: %v\n", runID, err)
+			code = 1
+			continue
+		}
+		fmt.Println(runID)
+	}
+	return code
+}
+
 func runDebug(ctx context.Context, args []string) int {
 	if len(args) == 0 || args[0] != "shell" {
 		fmt.Fprintln(os.Stderr, "usage: metaclaw debug shell <run-id> [--state-dir=.metaclaw]")
@@ -429,28 +1648,53 @@ func printUsage() {
 commands:
   init
   wizard [--interactive] [--project-dir=./my-bot] [--out=obsidian-bot.claw] [--vault=./vault] [--provider=gemini_openai]
-  quickstart obsidian [--project-dir=./my-bot] [--vault=/abs/path/to/vault] [--runtime=auto|apple_container|podman|docker] [--profile=obsidian-chat]
+  quickstart obsidian [--project-dir=./my-bot] [--vault=/abs/path/to/vault] [--runtime=auto|apple_container|podman|docker|nerdctl] [--profile=obsidian-chat] [--seed-vault]
   onboard obsidian (interactive prompts)
-  doctor [--runtime=auto|apple_container|podman|docker] [--vault=/path] [--llm-key-env=OPENAI_FORMAT_API_KEY] [--web-key-env=TAVILY_API_KEY]
-  project init --project-dir=... (--template-dir=... | --template-repo=... --template-path=...) [--ref=main]
-  project upgrade [--project-dir=.] [--force] [--dry-run]
-  validate <file.claw>
-  compile <file.claw> [-o dir]
-  keygen [--private-key=.metaclaw/keys/release.ed25519.pem] [--public-key=.metaclaw/keys/release.ed25519.pub.pem] [--force]
-  release <file.claw|capsule_dir> [--strict] [--state-dir=.metaclaw] [--out=dir] [--sign-key=path] [--key-id=id]
-  verify <release_dir|capsule_dir> [--public-key=path] [--require-release]
-  run <file.claw|capsule_dir> [--detach] [--runtime=podman|apple_container|docker] [--llm-api-key=..|--llm-api-key-env=..] [--secret-env=NAME ...]
-  ps [--json]
-  logs <run-id> [--follow]
-  inspect <run-id|capsule-dir> [--json]
+  doctor [--runtime=auto|apple_container|podman|docker|nerdctl] [--vault=/path] [--llm-key-env=OPENAI_FORMAT_API_KEY] [--web-key-env=TAVILY_API_KEY] [--image=ref@sha256:...] [--fix] [--state-dir=.metaclaw] [--probe-llm=agent.claw] [--json]   (exit 0 pass, 2 warnings, 1 failures)
+  project init --project-dir=... (--template-dir=... | --template-tar=... | --template-repo=... --template-path=...) [--ref=main] [--force] [--dry-run] [--json]
+  project upgrade [--project-dir=.] [--pinned] [--expect-commit=<sha>] [--force|--merge] [--dry-run] [--json]   (exit 0 ok, 2 conflicts, 1 error)
+  project status [--project-dir=.] [--json]   (exit 0 clean, 2 drifted, 1 error)
+  project rollback [--project-dir=.] [--to=<timestamp>] [--list] [--dry-run] [--json]
+  validate <file.claw|dir>... [--json|--quiet] [--check-skills-network] [--skill-registry=dir] [--allowed-registries=host,...] [--check-mounts [--allow-missing-mounts]] [--state-dir=.metaclaw] [--species-file=path] [--allow-sys-admin]
+  compile <file.claw> [-o dir] [--state-dir=.metaclaw] [--species-file=path] [--no-hash-cache] [--skill-registry=dir] [--allowed-registries=host,...] [--check-mounts [--allow-missing-mounts]] [--lock-only|--emit-ir] [--resolve-digests [--runtime=..]] [--allow-sys-admin]
+  keygen [--private-key=.metaclaw/keys/release.ed25519.pem] [--public-key=.metaclaw/keys/release.ed25519.pub.pem] [--force] [--password]
+  keygen --print-public [--private-key=.metaclaw/keys/release.ed25519.pem]
+  release <file.claw|capsule_dir> [--strict] [--require-strict-pass=check1,check2] [--allowed-registries=host,...] [--state-dir=.metaclaw] [--species-file=path] [--out=dir] [--sign-key=path ...] [--key-id=id] [--password] [--tar] [--sbom] [--counter] [--allow-sys-admin]
+  release list [--state-dir=.metaclaw] [--json]
+  release sign <release_dir> --sign-key=path [--key-id=id] [--json]
+  release resign <release_dir> --old-public-key=path --new-sign-key=path [--key-id=id] [--json]
+  verify <release_dir|release_tarball|capsule_dir> [--public-key=path | --trust-dir=dir] [--threshold=N] [--min-counter=N] [--require-release]
+  run <file.claw|capsule_dir> [--detach] [--runtime=podman|apple_container|docker|nerdctl] [--state-dir=.metaclaw] [--species-file=path] [--llm-api-key=..|--llm-api-key-env=..] [--llm-model=..] [--llm-base-url=..] [--secret-env=NAME ...] [--secret-file=NAME=path ...] [--read-only-mounts] [--no-hash-cache] [--compile-only] [--save-release [--strict] [--sign-key=path]] [--healthcheck-cmd=.. [--healthcheck-interval=5s]] [--on-failure=debug] [--log-format=raw|json] [--timeout=10m] [--name=NAME] [--label key=value ...] [--max-log-size=10MB] [--env-file=path] [--no-redact] [--attach] [--allow-sys-admin]
+  ps [--json] [--wide] [--output=ids|--quiet] [--limit=50] [--filter key=value ...] [--watch[=2s]]
+  logs <run-id> [--follow] [--json] [--tail=N] [--since=10m|RFC3339]
+  logs --diff <run-id-a> <run-id-b>
+  inspect <run-id|capsule-dir> [--json|--format=TEMPLATE] [--follow-status [--timeout=10m] [--interval=2s]]
+  stop <run-id> [--timeout=10s]
+  exec <run-id> -- <cmd...>
+  rm <run-id>... [--force]
+  prune [--older-than=720h] [--keep-last=N] [--runs] [--dry-run]
   debug shell <run-id>
-  capsule list [--state-dir=.metaclaw] [--agent=...] [--since=...] [--until=...]
-  capsule diff <id-or-path-1> <id-or-path-2> [--state-dir=.metaclaw] [--json]
+  capsule list [--state-dir=.metaclaw] [--agent=...] [--since=...] [--until=...] [--json] [--detail]
+  capsule diff <id-or-path-1> <id-or-path-2> [--state-dir=.metaclaw] [--summary] [--ignore-order] [--json]
+  capsule import <file.tar.gz> [--state-dir=.metaclaw] [--verify-only] [--json]
+  capsule cat <id-or-path> <source|ir|policy|manifest> [--state-dir=.metaclaw]
+  capsule show <id-or-path> [--section=ir|policy|locks.deps|locks.image|locks.source] [--state-dir=.metaclaw] [--json]
+  capsule export <id-or-path> [-o cap_<id>.tar.gz] [--state-dir=.metaclaw]
+  capsule verify <id-or-path> [--state-dir=.metaclaw] [--json]
+  capability diff <contract-or-skill-dir-a> <contract-or-skill-dir-b> [--json]
+  capability lint <skill-path-or-contract> [--json]
+  skill digest <skill-path> [--verify=sha256:...]
+  completion <bash|zsh|fish>
+  version [--json]
 `)
 }
 
 func IsSecurityOverrideFlag(args []string) error {
 	for _, a := range args {
+		if a == "--env-file" || strings.HasPrefix(a, "--env-file=") {
+			// Values from the file are still limited to the agent's env allowlist.
+			continue
+		}
 		if strings.HasPrefix(a, "--mount") || strings.HasPrefix(a, "--network") || strings.HasPrefix(a, "--env") {
 			return errors.New("CLI overrides for habitat security boundaries are not allowed")
 		}
@@ -1,6 +1,21 @@
 package cli
 
-import "testing"
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/compiler"
+	"github.com/fpp-125/metaclaw/internal/policy"
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
 
 func TestStringListFlag(t *testing.T) {
 	var f stringListFlag
@@ -22,3 +37,305 @@ func TestStringListFlag(t *testing.T) {
 		t.Fatal("Values() should return a copy")
 	}
 }
+
+func TestTruncateCell(t *testing.T) {
+	if got := truncateCell("short", 10); got != "short" {
+		t.Fatalf("unexpected short value: %q", got)
+	}
+	if got := truncateCell("0123456789abcdef", 12); got != "012345678..." {
+		t.Fatalf("unexpected truncated value: %q", got)
+	}
+	if got := truncateCell("line one\n\tline two", 40); got != "line one line two" {
+		t.Fatalf("expected whitespace collapsed, got %q", got)
+	}
+}
+
+func TestWriteWidePS(t *testing.T) {
+	code := 3
+	runs := []store.RunRecord{
+		{
+			RunID:         "run_1",
+			Status:        "failed",
+			RuntimeTarget: "docker",
+			Lifecycle:     "ephemeral",
+			CapsuleID:     "cap_1",
+			ContainerID:   "abcdef0123456789abcdef",
+			ExitCode:      &code,
+			LastError:     "exit status 3",
+		},
+		{RunID: "run_2", Status: "running", RuntimeTarget: "podman", Lifecycle: "daemon", CapsuleID: "cap_2"},
+	}
+	var buf bytes.Buffer
+	writeWidePS(&buf, runs)
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], "RUN ID") || !strings.Contains(lines[0], "LAST ERROR") {
+		t.Fatalf("unexpected header: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "abcdef012345") || strings.Contains(lines[1], "abcdef0123456") {
+		t.Fatalf("expected container id truncated to 12 chars: %q", lines[1])
+	}
+	if !strings.Contains(lines[1], "exit status 3") {
+		t.Fatalf("expected last error column: %q", lines[1])
+	}
+	if strings.Count(lines[2], " - ") < 2 {
+		t.Fatalf("expected placeholder cells for missing values: %q", lines[2])
+	}
+}
+
+func TestIsSecurityOverrideFlagAllowsReadOnlyMounts(t *testing.T) {
+	if err := IsSecurityOverrideFlag([]string{"agent.claw", "--read-only-mounts"}); err != nil {
+		t.Fatalf("--read-only-mounts only tightens policy and should be allowed: %v", err)
+	}
+	if err := IsSecurityOverrideFlag([]string{"agent.claw", "--mount=/:/host"}); err == nil {
+		t.Fatal("expected --mount override to be blocked")
+	}
+}
+
+func TestIsSecurityOverrideFlagAllowsEnvFile(t *testing.T) {
+	if err := IsSecurityOverrideFlag([]string{"agent.claw", "--env-file=.env", "--env-file", ".env"}); err != nil {
+		t.Fatalf("--env-file is limited to the env allowlist and should be allowed: %v", err)
+	}
+	if err := IsSecurityOverrideFlag([]string{"agent.claw", "--env=FOO=bar"}); err == nil {
+		t.Fatal("expected --env override to be blocked")
+	}
+}
+
+func TestReadRunOutputFallsBackToGzip(t *testing.T) {
+	stateDir := t.TempDir()
+	runDir := filepath.Join(stateDir, "runs", "run_a")
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		t.Fatalf("mkdir run dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, "stdout.log"), []byte("plain\n"), 0o644); err != nil {
+		t.Fatalf("write stdout: %v", err)
+	}
+	got, err := readRunOutput(stateDir, "run_a", "stdout.log")
+	if err != nil || got != "plain\n" {
+		t.Fatalf("unexpected plain read: %q, %v", got, err)
+	}
+
+	f, err := os.Create(filepath.Join(runDir, "stderr.log.gz"))
+	if err != nil {
+		t.Fatalf("create gz: %v", err)
+	}
+	zw := gzip.NewWriter(f)
+	_, _ = zw.Write([]byte("compressed\n"))
+	_ = zw.Close()
+	_ = f.Close()
+	got, err = readRunOutput(stateDir, "run_a", "stderr.log")
+	if err != nil || got != "compressed\n" {
+		t.Fatalf("unexpected gzip read: %q, %v", got, err)
+	}
+
+	if _, err := readRunOutput(stateDir, "run_missing", "stdout.log"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not-exist error for missing output, got %v", err)
+	}
+}
+
+func TestNewProjectSummaryExitCodes(t *testing.T) {
+	ok := newProjectSummary("upgrade", "/p", false, nil, []string{"a"}, nil, nil, nil)
+	if ok.ExitCode != projectExitOK || ok.Updated == nil || ok.Conflicts == nil {
+		t.Fatalf("unexpected success summary: %+v", ok)
+	}
+	conflicts := newProjectSummary("upgrade", "/p", false, errors.New("upgrade has conflicts"), nil, nil, nil, []string{"README.md"})
+	if conflicts.ExitCode != projectExitConflicts || conflicts.Error == "" {
+		t.Fatalf("expected conflict exit code, got %+v", conflicts)
+	}
+	failed := newProjectSummary("init", "/p", false, errors.New("boom"), nil, nil, nil, nil)
+	if failed.ExitCode != projectExitError {
+		t.Fatalf("expected error exit code, got %+v", failed)
+	}
+}
+
+func TestWaitForTerminalRun(t *testing.T) {
+	calls := 0
+	get := func(id string) (store.RunRecord, error) {
+		calls++
+		if calls < 3 {
+			return store.RunRecord{RunID: id, Status: "running"}, nil
+		}
+		code := 3
+		return store.RunRecord{RunID: id, Status: "failed", ExitCode: &code}, nil
+	}
+	r, timedOut, err := waitForTerminalRun(context.Background(), get, "run_1", time.Millisecond, time.Minute)
+	if err != nil || timedOut {
+		t.Fatalf("waitForTerminalRun() timedOut=%v err=%v", timedOut, err)
+	}
+	if r.Status != "failed" || runExitCode(r) != 3 {
+		t.Fatalf("unexpected final record %+v (exit %d)", r, runExitCode(r))
+	}
+
+	stuck := func(id string) (store.RunRecord, error) {
+		return store.RunRecord{RunID: id, Status: "running"}, nil
+	}
+	r, timedOut, err = waitForTerminalRun(context.Background(), stuck, "run_2", time.Millisecond, 20*time.Millisecond)
+	if err != nil || !timedOut || r.Status != "running" {
+		t.Fatalf("expected timeout, got r=%+v timedOut=%v err=%v", r, timedOut, err)
+	}
+	if got := runExitCode(store.RunRecord{Status: "succeeded"}); got != 0 {
+		t.Fatalf("succeeded exit code = %d", got)
+	}
+}
+
+func TestLogRecordsTagSources(t *testing.T) {
+	var buf bytes.Buffer
+	writeEventLogRecords(&buf, "run_a", []string{`{"timestamp":"2026-01-01T00:00:00Z","runId":"run_a","phase":"runtime.exit","message":"failed","error":"exit 3"}`, "not json"})
+	writeTextLogRecords(&buf, "stdout", "run_a", "one\r\ntwo\n")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 records, got %d:\n%s", len(lines), buf.String())
+	}
+	var recs []logRecord
+	for _, line := range lines {
+		var rec logRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("unmarshal %q: %v", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	if recs[0].Source != "event" || recs[0].Message != "failed: exit 3" || recs[0].Timestamp == "" || len(recs[0].Event) == 0 {
+		t.Fatalf("unexpected event record: %+v", recs[0])
+	}
+	if recs[1].Source != "event" || recs[1].Message != "not json" || recs[1].Event != nil {
+		t.Fatalf("unparsable event should pass through as message: %+v", recs[1])
+	}
+	if recs[2].Source != "stdout" || recs[2].RunID != "run_a" || recs[2].Message != "one" || recs[3].Message != "two" {
+		t.Fatalf("unexpected stdout records: %+v %+v", recs[2], recs[3])
+	}
+}
+
+func TestParseRunFilters(t *testing.T) {
+	f, err := parseRunFilters([]string{"status=failed", "status=failed_paused", "runtime=podman", "capsule=cap_1"})
+	if err != nil {
+		t.Fatalf("parseRunFilters() error = %v", err)
+	}
+	if len(f.Status) != 2 || len(f.RuntimeTarget) != 1 || f.CapsuleID[0] != "cap_1" || f.Lifecycle != nil {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+	if _, err := parseRunFilters([]string{"image=alpine"}); err == nil || !strings.Contains(err.Error(), "unsupported --filter key") {
+		t.Fatalf("expected unsupported key error, got %v", err)
+	}
+	if _, err := parseRunFilters([]string{"status"}); err == nil {
+		t.Fatal("expected error for filter without value")
+	}
+	f, err = parseRunFilters([]string{"label=team=infra", "label=env=prod"})
+	if err != nil || len(f.Labels) != 2 || f.Labels[0] != (store.Label{Key: "team", Value: "infra"}) {
+		t.Fatalf("unexpected label filter: %+v (%v)", f, err)
+	}
+	if _, err := parseRunFilters([]string{"label=team"}); err == nil {
+		t.Fatal("expected error for label filter without value")
+	}
+}
+
+func TestInspectTemplate(t *testing.T) {
+	code := 7
+	payload := templatePayload(map[string]any{
+		"run":            store.RunRecord{RunID: "run_1", Status: "failed", ExitCode: &code},
+		"runtimeInspect": `[{"State":{"Status":"exited"}}]`,
+	})
+	tmpl, err := parseInspectTemplate(`{{.run.RunID}} {{.run.ExitCode}} {{(index .runtimeInspect 0).State.Status}}`)
+	if err != nil {
+		t.Fatalf("parseInspectTemplate() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := executeInspectTemplate(&buf, tmpl, payload); err != nil {
+		t.Fatalf("executeInspectTemplate() error = %v", err)
+	}
+	if got := buf.String(); got != "run_1 7 exited\n" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+	if _, err := parseInspectTemplate(`{{.run.Status`); err == nil || !strings.Contains(err.Error(), "parse --format") {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+	tmpl, _ = parseInspectTemplate(`{{.missing}}`)
+	if err := executeInspectTemplate(&buf, tmpl, payload); err == nil || !strings.Contains(err.Error(), "execute --format") {
+		t.Fatalf("expected execute error, got %v", err)
+	}
+}
+
+func TestFormatMountPolicy(t *testing.T) {
+	if got := formatMountPolicy(policy.MountPolicy{Source: "/vault", Target: "/workspace/vault", ReadOnly: true}); got != "/vault -> /workspace/vault (ro)" {
+		t.Fatalf("unexpected read-only mount: %q", got)
+	}
+	if got := formatMountPolicy(policy.MountPolicy{Source: "/out", Target: "/workspace/out"}); got != "/out -> /workspace/out (rw)" {
+		t.Fatalf("unexpected writable mount: %q", got)
+	}
+}
+
+func TestValidateClawfilesInDirectory(t *testing.T) {
+	root := t.TempDir()
+	vault := filepath.Join(root, "vault")
+	if err := os.MkdirAll(filepath.Join(root, "nested"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".hidden"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{
+		"good.claw":         renderCLIClaw(vault, "none"),
+		"nested/bad.claw":   "apiVersion: metaclaw/v1\nkind: Agent\n",
+		".hidden/skip.claw": "not yaml: [",
+		"notes.txt":         "ignored",
+	} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := collectClawfiles([]string{root})
+	if err != nil {
+		t.Fatalf("collectClawfiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("unexpected files: %v", files)
+	}
+	results := validateClawfiles(files, compiler.Options{})
+	if !results[0].OK || results[1].OK || results[1].Error == "" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if _, err := collectClawfiles([]string{vault}); err == nil {
+		t.Fatal("expected error for missing path")
+	}
+}
+
+func TestRunSaveReleaseRequiresForegroundRun(t *testing.T) {
+	stateDir := filepath.Join(t.TempDir(), "state")
+	for _, extra := range []string{"--detach", "--compile-only"} {
+		if code := runRun(context.Background(), []string{"agent.claw", "--state-dir", stateDir, "--save-release", extra}); code != 1 {
+			t.Fatalf("run --save-release %s exit = %d, want 1", extra, code)
+		}
+	}
+	if _, err := os.Stat(stateDir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected flag check to fail before touching state, got %v", err)
+	}
+}
+
+func TestRunCompileUsesHashCacheOnlyInKnownStateDir(t *testing.T) {
+	root := t.TempDir()
+	vault := filepath.Join(root, "vault")
+	if err := os.MkdirAll(vault, 0o755); err != nil {
+		t.Fatalf("mkdir vault: %v", err)
+	}
+	claw := filepath.Join(root, "agent.claw")
+	if err := os.WriteFile(claw, []byte(renderCLIClaw(vault, "none")), 0o644); err != nil {
+		t.Fatalf("write claw: %v", err)
+	}
+	t.Chdir(root)
+
+	if code := runCompile([]string{claw, "-o", filepath.Join(root, "out")}); code != 0 {
+		t.Fatalf("runCompile code=%d", code)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".metaclaw")); !os.IsNotExist(err) {
+		t.Fatalf("plain compile must not create .metaclaw, stat err=%v", err)
+	}
+
+	stateDir := filepath.Join(root, "state")
+	if code := runCompile([]string{claw, "-o", filepath.Join(root, "out"), "--state-dir", stateDir}); code != 0 {
+		t.Fatalf("runCompile --state-dir code=%d", code)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "hash-cache", "index.json")); err != nil {
+		t.Fatalf("expected hash cache under explicit state dir: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completionCommand describes one (sub)command for shell completion. Flags are
+// listed without dashes; a trailing "=" marks a flag that takes a value. Keep
+// this in sync with the flag sets and reorderFlags maps of each command. A
+// command with both Subs and Flags offers its own flags when the word after it
+// is not one of the subcommands.
+type completionCommand struct {
+	Name  string
+	Flags []string
+	Subs  []completionCommand
+}
+
+var completionCommands = []completionCommand{
+	{Name: "init", Flags: []string{"out="}},
+	{Name: "validate", Flags: []string{"json", "quiet", "check-skills-network", "skill-registry=", "allowed-registries=", "check-mounts", "allow-missing-mounts", "state-dir=", "species-file=", "allow-sys-admin"}},
+	{Name: "compile", Flags: []string{"o=", "state-dir=", "species-file=", "no-hash-cache", "skill-registry=", "allowed-registries=", "check-mounts", "allow-missing-mounts", "lock-only", "emit-ir", "resolve-digests", "runtime=", "allow-sys-admin"}},
+	{Name: "release", Flags: []string{"state-dir=", "species-file=", "out=", "strict", "require-strict-pass=", "allowed-registries=", "sign-key=", "key-id=", "password", "tar", "sbom", "counter", "allow-sys-admin", "json"}, Subs: []completionCommand{
+		{Name: "list", Flags: []string{"state-dir=", "json"}},
+		{Name: "sign", Flags: []string{"sign-key=", "key-id=", "json"}},
+		{Name: "resign", Flags: []string{"old-public-key=", "new-sign-key=", "key-id=", "json"}},
+	}},
+	{Name: "verify", Flags: []string{"public-key=", "trust-dir=", "threshold=", "min-counter=", "require-release", "json"}},
+	{Name: "keygen", Flags: []string{"private-key=", "public-key=", "force", "print-public", "password"}},
+	{Name: "run", Flags: []string{"detach", "runtime=", "state-dir=", "species-file=", "llm-api-key=", "llm-api-key-env=", "llm-model=", "llm-base-url=", "secret-env=", "secret-file=", "read-only-mounts", "no-hash-cache", "compile-only", "save-release", "strict", "sign-key=", "healthcheck-cmd=", "healthcheck-interval=", "log-format=", "on-failure=", "timeout=", "name=", "label=", "max-log-size=", "env-file=", "no-redact", "attach", "allow-sys-admin"}},
+	{Name: "ps", Flags: []string{"state-dir=", "limit=", "json", "wide", "output=", "quiet", "filter=", "watch"}},
+	{Name: "logs", Flags: []string{"state-dir=", "follow", "diff", "json", "tail=", "since="}},
+	{Name: "inspect", Flags: []string{"state-dir=", "json", "format=", "follow-status", "timeout=", "interval="}},
+	{Name: "stop", Flags: []string{"state-dir=", "timeout="}},
+	{Name: "exec", Flags: []string{"state-dir="}},
+	{Name: "rm", Flags: []string{"state-dir=", "force"}},
+	{Name: "prune", Flags: []string{"state-dir=", "older-than=", "keep-last=", "dry-run", "runs"}},
+	{Name: "debug", Subs: []completionCommand{
+		{Name: "shell", Flags: []string{"state-dir="}},
+	}},
+	{Name: "capsule", Subs: []completionCommand{
+		{Name: "list", Flags: []string{"state-dir=", "agent=", "since=", "until=", "limit=", "json", "detail"}},
+		{Name: "diff", Flags: []string{"state-dir=", "json", "summary", "ignore-order"}},
+		{Name: "import", Flags: []string{"state-dir=", "verify-only", "json"}},
+		{Name: "cat", Flags: []string{"state-dir="}},
+		{Name: "show", Flags: []string{"state-dir=", "section=", "json"}},
+		{Name: "export", Flags: []string{"o=", "state-dir="}},
+		{Name: "verify", Flags: []string{"state-dir=", "json"}},
+	}},
+	{Name: "capability", Subs: []completionCommand{
+		{Name: "diff", Flags: []string{"json"}},
+		{Name: "lint", Flags: []string{"json"}},
+	}},
+	{Name: "skill", Subs: []completionCommand{
+		{Name: "digest", Flags: []string{"verify="}},
+	}},
+	{Name: "wizard", Flags: []string{"project-dir=", "out=", "agent-name=", "vault=", "config-dir=", "logs-dir=", "read-only", "network=", "lifecycle=", "runtime=", "provider=", "model=", "base-url=", "api-key-env=", "llm-disabled", "species-image=", "interactive"}},
+	{Name: "quickstart", Subs: []completionCommand{
+		{Name: "obsidian", Flags: []string{"project-dir=", "vault=", "vault-write", "runtime=", "llm-key-env=", "web-key-env=", "profile=", "template-dir=", "skip-build", "no-run", "force", "seed-vault"}},
+	}},
+	{Name: "onboard", Subs: []completionCommand{
+		{Name: "obsidian", Flags: []string{"project-dir=", "vault=", "vault-write", "runtime=", "profile=", "llm-key-env=", "web-key-env=", "interactive", "save-env", "skip-build", "no-run", "force"}},
+	}},
+	{Name: "doctor", Flags: []string{"runtime=", "vault=", "llm-key-env=", "web-key-env=", "require-llm-key", "image=", "fix", "state-dir=", "probe-llm=", "json"}},
+	{Name: "project", Subs: []completionCommand{
+		{Name: "init", Flags: []string{"project-dir=", "host-data-dir=", "template-dir=", "template-repo=", "template-path=", "template-tar=", "ref=", "force", "dry-run", "json"}},
+		{Name: "upgrade", Flags: []string{"project-dir=", "host-data-dir=", "template-dir=", "template-repo=", "template-path=", "template-tar=", "ref=", "pinned", "expect-commit=", "force", "merge", "dry-run", "json"}},
+		{Name: "status", Flags: []string{"project-dir=", "host-data-dir=", "json"}},
+		{Name: "rollback", Flags: []string{"project-dir=", "host-data-dir=", "to=", "list", "dry-run", "json"}},
+	}},
+	{Name: "completion", Subs: []completionCommand{{Name: "bash"}, {Name: "zsh"}, {Name: "fish"}}},
+	{Name: "version", Flags: []string{"json"}},
+	{Name: "help"},
+}
+
+func runCompletion(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw completion <bash|zsh|fish>")
+		return 1
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout, completionCommands)
+	case "zsh":
+		writeZshCompletion(os.Stdout, completionCommands)
+	case "fish":
+		writeFishCompletion(os.Stdout, completionCommands)
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported shell: %s (supported: bash, zsh, fish)\n", args[0])
+		return 1
+	}
+	return 0
+}
+
+// completionFlagToken renders a registry flag as typed on the command line;
+// single-letter flags keep the single-dash form shown in usage (e.g. -o).
+func completionFlagToken(name string) string {
+	if len(strings.TrimSuffix(name, "=")) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+func completionFlagTokens(flags []string) string {
+	out := make([]string, 0, len(flags))
+	for _, f := range flags {
+		out = append(out, completionFlagToken(f))
+	}
+	return strings.Join(out, " ")
+}
+
+func completionNames(cmds []completionCommand) string {
+	out := make([]string, 0, len(cmds))
+	for _, c := range cmds {
+		out = append(out, c.Name)
+	}
+	return strings.Join(out, " ")
+}
+
+func writeBashCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprintln(w, "# bash completion for metaclaw; load with: source <(metaclaw completion bash)")
+	fmt.Fprintln(w, "_metaclaw() {")
+	fmt.Fprintln(w, `  local cur="${COMP_WORDS[COMP_CWORD]}" words=""`)
+	fmt.Fprintln(w, "  if [[ $COMP_CWORD -eq 1 ]]; then")
+	fmt.Fprintf(w, "    COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", completionNames(cmds))
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, `  case "${COMP_WORDS[1]}" in`)
+	for _, c := range cmds {
+		if len(c.Subs) == 0 && len(c.Flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "    %s)\n", c.Name)
+		if len(c.Subs) > 0 {
+			fmt.Fprintln(w, "      if [[ $COMP_CWORD -eq 2 ]]; then")
+			fmt.Fprintf(w, "        words=%q\n", completionNames(c.Subs))
+			fmt.Fprintln(w, "      else")
+			fmt.Fprintln(w, `        case "${COMP_WORDS[2]}" in`)
+			for _, sub := range c.Subs {
+				if len(sub.Flags) > 0 {
+					fmt.Fprintf(w, "          %s) [[ \"$cur\" == -* ]] && words=%q ;;\n", sub.Name, completionFlagTokens(sub.Flags))
+				}
+			}
+			if len(c.Flags) > 0 {
+				fmt.Fprintf(w, "          *) [[ \"$cur\" == -* ]] && words=%q ;;\n", completionFlagTokens(c.Flags))
+			}
+			fmt.Fprintln(w, "        esac")
+			fmt.Fprintln(w, "      fi")
+		} else {
+			fmt.Fprintf(w, "      [[ \"$cur\" == -* ]] && words=%q\n", completionFlagTokens(c.Flags))
+		}
+		fmt.Fprintln(w, "      ;;")
+	}
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, `  COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, `  if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == *= ]]; then compopt -o nospace; fi`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _metaclaw metaclaw")
+}
+
+func writeZshCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprintln(w, "#compdef metaclaw")
+	fmt.Fprintln(w, "# zsh completion for metaclaw; load with: source <(metaclaw completion zsh)")
+	fmt.Fprintln(w, "_metaclaw() {")
+	fmt.Fprintln(w, "  local -a flags")
+	fmt.Fprintln(w, "  if (( CURRENT == 2 )); then")
+	fmt.Fprintf(w, "    compadd -- %s\n", completionNames(cmds))
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, `  case "${words[2]}" in`)
+	for _, c := range cmds {
+		if len(c.Subs) == 0 && len(c.Flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "    %s)\n", c.Name)
+		if len(c.Subs) > 0 {
+			fmt.Fprintln(w, "      if (( CURRENT == 3 )); then")
+			fmt.Fprintf(w, "        compadd -- %s\n", completionNames(c.Subs))
+			fmt.Fprintln(w, "        return")
+			fmt.Fprintln(w, "      fi")
+			fmt.Fprintln(w, `      case "${words[3]}" in`)
+			for _, sub := range c.Subs {
+				if len(sub.Flags) > 0 {
+					fmt.Fprintf(w, "        %s) flags=(%s) ;;\n", sub.Name, completionFlagTokens(sub.Flags))
+				}
+			}
+			if len(c.Flags) > 0 {
+				fmt.Fprintf(w, "        *) flags=(%s) ;;\n", completionFlagTokens(c.Flags))
+			}
+			fmt.Fprintln(w, "      esac")
+		} else {
+			fmt.Fprintf(w, "      flags=(%s)\n", completionFlagTokens(c.Flags))
+		}
+		fmt.Fprintln(w, "      ;;")
+	}
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, `  if [[ "${words[CURRENT]}" == -* ]]; then`)
+	fmt.Fprintln(w, `    compadd -S '' -- ${(M)flags:#*=}`)
+	fmt.Fprintln(w, `    compadd -- ${flags:#*=}`)
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, "  _files")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "compdef _metaclaw metaclaw")
+}
+
+func writeFishCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprintln(w, "# fish completion for metaclaw; load with: metaclaw completion fish | source")
+	fmt.Fprintf(w, "complete -c metaclaw -f -n '__fish_use_subcommand' -a '%s'\n", completionNames(cmds))
+	for _, c := range cmds {
+		cond := "__fish_seen_subcommand_from " + c.Name
+		writeFishFlags(w, cond, c.Flags)
+		if len(c.Subs) == 0 {
+			continue
+		}
+		subs := completionNames(c.Subs)
+		fmt.Fprintf(w, "complete -c metaclaw -f -n '%s; and not __fish_seen_subcommand_from %s' -a '%s'\n", cond, subs, subs)
+		for _, sub := range c.Subs {
+			writeFishFlags(w, cond+"; and __fish_seen_subcommand_from "+sub.Name, sub.Flags)
+		}
+	}
+}
+
+func writeFishFlags(w io.Writer, cond string, flags []string) {
+	for _, f := range flags {
+		name := strings.TrimSuffix(f, "=")
+		opt := "-l " + name
+		if len(name) == 1 {
+			opt = "-o " + name
+		}
+		if strings.HasSuffix(f, "=") {
+			opt += " -r"
+		}
+		fmt.Fprintf(w, "complete -c metaclaw -n '%s' %s\n", cond, opt)
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	var bash, zsh, fish bytes.Buffer
+	writeBashCompletion(&bash, completionCommands)
+	writeZshCompletion(&zsh, completionCommands)
+	writeFishCompletion(&fish, completionCommands)
+
+	for name, out := range map[string]string{"bash": bash.String(), "zsh": zsh.String(), "fish": fish.String()} {
+		for _, want := range []string{"capsule", "run", "import"} {
+			if !strings.Contains(out, want) {
+				t.Fatalf("%s completion missing %q", name, want)
+			}
+		}
+	}
+	if !strings.Contains(bash.String(), "--healthcheck-cmd=") || !strings.Contains(bash.String(), "-o=") {
+		t.Fatalf("bash completion missing value flags:\n%s", bash.String())
+	}
+	if !strings.Contains(fish.String(), "-l state-dir -r") {
+		t.Fatalf("fish completion missing value flag marker:\n%s", fish.String())
+	}
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		cmd := exec.Command("bash", "-n")
+		cmd.Stdin = &bash
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("bash -n rejected completion script: %v\n%s", err, out)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	diskSpaceWarnBytes uint64 = 1 << 30
+	diskSpaceFailBytes uint64 = 256 << 20
+)
+
+// checkDiskSpace reports free space on the filesystem backing stateDir. The
+// state dir may not exist yet, so the nearest existing parent is measured.
+func checkDiskSpace(stateDir string) (string, string) {
+	dir := strings.TrimSpace(stateDir)
+	if dir == "" {
+		dir = ".metaclaw"
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return doctorStatusWarn, fmt.Sprintf("resolve %s: %v", dir, err)
+	}
+	probe := abs
+	for {
+		if _, err := os.Stat(probe); err == nil || !errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		parent := filepath.Dir(probe)
+		if parent == probe {
+			break
+		}
+		probe = parent
+	}
+	avail, err := availableDiskBytes(probe)
+	if err != nil {
+		return doctorStatusWarn, fmt.Sprintf("cannot stat filesystem for %s: %v", abs, err)
+	}
+	status, detail := diskSpaceStatus(avail)
+	return status, fmt.Sprintf("%s (%s)", detail, abs)
+}
+
+func diskSpaceStatus(avail uint64) (string, string) {
+	detail := fmt.Sprintf("%s available", formatBytes(avail))
+	switch {
+	case avail < diskSpaceFailBytes:
+		return doctorStatusFail, fmt.Sprintf("%s, below critical %s", detail, formatBytes(diskSpaceFailBytes))
+	case avail < diskSpaceWarnBytes:
+		return doctorStatusWarn, fmt.Sprintf("%s, below %s", detail, formatBytes(diskSpaceWarnBytes))
+	}
+	return doctorStatusPass, detail
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin)
+
+package cli
+
+import (
+	"fmt"
+	goruntime "runtime"
+)
+
+func availableDiskBytes(string) (uint64, error) {
+	return 0, fmt.Errorf("free space check not supported on %s", goruntime.GOOS)
+}
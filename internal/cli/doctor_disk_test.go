@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiskSpaceStatus(t *testing.T) {
+	cases := []struct {
+		avail  uint64
+		status string
+	}{
+		{10 << 30, doctorStatusPass},
+		{diskSpaceWarnBytes, doctorStatusPass},
+		{diskSpaceWarnBytes - 1, doctorStatusWarn},
+		{diskSpaceFailBytes, doctorStatusWarn},
+		{diskSpaceFailBytes - 1, doctorStatusFail},
+		{0, doctorStatusFail},
+	}
+	for _, tc := range cases {
+		status, detail := diskSpaceStatus(tc.avail)
+		if status != tc.status {
+			t.Fatalf("diskSpaceStatus(%d) status = %s, want %s", tc.avail, status, tc.status)
+		}
+		if !strings.Contains(detail, formatBytes(tc.avail)+" available") {
+			t.Fatalf("diskSpaceStatus(%d) detail = %q, want available bytes", tc.avail, detail)
+		}
+	}
+	if got := formatBytes(3 << 29); got != "1.5 GiB" {
+		t.Fatalf("formatBytes = %q, want 1.5 GiB", got)
+	}
+}
+
+func TestCheckDiskSpaceMeasuresMissingStateDirParent(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "not", "yet", "created")
+	_, detail := checkDiskSpace(dir)
+	if strings.Contains(detail, "cannot stat") || !strings.Contains(detail, dir) {
+		t.Fatalf("checkDiskSpace detail = %q", detail)
+	}
+}
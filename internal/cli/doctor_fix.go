@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	goruntime "runtime"
+	"strings"
+	"time"
+)
+
+type doctorFix struct {
+	Runtime   string `json:"runtime"`
+	Command   string `json:"command"`
+	Succeeded bool   `json:"succeeded"`
+	Detail    string `json:"detail"`
+}
+
+const (
+	doctorFixTimeout       = 2 * time.Minute
+	doctorFixProbeAttempts = 10
+	doctorFixProbeInterval = 3 * time.Second
+)
+
+// fixUnhealthyRuntimes tries the documented recovery command for each
+// installed-but-unhealthy runtime candidate and re-probes it, stopping at the
+// first runtime that becomes healthy. Every command is echoed to stderr before
+// it runs.
+func fixUnhealthyRuntimes(requested string) []doctorFix {
+	candidates := []string{strings.TrimSpace(requested)}
+	if candidates[0] == "" || candidates[0] == "auto" {
+		candidates = runtimeProbeOrder()
+	}
+	var fixes []doctorFix
+	for _, target := range candidates {
+		bin := runtimeBinaryForTarget(target)
+		if bin == "" || !commandExists(bin) {
+			continue
+		}
+		_, healthErr := checkRuntimeHealth(target, bin)
+		if healthErr == nil {
+			return fixes
+		}
+		cmd := runtimeFixCommand(target, healthErr.Error(), goruntime.GOOS, commandExists)
+		if len(cmd) == 0 {
+			continue
+		}
+		fix := doctorFix{Runtime: target, Command: strings.Join(cmd, " ")}
+		fmt.Fprintf(os.Stderr, "doctor --fix: running: %s\n", fix.Command)
+		ctx, cancel := context.WithTimeout(context.Background(), doctorFixTimeout)
+		_, stderr, err := runDoctorCmd(ctx, cmd[0], cmd[1:]...)
+		cancel()
+		if err != nil {
+			fix.Detail = err.Error()
+			if msg := strings.TrimSpace(stderr); msg != "" {
+				fix.Detail = msg
+			}
+			fixes = append(fixes, fix)
+			continue
+		}
+		for attempt := 0; attempt < doctorFixProbeAttempts; attempt++ {
+			var detail string
+			detail, healthErr = checkRuntimeHealth(target, bin)
+			if healthErr == nil {
+				fix.Succeeded = true
+				fix.Detail = detail
+				break
+			}
+			time.Sleep(doctorFixProbeInterval)
+		}
+		if !fix.Succeeded {
+			fix.Detail = fmt.Sprintf("still unhealthy after fix: %v", healthErr)
+		}
+		fixes = append(fixes, fix)
+		if fix.Succeeded {
+			return fixes
+		}
+	}
+	return fixes
+}
+
+// runtimeFixCommand returns the recovery command for an unhealthy runtime, or
+// nil when there is no safe automatic fix (e.g. a Linux docker daemon that
+// needs root to start).
+func runtimeFixCommand(target, healthErr, goos string, has func(string) bool) []string {
+	switch target {
+	case "podman":
+		if goos == "linux" && !strings.Contains(strings.ToLower(healthErr), "machine") {
+			return nil
+		}
+		return []string{"podman", "machine", "start"}
+	case "docker":
+		if has("colima") {
+			return []string{"colima", "start"}
+		}
+		if goos == "darwin" {
+			return []string{"open", "-a", "Docker"}
+		}
+		return nil
+	case "apple_container":
+		return []string{"container", "system", "start"}
+	default:
+		return nil
+	}
+}
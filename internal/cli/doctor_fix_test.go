@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRuntimeFixCommand(t *testing.T) {
+	none := func(string) bool { return false }
+	colima := func(bin string) bool { return bin == "colima" }
+	cases := []struct {
+		name      string
+		target    string
+		healthErr string
+		goos      string
+		has       func(string) bool
+		want      []string
+	}{
+		{"podman machine", "podman", "cannot connect to Podman. please run podman machine start", "darwin", none, []string{"podman", "machine", "start"}},
+		{"podman linux socket", "podman", "permission denied", "linux", none, nil},
+		{"docker colima", "docker", "docker daemon not reachable", "linux", colima, []string{"colima", "start"}},
+		{"docker desktop", "docker", "docker daemon not reachable", "darwin", none, []string{"open", "-a", "Docker"}},
+		{"docker linux daemon", "docker", "docker daemon not reachable", "linux", none, nil},
+		{"apple container", "apple_container", "not running", "darwin", none, []string{"container", "system", "start"}},
+		{"unknown", "lxc", "", "linux", none, nil},
+	}
+	for _, tc := range cases {
+		got := runtimeFixCommand(tc.target, tc.healthErr, tc.goos, tc.has)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s: runtimeFixCommand() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/claw/parse"
+	"github.com/fpp-125/metaclaw/internal/claw/validate"
+	"github.com/fpp-125/metaclaw/internal/llm"
+)
+
+const doctorLLMProbeTimeout = 5 * time.Second
+
+// probeLLMKey resolves the llm block of clawPath and lists models at the
+// provider with the configured key. Details name the key env, never its value.
+func probeLLMKey(clawPath string, client *http.Client) (string, string) {
+	cfg, err := parse.File(clawPath)
+	if err != nil {
+		return doctorStatusFail, err.Error()
+	}
+	cfg, err = validate.NormalizeAndValidate(cfg, clawPath)
+	if err != nil {
+		return doctorStatusFail, err.Error()
+	}
+	spec := cfg.Agent.LLM
+	if spec.Provider == "" {
+		return doctorStatusWarn, fmt.Sprintf("%s has no llm provider to probe", clawPath)
+	}
+	key := strings.TrimSpace(os.Getenv(spec.APIKeyEnv))
+	if key == "" {
+		return doctorStatusFail, fmt.Sprintf("%s not set; cannot probe %s", spec.APIKeyEnv, spec.Provider)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), doctorLLMProbeTimeout)
+	defer cancel()
+	res, err := llm.Probe(ctx, client, spec, key)
+	if err != nil {
+		return doctorStatusFail, fmt.Sprintf("%s unreachable: %v", spec.Provider, err)
+	}
+	detail := fmt.Sprintf("%s %s with %s (HTTP %d from %s)", spec.Provider, res.Outcome, spec.APIKeyEnv, res.HTTPStatus, res.URL)
+	switch res.Outcome {
+	case llm.ProbeReachable:
+		return doctorStatusPass, detail
+	case llm.ProbeUnexpected:
+		return doctorStatusWarn, detail
+	}
+	return doctorStatusFail, detail
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProbeLLMKeyReportsOutcomeWithoutKey(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	clawPath := filepath.Join(t.TempDir(), "agent.claw")
+	content := fmt.Sprintf(`apiVersion: metaclaw/v1
+kind: Agent
+agent:
+  name: probe
+  species: nano
+  lifecycle: ephemeral
+  habitat:
+    network:
+      mode: outbound
+  llm:
+    provider: openai_compatible
+    model: gpt-test
+    baseURL: %s/v1
+    apiKeyEnv: METACLAW_TEST_PROBE_KEY
+  runtime:
+    target: docker
+    image: alpine:3.20@sha256:1111111111111111111111111111111111111111111111111111111111111111
+  command:
+    - echo
+`, srv.URL)
+	if err := os.WriteFile(clawPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write clawfile: %v", err)
+	}
+
+	t.Setenv("METACLAW_TEST_PROBE_KEY", "")
+	if got, detail := probeLLMKey(clawPath, srv.Client()); got != doctorStatusFail || !strings.Contains(detail, "not set") {
+		t.Fatalf("probe without key = %s %q, want fail not set", got, detail)
+	}
+
+	t.Setenv("METACLAW_TEST_PROBE_KEY", "sk-do-not-print")
+	for code, want := range map[int]string{
+		http.StatusOK:           doctorStatusPass,
+		http.StatusUnauthorized: doctorStatusFail,
+		http.StatusForbidden:    doctorStatusFail,
+	} {
+		status = code
+		got, detail := probeLLMKey(clawPath, srv.Client())
+		if got != want {
+			t.Fatalf("probe HTTP %d status = %s (%s), want %s", code, got, detail, want)
+		}
+		if strings.Contains(detail, "sk-do-not-print") {
+			t.Fatalf("probe detail leaked the key: %s", detail)
+		}
+	}
+}
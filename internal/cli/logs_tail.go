@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/logs"
+)
+
+const tailChunkSize = 64 * 1024
+
+// readRunOutputTail returns the last n lines of a captured run output file.
+// Plain files are read backwards in chunks so only the tail is held in
+// memory; a rotated set and the gzip fallback are streamed through a ring of
+// n lines. A negative
+// n returns the whole file.
+func readRunOutputTail(stateDir, runID, name string, n int) (string, error) {
+	if n < 0 {
+		return readRunOutput(stateDir, runID, name)
+	}
+	p := filepath.Join(stateDir, "runs", runID, name)
+	if set := logs.RotatedSet(p); len(set) > 1 {
+		rc, err := logs.OpenRotated(p)
+		if err == nil {
+			defer rc.Close()
+			return tailStream(rc, n)
+		}
+	}
+	f, err := os.Open(p)
+	if err == nil {
+		defer f.Close()
+		return tailReader(f, n)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	gz, gzErr := os.Open(p + ".gz")
+	if gzErr != nil {
+		if errors.Is(gzErr, os.ErrNotExist) {
+			return "", err
+		}
+		return "", gzErr
+	}
+	defer gz.Close()
+	zr, gzErr := gzip.NewReader(gz)
+	if gzErr != nil {
+		return "", fmt.Errorf("read %s.gz: %w", p, gzErr)
+	}
+	defer zr.Close()
+	return tailStream(zr, n)
+}
+
+// tailReader seeks backwards from the end of r until it has seen n line
+// breaks (ignoring a trailing one) and returns everything after that point.
+func tailReader(r io.ReadSeeker, n int) (string, error) {
+	if n == 0 {
+		return "", nil
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", err
+	}
+	var buf []byte
+	pos := size
+	for pos > 0 {
+		step := int64(tailChunkSize)
+		if pos < step {
+			step = pos
+		}
+		pos -= step
+		chunk := make([]byte, step)
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return "", err
+		}
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return "", err
+		}
+		buf = append(chunk, buf...)
+		if cut := tailCut(buf, n); cut >= 0 {
+			return string(buf[cut:]), nil
+		}
+	}
+	return string(buf), nil
+}
+
+// tailCut returns the offset where the last n lines of buf start, or -1 when
+// buf holds fewer than n complete lines.
+func tailCut(buf []byte, n int) int {
+	end := len(buf)
+	if end > 0 && buf[end-1] == '\n' {
+		end--
+	}
+	for i := 0; i < n; i++ {
+		idx := bytes.LastIndexByte(buf[:end], '\n')
+		if idx < 0 {
+			return -1
+		}
+		end = idx
+	}
+	return end + 1
+}
+
+func tailStream(r io.Reader, n int) (string, error) {
+	if n == 0 {
+		return "", nil
+	}
+	ring := make([]string, n)
+	seen := 0
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		ring[seen%n] = s.Text()
+		seen++
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	if seen == 0 {
+		return "", nil
+	}
+	var b strings.Builder
+	start := 0
+	if seen > n {
+		start = seen - n
+	}
+	for i := start; i < seen; i++ {
+		b.WriteString(ring[i%n])
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// tailLines applies --tail to text that is already in memory (runtime logs).
+func tailLines(text string, n int) string {
+	if n < 0 {
+		return text
+	}
+	if cut := tailCut([]byte(text), n); cut >= 0 {
+		return text[cut:]
+	}
+	return text
+}
+
+// parseLogsSince accepts a duration relative to now (e.g. 10m) or an RFC3339
+// timestamp.
+func parseLogsSince(v string, now time.Time) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if d, err := time.ParseDuration(v); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("--since duration must not be negative")
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--since must be a duration (10m) or RFC3339 timestamp: %q", v)
+	}
+	return t, nil
+}
+
+// filterEventsSince keeps events whose timestamp is at or after since. Lines
+// without a parsable timestamp are kept.
+func filterEventsSince(events []string, since time.Time) []string {
+	out := make([]string, 0, len(events))
+	for _, line := range events {
+		var e logs.Event
+		if err := json.Unmarshal([]byte(line), &e); err == nil {
+			if ts, err := time.Parse(time.RFC3339Nano, e.Timestamp); err == nil && ts.Before(since) {
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadRunOutputTail(t *testing.T) {
+	stateDir := t.TempDir()
+	runDir := filepath.Join(stateDir, "runs", "run_a")
+	if err := os.MkdirAll(runDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, "stdout.log"), []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(runDir, "stderr.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(f)
+	_, _ = zw.Write([]byte("e1\ne2\ne3\n"))
+	_ = zw.Close()
+	_ = f.Close()
+
+	got, err := readRunOutputTail(stateDir, "run_a", "stdout.log", 2)
+	if err != nil || got != "line 19998\nline 19999\n" {
+		t.Fatalf("tail stdout = %q, %v", got, err)
+	}
+	got, err = readRunOutputTail(stateDir, "run_a", "stderr.log", 2)
+	if err != nil || got != "e2\ne3\n" {
+		t.Fatalf("tail gz stderr = %q, %v", got, err)
+	}
+	got, err = readRunOutputTail(stateDir, "run_a", "stderr.log", 10)
+	if err != nil || got != "e1\ne2\ne3\n" {
+		t.Fatalf("tail beyond length = %q, %v", got, err)
+	}
+	if got := tailLines("a\nb\nc", 1); got != "c" {
+		t.Fatalf("tailLines() = %q", got)
+	}
+
+	// A rotated set is read oldest file first.
+	if err := os.WriteFile(filepath.Join(runDir, "rolled.log.1"), []byte("r1\nr2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, "rolled.log"), []byte("r3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err = readRunOutputTail(stateDir, "run_a", "rolled.log", 2)
+	if err != nil || got != "r2\nr3\n" {
+		t.Fatalf("tail rotated = %q, %v", got, err)
+	}
+	got, err = readRunOutput(stateDir, "run_a", "rolled.log")
+	if err != nil || got != "r1\nr2\nr3\n" {
+		t.Fatalf("read rotated = %q, %v", got, err)
+	}
+	if n, err := parseByteSize("10MB"); err != nil || n != 10<<20 {
+		t.Fatalf("parseByteSize(10MB) = %d, %v", n, err)
+	}
+	if _, err := parseByteSize("-1"); err == nil {
+		t.Fatal("expected error for negative size")
+	}
+}
+
+func TestParseLogsSinceAndFilter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	since, err := parseLogsSince("10m", now)
+	if err != nil || !since.Equal(now.Add(-10*time.Minute)) {
+		t.Fatalf("parseLogsSince(10m) = %v, %v", since, err)
+	}
+	if _, err := parseLogsSince("2026-03-01T11:00:00Z", now); err != nil {
+		t.Fatalf("parseLogsSince(RFC3339) error = %v", err)
+	}
+	if _, err := parseLogsSince("yesterday", now); err == nil {
+		t.Fatal("expected error for unparsable --since")
+	}
+	events := []string{
+		`{"timestamp":"2026-03-01T11:00:00Z","phase":"old"}`,
+		`{"timestamp":"2026-03-01T11:55:00Z","phase":"new"}`,
+		"garbage",
+	}
+	got := filterEventsSince(events, since)
+	if len(got) != 2 || !strings.Contains(got[0], `"new"`) || got[1] != "garbage" {
+		t.Fatalf("filterEventsSince() = %v", got)
+	}
+}
@@ -61,7 +61,7 @@ func runOnboard(args []string) int {
 	fs.StringVar(&opts.ProjectDir, "project-dir", opts.ProjectDir, "project directory (default ./my-obsidian-bot)")
 	fs.StringVar(&opts.VaultPath, "vault", "", "absolute vault path (interactive prompt if omitted)")
 	fs.BoolVar(&opts.VaultWrite, "vault-write", false, "mount vault read-write inside container (less safe; default is read-only)")
-	fs.StringVar(&opts.Runtime, "runtime", opts.Runtime, "runtime target (auto|apple_container|podman|docker)")
+	fs.StringVar(&opts.Runtime, "runtime", opts.Runtime, "runtime target (auto|apple_container|podman|docker|nerdctl)")
 	fs.StringVar(&opts.Profile, "profile", opts.Profile, "profile (obsidian-chat|obsidian-research)")
 	fs.StringVar(&opts.LLMKeyEnv, "llm-key-env", opts.LLMKeyEnv, "LLM API key env name (default OPENAI_FORMAT_API_KEY)")
 	fs.StringVar(&opts.WebKeyEnv, "web-key-env", opts.WebKeyEnv, "web search API key env name (default TAVILY_API_KEY)")
@@ -76,7 +76,7 @@ func runOnboard(args []string) int {
 
 	remaining := fs.Args()
 	if len(remaining) != 1 || remaining[0] != "obsidian" {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw onboard obsidian [--interactive] [--project-dir=./my-obsidian-bot] [--vault=/abs/path/to/vault] [--vault-write] [--runtime=auto|apple_container|podman|docker] [--profile=obsidian-chat] [--save-env] [--skip-build] [--no-run] [--force]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw onboard obsidian [--interactive] [--project-dir=./my-obsidian-bot] [--vault=/abs/path/to/vault] [--vault-write] [--runtime=auto|apple_container|podman|docker|nerdctl] [--profile=obsidian-chat] [--save-env] [--skip-build] [--no-run] [--force]")
 		return 1
 	}
 
@@ -301,7 +301,7 @@ func collectOnboardInteractiveOptions(in onboardOptions) (onboardOptions, error)
 	}
 	in.VaultWrite = strings.HasPrefix(vaultAccess, "read-write")
 
-	runtime, err := promptSelect(os.Stderr, "Runtime target", []string{"auto", "apple_container", "podman", "docker"}, in.Runtime)
+	runtime, err := promptSelect(os.Stderr, "Runtime target", []string{"auto", "apple_container", "podman", "docker", "nerdctl"}, in.Runtime)
 	if err != nil {
 		return in, err
 	}
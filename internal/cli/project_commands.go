@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -11,9 +12,66 @@ import (
 	"github.com/fpp-125/metaclaw/internal/project"
 )
 
+// Exit codes for project init/upgrade, relied on by CI scaffolding.
+const (
+	projectExitOK        = 0
+	projectExitError     = 1
+	projectExitConflicts = 2
+)
+
+type projectSummary struct {
+	Command        string   `json:"command"`
+	ProjectDir     string   `json:"projectDir"`
+	TemplateID     string   `json:"templateId,omitempty"`
+	TemplateCommit string   `json:"templateCommit,omitempty"`
+	DryRun         bool     `json:"dryRun"`
+	Created        []string `json:"created"`
+	Updated        []string `json:"updated"`
+	Skipped        []string `json:"skipped"`
+	Conflicts      []string `json:"conflicts"`
+	Merged         []string `json:"merged,omitempty"`
+	Error          string   `json:"error,omitempty"`
+	ExitCode       int      `json:"exitCode"`
+}
+
+func newProjectSummary(command, projectDir string, dryRun bool, err error, created, updated, skipped, conflicts []string) projectSummary {
+	orEmpty := func(v []string) []string {
+		if v == nil {
+			return []string{}
+		}
+		return v
+	}
+	s := projectSummary{
+		Command:    command,
+		ProjectDir: projectDir,
+		DryRun:     dryRun,
+		Created:    orEmpty(created),
+		Updated:    orEmpty(updated),
+		Skipped:    orEmpty(skipped),
+		Conflicts:  orEmpty(conflicts),
+		ExitCode:   projectExitOK,
+	}
+	switch {
+	case len(s.Conflicts) > 0:
+		s.ExitCode = projectExitConflicts
+	case err != nil:
+		s.ExitCode = projectExitError
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	return s
+}
+
+func printProjectSummaryJSON(s projectSummary) int {
+	b, _ := json.MarshalIndent(s, "", "  ")
+	fmt.Println(string(b))
+	return s.ExitCode
+}
+
 func runProject(args []string) int {
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw project <init|upgrade> ...")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw project <init|upgrade|status|rollback> ...")
 		return 1
 	}
 	switch args[0] {
@@ -21,9 +79,13 @@ func runProject(args []string) int {
 		return runProjectInit(args[1:])
 	case "upgrade":
 		return runProjectUpgrade(args[1:])
+	case "status":
+		return runProjectStatus(args[1:])
+	case "rollback":
+		return runProjectRollback(args[1:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown project command: %s\n", args[0])
-		fmt.Fprintln(os.Stderr, "usage: metaclaw project <init|upgrade> ...")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw project <init|upgrade|status|rollback> ...")
 		return 1
 	}
 }
@@ -35,8 +97,11 @@ func runProjectInit(args []string) int {
 		"--template-dir":  true,
 		"--template-repo": true,
 		"--template-path": true,
+		"--template-tar":  true,
 		"--ref":           true,
 		"--force":         false,
+		"--dry-run":       false,
+		"--json":          false,
 	})
 	fs := flag.NewFlagSet("project init", flag.ContinueOnError)
 	var projectDir string
@@ -44,20 +109,26 @@ func runProjectInit(args []string) int {
 	var templateDir string
 	var templateRepo string
 	var templatePath string
+	var templateTar string
 	var ref string
 	var force bool
+	var dryRun bool
+	var asJSON bool
 	fs.StringVar(&projectDir, "project-dir", "", "project directory")
 	fs.StringVar(&hostDataDir, "host-data-dir", "", "host data directory (default <project>/.metaclaw)")
 	fs.StringVar(&templateDir, "template-dir", "", "local template directory (alternative to --template-repo/--template-path)")
 	fs.StringVar(&templateRepo, "template-repo", "", "git template repo URL (e.g. https://github.com/org/repo.git)")
 	fs.StringVar(&templatePath, "template-path", "", "template subdirectory within repo")
+	fs.StringVar(&templateTar, "template-tar", "", "local template tarball (.tar or .tar.gz) containing "+project.ManifestFilename)
 	fs.StringVar(&ref, "ref", "main", "git ref (branch or tag)")
 	fs.BoolVar(&force, "force", false, "allow using a non-empty project directory")
+	fs.BoolVar(&dryRun, "dry-run", false, "list the files init would write without writing them")
+	fs.BoolVar(&asJSON, "json", false, "json summary output (exit 0 ok, 2 conflicts, 1 error)")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	if len(fs.Args()) != 0 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw project init --project-dir=... (--template-dir=... | --template-repo=... --template-path=...) [--ref=main] [--force]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw project init --project-dir=... (--template-dir=... | --template-tar=... | --template-repo=... --template-path=...) [--ref=main] [--force] [--dry-run] [--json]")
 		return 1
 	}
 	if strings.TrimSpace(projectDir) == "" {
@@ -78,9 +149,16 @@ func runProjectInit(args []string) int {
 			return 1
 		}
 		src = project.TemplateSource{Kind: project.TemplateSourceKindLocal, Dir: abs}
+	} else if strings.TrimSpace(templateTar) != "" {
+		abs, err := filepath.Abs(strings.TrimSpace(templateTar))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "project init failed: resolve --template-tar: %v\n", err)
+			return 1
+		}
+		src = project.TemplateSource{Kind: project.TemplateSourceKindTarball, Tarball: abs}
 	} else {
 		if strings.TrimSpace(templateRepo) == "" || strings.TrimSpace(templatePath) == "" {
-			fmt.Fprintln(os.Stderr, "project init failed: provide --template-dir or (--template-repo and --template-path)")
+			fmt.Fprintln(os.Stderr, "project init failed: provide --template-dir, --template-tar or (--template-repo and --template-path)")
 			return 1
 		}
 		src = project.TemplateSource{
@@ -96,18 +174,32 @@ func runProjectInit(args []string) int {
 		HostDataDir: hostDataDir,
 		Template:    src,
 		Force:       force,
+		DryRun:      dryRun,
 	})
+	if asJSON {
+		summary := newProjectSummary("init", absProject, dryRun, err, res.Created, res.Updated, nil, res.Conflicts)
+		summary.TemplateID = res.TemplateID
+		summary.TemplateCommit = res.TemplateCommit
+		return printProjectSummaryJSON(summary)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "project init failed: %v\n", err)
-		return 1
+		if len(res.Conflicts) > 0 {
+			return projectExitConflicts
+		}
+		return projectExitError
+	}
+	if dryRun {
+		fmt.Printf("project init (dry run): %s\n", absProject)
+	} else {
+		fmt.Printf("project ready: %s\n", absProject)
 	}
-	fmt.Printf("project ready: %s\n", absProject)
 	fmt.Printf("template: %s\n", res.TemplateID)
 	if res.TemplateCommit != "" {
 		fmt.Printf("template_commit: %s\n", res.TemplateCommit)
 	}
 	fmt.Printf("files: %d\n", res.CreatedFiles)
-	return 0
+	return projectExitOK
 }
 
 func runProjectUpgrade(args []string) int {
@@ -117,9 +209,14 @@ func runProjectUpgrade(args []string) int {
 		"--template-dir":  true,
 		"--template-repo": true,
 		"--template-path": true,
+		"--template-tar":  true,
 		"--ref":           true,
+		"--expect-commit": true,
+		"--pinned":        false,
 		"--force":         false,
+		"--merge":         false,
 		"--dry-run":       false,
+		"--json":          false,
 	})
 	fs := flag.NewFlagSet("project upgrade", flag.ContinueOnError)
 	var projectDir string
@@ -127,22 +224,36 @@ func runProjectUpgrade(args []string) int {
 	var templateDir string
 	var templateRepo string
 	var templatePath string
+	var templateTar string
 	var ref string
+	var expectCommit string
+	var pinned bool
 	var force bool
+	var merge bool
 	var dryRun bool
+	var asJSON bool
 	fs.StringVar(&projectDir, "project-dir", ".", "project directory")
 	fs.StringVar(&hostDataDir, "host-data-dir", "", "host data directory (default <project>/.metaclaw)")
 	fs.StringVar(&templateDir, "template-dir", "", "override: local template directory")
 	fs.StringVar(&templateRepo, "template-repo", "", "override: git template repo URL")
 	fs.StringVar(&templatePath, "template-path", "", "override: template subdirectory within repo")
+	fs.StringVar(&templateTar, "template-tar", "", "override: local template tarball")
 	fs.StringVar(&ref, "ref", "main", "override: git ref (branch or tag)")
+	fs.BoolVar(&pinned, "pinned", false, "re-resolve the git template at the commit recorded in the lock instead of the branch tip")
+	fs.StringVar(&expectCommit, "expect-commit", "", "refuse to upgrade unless the resolved template commit starts with this sha")
 	fs.BoolVar(&force, "force", false, "overwrite managed files even if locally modified (backs up to .metaclaw/upgrade-backups)")
+	fs.BoolVar(&merge, "merge", false, "three-way merge locally modified managed files with the template (conflicts get markers)")
 	fs.BoolVar(&dryRun, "dry-run", false, "show what would change without writing files")
+	fs.BoolVar(&asJSON, "json", false, "json summary output (exit 0 ok, 2 conflicts, 1 error)")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	if len(fs.Args()) != 0 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw project upgrade [--project-dir=.] [--force] [--dry-run]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw project upgrade [--project-dir=.] [--pinned] [--expect-commit=<sha>] [--force|--merge] [--dry-run] [--json]")
+		return 1
+	}
+	if force && merge {
+		fmt.Fprintln(os.Stderr, "project upgrade failed: --force and --merge are mutually exclusive")
 		return 1
 	}
 
@@ -173,6 +284,14 @@ func runProjectUpgrade(args []string) int {
 		}
 		src = project.TemplateSource{Kind: project.TemplateSourceKindLocal, Dir: abs}
 	}
+	if strings.TrimSpace(templateTar) != "" {
+		abs, err := filepath.Abs(strings.TrimSpace(templateTar))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "project upgrade failed: resolve --template-tar: %v\n", err)
+			return 1
+		}
+		src = project.TemplateSource{Kind: project.TemplateSourceKindTarball, Tarball: abs}
+	}
 	if strings.TrimSpace(templateRepo) != "" || strings.TrimSpace(templatePath) != "" {
 		if strings.TrimSpace(templateRepo) == "" || strings.TrimSpace(templatePath) == "" {
 			fmt.Fprintln(os.Stderr, "project upgrade failed: provide both --template-repo and --template-path")
@@ -187,20 +306,43 @@ func runProjectUpgrade(args []string) int {
 	}
 	if src.Kind == "" {
 		if errors.Is(lockErr, os.ErrNotExist) {
-			fmt.Fprintln(os.Stderr, "project upgrade failed: missing .metaclaw/project.lock.json; re-run onboard/quickstart or pass --template-dir/--template-repo")
+			fmt.Fprintln(os.Stderr, "project upgrade failed: missing .metaclaw/project.lock.json; re-run onboard/quickstart or pass --template-dir/--template-tar/--template-repo")
 			return 1
 		}
 		fmt.Fprintf(os.Stderr, "project upgrade failed: cannot load project lock: %v\n", lockErr)
 		return 1
 	}
 
+	var pinnedCommit string
+	if pinned {
+		if lockErr != nil || strings.TrimSpace(lock.TemplateCommit) == "" {
+			fmt.Fprintln(os.Stderr, "project upgrade failed: --pinned requires a project lock with a recorded template commit")
+			return 1
+		}
+		if src.Kind != project.TemplateSourceKindGit {
+			fmt.Fprintln(os.Stderr, "project upgrade failed: --pinned only applies to git templates")
+			return 1
+		}
+		pinnedCommit = lock.TemplateCommit
+	}
+
 	res, err := project.Upgrade(project.UpgradeOptions{
-		ProjectDir:  absProject,
-		HostDataDir: hostDataDir,
-		Template:    src,
-		Force:       force,
-		DryRun:      dryRun,
+		ProjectDir:   absProject,
+		HostDataDir:  hostDataDir,
+		Template:     src,
+		Force:        force,
+		Merge:        merge,
+		DryRun:       dryRun,
+		PinnedCommit: pinnedCommit,
+		ExpectCommit: strings.TrimSpace(expectCommit),
 	})
+	summary := newProjectSummary("upgrade", absProject, dryRun, err, res.Added, res.Updated, res.Skipped, res.Conflicts)
+	summary.Merged = res.Merged
+	summary.TemplateID = res.TemplateID
+	summary.TemplateCommit = res.TemplateCommit
+	if asJSON {
+		return printProjectSummaryJSON(summary)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "project upgrade failed: %v\n", err)
 		// Still print the summary if available.
@@ -213,6 +355,9 @@ func runProjectUpgrade(args []string) int {
 	fmt.Printf("updated: %d\n", len(res.Updated))
 	fmt.Printf("added: %d\n", len(res.Added))
 	fmt.Printf("skipped: %d\n", len(res.Skipped))
+	if merge {
+		fmt.Printf("merged: %d\n", len(res.Merged))
+	}
 	fmt.Printf("conflicts: %d\n", len(res.Conflicts))
 
 	printList := func(label string, items []string) {
@@ -226,10 +371,211 @@ func runProjectUpgrade(args []string) int {
 	}
 	printList("updated_files", res.Updated)
 	printList("added_files", res.Added)
+	printList("merged_files", res.Merged)
 	printList("conflicts", res.Conflicts)
 
+	return summary.ExitCode
+}
+
+type projectStatusSummary struct {
+	ProjectDir     string   `json:"projectDir"`
+	TemplateID     string   `json:"templateId,omitempty"`
+	TemplateCommit string   `json:"templateCommit,omitempty"`
+	InstalledAtUTC string   `json:"installedAtUtc,omitempty"`
+	Modified       []string `json:"modified"`
+	Missing        []string `json:"missing"`
+	Clean          int      `json:"clean"`
+	Drifted        bool     `json:"drifted"`
+	Error          string   `json:"error,omitempty"`
+	ExitCode       int      `json:"exitCode"`
+}
+
+// runProjectStatus reports managed files that drifted from the project lock.
+// It only reads the lock and the project tree, so it is safe as an offline CI
+// guard: exit 0 clean, 2 drifted, 1 error.
+func runProjectStatus(args []string) int {
+	args = reorderFlags(args, map[string]bool{
+		"--project-dir":   true,
+		"--host-data-dir": true,
+		"--json":          false,
+	})
+	fs := flag.NewFlagSet("project status", flag.ContinueOnError)
+	var projectDir string
+	var hostDataDir string
+	var asJSON bool
+	fs.StringVar(&projectDir, "project-dir", ".", "project directory")
+	fs.StringVar(&hostDataDir, "host-data-dir", "", "host data directory (default <project>/.metaclaw)")
+	fs.BoolVar(&asJSON, "json", false, "json summary output (exit 0 clean, 2 drifted, 1 error)")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw project status [--project-dir=.] [--host-data-dir=...] [--json]")
+		return 1
+	}
+	absProject, err := filepath.Abs(strings.TrimSpace(projectDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "project status failed: resolve project dir: %v\n", err)
+		return projectExitError
+	}
+
+	res, err := project.Status(project.StatusOptions{ProjectDir: absProject, HostDataDir: hostDataDir})
+	summary := projectStatusSummary{
+		ProjectDir:     absProject,
+		TemplateID:     res.TemplateID,
+		TemplateCommit: res.TemplateCommit,
+		InstalledAtUTC: res.InstalledAtUTC,
+		Modified:       res.Modified,
+		Missing:        res.Missing,
+		Clean:          len(res.Clean),
+		Drifted:        res.Drifted(),
+		ExitCode:       projectExitOK,
+	}
+	switch {
+	case err != nil:
+		summary.ExitCode = projectExitError
+		summary.Error = err.Error()
+		if errors.Is(err, os.ErrNotExist) {
+			summary.Error = "missing .metaclaw/project.lock.json; run project init first"
+		}
+	case summary.Drifted:
+		summary.ExitCode = projectExitConflicts
+	}
+	if asJSON {
+		if summary.Modified == nil {
+			summary.Modified = []string{}
+		}
+		if summary.Missing == nil {
+			summary.Missing = []string{}
+		}
+		b, _ := json.MarshalIndent(summary, "", "  ")
+		fmt.Println(string(b))
+		return summary.ExitCode
+	}
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "project status failed: %s\n", summary.Error)
+		return summary.ExitCode
+	}
+
+	fmt.Printf("template: %s\n", res.TemplateID)
+	if res.TemplateCommit != "" {
+		fmt.Printf("template_commit: %s\n", res.TemplateCommit)
+	}
+	if res.InstalledAtUTC != "" {
+		fmt.Printf("installed_at: %s\n", res.InstalledAtUTC)
+	}
+	fmt.Printf("clean: %d\n", len(res.Clean))
+	fmt.Printf("modified: %d\n", len(res.Modified))
+	fmt.Printf("missing: %d\n", len(res.Missing))
+	for _, rel := range res.Modified {
+		fmt.Printf("  M %s\n", rel)
+	}
+	for _, rel := range res.Missing {
+		fmt.Printf("  D %s\n", rel)
+	}
+	return summary.ExitCode
+}
+
+// runProjectRollback restores files saved by a forced upgrade. With --list it
+// only prints the available snapshots, newest first.
+func runProjectRollback(args []string) int {
+	args = reorderFlags(args, map[string]bool{
+		"--project-dir":   true,
+		"--host-data-dir": true,
+		"--to":            true,
+		"--list":          false,
+		"--dry-run":       false,
+		"--json":          false,
+	})
+	fs := flag.NewFlagSet("project rollback", flag.ContinueOnError)
+	var projectDir string
+	var hostDataDir string
+	var to string
+	var list bool
+	var dryRun bool
+	var asJSON bool
+	fs.StringVar(&projectDir, "project-dir", ".", "project directory")
+	fs.StringVar(&hostDataDir, "host-data-dir", "", "host data directory (default <project>/.metaclaw)")
+	fs.StringVar(&to, "to", "", "backup snapshot timestamp to restore (default most recent)")
+	fs.BoolVar(&list, "list", false, "list available backup snapshots")
+	fs.BoolVar(&dryRun, "dry-run", false, "show which files would be restored without writing")
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw project rollback [--project-dir=.] [--to=<timestamp>] [--list] [--dry-run] [--json]")
 		return 1
 	}
+	absProject, err := filepath.Abs(strings.TrimSpace(projectDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "project rollback failed: resolve project dir: %v\n", err)
+		return 1
+	}
+
+	if list {
+		effectiveHostDataDir := hostDataDir
+		if strings.TrimSpace(effectiveHostDataDir) == "" {
+			effectiveHostDataDir = project.DefaultHostDataDir(absProject)
+		}
+		snapshots, err := project.ListBackups(effectiveHostDataDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "project rollback failed: %v\n", err)
+			return 1
+		}
+		if asJSON {
+			b, _ := json.MarshalIndent(map[string]any{"snapshots": snapshots}, "", "  ")
+			fmt.Println(string(b))
+			return 0
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("no upgrade backups")
+		}
+		for _, s := range snapshots {
+			fmt.Println(s)
+		}
+		return 0
+	}
+
+	res, err := project.Rollback(project.RollbackOptions{
+		ProjectDir:  absProject,
+		HostDataDir: hostDataDir,
+		To:          to,
+		DryRun:      dryRun,
+	})
+	if asJSON {
+		payload := map[string]any{
+			"projectDir":   absProject,
+			"snapshot":     res.Snapshot,
+			"restored":     res.Restored,
+			"lockRestored": res.LockRestored,
+			"dryRun":       dryRun,
+		}
+		if err != nil {
+			payload["error"] = err.Error()
+		}
+		b, _ := json.MarshalIndent(payload, "", "  ")
+		fmt.Println(string(b))
+		if err != nil {
+			return 1
+		}
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "project rollback failed: %v\n", err)
+		return 1
+	}
+	if dryRun {
+		fmt.Printf("project rollback (dry run): %s\n", res.Snapshot)
+	} else {
+		fmt.Printf("rolled back to: %s\n", res.Snapshot)
+	}
+	fmt.Printf("restored: %d\n", len(res.Restored))
+	for _, rel := range res.Restored {
+		fmt.Printf("  %s\n", rel)
+	}
+	if !res.LockRestored {
+		fmt.Println("note: backup predates lock snapshots; restored files were re-hashed into the current lock")
+	}
 	return 0
 }
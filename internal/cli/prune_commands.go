@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/manager"
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+// pruneRunScanLimit bounds the run history consulted for capsule references.
+const pruneRunScanLimit = 1 << 20
+
+func runPrune(ctx context.Context, args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true, "--older-than": true, "--keep-last": true})
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	var stateDir string
+	var olderThan time.Duration
+	var keepLast int
+	var dryRun bool
+	var pruneRuns bool
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.DurationVar(&olderThan, "older-than", 720*time.Hour, "only prune capsules (and runs) older than this")
+	fs.IntVar(&keepLast, "keep-last", 0, "always keep the N most recent capsules")
+	fs.BoolVar(&dryRun, "dry-run", false, "print what would be removed without deleting anything")
+	fs.BoolVar(&pruneRuns, "runs", false, "also delete finished run records older than --older-than")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 0 || olderThan < 0 || keepLast < 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw prune [--older-than=720h] [--keep-last=N] [--runs] [--dry-run] [--state-dir=.metaclaw]")
+		return 1
+	}
+	m, err := manager.New(stateDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open manager: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+
+	runs, err := m.ListRuns(pruneRunScanLimit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prune failed: %v\n", err)
+		return 1
+	}
+	items, err := discoverCapsules(filepath.Join(stateDir, "capsules"), false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prune failed: %v\n", err)
+		return 1
+	}
+	cutoff := time.Now().UTC().Add(-olderThan)
+
+	verb := "removed"
+	if dryRun {
+		verb = "would remove"
+	}
+	code := 0
+	prunedRuns := 0
+	if pruneRuns {
+		for _, r := range selectPrunableRuns(runs, cutoff) {
+			if !dryRun {
+				if err := m.RemoveRun(ctx, r.RunID, false); err != nil {
+					fmt.Fprintf(os.Stderr, "prune run %s failed: %v\n", r.RunID, err)
+					code = 1
+					continue
+				}
+			}
+			fmt.Printf("%s run %s\n", verb, r.RunID)
+			prunedRuns++
+		}
+	}
+	prunedCapsules := 0
+	for _, it := range selectPrunableCapsules(items, runs, cutoff, keepLast) {
+		if !dryRun {
+			if err := os.RemoveAll(it.Path); err != nil {
+				fmt.Fprintf(os.Stderr, "prune capsule %s failed: %v\n", it.ID, err)
+				code = 1
+				continue
+			}
+		}
+		fmt.Printf("%s capsule %s\t%s\n", verb, it.ID, it.Path)
+		prunedCapsules++
+	}
+	fmt.Printf("prune: %s %d capsule(s), %d run(s)\n", verb, prunedCapsules, prunedRuns)
+	return code
+}
+
+// selectPrunableCapsules returns capsules older than cutoff that are not among
+// the keepLast newest and are not referenced by a live run or by any run that
+// started after cutoff. items must be sorted newest first, as discoverCapsules
+// returns them.
+func selectPrunableCapsules(items []capsuleListItem, runs []store.RunRecord, cutoff time.Time, keepLast int) []capsuleListItem {
+	referenced := map[string]struct{}{}
+	for _, r := range runs {
+		if runIsLive(r) || runStartedAfter(r, cutoff) {
+			referenced[r.CapsuleID] = struct{}{}
+		}
+	}
+	out := make([]capsuleListItem, 0)
+	for i, it := range items {
+		if i < keepLast || !it.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if _, ok := referenced[it.ID]; ok {
+			continue
+		}
+		out = append(out, it)
+	}
+	return out
+}
+
+// selectPrunableRuns returns finished runs that started before cutoff.
+func selectPrunableRuns(runs []store.RunRecord, cutoff time.Time) []store.RunRecord {
+	out := make([]store.RunRecord, 0)
+	for _, r := range runs {
+		if runIsLive(r) || runStartedAfter(r, cutoff) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func runIsLive(r store.RunRecord) bool {
+	return r.Status == "running" || r.Status == "failed_paused"
+}
+
+// runStartedAfter treats an unparsable start time as recent so prune never
+// deletes something it cannot date.
+func runStartedAfter(r store.RunRecord, cutoff time.Time) bool {
+	started, err := time.Parse(time.RFC3339Nano, r.StartedAt)
+	if err != nil {
+		return true
+	}
+	return started.After(cutoff)
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+func TestSelectPrunableCapsules(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-24 * time.Hour)
+	old := now.Add(-72 * time.Hour)
+	items := []capsuleListItem{
+		{ID: "fresh", CreatedAt: now},
+		{ID: "kept", CreatedAt: old},
+		{ID: "live", CreatedAt: old},
+		{ID: "recent-run", CreatedAt: old},
+		{ID: "orphan", CreatedAt: old},
+		{ID: "stale-run", CreatedAt: old},
+	}
+	runs := []store.RunRecord{
+		{RunID: "r1", CapsuleID: "live", Status: "running", StartedAt: old.Format(time.RFC3339Nano)},
+		{RunID: "r2", CapsuleID: "recent-run", Status: "succeeded", StartedAt: now.Format(time.RFC3339Nano)},
+		{RunID: "r3", CapsuleID: "stale-run", Status: "failed", StartedAt: old.Format(time.RFC3339Nano)},
+	}
+
+	got := selectPrunableCapsules(items, runs, cutoff, 2)
+	if len(got) != 2 || got[0].ID != "orphan" || got[1].ID != "stale-run" {
+		t.Fatalf("selectPrunableCapsules() = %+v, want orphan and stale-run", got)
+	}
+	prunableRuns := selectPrunableRuns(runs, cutoff)
+	if len(prunableRuns) != 1 || prunableRuns[0].RunID != "r3" {
+		t.Fatalf("selectPrunableRuns() = %+v, want r3", prunableRuns)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+const defaultPSWatchInterval = 2 * time.Second
+
+// watchFlag is an optional-value duration flag: a bare --watch enables
+// watching at the default interval, --watch=5s sets the interval.
+type watchFlag struct {
+	enabled  bool
+	interval time.Duration
+}
+
+func (f *watchFlag) String() string {
+	if f == nil || !f.enabled {
+		return ""
+	}
+	return f.interval.String()
+}
+
+func (f *watchFlag) Set(value string) error {
+	if value == "true" {
+		f.enabled, f.interval = true, defaultPSWatchInterval
+		return nil
+	}
+	if value == "false" {
+		f.enabled = false
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid --watch interval %q (want a positive duration like 5s)", value)
+	}
+	f.enabled, f.interval = true, d
+	return nil
+}
+
+func (f *watchFlag) IsBoolFlag() bool { return true }
+
+// ANSI sequences for redrawing the table in place. The cursor is hidden while
+// watching and shown again on exit so an interrupted watch leaves the
+// terminal usable.
+const (
+	ansiClearScreen = "\033[H\033[2J"
+	ansiHideCursor  = "\033[?25l"
+	ansiShowCursor  = "\033[?25h"
+)
+
+// watchPS redraws the run table every interval until ctx is cancelled or the
+// process receives SIGINT/SIGTERM. list is called on every tick, so statuses
+// are refreshed the same way a one-off ps refreshes them.
+func watchPS(ctx context.Context, w io.Writer, interval time.Duration, list func() ([]store.RunRecord, error), render func(io.Writer, []store.RunRecord)) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprint(w, ansiHideCursor)
+	defer fmt.Fprint(w, ansiShowCursor)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		runs, err := list()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, ansiClearScreen)
+		fmt.Fprintf(w, "Every %s: metaclaw ps\t%s\n\n", interval, time.Now().Format(time.RFC3339))
+		render(w, runs)
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(w)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+func TestWatchFlag(t *testing.T) {
+	parse := func(args ...string) (watchFlag, error) {
+		var w watchFlag
+		fs := flag.NewFlagSet("ps", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Var(&w, "watch", "")
+		return w, fs.Parse(args)
+	}
+	if w, err := parse(); err != nil || w.enabled {
+		t.Fatalf("expected watch disabled by default: %+v (%v)", w, err)
+	}
+	if w, err := parse("--watch"); err != nil || !w.enabled || w.interval != defaultPSWatchInterval {
+		t.Fatalf("unexpected bare --watch: %+v (%v)", w, err)
+	}
+	if w, err := parse("--watch=500ms"); err != nil || !w.enabled || w.interval != 500*time.Millisecond {
+		t.Fatalf("unexpected --watch=500ms: %+v (%v)", w, err)
+	}
+	for _, bad := range []string{"--watch=soon", "--watch=0s", "--watch=-1s"} {
+		if _, err := parse(bad); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestWatchPSRedrawsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	list := func() ([]store.RunRecord, error) {
+		calls++
+		if calls >= 2 {
+			cancel()
+		}
+		return []store.RunRecord{{RunID: "run_1", Status: "running"}}, nil
+	}
+	var buf bytes.Buffer
+	if err := watchPS(ctx, &buf, time.Millisecond, list, writePSTable); err != nil {
+		t.Fatalf("watchPS() error = %v", err)
+	}
+	out := buf.String()
+	if calls < 2 || strings.Count(out, ansiClearScreen) != calls || strings.Count(out, "run_1\trunning") != calls {
+		t.Fatalf("expected one redraw per list call, got %d calls:\n%q", calls, out)
+	}
+	if !strings.HasPrefix(out, ansiHideCursor) || !strings.HasSuffix(out, ansiShowCursor) {
+		t.Fatalf("expected cursor to be hidden and restored: %q", out)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fpp-125/metaclaw/internal/claw/validate"
 	"github.com/fpp-125/metaclaw/internal/project"
 )
 
@@ -29,9 +30,13 @@ type doctorCheck struct {
 }
 
 type doctorReport struct {
+	// Status aggregates the checks: fail if any failed, else warn if any
+	// warned, else pass. It maps onto the doctor exit code.
+	Status          string        `json:"status"`
 	SelectedRuntime string        `json:"selectedRuntime,omitempty"`
 	RuntimeBin      string        `json:"runtimeBin,omitempty"`
 	Checks          []doctorCheck `json:"checks"`
+	Fixes           []doctorFix   `json:"fixes,omitempty"`
 }
 
 type doctorOptions struct {
@@ -43,6 +48,10 @@ type doctorOptions struct {
 	CheckJQ       bool
 	CheckPython   bool
 	RequireVault  bool
+	Image         string
+	Fix           bool
+	StateDir      string
+	ProbeLLM      string
 }
 
 type quickstartOptions struct {
@@ -57,6 +66,7 @@ type quickstartOptions struct {
 	SkipBuild   bool
 	NoRun       bool
 	Force       bool
+	SeedVault   bool
 }
 
 type obsidianProfile struct {
@@ -104,6 +114,10 @@ func runDoctor(args []string) int {
 		"--web-key-env":     true,
 		"--require-llm-key": false,
 		"--json":            false,
+		"--image":           true,
+		"--fix":             false,
+		"--state-dir":       true,
+		"--probe-llm":       true,
 	})
 
 	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
@@ -115,17 +129,21 @@ func runDoctor(args []string) int {
 		CheckPython: true,
 	}
 	var asJSON bool
-	fs.StringVar(&opts.Runtime, "runtime", opts.Runtime, "runtime target (auto|apple_container|podman|docker)")
+	fs.StringVar(&opts.Runtime, "runtime", opts.Runtime, "runtime target (auto|apple_container|podman|docker|nerdctl)")
 	fs.StringVar(&opts.VaultPath, "vault", "", "vault path to validate")
 	fs.StringVar(&opts.LLMKeyEnv, "llm-key-env", opts.LLMKeyEnv, "LLM API key env name")
 	fs.StringVar(&opts.WebKeyEnv, "web-key-env", opts.WebKeyEnv, "web search API key env name")
 	fs.BoolVar(&opts.RequireLLMKey, "require-llm-key", false, "treat missing llm key env as failure")
+	fs.StringVar(&opts.Image, "image", "", "digest-pinned image ref that must be present on the selected runtime")
+	fs.BoolVar(&opts.Fix, "fix", false, "try to start an installed but stopped runtime, then re-check")
+	fs.StringVar(&opts.StateDir, "state-dir", ".metaclaw", "state directory whose filesystem free space is checked")
+	fs.StringVar(&opts.ProbeLLM, "probe-llm", "", "clawfile whose llm provider is probed with its key (makes one network request)")
 	fs.BoolVar(&asJSON, "json", false, "json output")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	if len(fs.Args()) != 0 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw doctor [--runtime=auto|apple_container|podman|docker] [--vault=/path] [--llm-key-env=OPENAI_FORMAT_API_KEY] [--web-key-env=TAVILY_API_KEY] [--require-llm-key] [--json]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw doctor [--runtime=auto|apple_container|podman|docker|nerdctl] [--vault=/path] [--llm-key-env=OPENAI_FORMAT_API_KEY] [--web-key-env=TAVILY_API_KEY] [--require-llm-key] [--image=ref@sha256:...] [--fix] [--state-dir=.metaclaw] [--probe-llm=agent.claw] [--json]")
 		return 1
 	}
 
@@ -138,9 +156,21 @@ func runDoctor(args []string) int {
 	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "doctor failed: %v\n", err)
+	}
+	return doctorExitCode(report)
+}
+
+// doctorExitCode is the documented exit-code contract of doctor: 0 when every
+// check passed, 2 when only warnings remain, 1 when any check failed.
+func doctorExitCode(report doctorReport) int {
+	switch report.Status {
+	case doctorStatusPass:
+		return 0
+	case doctorStatusWarn:
+		return 2
+	default:
 		return 1
 	}
-	return 0
 }
 
 func runQuickstart(args []string) int {
@@ -156,6 +186,7 @@ func runQuickstart(args []string) int {
 		"--skip-build":   false,
 		"--no-run":       false,
 		"--force":        false,
+		"--seed-vault":   false,
 	})
 
 	fs := flag.NewFlagSet("quickstart", flag.ContinueOnError)
@@ -169,7 +200,7 @@ func runQuickstart(args []string) int {
 	fs.StringVar(&opts.ProjectDir, "project-dir", opts.ProjectDir, "project directory")
 	fs.StringVar(&opts.VaultPath, "vault", "", "absolute vault path (interactive prompt if omitted)")
 	fs.BoolVar(&opts.VaultWrite, "vault-write", false, "mount vault read-write inside container (less safe; default is read-only)")
-	fs.StringVar(&opts.Runtime, "runtime", opts.Runtime, "runtime target (auto|apple_container|podman|docker)")
+	fs.StringVar(&opts.Runtime, "runtime", opts.Runtime, "runtime target (auto|apple_container|podman|docker|nerdctl)")
 	fs.StringVar(&opts.LLMKeyEnv, "llm-key-env", opts.LLMKeyEnv, "LLM API key env name")
 	fs.StringVar(&opts.WebKeyEnv, "web-key-env", opts.WebKeyEnv, "web search API key env name")
 	fs.StringVar(&opts.Profile, "profile", opts.Profile, "quickstart profile (obsidian-chat|obsidian-research)")
@@ -177,13 +208,14 @@ func runQuickstart(args []string) int {
 	fs.BoolVar(&opts.SkipBuild, "skip-build", false, "skip image build")
 	fs.BoolVar(&opts.NoRun, "no-run", false, "prepare project only, do not launch chat")
 	fs.BoolVar(&opts.Force, "force", false, "allow using a non-empty project directory")
+	fs.BoolVar(&opts.SeedVault, "seed-vault", false, "copy starter notes from the template seed/ dir when the vault is empty")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 
 	remaining := fs.Args()
 	if len(remaining) != 1 || remaining[0] != "obsidian" {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw quickstart obsidian [--project-dir=./my-bot] [--vault=/abs/path/to/vault] [--vault-write] [--runtime=auto|apple_container|podman|docker] [--profile=obsidian-chat] [--skip-build] [--no-run]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw quickstart obsidian [--project-dir=./my-bot] [--vault=/abs/path/to/vault] [--vault-write] [--runtime=auto|apple_container|podman|docker|nerdctl] [--profile=obsidian-chat] [--seed-vault] [--skip-build] [--no-run]")
 		return 1
 	}
 
@@ -233,6 +265,7 @@ func runQuickstart(args []string) int {
 		CheckJQ:       !opts.SkipBuild,
 		CheckPython:   !opts.NoRun,
 		RequireVault:  true,
+		StateDir:      stateDir,
 	})
 	printDoctorReport(report)
 	if err != nil {
@@ -251,6 +284,19 @@ func runQuickstart(args []string) int {
 		return 1
 	}
 
+	var seeded []string
+	seedSkipped := ""
+	if opts.SeedVault {
+		seeded, err = seedObsidianVault(templateDir, opts.VaultPath)
+		switch {
+		case errors.Is(err, errVaultNotEmpty):
+			seedSkipped = "vault is not empty"
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "warning: cannot seed vault: %v\n", err)
+			seedSkipped = "seeding failed"
+		}
+	}
+
 	// Write a generic project lock so future upgrades can refresh managed template files in-place
 	// without overwriting user-owned data like agent.claw, vault content, or .env.
 	{
@@ -296,6 +342,19 @@ func runQuickstart(args []string) int {
 	} else {
 		fmt.Printf("vault access: read-only (recommended)\n")
 	}
+	if profile.RetrievalScope == "limited" && strings.TrimSpace(profile.SaveDefaultDir) != "" {
+		fmt.Printf("vault scope: limited (vault read-only; %s mounted separately)\n", profile.SaveDefaultDir)
+	}
+	if opts.SeedVault {
+		if seedSkipped != "" {
+			fmt.Printf("vault seed: skipped (%s)\n", seedSkipped)
+		} else {
+			fmt.Printf("vault seed: added %d note(s)\n", len(seeded))
+			for _, rel := range seeded {
+				fmt.Printf("  + %s\n", rel)
+			}
+		}
+	}
 	fmt.Printf("host data: %s\n", hostDataDir)
 	fmt.Printf("profile: %s\n", profile.Name)
 	fmt.Printf("runtime: %s\n", report.SelectedRuntime)
@@ -378,6 +437,12 @@ func collectDoctorReport(opts doctorOptions) (doctorReport, error) {
 	}
 
 	runtimeTarget, runtimeBin, runtimeHealth, err := resolveRequestedRuntime(opts.Runtime)
+	if err != nil && opts.Fix {
+		report.Fixes = fixUnhealthyRuntimes(opts.Runtime)
+		if len(report.Fixes) > 0 && report.Fixes[len(report.Fixes)-1].Succeeded {
+			runtimeTarget, runtimeBin, runtimeHealth, err = resolveRequestedRuntime(opts.Runtime)
+		}
+	}
 	if err != nil {
 		add("runtime", doctorStatusFail, err.Error())
 	} else {
@@ -405,6 +470,21 @@ func collectDoctorReport(opts doctorOptions) (doctorReport, error) {
 		}
 	}
 
+	if image := strings.TrimSpace(opts.Image); image != "" {
+		if validate.IsDigestPinned(image) {
+			add("image_pinned", doctorStatusPass, image)
+		} else {
+			add("image_pinned", doctorStatusFail, fmt.Sprintf("%s is not digest-pinned (expected image@sha256:...)", image))
+		}
+		if report.RuntimeBin == "" {
+			add("image_present", doctorStatusFail, "no healthy runtime to inspect image")
+		} else if detail, err := checkImagePresent(report.RuntimeBin, image); err != nil {
+			add("image_present", doctorStatusFail, err.Error())
+		} else {
+			add("image_present", doctorStatusPass, detail)
+		}
+	}
+
 	llmEnv := strings.TrimSpace(opts.LLMKeyEnv)
 	if llmEnv == "" {
 		llmEnv = "OPENAI_FORMAT_API_KEY"
@@ -447,10 +527,25 @@ func collectDoctorReport(opts doctorOptions) (doctorReport, error) {
 		}
 	}
 
+	if clawPath := strings.TrimSpace(opts.ProbeLLM); clawPath != "" {
+		status, detail := probeLLMKey(clawPath, nil)
+		add("llm_probe", status, detail)
+	}
+
+	diskStatus, diskDetail := checkDiskSpace(opts.StateDir)
+	add("disk_space", diskStatus, diskDetail)
+
+	report.Status = doctorStatusPass
 	failed := make([]string, 0, 4)
 	for _, c := range report.Checks {
-		if c.Status == doctorStatusFail {
+		switch c.Status {
+		case doctorStatusFail:
 			failed = append(failed, c.Name)
+			report.Status = doctorStatusFail
+		case doctorStatusWarn:
+			if report.Status == doctorStatusPass {
+				report.Status = doctorStatusWarn
+			}
 		}
 	}
 	if len(failed) > 0 {
@@ -472,9 +567,34 @@ func printDoctorReport(report doctorReport) {
 		}
 		fmt.Printf("  [%s] %s: %s\n", prefix, c.Name, c.Detail)
 	}
+	for _, f := range report.Fixes {
+		result := "failed"
+		if f.Succeeded {
+			result = "ok"
+		}
+		fmt.Printf("  [FIX] %s: `%s` %s: %s\n", f.Runtime, f.Command, result, f.Detail)
+	}
 	if report.SelectedRuntime != "" {
 		fmt.Printf("selected runtime: %s\n", report.SelectedRuntime)
 	}
+	fmt.Printf("status: %s\n", report.Status)
+}
+
+func checkImagePresent(bin, image string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 7*time.Second)
+	defer cancel()
+	// apple container, podman, and docker all accept `image inspect <ref>`.
+	if _, stderr, err := runDoctorCmd(ctx, bin, "image", "inspect", image); err != nil {
+		msg := strings.TrimSpace(stderr)
+		if i := strings.IndexByte(msg, '\n'); i >= 0 {
+			msg = strings.TrimSpace(msg[:i])
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("image not found on %s (pull or build it first): %s", bin, msg)
+	}
+	return fmt.Sprintf("present on %s", bin), nil
 }
 
 func resolveRequestedRuntime(requested string) (string, string, string, error) {
@@ -520,7 +640,7 @@ func runtimeProbeOrder() []string {
 	if goruntime.GOOS == "darwin" {
 		return []string{"apple_container", "podman", "docker"}
 	}
-	return []string{"podman", "docker", "apple_container"}
+	return []string{"podman", "docker", "nerdctl", "apple_container"}
 }
 
 func buildQuickstartRuntimeCandidates(requested, selected string) []string {
@@ -561,6 +681,8 @@ func runtimeBinaryForTarget(target string) string {
 		return "podman"
 	case "docker":
 		return "docker"
+	case "nerdctl":
+		return "nerdctl"
 	default:
 		return ""
 	}
@@ -632,6 +754,23 @@ func checkRuntimeHealth(target, bin string) (string, error) {
 			return "", fmt.Errorf("podman not reachable (%s)", help)
 		}
 		return "podman reachable", nil
+	case "nerdctl":
+		// nerdctl talks to containerd directly; `info` fails when the containerd socket is unreachable.
+		stdout, stderr, err := runDoctorCmd(ctx, bin, "info", "--format", "{{.ServerVersion}}")
+		if err != nil {
+			msg := firstLine(stderr)
+			if msg == "" {
+				msg = firstLine(stdout)
+			}
+			if msg == "" {
+				msg = err.Error()
+			}
+			return "", fmt.Errorf("containerd not reachable via nerdctl (%s)", msg)
+		}
+		if v := firstLine(stdout); v != "" {
+			return fmt.Sprintf("containerd reachable (server %s)", v), nil
+		}
+		return "containerd reachable", nil
 	case "apple_container":
 		// Apple Container should at least report a version; some environments require permissions on first run.
 		stdout, stderr, err := runDoctorCmd(ctx, bin, "--version")
@@ -928,6 +1067,9 @@ func scaffoldObsidianProject(templateDir, projectDir, vaultPath string, vaultWri
 	if err := rewriteObsidianAgentFile(filepath.Join(projectDir, "agent.claw"), vaultPath, hostDataDir, profile.NetworkMode, vaultWrite); err != nil {
 		return err
 	}
+	if err := applyObsidianRetrievalScope(filepath.Join(projectDir, "agent.claw"), vaultPath, vaultWrite, profile); err != nil {
+		return err
+	}
 	if err := rewriteQuickstartChatScript(filepath.Join(projectDir, "chat.sh"), hostDataDir, llmKeyEnv, webKeyEnv, runtimeTarget, profile); err != nil {
 		return err
 	}
@@ -943,6 +1085,56 @@ func scaffoldObsidianProject(templateDir, projectDir, vaultPath string, vaultWri
 	return nil
 }
 
+var errVaultNotEmpty = errors.New("vault is not empty")
+
+// seedObsidianVault copies markdown notes from the template's seed/ dir into an
+// empty vault. Hidden entries such as .obsidian/ do not count as content.
+func seedObsidianVault(templateDir, vaultPath string) ([]string, error) {
+	seedDir := filepath.Join(templateDir, "seed")
+	if st, err := os.Stat(seedDir); err != nil || !st.IsDir() {
+		return nil, fmt.Errorf("template has no seed/ directory: %s", seedDir)
+	}
+	entries, err := os.ReadDir(vaultPath)
+	if err != nil {
+		return nil, fmt.Errorf("read vault: %w", err)
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".") {
+			return nil, errVaultNotEmpty
+		}
+	}
+
+	var added []string
+	err = filepath.WalkDir(seedDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			if path != seedDir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !strings.EqualFold(filepath.Ext(d.Name()), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(seedDir, path)
+		if err != nil {
+			return err
+		}
+		if err := copyTemplateFile(path, filepath.Join(vaultPath, rel), 0o644); err != nil {
+			return fmt.Errorf("copy seed note %s: %w", rel, err)
+		}
+		added = append(added, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return added, err
+	}
+	sort.Strings(added)
+	return added, nil
+}
+
 func copyTemplateEntry(src, dst string) error {
 	info, err := os.Stat(src)
 	if err != nil {
@@ -1019,6 +1211,113 @@ func rewriteObsidianAgentFile(path, vaultPath, hostDataDir, networkMode string,
 	return nil
 }
 
+// applyObsidianRetrievalScope turns the advisory "limited" retrieval scope into a
+// filesystem boundary: the whole vault is mounted read-only and only the
+// profile's save directory gets its own mount, writable when vaultWrite is set.
+func applyObsidianRetrievalScope(path, vaultPath string, vaultWrite bool, profile obsidianProfile) error {
+	if profile.RetrievalScope != "limited" {
+		return nil
+	}
+	saveDir := strings.TrimSpace(profile.SaveDefaultDir)
+	if saveDir == "" {
+		return nil
+	}
+	clean := filepath.Clean(filepath.FromSlash(saveDir))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(os.PathSeparator)) {
+		return fmt.Errorf("profile %s save dir must be a relative path inside the vault: %q", profile.Name, profile.SaveDefaultDir)
+	}
+	hostSaveDir := filepath.Join(vaultPath, clean)
+	if err := os.MkdirAll(hostSaveDir, 0o755); err != nil {
+		return fmt.Errorf("create vault save dir: %w", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read agent.claw: %w", err)
+	}
+	text := setMountReadOnlyByTarget(string(b), "/vault", true)
+	text = insertMountAfterTarget(text, "/vault", hostSaveDir, "/vault/"+filepath.ToSlash(clean), !vaultWrite)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return fmt.Errorf("write agent.claw: %w", err)
+	}
+	return nil
+}
+
+// insertMountAfterTarget adds a mount item right after the mount whose target is
+// afterTarget. It is a no-op if newTarget is already mounted or afterTarget is absent.
+func insertMountAfterTarget(content, afterTarget, source, newTarget string, readOnly bool) string {
+	lines := strings.Split(content, "\n")
+	inMounts := false
+	mountsIndent := 0
+	itemIndent := ""
+	matchAt := -1
+	fieldIndent := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if trimmed == "mounts:" {
+			inMounts = true
+			mountsIndent = indent
+			continue
+		}
+		if inMounts && indent <= mountsIndent && trimmed != "" && !strings.HasPrefix(trimmed, "-") {
+			inMounts = false
+		}
+		if !inMounts {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "-") {
+			itemIndent = line[:indent]
+		}
+		field := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+		if !strings.HasPrefix(field, "target:") {
+			continue
+		}
+		val := stripOuterQuotesScalar(strings.TrimPrefix(field, "target:"))
+		if val == newTarget {
+			return content
+		}
+		if val == afterTarget && matchAt < 0 {
+			matchAt = i
+			if strings.HasPrefix(trimmed, "-") {
+				fieldIndent = itemIndent + "  "
+			} else {
+				fieldIndent = line[:indent]
+			}
+		}
+	}
+	if matchAt < 0 {
+		return content
+	}
+	itemIndentLen := len(itemIndent)
+	insertAt := len(lines)
+	for j := matchAt + 1; j < len(lines); j++ {
+		jLine := lines[j]
+		jTrim := strings.TrimSpace(jLine)
+		if jTrim == "" {
+			continue
+		}
+		jIndent := len(jLine) - len(strings.TrimLeft(jLine, " \t"))
+		if (strings.HasPrefix(jTrim, "-") && jIndent <= itemIndentLen) || jIndent <= mountsIndent {
+			insertAt = j
+			break
+		}
+	}
+	for insertAt > matchAt+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	item := []string{
+		itemIndent + "- source: " + strconv.Quote(source),
+		fieldIndent + "target: " + strconv.Quote(newTarget),
+		fieldIndent + "readOnly: " + strconv.FormatBool(readOnly),
+	}
+	out := make([]string, 0, len(lines)+len(item))
+	out = append(out, lines[:insertAt]...)
+	out = append(out, item...)
+	out = append(out, lines[insertAt:]...)
+	return strings.Join(out, "\n")
+}
+
 func replaceFirstNetworkMode(content, networkMode string) string {
 	lines := strings.Split(content, "\n")
 	for i, line := range lines {
@@ -1315,7 +1614,7 @@ func resolvePinnedImageRef(runtimeTarget, runtimeBin, taggedImage string) (strin
 	switch runtimeTarget {
 	case "apple_container":
 		return resolveApplePinnedImageRef(runtimeBin, taggedImage)
-	case "podman", "docker":
+	case "podman", "docker", "nerdctl":
 		return resolveOCICompatiblePinnedImageRef(runtimeBin, taggedImage)
 	default:
 		return "", fmt.Errorf("unsupported runtime target for pin recovery: %s", runtimeTarget)
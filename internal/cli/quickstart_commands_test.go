@@ -2,6 +2,7 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -323,3 +324,145 @@ exec python3 "$PROJECT_DIR/chat_tui.py" "$@"
 		t.Fatalf("expected agents/soul.md to be copied: %v", err)
 	}
 }
+
+func TestCollectDoctorReportImageChecks(t *testing.T) {
+	report, err := collectDoctorReport(doctorOptions{Runtime: "invalid-runtime", Image: "alpine:latest"})
+	if err == nil {
+		t.Fatal("expected doctor to fail")
+	}
+	statuses := map[string]string{}
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	if statuses["image_pinned"] != doctorStatusFail {
+		t.Fatalf("expected image_pinned failure for tag ref, got %q", statuses["image_pinned"])
+	}
+	if statuses["image_present"] != doctorStatusFail {
+		t.Fatalf("expected image_present failure without runtime, got %q", statuses["image_present"])
+	}
+
+	pinned := "alpine@sha256:" + strings.Repeat("a", 64)
+	report, _ = collectDoctorReport(doctorOptions{Runtime: "invalid-runtime", Image: pinned})
+	for _, c := range report.Checks {
+		if c.Name == "image_pinned" && c.Status != doctorStatusPass {
+			t.Fatalf("expected pinned ref to pass, got %+v", c)
+		}
+	}
+}
+
+func TestDoctorStatusAndExitCode(t *testing.T) {
+	report, err := collectDoctorReport(doctorOptions{Runtime: "invalid-runtime"})
+	if err == nil || report.Status != doctorStatusFail || doctorExitCode(report) != 1 {
+		t.Fatalf("expected failing report with exit 1, got status=%q err=%v", report.Status, err)
+	}
+	for status, want := range map[string]int{doctorStatusPass: 0, doctorStatusWarn: 2, doctorStatusFail: 1} {
+		if got := doctorExitCode(doctorReport{Status: status}); got != want {
+			t.Fatalf("doctorExitCode(%s) = %d, want %d", status, got, want)
+		}
+	}
+}
+
+func TestSeedObsidianVault(t *testing.T) {
+	templateDir := t.TempDir()
+	seedDir := filepath.Join(templateDir, "seed")
+	if err := os.MkdirAll(filepath.Join(seedDir, "guides"), 0o755); err != nil {
+		t.Fatalf("mkdir seed: %v", err)
+	}
+	for rel, content := range map[string]string{
+		"Welcome.md":                "# Welcome\n",
+		"guides/Getting Started.md": "# Getting started\n",
+		"image.png":                 "not a note",
+	} {
+		if err := os.WriteFile(filepath.Join(seedDir, rel), []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", rel, err)
+		}
+	}
+
+	vault := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(vault, ".obsidian"), 0o755); err != nil {
+		t.Fatalf("mkdir .obsidian: %v", err)
+	}
+	added, err := seedObsidianVault(templateDir, vault)
+	if err != nil {
+		t.Fatalf("seedObsidianVault() error = %v", err)
+	}
+	if len(added) != 2 || added[0] != "Welcome.md" || added[1] != "guides/Getting Started.md" {
+		t.Fatalf("unexpected seeded notes: %v", added)
+	}
+	if _, err := os.Stat(filepath.Join(vault, "image.png")); !os.IsNotExist(err) {
+		t.Fatalf("expected non-markdown seed files to be skipped, stat err=%v", err)
+	}
+
+	if _, err := seedObsidianVault(templateDir, vault); !errors.Is(err, errVaultNotEmpty) {
+		t.Fatalf("expected errVaultNotEmpty on second seed, got %v", err)
+	}
+}
+
+func TestApplyObsidianRetrievalScopeLimited(t *testing.T) {
+	dir := t.TempDir()
+	vault := filepath.Join(dir, "vault")
+	if err := os.MkdirAll(vault, 0o755); err != nil {
+		t.Fatalf("mkdir vault: %v", err)
+	}
+	agent := filepath.Join(dir, "agent.claw")
+	content := `apiVersion: metaclaw/v1
+kind: Agent
+agent:
+  habitat:
+    network:
+      mode: none
+    mounts:
+      - source: ` + vault + `
+        target: /vault
+        readOnly: false
+      - source: /bot/data/runtime
+        target: /runtime
+  command: ["sh"]
+`
+	if err := os.WriteFile(agent, []byte(content), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	profile := obsidianProfiles["obsidian-chat"]
+	if err := applyObsidianRetrievalScope(agent, vault, true, profile); err != nil {
+		t.Fatalf("apply scope: %v", err)
+	}
+	b, err := os.ReadFile(agent)
+	if err != nil {
+		t.Fatalf("read agent: %v", err)
+	}
+	text := string(b)
+	wantItem := "      - source: \"" + filepath.Join(vault, "Research", "Market-Reports") + "\"\n" +
+		"        target: \"/vault/Research/Market-Reports\"\n" +
+		"        readOnly: false\n" +
+		"      - source: /bot/data/runtime\n"
+	if !strings.Contains(text, wantItem) {
+		t.Fatalf("expected save dir mount inserted after vault mount:\n%s", text)
+	}
+	if !strings.Contains(text, "target: /vault\n        readOnly: true\n") {
+		t.Fatalf("expected whole vault forced read-only:\n%s", text)
+	}
+	if st, err := os.Stat(filepath.Join(vault, "Research", "Market-Reports")); err != nil || !st.IsDir() {
+		t.Fatalf("expected save dir created on host: %v", err)
+	}
+
+	// Re-applying must not duplicate the mount.
+	if err := applyObsidianRetrievalScope(agent, vault, true, profile); err != nil {
+		t.Fatalf("re-apply scope: %v", err)
+	}
+	b, _ = os.ReadFile(agent)
+	if strings.Count(string(b), `target: "/vault/Research/Market-Reports"`) != 1 {
+		t.Fatalf("expected single save dir mount after re-apply:\n%s", string(b))
+	}
+
+	// The research profile keeps full-vault retrieval.
+	if err := os.WriteFile(agent, []byte(content), 0o644); err != nil {
+		t.Fatalf("rewrite fixture: %v", err)
+	}
+	if err := applyObsidianRetrievalScope(agent, vault, true, obsidianProfiles["obsidian-research"]); err != nil {
+		t.Fatalf("apply research scope: %v", err)
+	}
+	b, _ = os.ReadFile(agent)
+	if string(b) != content {
+		t.Fatalf("expected research profile to leave agent.claw unchanged:\n%s", string(b))
+	}
+}
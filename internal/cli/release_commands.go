@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/fpp-125/metaclaw/internal/release"
 	"github.com/fpp-125/metaclaw/internal/signing"
@@ -12,24 +18,39 @@ import (
 
 func runKeygen(args []string) int {
 	args = reorderFlags(args, map[string]bool{
-		"--private-key": true,
-		"--public-key":  true,
-		"--force":       false,
+		"--private-key":  true,
+		"--public-key":   true,
+		"--force":        false,
+		"--print-public": false,
 	})
 	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
 	var privateKeyPath string
 	var publicKeyPath string
 	var force bool
+	var printPublic bool
+	var password bool
 	fs.StringVar(&privateKeyPath, "private-key", ".metaclaw/keys/release.ed25519.pem", "output private key path (PEM PKCS8)")
 	fs.StringVar(&publicKeyPath, "public-key", ".metaclaw/keys/release.ed25519.pub.pem", "output public key path (PEM PKIX)")
 	fs.BoolVar(&force, "force", false, "overwrite existing key files")
+	fs.BoolVar(&printPublic, "print-public", false, "print the public key PEM and key id derived from --private-key without writing files")
+	fs.BoolVar(&password, "password", false, "encrypt the private key with a passphrase from $"+signing.PassphraseEnv+" or a prompt")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	if len(fs.Args()) != 0 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw keygen [--private-key=.metaclaw/keys/release.ed25519.pem] [--public-key=.metaclaw/keys/release.ed25519.pub.pem] [--force]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw keygen [--private-key=.metaclaw/keys/release.ed25519.pem] [--public-key=.metaclaw/keys/release.ed25519.pub.pem] [--force] [--password] | metaclaw keygen --print-public [--private-key=path]")
 		return 1
 	}
+	if printPublic {
+		pemBytes, keyID, err := derivePublicKey(privateKeyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keygen failed: %v\n", err)
+			return 1
+		}
+		fmt.Print(string(pemBytes))
+		fmt.Printf("key_id: %s\n", keyID)
+		return 0
+	}
 
 	if !force {
 		if _, err := os.Stat(privateKeyPath); err == nil {
@@ -47,7 +68,17 @@ func runKeygen(args []string) int {
 		fmt.Fprintf(os.Stderr, "keygen failed: %v\n", err)
 		return 1
 	}
-	if err := signing.WritePrivateKeyPEM(privateKeyPath, priv); err != nil {
+	if password {
+		pass, err := signing.ReadPassphrase("New passphrase for "+privateKeyPath+": ", true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "keygen failed: %v\n", err)
+			return 1
+		}
+		if err := signing.WriteEncryptedPrivateKeyPEM(privateKeyPath, priv, pass); err != nil {
+			fmt.Fprintf(os.Stderr, "keygen failed: %v\n", err)
+			return 1
+		}
+	} else if err := signing.WritePrivateKeyPEM(privateKeyPath, priv); err != nil {
 		fmt.Fprintf(os.Stderr, "keygen failed: %v\n", err)
 		return 1
 	}
@@ -62,42 +93,113 @@ func runKeygen(args []string) int {
 	return 0
 }
 
+func derivePublicKey(privateKeyPath string) ([]byte, string, error) {
+	priv, err := signing.LoadPrivateKeyPEM(privateKeyPath)
+	if err != nil {
+		return nil, "", err
+	}
+	pub, ok := priv.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, "", fmt.Errorf("private key %s is not ed25519", privateKeyPath)
+	}
+	pemBytes, err := signing.EncodePublicKeyPEM(pub)
+	if err != nil {
+		return nil, "", err
+	}
+	return pemBytes, signing.KeyIDFromPublicKey(pub), nil
+}
+
+type releaseListItem struct {
+	ReleaseID string    `json:"releaseId"`
+	CapsuleID string    `json:"capsuleId"`
+	CreatedAt time.Time `json:"createdAt"`
+	Strict    bool      `json:"strict"`
+	KeyID     string    `json:"keyId"`
+	Path      string    `json:"path"`
+}
+
 func runRelease(args []string) int {
+	if len(args) > 0 && args[0] == "list" {
+		return runReleaseList(args[1:])
+	}
+	if len(args) > 0 && args[0] == "sign" {
+		return runReleaseSign(args[1:])
+	}
+	if len(args) > 0 && args[0] == "resign" {
+		return runReleaseResign(args[1:])
+	}
 	args = reorderFlags(args, map[string]bool{
-		"--state-dir": true,
-		"--out":       true,
-		"--sign-key":  true,
-		"--key-id":    true,
+		"--state-dir":           true,
+		"--species-file":        true,
+		"--out":                 true,
+		"--sign-key":            true,
+		"--key-id":              true,
+		"--require-strict-pass": true,
+		"--allowed-registries":  true,
 	})
 	fs := flag.NewFlagSet("release", flag.ContinueOnError)
 	var stateDir string
+	var speciesFile string
 	var outDir string
 	var strict bool
-	var signKey string
+	var signKeys stringListFlag
 	var keyID string
 	var asJSON bool
+	var requireStrictPass string
+	var tarball bool
+	var sbom bool
+	var counter bool
+	var password bool
+	var allowedRegistries string
+	var allowSysAdmin bool
 	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.StringVar(&speciesFile, "species-file", "", "species registry file defining custom species (default: <state-dir>/species.yaml if present)")
 	fs.StringVar(&outDir, "out", "", "release output directory root")
 	fs.BoolVar(&strict, "strict", false, "enforce strict release checks")
-	fs.StringVar(&signKey, "sign-key", "", "ed25519 private key path (PEM PKCS8); auto-generated if absent")
+	fs.StringVar(&requireStrictPass, "require-strict-pass", "", "comma-separated strict checks that must pass even without --strict")
+	fs.StringVar(&allowedRegistries, "allowed-registries", "", "comma-separated registry hosts (or host/prefix) checked by runtime.image_registry_allowed")
+	fs.Var(&signKeys, "sign-key", "ed25519 private key path (PEM PKCS8); repeat to co-sign, the first is auto-generated if absent")
 	fs.StringVar(&keyID, "key-id", "", "signing key identifier override")
+	fs.BoolVar(&tarball, "tar", false, "also package the signed release as rel_<id>.tar.gz")
+	fs.BoolVar(&sbom, "sbom", false, "write a CycloneDX-style sbom.cdx.json and sign its digest into the attestation")
+	fs.BoolVar(&password, "password", false, "encrypt an auto-generated signing key with a passphrase from $"+signing.PassphraseEnv+" or a prompt")
+	fs.BoolVar(&counter, "counter", false, "bump <state-dir>/releases/counter and sign the new value into the attestation")
+	fs.BoolVar(&allowSysAdmin, "allow-sys-admin", false, "permit habitat.capabilities.add to grant SYS_ADMIN (strict releases still fail runtime.no_sys_admin)")
 	fs.BoolVar(&asJSON, "json", false, "json output")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	remaining := fs.Args()
 	if len(remaining) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw release <file.claw|capsule_dir> [--strict] [--state-dir=.metaclaw] [--out=dir] [--sign-key=path] [--key-id=id] [--json]")
+		fmt.Fprintln(os.Stderr, "usage: metaclaw release <file.claw|capsule_dir> [--strict] [--require-strict-pass=check1,check2] [--allowed-registries=host,...] [--state-dir=.metaclaw] [--species-file=path] [--out=dir] [--sign-key=path ...] [--key-id=id] [--password] [--tar] [--sbom] [--counter] [--allow-sys-admin] [--json]")
 		return 1
 	}
+	if err := loadSpeciesRegistry(stateDir, speciesFile); err != nil {
+		fmt.Fprintf(os.Stderr, "release failed: %v\n", err)
+		return 1
+	}
+	var signKey string
+	var coSignKeys []string
+	if keys := signKeys.Values(); len(keys) > 0 {
+		signKey, coSignKeys = keys[0], keys[1:]
+	}
 
 	res, err := release.Create(release.CreateOptions{
-		InputPath:      remaining[0],
-		StateDir:       stateDir,
-		OutputDir:      outDir,
-		Strict:         strict,
-		PrivateKeyPath: signKey,
-		KeyID:          keyID,
+		InputPath:         remaining[0],
+		StateDir:          stateDir,
+		OutputDir:         outDir,
+		Strict:            strict,
+		RequiredChecks:    strings.Split(requireStrictPass, ","),
+		PrivateKeyPath:    signKey,
+		KeyID:             keyID,
+		PackageTarball:    tarball,
+		SBOM:              sbom,
+		CoSignKeys:        coSignKeys,
+		Counter:           counter,
+		EncryptKey:        password,
+		Passphrase:        keyPassphrase,
+		AllowedRegistries: strings.Split(allowedRegistries, ","),
+		AllowSysAdmin:     allowSysAdmin,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "release failed: %v\n", err)
@@ -111,47 +213,94 @@ func runRelease(args []string) int {
 	}
 
 	fmt.Printf("release_dir: %s\n", res.ReleaseDir)
+	if res.TarballPath != "" {
+		fmt.Printf("tarball: %s\n", res.TarballPath)
+	}
+	if res.ReleaseManifest.Artifacts.SBOM != "" {
+		fmt.Printf("sbom: %s\n", filepath.Join(res.ReleaseDir, res.ReleaseManifest.Artifacts.SBOM))
+	}
 	fmt.Printf("release_id: %s\n", res.ReleaseID)
+	if res.Counter > 0 {
+		fmt.Printf("counter: %d\n", res.Counter)
+	}
 	fmt.Printf("capsule_id: %s\n", res.CapsuleID)
 	fmt.Printf("capsule_path: %s\n", res.CapsulePath)
 	fmt.Printf("strict: %v\n", res.StrictEnforced)
+	if len(res.RequiredChecks) > 0 {
+		fmt.Printf("required_checks: %s\n", strings.Join(res.RequiredChecks, ","))
+	}
 	fmt.Printf("sign_key: %s\n", res.PrivateKeyPath)
 	fmt.Printf("public_key: %s\n", res.PublicKeyPath)
 	fmt.Printf("key_id: %s\n", res.ReleaseManifest.Signing.KeyID)
+	if len(coSignKeys) > 0 {
+		fmt.Printf("co_signatures: %d\n", len(coSignKeys))
+	}
 	for _, check := range res.Checks {
-		status := "FAIL"
-		if check.Passed {
-			status = "OK"
-		}
-		fmt.Printf("check[%s]: %s (%s)\n", check.Name, status, check.Details)
+		fmt.Printf("check[%s]: %s (%s)\n", check.Name, strictCheckStatus(check), check.Details)
 	}
 	return 0
 }
 
+func strictCheckStatus(check release.StrictCheck) string {
+	switch {
+	case check.Passed:
+		return "OK"
+	case check.Advisory:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
 func runVerify(args []string) int {
 	args = reorderFlags(args, map[string]bool{
-		"--public-key": true,
+		"--public-key":  true,
+		"--trust-dir":   true,
+		"--threshold":   true,
+		"--min-counter": true,
 	})
 	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
 	var publicKey string
+	var trustDir string
 	var requireRelease bool
 	var asJSON bool
+	var threshold int
+	var minCounter uint64
 	fs.StringVar(&publicKey, "public-key", "", "public key PEM for signature verification override")
+	fs.StringVar(&trustDir, "trust-dir", "", "directory of trusted signer public keys (*.pem); the release must be signed by one of them")
 	fs.BoolVar(&requireRelease, "require-release", false, "fail if input is not a release directory")
+	fs.Uint64Var(&minCounter, "min-counter", 0, "reject releases whose signed release counter is below N")
+	fs.IntVar(&threshold, "threshold", 1, "minimum number of distinct trusted keys that must have signed the release attestation (above 1 requires --trust-dir)")
 	fs.BoolVar(&asJSON, "json", false, "json output")
 	if err := fs.Parse(args); err != nil {
 		return 1
 	}
 	remaining := fs.Args()
-	if len(remaining) != 1 {
-		fmt.Fprintln(os.Stderr, "usage: metaclaw verify <release_dir|capsule_dir> [--public-key=path] [--require-release] [--json]")
+	if len(remaining) != 1 || (publicKey != "" && trustDir != "") || threshold < 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw verify <release_dir|release_tarball|capsule_dir> [--public-key=path | --trust-dir=dir] [--threshold=N] [--min-counter=N] [--require-release] [--json]")
 		return 1
 	}
+	if threshold > 1 && trustDir == "" {
+		fmt.Fprintln(os.Stderr, "verify failed: --threshold above 1 requires --trust-dir")
+		return 1
+	}
+	var trusted []string
+	if trustDir != "" {
+		var err error
+		trusted, err = trustedKeyPaths(trustDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "verify failed: %v\n", err)
+			return 1
+		}
+	}
 
 	res, err := release.Verify(release.VerifyOptions{
 		InputPath:      remaining[0],
 		PublicKeyPath:  publicKey,
 		RequireRelease: requireRelease,
+		TrustedKeys:    trusted,
+		Threshold:      threshold,
+		MinCounter:     minCounter,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "verify failed: %v\n", err)
@@ -169,13 +318,208 @@ func runVerify(args []string) int {
 	}
 	fmt.Printf("capsule_id: %s\n", res.CapsuleID)
 	fmt.Printf("signature_valid: %v\n", res.SignatureValid)
+	if res.Counter > 0 {
+		fmt.Printf("counter: %d\n", res.Counter)
+	}
+	if len(res.Signers) > 0 {
+		fmt.Printf("signers: %s\n", strings.Join(res.Signers, ","))
+	}
 	fmt.Printf("strict_satisfied: %v\n", res.StrictSatisfied)
 	for _, check := range res.Checks {
-		status := "FAIL"
-		if check.Passed {
-			status = "OK"
+		fmt.Printf("check[%s]: %s (%s)\n", check.Name, strictCheckStatus(check), check.Details)
+	}
+	return 0
+}
+
+// keyPassphrase resolves the passphrase of an encrypted signing key from
+// $METACLAW_KEY_PASSPHRASE or a terminal prompt.
+func keyPassphrase(path string, confirm bool) ([]byte, error) {
+	if confirm {
+		return signing.ReadPassphrase("New passphrase for "+path+": ", true)
+	}
+	return signing.ReadPassphrase("Passphrase for "+path+": ", false)
+}
+
+// trustedKeyPaths lists the *.pem files in dir; an empty trust directory is an
+// error rather than a silent fallback to the embedded key.
+func trustedKeyPaths(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read trust dir: %w", err)
+	}
+	paths := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".pem") {
+			paths = append(paths, filepath.Join(dir, e.Name()))
 		}
-		fmt.Printf("check[%s]: %s (%s)\n", check.Name, status, check.Details)
 	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("trust dir %s contains no *.pem public keys", dir)
+	}
+	return paths, nil
+}
+
+func runReleaseSign(args []string) int {
+	args = reorderFlags(args, map[string]bool{
+		"--sign-key": true,
+		"--key-id":   true,
+	})
+	fs := flag.NewFlagSet("release sign", flag.ContinueOnError)
+	var signKey string
+	var keyID string
+	var asJSON bool
+	fs.StringVar(&signKey, "sign-key", "", "ed25519 private key path (PEM PKCS8) of the co-signer")
+	fs.StringVar(&keyID, "key-id", "", "co-signature name override (defaults to the derived key id)")
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 1 || signKey == "" {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw release sign <release-dir> --sign-key=path [--key-id=id] [--json]")
+		return 1
+	}
+	res, err := release.Sign(release.SignOptions{
+		ReleaseDir:     fs.Args()[0],
+		PrivateKeyPath: signKey,
+		KeyID:          keyID,
+		Passphrase:     keyPassphrase,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "release sign failed: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+		return 0
+	}
+	fmt.Printf("release_id: %s\n", res.ReleaseID)
+	fmt.Printf("key_id: %s\n", res.KeyID)
+	fmt.Printf("signature: %s\n", res.SignaturePath)
+	fmt.Printf("public_key: %s\n", res.PublicKeyPath)
 	return 0
 }
+
+func runReleaseResign(args []string) int {
+	args = reorderFlags(args, map[string]bool{
+		"--old-public-key": true,
+		"--new-sign-key":   true,
+		"--key-id":         true,
+	})
+	fs := flag.NewFlagSet("release resign", flag.ContinueOnError)
+	var oldPublicKey string
+	var newSignKey string
+	var keyID string
+	var asJSON bool
+	fs.StringVar(&oldPublicKey, "old-public-key", "", "public key PEM the current signature must verify with")
+	fs.StringVar(&newSignKey, "new-sign-key", "", "ed25519 private key path (PEM PKCS8) to re-sign the release with")
+	fs.StringVar(&keyID, "key-id", "", "signing key identifier override for the new key")
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 1 || oldPublicKey == "" || newSignKey == "" {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw release resign <release-dir> --old-public-key=path --new-sign-key=path [--key-id=id] [--json]")
+		return 1
+	}
+	res, err := release.Resign(release.ResignOptions{
+		ReleaseDir:        fs.Args()[0],
+		OldPublicKeyPath:  oldPublicKey,
+		NewPrivateKeyPath: newSignKey,
+		KeyID:             keyID,
+		Passphrase:        keyPassphrase,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "release resign failed: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Println(string(b))
+		return 0
+	}
+	fmt.Printf("release_id: %s\n", res.ReleaseID)
+	fmt.Printf("old_key_id: %s\n", res.OldKeyID)
+	fmt.Printf("new_key_id: %s\n", res.NewKeyID)
+	fmt.Printf("public_key: %s\n", res.PublicKeyPath)
+	if res.DroppedCoSignatures > 0 {
+		fmt.Printf("dropped_co_signatures: %d (co-signers must run release sign again)\n", res.DroppedCoSignatures)
+	}
+	return 0
+}
+
+func runReleaseList(args []string) int {
+	args = reorderFlags(args, map[string]bool{"--state-dir": true})
+	fs := flag.NewFlagSet("release list", flag.ContinueOnError)
+	var stateDir string
+	var asJSON bool
+	fs.StringVar(&stateDir, "state-dir", ".metaclaw", "state directory")
+	fs.BoolVar(&asJSON, "json", false, "json output")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if len(fs.Args()) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw release list [--state-dir=.metaclaw] [--json]")
+		return 1
+	}
+
+	items, err := discoverReleases(filepath.Join(stateDir, "releases"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "release list failed: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		b, _ := json.MarshalIndent(items, "", "  ")
+		fmt.Println(string(b))
+		return 0
+	}
+	for _, it := range items {
+		fmt.Printf("%s\t%s\t%s\tstrict=%v\t%s\n", it.ReleaseID, it.CapsuleID, it.CreatedAt.Format(time.RFC3339), it.Strict, it.KeyID)
+	}
+	return 0
+}
+
+// discoverReleases reads release.json from every rel_* directory under root,
+// newest first. A missing root yields an empty list.
+func discoverReleases(root string) ([]releaseListItem, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []releaseListItem{}, nil
+		}
+		return nil, err
+	}
+	items := make([]releaseListItem, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "rel_") {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		b, err := os.ReadFile(filepath.Join(dir, "release.json"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: skipping release %s: %v\n", dir, err)
+			continue
+		}
+		var rel release.ReleaseManifest
+		if err := json.Unmarshal(b, &rel); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: skipping invalid release %s: %v\n", dir, err)
+			continue
+		}
+		createdAt, _ := time.Parse(time.RFC3339Nano, rel.CreatedAt)
+		items = append(items, releaseListItem{
+			ReleaseID: rel.ReleaseID,
+			CapsuleID: rel.Capsule.ID,
+			CreatedAt: createdAt,
+			Strict:    rel.Strict,
+			KeyID:     rel.Signing.KeyID,
+			Path:      dir,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].ReleaseID < items[j].ReleaseID
+	})
+	return items, nil
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/fpp-125/metaclaw/internal/signing"
 )
 
 const cliPinnedImage = "alpine:3.20@sha256:a4f4213abb84c497377b8544c81b3564f313746700372ec4fe84653e4fb03805"
@@ -28,7 +30,7 @@ func TestRunKeygenReleaseVerify(t *testing.T) {
 	}
 
 	out := filepath.Join(root, "out")
-	if code := runRelease([]string{claw, "--out", out, "--strict", "--sign-key", priv}); code != 0 {
+	if code := runRelease([]string{claw, "--out", out, "--state-dir", filepath.Join(root, ".metaclaw"), "--strict", "--sign-key", priv}); code != 0 {
 		t.Fatalf("runRelease code=%d", code)
 	}
 
@@ -62,7 +64,7 @@ func TestRunReleaseStrictRejectsNetworkAll(t *testing.T) {
 	}
 
 	out := filepath.Join(root, "out")
-	if code := runRelease([]string{claw, "--out", out, "--strict", "--sign-key", priv}); code == 0 {
+	if code := runRelease([]string{claw, "--out", out, "--state-dir", filepath.Join(root, ".metaclaw"), "--strict", "--sign-key", priv}); code == 0 {
 		t.Fatal("expected strict release failure")
 	}
 }
@@ -90,3 +92,64 @@ agent:
     - echo "ok"
 `, networkMode, vaultPath, cliPinnedImage)
 }
+
+func TestDerivePublicKeyMatchesKeygen(t *testing.T) {
+	root := t.TempDir()
+	priv := filepath.Join(root, "k.priv.pem")
+	pub := filepath.Join(root, "k.pub.pem")
+	if code := runKeygen([]string{"--private-key", priv, "--public-key", pub}); code != 0 {
+		t.Fatalf("runKeygen code=%d", code)
+	}
+	want, err := os.ReadFile(pub)
+	if err != nil {
+		t.Fatalf("read public key: %v", err)
+	}
+	got, keyID, err := derivePublicKey(priv)
+	if err != nil {
+		t.Fatalf("derivePublicKey() error = %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("derived public key differs from keygen output:\n%s\nvs\n%s", got, want)
+	}
+	pubKey, err := signing.LoadPublicKeyPEM(pub)
+	if err != nil {
+		t.Fatalf("load public key: %v", err)
+	}
+	if keyID != signing.KeyIDFromPublicKey(pubKey) {
+		t.Fatalf("key id = %q, want %q", keyID, signing.KeyIDFromPublicKey(pubKey))
+	}
+}
+
+func TestDiscoverReleasesNewestFirst(t *testing.T) {
+	root := t.TempDir()
+	for id, createdAt := range map[string]string{
+		"old": "2026-01-01T00:00:00Z",
+		"new": "2026-03-01T00:00:00Z",
+	} {
+		dir := filepath.Join(root, "rel_"+id)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		body := fmt.Sprintf(`{"releaseId":%q,"createdAt":%q,"strict":true,"capsule":{"id":"cap-%s"},"signing":{"keyId":"ed25519:k"}}`, id, createdAt, id)
+		if err := os.WriteFile(filepath.Join(dir, "release.json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "rel_broken"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := discoverReleases(root)
+	if err != nil {
+		t.Fatalf("discoverReleases() error = %v", err)
+	}
+	if len(items) != 2 || items[0].ReleaseID != "new" || items[1].ReleaseID != "old" {
+		t.Fatalf("unexpected releases: %+v", items)
+	}
+	if items[0].CapsuleID != "cap-new" || !items[0].Strict || items[0].KeyID != "ed25519:k" {
+		t.Fatalf("unexpected release fields: %+v", items[0])
+	}
+	if items, err := discoverReleases(filepath.Join(root, "missing")); err != nil || len(items) != 0 {
+		t.Fatalf("missing root: items=%v err=%v", items, err)
+	}
+}
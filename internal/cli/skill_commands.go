@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fpp-125/metaclaw/internal/locks"
+)
+
+func runSkill(args []string) int {
+	if len(args) == 0 {
+		printSkillUsage()
+		return 1
+	}
+	switch args[0] {
+	case "digest":
+		return runSkillDigest(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown skill subcommand: %s\n", args[0])
+		printSkillUsage()
+		return 1
+	}
+}
+
+func printSkillUsage() {
+	fmt.Print(`metaclaw skill commands:
+  skill digest <skill-path> [--verify=sha256:...]
+`)
+}
+
+// runSkillDigest prints the digest compile records for a skill in
+// deps.lock.json, i.e. the value a clawfile's skills[].digest must carry.
+// With --verify it exits non-zero when the skill does not hash to the
+// expected value.
+func runSkillDigest(args []string) int {
+	args = reorderFlags(args, map[string]bool{"--verify": true})
+	fs := flag.NewFlagSet("skill digest", flag.ContinueOnError)
+	var expected string
+	fs.StringVar(&expected, "verify", "", "expected digest (sha256:...); exit 1 if the skill hashes differently")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	remaining := fs.Args()
+	if len(remaining) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: metaclaw skill digest <skill-path> [--verify=sha256:...]")
+		return 1
+	}
+	d, err := locks.SkillDigest(remaining[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "skill digest failed: %v\n", err)
+		return 1
+	}
+	fmt.Println(d)
+	if expected == "" {
+		return 0
+	}
+	if err := checkSkillDigest(d, expected); err != nil {
+		fmt.Fprintf(os.Stderr, "skill digest failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// checkSkillDigest compares a computed digest with the expected one. The
+// sha256: prefix is optional on expected and hex case is ignored.
+func checkSkillDigest(got, expected string) error {
+	want := strings.ToLower(strings.TrimSpace(expected))
+	if !strings.HasPrefix(want, "sha256:") {
+		want = "sha256:" + want
+	}
+	if got != want {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", want, got)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"github.com/fpp-125/metaclaw/internal/locks"
+)
+
+func TestSkillDigestMatchesDepsLock(t *testing.T) {
+	root := t.TempDir()
+	skillDir := filepath.Join(root, "skill")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "run.sh"), []byte("#!/bin/sh\necho hi\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := v1.Clawfile{Agent: v1.AgentSpec{Skills: []v1.SkillRef{{Path: "skill"}}}}
+	bundle, err := locks.Generate(cfg, filepath.Join(root, "agent.claw"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := bundle.Deps
+	got, err := locks.SkillDigest(skillDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != deps.Skills[0].Digest {
+		t.Fatalf("skill digest %s differs from deps lock %s", got, deps.Skills[0].Digest)
+	}
+
+	if err := checkSkillDigest(got, got); err != nil {
+		t.Fatalf("checkSkillDigest() error = %v", err)
+	}
+	if err := checkSkillDigest(got, strings.ToUpper(strings.TrimPrefix(got, "sha256:"))); err != nil {
+		t.Fatalf("bare upper-case hex should match: %v", err)
+	}
+	if err := checkSkillDigest(got, "sha256:"+strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected mismatch error, got %v", err)
+	}
+	if code := runSkillDigest([]string{skillDir, "--verify=sha256:" + strings.Repeat("0", 64)}); code != 1 {
+		t.Fatalf("runSkillDigest --verify mismatch exit = %d, want 1", code)
+	}
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/fpp-125/metaclaw/internal/capability"
+	"github.com/fpp-125/metaclaw/internal/claw/parse"
+)
+
+type skillsNetworkReport struct {
+	capability.Requirements
+	AgentNetwork string   `json:"agentNetwork"`
+	Unresolved   []string `json:"unresolved"`
+	Satisfied    bool     `json:"satisfied"`
+	Unsatisfied  []string `json:"unsatisfied"`
+}
+
+// buildSkillsNetworkReport loads every path-based skill contract of the
+// clawfile and checks their combined demands against the agent grants. Skills
+// referenced by id have no local contract and are listed as unresolved.
+func buildSkillsNetworkReport(clawPath string) (skillsNetworkReport, error) {
+	cfg, err := parse.File(clawPath)
+	if err != nil {
+		return skillsNetworkReport{}, err
+	}
+	baseDir := filepath.Dir(clawPath)
+	var contracts []capability.Contract
+	unresolved := []string{}
+	for _, s := range cfg.Agent.Skills {
+		if s.Path == "" {
+			unresolved = append(unresolved, s.ID)
+			continue
+		}
+		resolved := s.Path
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(baseDir, s.Path)
+		}
+		c, _, err := capability.LoadFromSkillPath(resolved)
+		if err != nil {
+			return skillsNetworkReport{}, fmt.Errorf("skill %s: %w", s.Path, err)
+		}
+		contracts = append(contracts, c)
+	}
+	agentNetwork := strings.TrimSpace(cfg.Agent.Habitat.Network.Mode)
+	if agentNetwork == "" {
+		agentNetwork = "none"
+	}
+	req := capability.Combine(contracts)
+	unsatisfied := req.Unsatisfied(cfg.Agent)
+	return skillsNetworkReport{
+		Requirements: req,
+		AgentNetwork: agentNetwork,
+		Unresolved:   unresolved,
+		Satisfied:    len(unsatisfied) == 0,
+		Unsatisfied:  unsatisfied,
+	}, nil
+}
+
+func writeSkillsNetworkReport(w io.Writer, r skillsNetworkReport) {
+	fmt.Fprintf(w, "skills: %s\n", orDash(strings.Join(r.Skills, ", ")))
+	if len(r.Unresolved) > 0 {
+		fmt.Fprintf(w, "unresolved (id-based, not checked): %s\n", strings.Join(r.Unresolved, ", "))
+	}
+	fmt.Fprintf(w, "network: %s (agent grants %s)\n", r.Network, r.AgentNetwork)
+	mounts := make([]string, 0, len(r.Mounts))
+	for _, m := range r.Mounts {
+		desc := m.Target + " " + m.Access
+		if m.Required {
+			desc += " required"
+		}
+		mounts = append(mounts, desc)
+	}
+	fmt.Fprintf(w, "mounts: %s\n", orDash(strings.Join(mounts, ", ")))
+	fmt.Fprintf(w, "env: %s\n", orDash(strings.Join(r.Env, ", ")))
+	fmt.Fprintf(w, "secrets: %s\n", orDash(strings.Join(r.Secrets, ", ")))
+	if r.Satisfied {
+		fmt.Fprintln(w, "skills network check: OK")
+		return
+	}
+	fmt.Fprintln(w, "skills network check: UNSATISFIED")
+	for _, reason := range r.Unsatisfied {
+		fmt.Fprintf(w, "  - %s\n", reason)
+	}
+}
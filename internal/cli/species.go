@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/fpp-125/metaclaw/internal/claw/validate"
+)
+
+// loadSpeciesRegistry registers custom species before a clawfile is
+// validated. An explicit --species-file must exist; otherwise
+// <stateDir>/species.yaml is read when present.
+func loadSpeciesRegistry(stateDir, speciesFile string) error {
+	if speciesFile != "" {
+		return validate.RegisterSpeciesFile(speciesFile)
+	}
+	if stateDir == "" {
+		return nil
+	}
+	path := filepath.Join(stateDir, validate.SpeciesFile)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return validate.RegisterSpeciesFile(path)
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"github.com/fpp-125/metaclaw/internal/claw/validate"
+)
+
+func TestLoadSpeciesRegistry(t *testing.T) {
+	stateDir := t.TempDir()
+	if err := loadSpeciesRegistry(stateDir, ""); err != nil {
+		t.Fatalf("missing state-dir species file should be ignored: %v", err)
+	}
+	if err := loadSpeciesRegistry(stateDir, filepath.Join(stateDir, "nope.yaml")); err == nil {
+		t.Fatal("expected an explicit --species-file that does not exist to fail")
+	}
+	body := "species:\n  - name: cli-state-test\n    defaultImage: alpine:3.20@sha256:a4f4213abb84c497377b8544c81b3564f313746700372ec4fe84653e4fb03805\n    defaultCPU: \"1\"\n    defaultMemory: 1g\n"
+	if err := os.WriteFile(filepath.Join(stateDir, validate.SpeciesFile), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadSpeciesRegistry(stateDir, ""); err != nil {
+		t.Fatalf("loadSpeciesRegistry() error = %v", err)
+	}
+	if !v1.Species("cli-state-test").Valid() {
+		t.Fatal("expected species from <state-dir>/species.yaml to be registered")
+	}
+}
@@ -86,11 +86,11 @@ func runWizard(args []string) int {
 	lifecycle := string(opts.Lifecycle)
 	fs.StringVar(&lifecycle, "lifecycle", lifecycle, "agent lifecycle (ephemeral|daemon|debug)")
 	runtimeTarget := string(opts.RuntimeTarget)
-	fs.StringVar(&runtimeTarget, "runtime", runtimeTarget, "runtime target override in clawfile (podman|apple_container|docker)")
+	fs.StringVar(&runtimeTarget, "runtime", runtimeTarget, "runtime target override in clawfile (podman|apple_container|docker|nerdctl)")
 	provider := string(opts.LLMProvider)
-	fs.StringVar(&provider, "provider", provider, "llm provider (gemini_openai|openai_compatible|none)")
+	fs.StringVar(&provider, "provider", provider, "llm provider (gemini_openai|openai_compatible|anthropic|none)")
 	fs.StringVar(&opts.LLMModel, "model", opts.LLMModel, "llm model name")
-	fs.StringVar(&opts.LLMBaseURL, "base-url", opts.LLMBaseURL, "llm base URL (optional; for openai_compatible endpoints)")
+	fs.StringVar(&opts.LLMBaseURL, "base-url", opts.LLMBaseURL, "llm base URL (optional; for openai_compatible and anthropic endpoints)")
 	fs.StringVar(&opts.LLMAPIKeyEnv, "api-key-env", opts.LLMAPIKeyEnv, "host env variable used for runtime key injection")
 	fs.BoolVar(&opts.LLMFlagDisabled, "llm-disabled", false, "disable llm contract in scaffold")
 	fs.StringVar(&opts.DefaultImage, "species-image", opts.DefaultImage, "runtime image (must be digest-pinned)")
@@ -104,6 +104,19 @@ func runWizard(args []string) int {
 	}
 
 	modeInteractive := len(rawArgs) == 0 || opts.InteractiveExplicit
+	if !modeInteractive && hasFlagToken(rawArgs, "--provider", "-provider") && v1.LLMProvider(strings.TrimSpace(provider)) != v1.LLMProviderGeminiOpenAI {
+		// The flag defaults target gemini_openai; replace the ones not set explicitly.
+		model, baseURL, apiKeyEnv := wizardProviderDefaults(v1.LLMProvider(strings.TrimSpace(provider)))
+		if !hasFlagToken(rawArgs, "--model", "-model") && model != "" {
+			opts.LLMModel = model
+		}
+		if !hasFlagToken(rawArgs, "--base-url", "-base-url") {
+			opts.LLMBaseURL = baseURL
+		}
+		if !hasFlagToken(rawArgs, "--api-key-env", "-api-key-env") {
+			opts.LLMAPIKeyEnv = apiKeyEnv
+		}
+	}
 	if modeInteractive {
 		var err error
 		opts, err = collectWizardInteractiveOptions(opts)
@@ -145,7 +158,7 @@ func runWizard(args []string) int {
 	}
 	opts.RuntimeTarget = v1.RuntimeTarget(strings.TrimSpace(runtimeTarget))
 	if !opts.RuntimeTarget.Valid() {
-		fmt.Fprintln(os.Stderr, "wizard failed: --runtime must be podman|apple_container|docker")
+		fmt.Fprintln(os.Stderr, "wizard failed: --runtime must be podman|apple_container|docker|nerdctl")
 		return 1
 	}
 	opts.LLMEnabled = !opts.LLMFlagDisabled
@@ -320,7 +333,7 @@ func normalizeWizardLLM(opts *wizardOptions, providerRaw string) error {
 		return nil
 	}
 	if !provider.Valid() || provider == "" {
-		return fmt.Errorf("--provider must be gemini_openai|openai_compatible|none")
+		return fmt.Errorf("--provider must be gemini_openai|openai_compatible|anthropic|none")
 	}
 	opts.LLMProvider = provider
 
@@ -343,6 +356,13 @@ func normalizeWizardLLM(opts *wizardOptions, providerRaw string) error {
 		if opts.LLMAPIKeyEnv == "" {
 			opts.LLMAPIKeyEnv = "OPENAI_API_KEY"
 		}
+	case v1.LLMProviderAnthropic:
+		if opts.LLMBaseURL == "" {
+			opts.LLMBaseURL = "https://api.anthropic.com"
+		}
+		if opts.LLMAPIKeyEnv == "" {
+			opts.LLMAPIKeyEnv = "ANTHROPIC_API_KEY"
+		}
 	}
 	if !wizardEnvNameRef.MatchString(opts.LLMAPIKeyEnv) {
 		return fmt.Errorf("--api-key-env must be a valid environment variable name")
@@ -462,7 +482,7 @@ func collectWizardInteractiveOptions(base wizardOptions) (wizardOptions, error)
 		return wizardOptions{}, err
 	}
 
-	runtimeChoice, err := promptChoice(reader, "Runtime target", []string{"auto", "podman", "apple_container", "docker"}, "auto")
+	runtimeChoice, err := promptChoice(reader, "Runtime target", []string{"auto", "podman", "apple_container", "docker", "nerdctl"}, "auto")
 	if err != nil {
 		return wizardOptions{}, err
 	}
@@ -476,25 +496,26 @@ func collectWizardInteractiveOptions(base wizardOptions) (wizardOptions, error)
 		return wizardOptions{}, err
 	}
 	if opts.LLMEnabled {
-		providerRaw, err := promptChoice(reader, "LLM provider", []string{"gemini_openai", "openai_compatible"}, string(opts.LLMProvider))
+		providerRaw, err := promptChoice(reader, "LLM provider", []string{"gemini_openai", "openai_compatible", "anthropic"}, string(opts.LLMProvider))
 		if err != nil {
 			return wizardOptions{}, err
 		}
+		if v1.LLMProvider(providerRaw) != opts.LLMProvider {
+			// The incoming defaults belong to the previous provider.
+			var model string
+			model, opts.LLMBaseURL, opts.LLMAPIKeyEnv = wizardProviderDefaults(v1.LLMProvider(providerRaw))
+			if model != "" {
+				opts.LLMModel = model
+			}
+		}
 		opts.LLMProvider = v1.LLMProvider(providerRaw)
 		if opts.LLMModel, err = promptString(reader, "LLM model", opts.LLMModel); err != nil {
 			return wizardOptions{}, err
 		}
-		baseURLDefault := strings.TrimSpace(opts.LLMBaseURL)
-		if opts.LLMProvider == v1.LLMProviderOpenAICompatible && baseURLDefault == "" {
-			baseURLDefault = "https://api.openai.com/v1"
-		}
-		if opts.LLMBaseURL, err = promptString(reader, "LLM base URL", baseURLDefault); err != nil {
+		if opts.LLMBaseURL, err = promptString(reader, "LLM base URL", strings.TrimSpace(opts.LLMBaseURL)); err != nil {
 			return wizardOptions{}, err
 		}
 		apiEnvDefault := strings.TrimSpace(opts.LLMAPIKeyEnv)
-		if opts.LLMProvider == v1.LLMProviderOpenAICompatible && apiEnvDefault == "" {
-			apiEnvDefault = "OPENAI_API_KEY"
-		}
 		if opts.LLMAPIKeyEnv, err = promptString(reader, "API key env var", apiEnvDefault); err != nil {
 			return wizardOptions{}, err
 		}
@@ -572,6 +593,21 @@ func promptBool(reader *bufio.Reader, label string, defaultValue bool) (bool, er
 	}
 }
 
+// wizardProviderDefaults returns the model, base URL and API key env the
+// wizard suggests for provider. An empty model keeps the current one.
+func wizardProviderDefaults(provider v1.LLMProvider) (model, baseURL, apiKeyEnv string) {
+	switch provider {
+	case v1.LLMProviderGeminiOpenAI:
+		return "gemini-2.5-pro", "https://generativelanguage.googleapis.com/v1beta/openai/", "GEMINI_API_KEY"
+	case v1.LLMProviderOpenAICompatible:
+		return "", "https://api.openai.com/v1", "OPENAI_API_KEY"
+	case v1.LLMProviderAnthropic:
+		return "claude-sonnet-4-5", "https://api.anthropic.com", "ANTHROPIC_API_KEY"
+	default:
+		return "", "", ""
+	}
+}
+
 func hasFlagToken(args []string, names ...string) bool {
 	for _, token := range args {
 		for _, name := range names {
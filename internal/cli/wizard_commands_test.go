@@ -85,6 +85,34 @@ func TestRunWizardProviderNoneDisablesLLMBlock(t *testing.T) {
 	}
 }
 
+func TestRunWizardProviderAnthropicDefaults(t *testing.T) {
+	root := t.TempDir()
+	out := filepath.Join(root, "anthropic.claw")
+	vault := filepath.Join(root, "vault")
+
+	code := runWizard([]string{
+		"--out", out,
+		"--vault", vault,
+		"--provider", "anthropic",
+	})
+	if code != 0 {
+		t.Fatalf("runWizard() code = %d, want 0", code)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read generated clawfile: %v", err)
+	}
+	text := string(b)
+	for _, want := range []string{"provider: anthropic", "baseURL: https://api.anthropic.com", "apiKeyEnv: ANTHROPIC_API_KEY", "model: claude-"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in output: %s", want, text)
+		}
+	}
+	if strings.Contains(text, "generativelanguage") || strings.Contains(text, "GEMINI_API_KEY") {
+		t.Fatalf("did not expect gemini defaults for anthropic provider: %s", text)
+	}
+}
+
 func TestRunWizardRejectsBadRuntime(t *testing.T) {
 	root := t.TempDir()
 	out := filepath.Join(root, "bad-runtime.claw")
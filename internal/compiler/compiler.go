@@ -2,6 +2,10 @@ package compiler
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/fpp-125/metaclaw/internal/capsule"
 	"github.com/fpp-125/metaclaw/internal/claw/parse"
@@ -12,26 +16,81 @@ import (
 )
 
 type Result struct {
-	Config  v1.Clawfile
-	Policy  policy.Policy
-	Locks   locks.BundleLocks
-	Capsule capsule.Capsule
+	Config   v1.Clawfile
+	Policy   policy.Policy
+	Locks    locks.BundleLocks
+	Capsule  capsule.Capsule
+	Warnings []validate.Warning
 }
 
 func LoadNormalize(path string) (v1.Clawfile, error) {
+	n, _, err := LoadNormalizeWithWarnings(path)
+	return n, err
+}
+
+func LoadNormalizeWithWarnings(path string) (v1.Clawfile, []validate.Warning, error) {
+	return LoadNormalizeWithOptions(path, Options{})
+}
+
+// LoadNormalizeWithOptions parses and validates path; only the validation
+// options (skill registry, registry allowlist, mount checks) are consulted
+// from opts.
+func LoadNormalizeWithOptions(path string, opts Options) (v1.Clawfile, []validate.Warning, error) {
 	cfg, err := parse.File(path)
 	if err != nil {
-		return v1.Clawfile{}, err
+		return v1.Clawfile{}, nil, err
 	}
-	n, err := validate.NormalizeAndValidate(cfg, path)
+	return validate.NormalizeAndValidateWithOptions(cfg, path, opts.validateOptions())
+}
+
+// LoadNormalizeReport is LoadNormalizeWithOptions reporting parse and
+// validation failures in the report instead of as an error.
+func LoadNormalizeReport(path string, opts Options) validate.ValidationReport {
+	cfg, err := parse.File(path)
 	if err != nil {
-		return v1.Clawfile{}, err
+		return validate.ValidationReport{Errors: []string{err.Error()}, Warnings: []validate.Warning{}}
 	}
-	return n, nil
+	return validate.NormalizeAndValidateReport(cfg, path, opts.validateOptions())
 }
 
+// Options tunes compilation. An empty HashCacheDir disables the source hash
+// cache; SkillRegistry points id-based skills at a local registry directory;
+// AllowedRegistries restricts the runtime image to those registries;
+// CheckMounts and AllowMissingMounts stat habitat mount sources on this host.
+// ResolveImageDigest, when set, looks up the manifest digest of the runtime
+// image (given the image ref and the clawfile's runtime target) so the image
+// lock records it instead of a hash of the reference string. AllowSysAdmin
+// lets habitat.capabilities.add grant SYS_ADMIN.
+type Options struct {
+	HashCacheDir       string
+	SkillRegistry      string
+	AllowedRegistries  []string
+	CheckMounts        bool
+	AllowMissingMounts bool
+	ResolveImageDigest func(image, target string) (string, error)
+	AllowSysAdmin      bool
+}
+
+func (o Options) validateOptions() validate.Options {
+	return validate.Options{
+		SkillRegistry:      o.SkillRegistry,
+		AllowedRegistries:  o.AllowedRegistries,
+		CheckMounts:        o.CheckMounts,
+		AllowMissingMounts: o.AllowMissingMounts,
+		AllowSysAdmin:      o.AllowSysAdmin,
+	}
+}
+
+// WarnImageDigestUnresolved is reported when ResolveImageDigest fails and the
+// image lock falls back to hashing the reference string.
+const WarnImageDigestUnresolved = "image_digest_unresolved"
+
 func Compile(path string, outputDir string) (Result, error) {
-	normalized, err := LoadNormalize(path)
+	return CompileWithOptions(path, outputDir, Options{})
+}
+
+func CompileWithOptions(path string, outputDir string, opts Options) (Result, error) {
+	normalized, warnings, err := LoadNormalizeWithOptions(path, opts)
 	if err != nil {
 		return Result{}, err
 	}
@@ -39,12 +98,41 @@ func Compile(path string, outputDir string) (Result, error) {
 	if err != nil {
 		return Result{}, err
 	}
-	lk, err := locks.Generate(normalized, path, outputDir)
+	var lockOpts locks.Options
+	if w := resolveImageDigest(normalized, opts, &lockOpts); w != nil {
+		warnings = append(warnings, *w)
+	}
+	var cache *locks.FileHashCache
+	if opts.HashCacheDir != "" {
+		// The cache is an optimization only; fall back to full hashing if it can't be opened.
+		if c, err := locks.OpenFileHashCache(opts.HashCacheDir); err == nil {
+			cache = c
+			lockOpts.HashCache = c
+		}
+	}
+	lk, err := locks.GenerateWithOptions(normalized, path, outputDir, lockOpts)
 	if err != nil {
 		return Result{}, err
 	}
+	if cache != nil {
+		_ = cache.Save()
+	}
 
-	ir := map[string]any{
+	ir := buildIR(normalized)
+
+	source, err := yaml.Marshal(normalized)
+	if err != nil {
+		return Result{}, fmt.Errorf("marshal normalized clawfile: %w", err)
+	}
+	cap, err := capsule.Write(outputDir, path, source, ir, pol, lk)
+	if err != nil {
+		return Result{}, fmt.Errorf("write capsule: %w", err)
+	}
+	return Result{Config: normalized, Policy: pol, Locks: lk, Capsule: cap, Warnings: warnings}, nil
+}
+
+func buildIR(normalized v1.Clawfile) map[string]any {
+	return map[string]any{
 		"version":  "metaclaw.ir/v1",
 		"clawfile": normalized,
 		"runtime": map[string]any{
@@ -54,10 +142,107 @@ func Compile(path string, outputDir string) (Result, error) {
 		// Keep this stable so absolute vs relative compile paths produce identical capsules.
 		"sourceRoot": ".",
 	}
+}
 
-	cap, err := capsule.Write(outputDir, path, ir, pol, lk)
+// EmitIR validates path and returns the IR bytes CompileWithOptions would
+// write to the capsule's ir.json, without hashing sources or writing files.
+func EmitIR(path string, opts Options) ([]byte, []validate.Warning, error) {
+	normalized, warnings, err := LoadNormalizeWithOptions(path, opts)
 	if err != nil {
-		return Result{}, fmt.Errorf("write capsule: %w", err)
+		return nil, nil, err
+	}
+	if _, err := policy.Compile(normalized); err != nil {
+		return nil, nil, err
+	}
+	b, err := capsule.MarshalIR(buildIR(normalized))
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal ir: %w", err)
+	}
+	return b, warnings, nil
+}
+
+// LocksResult is the outcome of CompileLocks.
+type LocksResult struct {
+	Config   v1.Clawfile
+	Locks    locks.BundleLocks
+	Paths    []string
+	Warnings []validate.Warning
+}
+
+// CompileLocks validates path and writes only deps/image/source locks into
+// outputDir, skipping policy compilation and capsule assembly. Lock files
+// written inside the source tree are left out of the source lock so that
+// regenerating them is stable.
+func CompileLocks(path string, outputDir string, opts Options) (LocksResult, error) {
+	normalized, warnings, err := LoadNormalizeWithOptions(path, opts)
+	if err != nil {
+		return LocksResult{}, err
+	}
+	if outputDir == "" {
+		outputDir = "."
+	}
+	var lockOpts locks.Options
+	if rel, ok := relInside(filepath.Dir(path), outputDir); ok {
+		for _, name := range []string{locks.DepsLockFile, locks.ImageLockFile, locks.SourceLockFile} {
+			lockOpts.Exclude = append(lockOpts.Exclude, filepath.ToSlash(filepath.Join(rel, name)))
+		}
+	}
+	if w := resolveImageDigest(normalized, opts, &lockOpts); w != nil {
+		warnings = append(warnings, *w)
+	}
+	var cache *locks.FileHashCache
+	if opts.HashCacheDir != "" {
+		if c, err := locks.OpenFileHashCache(opts.HashCacheDir); err == nil {
+			cache = c
+			lockOpts.HashCache = c
+		}
+	}
+	lk, err := locks.GenerateWithOptions(normalized, path, outputDir, lockOpts)
+	if err != nil {
+		return LocksResult{}, err
+	}
+	if cache != nil {
+		_ = cache.Save()
+	}
+	paths, err := capsule.WriteLocks(outputDir, lk)
+	if err != nil {
+		return LocksResult{}, err
+	}
+	return LocksResult{Config: normalized, Locks: lk, Paths: paths, Warnings: warnings}, nil
+}
+
+// resolveImageDigest fills lockOpts.ImageDigest through opts.ResolveImageDigest.
+// A failed lookup is not fatal: the lock keeps the reference-string hash and
+// the returned warning says so.
+func resolveImageDigest(cfg v1.Clawfile, opts Options, lockOpts *locks.Options) *validate.Warning {
+	if opts.ResolveImageDigest == nil {
+		return nil
+	}
+	d, err := opts.ResolveImageDigest(cfg.Agent.Runtime.Image, string(cfg.Agent.Runtime.Target))
+	if err != nil {
+		return &validate.Warning{
+			Code:    WarnImageDigestUnresolved,
+			Message: fmt.Sprintf("could not resolve the image manifest digest (%v); image lock falls back to hashing the reference", err),
+			Field:   "agent.runtime.image",
+		}
+	}
+	lockOpts.ImageDigest = d
+	return nil
+}
+
+// relInside returns target relative to root when target is root or below it.
+func relInside(root, target string) (string, bool) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", false
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(absRoot, absTarget)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
 	}
-	return Result{Config: normalized, Policy: pol, Locks: lk, Capsule: cap}, nil
+	return rel, true
 }
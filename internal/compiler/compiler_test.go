@@ -1,8 +1,10 @@
 package compiler
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -128,3 +130,170 @@ agent:
 		t.Fatalf("expected identical capsule id for absolute vs relative compile paths: abs=%s rel=%s", absRes.Capsule.ID, relRes.Capsule.ID)
 	}
 }
+
+func TestCompileLocksIsStableInSourceRoot(t *testing.T) {
+	root := t.TempDir()
+	claw := filepath.Join(root, "agent.claw")
+	content := `apiVersion: metaclaw/v1
+kind: Agent
+agent:
+  name: hello
+  species: nano
+  command: ["sh", "-lc", "echo hello"]
+`
+	if err := os.WriteFile(claw, []byte(content), 0o644); err != nil {
+		t.Fatalf("write clawfile: %v", err)
+	}
+	res1, err := CompileLocks(claw, root, Options{})
+	if err != nil {
+		t.Fatalf("CompileLocks #1 failed: %v", err)
+	}
+	if len(res1.Paths) != 3 {
+		t.Fatalf("unexpected lock paths: %v", res1.Paths)
+	}
+	first, err := os.ReadFile(filepath.Join(root, "source.lock.json"))
+	if err != nil {
+		t.Fatalf("read source lock: %v", err)
+	}
+	if _, err := CompileLocks(claw, root, Options{}); err != nil {
+		t.Fatalf("CompileLocks #2 failed: %v", err)
+	}
+	second, _ := os.ReadFile(filepath.Join(root, "source.lock.json"))
+	if string(first) != string(second) {
+		t.Fatalf("source lock changed after regenerating in place:\n%s\n%s", first, second)
+	}
+	entries, _ := os.ReadDir(root)
+	for _, e := range entries {
+		if e.IsDir() {
+			t.Fatalf("lock-only compile created a directory: %s", e.Name())
+		}
+	}
+}
+
+func TestCompileResolveImageDigest(t *testing.T) {
+	claw := filepath.Join("..", "..", "testdata", "hello.claw")
+	manifest := "sha256:" + strings.Repeat("ab", 32)
+	res, err := CompileWithOptions(claw, t.TempDir(), Options{
+		ResolveImageDigest: func(image, target string) (string, error) { return manifest, nil },
+	})
+	if err != nil {
+		t.Fatalf("CompileWithOptions() error = %v", err)
+	}
+	if res.Locks.Image.Digest != manifest || !res.Locks.Image.Resolved {
+		t.Fatalf("unexpected image lock: %+v", res.Locks.Image)
+	}
+
+	res, err = CompileWithOptions(claw, t.TempDir(), Options{
+		ResolveImageDigest: func(image, target string) (string, error) { return "", errors.New("offline") },
+	})
+	if err != nil {
+		t.Fatalf("CompileWithOptions() error = %v", err)
+	}
+	if res.Locks.Image.Resolved || res.Locks.Image.Digest == manifest {
+		t.Fatalf("expected reference-hash fallback, got %+v", res.Locks.Image)
+	}
+	found := false
+	for _, w := range res.Warnings {
+		found = found || w.Code == WarnImageDigestUnresolved
+	}
+	if !found {
+		t.Fatalf("expected %s warning, got %+v", WarnImageDigestUnresolved, res.Warnings)
+	}
+}
+
+func TestEmitIRMatchesCapsuleIR(t *testing.T) {
+	claw := filepath.Join("..", "..", "testdata", "hello.claw")
+	res, err := Compile(claw, t.TempDir())
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	want, err := os.ReadFile(filepath.Join(res.Capsule.Path, "ir.json"))
+	if err != nil {
+		t.Fatalf("read ir.json: %v", err)
+	}
+	got, _, err := EmitIR(claw, Options{})
+	if err != nil {
+		t.Fatalf("EmitIR failed: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("EmitIR output differs from ir.json\nemit: %s\nfile: %s", got, want)
+	}
+}
+
+func TestCompileCapsuleIDIsReproducible(t *testing.T) {
+	root := t.TempDir()
+	for _, d := range []string{"vault", "cache", "notes"} {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatalf("mkdir %s: %v", d, err)
+		}
+	}
+	files := map[string]string{"b.txt": "b", "a.txt": "a", "skills/z/SKILL.md": "z", "skills/a/SKILL.md": "a"}
+	for _, name := range []string{"z", "a"} {
+		files["skills/"+name+"/capability.contract.yaml"] = "apiVersion: metaclaw.capability/v1\n" +
+			"kind: CapabilityContract\n" +
+			"metadata:\n  name: repro." + name + "\n  version: v1.0.0\n" +
+			"permissions:\n  network: none\n"
+	}
+	for f, body := range files {
+		p := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir for %s: %v", f, err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s: %v", f, err)
+		}
+	}
+	// Maps and lists are declared out of order so any serialization that
+	// follows map iteration or input order shows up as a different id.
+	content := `apiVersion: metaclaw/v1
+kind: Agent
+agent:
+  name: repro
+  species: nano
+  lifecycle: daemon
+  habitat:
+    network:
+      mode: outbound
+      allowedDomains: [z.example.com, a.example.com, m.example.com]
+    extraHosts: [zeta:10.0.0.3, alpha:10.0.0.1]
+    ports:
+      - {hostPort: 9090, containerPort: 90}
+      - {hostPort: 8080, containerPort: 80, protocol: udp}
+      - {hostPort: 8080, containerPort: 80}
+    mounts:
+      - {source: ` + filepath.Join(root, "vault") + `, target: /vault, readOnly: true}
+      - {source: ` + filepath.Join(root, "notes") + `, target: /notes}
+      - {source: ` + filepath.Join(root, "cache") + `, target: /cache}
+    env:
+      ZED: "1"
+      ALPHA: "2"
+      MIDDLE: "3"
+      BETA: "4"
+      OMEGA: "5"
+  runtime:
+    capAdd: [NET_RAW, CHOWN, NET_BIND_SERVICE]
+  skills:
+    - path: ./skills/z
+    - path: ./skills/a
+  command: [sh, -lc, echo repro]
+`
+	claw := filepath.Join(root, "agent.claw")
+	if err := os.WriteFile(claw, []byte(content), 0o644); err != nil {
+		t.Fatalf("write clawfile: %v", err)
+	}
+
+	var first Result
+	for i := 0; i < 10; i++ {
+		res, err := Compile(claw, t.TempDir())
+		if err != nil {
+			t.Fatalf("Compile #%d failed: %v", i, err)
+		}
+		if i == 0 {
+			first = res
+			continue
+		}
+		if res.Capsule.ID != first.Capsule.ID {
+			t.Fatalf("compile #%d capsule id %s, want %s (digests %v vs %v)", i, res.Capsule.ID, first.Capsule.ID, res.Capsule.Digests, first.Capsule.Digests)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package llm
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
@@ -69,6 +72,16 @@ func TestResolveAnthropic(t *testing.T) {
 	if res.Env["ANTHROPIC_BASE_URL"] != spec.BaseURL {
 		t.Fatalf("expected ANTHROPIC_BASE_URL mirror, got %q", res.Env["ANTHROPIC_BASE_URL"])
 	}
+	if res.Env["METACLAW_LLM_PROVIDER"] != "anthropic" || res.Env["METACLAW_LLM_BASE_URL"] != spec.BaseURL {
+		t.Fatalf("unexpected metaclaw llm env: %v", res.Env)
+	}
+	if _, ok := res.Env["OPENAI_API_KEY"]; ok {
+		t.Fatal("did not expect OPENAI_API_KEY for anthropic provider")
+	}
+	keys := AllowedEnvKeys(spec)
+	mustContain(t, keys, "ANTHROPIC_API_KEY")
+	mustContain(t, keys, "ANTHROPIC_BASE_URL")
+	mustContain(t, keys, "METACLAW_LLM_PROVIDER")
 }
 
 func TestAllowedEnvKeys(t *testing.T) {
@@ -94,3 +107,44 @@ func mustContain(t *testing.T, list []string, want string) {
 	}
 	t.Fatalf("expected key %q in %v", want, list)
 }
+
+func TestProbeClassifiesModelsResponse(t *testing.T) {
+	var gotPath, gotAuth, gotAnthropicKey string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotAnthropicKey = r.Header.Get("x-api-key")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	openai := v1.LLMSpec{Provider: v1.LLMProviderOpenAICompatible, BaseURL: srv.URL + "/v1/"}
+	for code, want := range map[int]string{
+		http.StatusOK:           ProbeReachable,
+		http.StatusUnauthorized: ProbeUnauthorized,
+		http.StatusForbidden:    ProbeForbidden,
+		http.StatusBadGateway:   ProbeUnexpected,
+	} {
+		status = code
+		res, err := Probe(context.Background(), srv.Client(), openai, "sk-secret")
+		if err != nil {
+			t.Fatalf("Probe() error = %v", err)
+		}
+		if res.Outcome != want || res.HTTPStatus != code {
+			t.Fatalf("Probe() for HTTP %d = %+v, want %s", code, res, want)
+		}
+	}
+	if gotPath != "/v1/models" || gotAuth != "Bearer sk-secret" {
+		t.Fatalf("openai probe hit %s with auth %q", gotPath, gotAuth)
+	}
+
+	status = http.StatusOK
+	anthropic := v1.LLMSpec{Provider: v1.LLMProviderAnthropic, BaseURL: srv.URL}
+	if _, err := Probe(context.Background(), srv.Client(), anthropic, "ant-secret"); err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if gotPath != "/v1/models" || gotAnthropicKey != "ant-secret" || gotAuth != "" {
+		t.Fatalf("anthropic probe hit %s with x-api-key %q auth %q", gotPath, gotAnthropicKey, gotAuth)
+	}
+}
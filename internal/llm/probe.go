@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+)
+
+const (
+	ProbeReachable    = "reachable"
+	ProbeUnauthorized = "unauthorized"
+	ProbeForbidden    = "forbidden"
+	ProbeUnexpected   = "unexpected"
+
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	anthropicAPIVersion  = "2023-06-01"
+)
+
+type ProbeResult struct {
+	Outcome    string
+	HTTPStatus int
+	URL        string
+}
+
+// ModelsURL returns the models-list endpoint for spec, the cheapest
+// authenticated request every supported provider offers.
+func ModelsURL(spec v1.LLMSpec) (string, error) {
+	base := strings.TrimRight(strings.TrimSpace(spec.BaseURL), "/")
+	switch spec.Provider {
+	case v1.LLMProviderOpenAICompatible, v1.LLMProviderGeminiOpenAI:
+		if base == "" {
+			base = defaultOpenAIBaseURL
+		}
+		return base + "/models", nil
+	case v1.LLMProviderAnthropic:
+		if base == "" {
+			base = "https://api.anthropic.com"
+		}
+		if !strings.HasSuffix(base, "/v1") {
+			base += "/v1"
+		}
+		return base + "/models", nil
+	case "":
+		return "", fmt.Errorf("no llm provider configured")
+	}
+	return "", fmt.Errorf("unsupported llm provider %q", spec.Provider)
+}
+
+// Probe lists models at the provider with key and classifies the response.
+// The key only travels in request headers; it never appears in the result or
+// in returned errors. Callers bound the request through ctx.
+func Probe(ctx context.Context, client *http.Client, spec v1.LLMSpec, key string) (ProbeResult, error) {
+	url, err := ModelsURL(spec)
+	if err != nil {
+		return ProbeResult{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ProbeResult{}, fmt.Errorf("build probe request: %w", err)
+	}
+	if spec.Provider == v1.LLMProviderAnthropic {
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", anthropicAPIVersion)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ProbeResult{URL: url}, fmt.Errorf("probe %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	out := ProbeResult{HTTPStatus: resp.StatusCode, URL: url}
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		out.Outcome = ProbeReachable
+	case resp.StatusCode == http.StatusUnauthorized:
+		out.Outcome = ProbeUnauthorized
+	case resp.StatusCode == http.StatusForbidden:
+		out.Outcome = ProbeForbidden
+	default:
+		out.Outcome = ProbeUnexpected
+	}
+	return out, nil
+}
@@ -0,0 +1,162 @@
+package locks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const hashCacheFile = "index.json"
+
+// HashCache lets hashFile skip re-reading files whose size and mtime have not
+// changed since the last compile. WalkRoot announces a source root before its
+// files are looked up, so a cache may forget files that left that root.
+type HashCache interface {
+	WalkRoot(root string)
+	Lookup(path string, info os.FileInfo) (string, bool)
+	Store(path string, info os.FileInfo, sum string)
+}
+
+type hashCacheEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"modTimeUnixNano"`
+	SHA256  string `json:"sha256"`
+}
+
+type hashCacheIndex struct {
+	Version string                    `json:"version"`
+	Entries map[string]hashCacheEntry `json:"entries"`
+}
+
+// FileHashCache is a HashCache persisted as a single JSON index under dir.
+type FileHashCache struct {
+	dir     string
+	mu      sync.Mutex
+	entries map[string]hashCacheEntry
+	used    map[string]bool
+	roots   []string
+	dirty   bool
+	hits    int
+	misses  int
+}
+
+// OpenFileHashCache loads the index under dir. A missing or unreadable index
+// starts an empty cache rather than failing the compile.
+func OpenFileHashCache(dir string) (*FileHashCache, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("hash cache dir is required")
+	}
+	c := &FileHashCache{dir: dir, entries: map[string]hashCacheEntry{}, used: map[string]bool{}}
+	b, err := os.ReadFile(filepath.Join(dir, hashCacheFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("read hash cache: %w", err)
+	}
+	var idx hashCacheIndex
+	if err := json.Unmarshal(b, &idx); err == nil && idx.Version == "metaclaw.hashcache/v1" && idx.Entries != nil {
+		c.entries = idx.Entries
+	}
+	return c, nil
+}
+
+func (c *FileHashCache) WalkRoot(root string) {
+	key := cacheKey(root)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.roots = append(c.roots, key)
+}
+
+func (c *FileHashCache) Lookup(path string, info os.FileInfo) (string, bool) {
+	key := cacheKey(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.Size != info.Size() || e.ModTime != info.ModTime().UnixNano() {
+		c.misses++
+		return "", false
+	}
+	c.used[key] = true
+	c.hits++
+	return e.SHA256, true
+}
+
+func (c *FileHashCache) Store(path string, info os.FileInfo, sum string) {
+	key := cacheKey(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = hashCacheEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), SHA256: sum}
+	c.used[key] = true
+	c.dirty = true
+}
+
+// Stats reports lookups served from the cache and lookups that required a re-hash.
+func (c *FileHashCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Save writes the index if any entry changed. Entries under a walked root
+// that were not looked up or stored are dropped, so files that left a tree do
+// not pile up; entries of other roots sharing the index are kept.
+func (c *FileHashCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if !c.used[key] && c.underWalkedRoot(key) {
+			delete(c.entries, key)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(hashCacheIndex{Version: "metaclaw.hashcache/v1", Entries: c.entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, hashCacheFile+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, hashCacheFile)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *FileHashCache) underWalkedRoot(key string) bool {
+	for _, root := range c.roots {
+		if rel, err := filepath.Rel(root, key); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
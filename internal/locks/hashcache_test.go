@@ -0,0 +1,150 @@
+package locks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileHashCacheHitMissAndInvalidation(t *testing.T) {
+	root := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "hash-cache")
+	file := filepath.Join(root, "notes.md")
+	if err := os.WriteFile(file, []byte("v1\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	cache, err := OpenFileHashCache(cacheDir)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	first, err := buildSourceLock(root, nil, cache)
+	if err != nil {
+		t.Fatalf("buildSourceLock #1: %v", err)
+	}
+	if hits, misses := cache.Stats(); hits != 0 || misses != 1 {
+		t.Fatalf("expected cold miss, got hits=%d misses=%d", hits, misses)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("save cache: %v", err)
+	}
+
+	reopened, err := OpenFileHashCache(cacheDir)
+	if err != nil {
+		t.Fatalf("reopen cache: %v", err)
+	}
+	second, err := buildSourceLock(root, nil, reopened)
+	if err != nil {
+		t.Fatalf("buildSourceLock #2: %v", err)
+	}
+	if hits, misses := reopened.Stats(); hits != 1 || misses != 0 {
+		t.Fatalf("expected persisted hit, got hits=%d misses=%d", hits, misses)
+	}
+	if first.Files[0].SHA256 != second.Files[0].SHA256 {
+		t.Fatalf("cached hash differs: %s vs %s", first.Files[0].SHA256, second.Files[0].SHA256)
+	}
+
+	if err := os.WriteFile(file, []byte("version two\n"), 0o644); err != nil {
+		t.Fatalf("rewrite file: %v", err)
+	}
+	later := time.Now().Add(2 * time.Second)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	third, err := buildSourceLock(root, nil, reopened)
+	if err != nil {
+		t.Fatalf("buildSourceLock #3: %v", err)
+	}
+	if hits, misses := reopened.Stats(); hits != 1 || misses != 1 {
+		t.Fatalf("expected miss after change, got hits=%d misses=%d", hits, misses)
+	}
+	uncached, err := buildSourceLock(root, nil, nil)
+	if err != nil {
+		t.Fatalf("buildSourceLock uncached: %v", err)
+	}
+	if third.Files[0].SHA256 != uncached.Files[0].SHA256 {
+		t.Fatal("expected re-hash after size/mtime change to match uncached digest")
+	}
+}
+
+func TestFileHashCacheSavePrunesUntouchedEntries(t *testing.T) {
+	root := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "hash-cache")
+	for _, name := range []string{"keep.md", "gone.md"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	cache, err := OpenFileHashCache(cacheDir)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	if _, err := buildSourceLock(root, nil, cache); err != nil {
+		t.Fatalf("buildSourceLock #1: %v", err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatalf("save cache: %v", err)
+	}
+
+	if err := os.Remove(filepath.Join(root, "gone.md")); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenFileHashCache(cacheDir)
+	if err != nil {
+		t.Fatalf("reopen cache: %v", err)
+	}
+	if _, err := buildSourceLock(root, nil, reopened); err != nil {
+		t.Fatalf("buildSourceLock #2: %v", err)
+	}
+	if err := reopened.Save(); err != nil {
+		t.Fatalf("save cache: %v", err)
+	}
+
+	final, err := OpenFileHashCache(cacheDir)
+	if err != nil {
+		t.Fatalf("reopen cache: %v", err)
+	}
+	if len(final.entries) != 1 {
+		t.Fatalf("expected only the touched entry to survive, got %v", final.entries)
+	}
+	if _, ok := final.entries[cacheKey(filepath.Join(root, "keep.md"))]; !ok {
+		t.Fatalf("expected keep.md to stay cached, got %v", final.entries)
+	}
+}
+
+func TestFileHashCacheKeepsOtherRootsEntries(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "hash-cache")
+	projectA, projectB := t.TempDir(), t.TempDir()
+	for _, f := range []string{filepath.Join(projectA, "a.md"), filepath.Join(projectB, "b.md")} {
+		if err := os.WriteFile(f, []byte("x\n"), 0o644); err != nil {
+			t.Fatalf("write %s: %v", f, err)
+		}
+	}
+	for _, root := range []string{projectA, projectB} {
+		cache, err := OpenFileHashCache(cacheDir)
+		if err != nil {
+			t.Fatalf("open cache: %v", err)
+		}
+		if _, err := buildSourceLock(root, nil, cache); err != nil {
+			t.Fatalf("buildSourceLock %s: %v", root, err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatalf("save cache: %v", err)
+		}
+	}
+
+	cache, err := OpenFileHashCache(cacheDir)
+	if err != nil {
+		t.Fatalf("reopen cache: %v", err)
+	}
+	if _, err := buildSourceLock(projectA, nil, cache); err != nil {
+		t.Fatalf("buildSourceLock A again: %v", err)
+	}
+	if hits, misses := cache.Stats(); hits != 1 || misses != 0 {
+		t.Fatalf("expected project A entries to survive compiling project B, got hits=%d misses=%d", hits, misses)
+	}
+	if _, ok := cache.entries[cacheKey(filepath.Join(projectB, "b.md"))]; !ok {
+		t.Fatalf("expected project B entry to stay cached, got %v", cache.entries)
+	}
+}
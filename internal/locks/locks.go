@@ -37,6 +37,9 @@ type ImageLock struct {
 	Version string `json:"version"`
 	Image   string `json:"image"`
 	Digest  string `json:"digest"`
+	// Resolved is set when Digest is the manifest digest reported by a
+	// runtime rather than a hash of the Image reference.
+	Resolved bool `json:"resolved,omitempty"`
 }
 
 type SourceLock struct {
@@ -51,18 +54,42 @@ type FileHash struct {
 	SHA256 string `json:"sha256"`
 }
 
+// Options tunes lock generation. A nil HashCache hashes every source file.
+// Exclude lists extra slash-separated paths, relative to the clawfile's
+// directory, to leave out of the source lock. ImageDigest is the runtime's
+// manifest digest for the image; when empty the image lock hashes the
+// reference string instead.
+type Options struct {
+	HashCache   HashCache
+	Exclude     []string
+	ImageDigest string
+}
+
+// File names used for the three locks, both inside a capsule's locks/ dir and
+// for compile --lock-only.
+const (
+	DepsLockFile   = "deps.lock.json"
+	ImageLockFile  = "image.lock.json"
+	SourceLockFile = "source.lock.json"
+)
+
 func Generate(cfg v1.Clawfile, clawfilePath string, outputDir string) (BundleLocks, error) {
+	return GenerateWithOptions(cfg, clawfilePath, outputDir, Options{})
+}
+
+func GenerateWithOptions(cfg v1.Clawfile, clawfilePath string, outputDir string, opts Options) (BundleLocks, error) {
 	deps, err := buildDepsLock(cfg, filepath.Dir(clawfilePath))
 	if err != nil {
 		return BundleLocks{}, err
 	}
-	img := buildImageLock(cfg)
+	img := buildImageLock(cfg, opts.ImageDigest)
 	srcRoot := filepath.Dir(clawfilePath)
 	excludes := []string{".git", ".metaclaw"}
 	if rel := relativeIfInside(srcRoot, outputDir); rel != "" {
 		excludes = append(excludes, rel)
 	}
-	src, err := buildSourceLock(srcRoot, excludes)
+	excludes = append(excludes, opts.Exclude...)
+	src, err := buildSourceLock(srcRoot, excludes, opts.HashCache)
 	if err != nil {
 		return BundleLocks{}, err
 	}
@@ -78,11 +105,11 @@ func buildDepsLock(cfg v1.Clawfile, base string) (DepsLock, error) {
 			if !filepath.IsAbs(p) {
 				p = filepath.Join(base, p)
 			}
-			h, err := hashSkillPath(p)
+			d, err := SkillDigest(p)
 			if err != nil {
 				return DepsLock{}, fmt.Errorf("hash skill path %s: %w", s.Path, err)
 			}
-			sl.Digest = "sha256:" + h
+			sl.Digest = d
 		} else {
 			target := s.ID + "@" + s.Version
 			if s.Digest != "" {
@@ -94,7 +121,11 @@ func buildDepsLock(cfg v1.Clawfile, base string) (DepsLock, error) {
 		out.Skills = append(out.Skills, sl)
 	}
 	sort.Slice(out.Skills, func(i, j int) bool {
-		return sortSkillKey(out.Skills[i]) < sortSkillKey(out.Skills[j])
+		a, b := sortSkillKey(out.Skills[i]), sortSkillKey(out.Skills[j])
+		if a != b {
+			return a < b
+		}
+		return out.Skills[i].Digest < out.Skills[j].Digest
 	})
 	return out, nil
 }
@@ -106,8 +137,11 @@ func sortSkillKey(s SkillLock) string {
 	return "id:" + s.ID + "@" + s.Version
 }
 
-func buildImageLock(cfg v1.Clawfile) ImageLock {
+func buildImageLock(cfg v1.Clawfile, manifestDigest string) ImageLock {
 	image := cfg.Agent.Runtime.Image
+	if manifestDigest != "" {
+		return ImageLock{Version: "metaclaw.imagelock/v1", Image: image, Digest: manifestDigest, Resolved: true}
+	}
 	sum := sha256.Sum256([]byte(image))
 	return ImageLock{
 		Version: "metaclaw.imagelock/v1",
@@ -116,13 +150,13 @@ func buildImageLock(cfg v1.Clawfile) ImageLock {
 	}
 }
 
-func buildSourceLock(root string, excludes []string) (SourceLock, error) {
+func buildSourceLock(root string, excludes []string, cache HashCache) (SourceLock, error) {
 	out := SourceLock{Version: "metaclaw.sourcelock/v1"}
 	commit, tree := gitMetadata(root)
 	out.GitCommit = commit
 	out.GitTree = tree
 
-	files, err := fileManifest(root, excludes)
+	files, err := fileManifest(root, excludes, cache)
 	if err != nil {
 		return SourceLock{}, err
 	}
@@ -141,7 +175,7 @@ func gitMetadata(root string) (string, string) {
 	return strings.TrimSpace(string(bCommit)), strings.TrimSpace(string(bTree))
 }
 
-func fileManifest(root string, excludes []string) ([]FileHash, error) {
+func fileManifest(root string, excludes []string, cache HashCache) ([]FileHash, error) {
 	var out []FileHash
 	rootAbs, err := filepath.Abs(root)
 	if err != nil {
@@ -158,6 +192,9 @@ func fileManifest(root string, excludes []string) ([]FileHash, error) {
 		}
 		excludeSet[filepath.ToSlash(filepath.Clean(e))] = struct{}{}
 	}
+	if cache != nil {
+		cache.WalkRoot(root)
+	}
 	err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -188,7 +225,7 @@ func fileManifest(root string, excludes []string) ([]FileHash, error) {
 			out = append(out, FileHash{Path: relSlash, SHA256: h})
 			return nil
 		}
-		h, err := hashFile(path)
+		h, err := hashFile(path, cache)
 		if err != nil {
 			return err
 		}
@@ -256,9 +293,9 @@ func hashPath(path string) (string, error) {
 		return "", err
 	}
 	if !st.IsDir() {
-		return hashFile(path)
+		return hashFile(path, nil)
 	}
-	entries, err := fileManifest(path, []string{".git", ".metaclaw"})
+	entries, err := fileManifest(path, []string{".git", ".metaclaw"}, nil)
 	if err != nil {
 		return "", err
 	}
@@ -270,6 +307,16 @@ func hashPath(path string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// SkillDigest returns the sha256:... digest compile records for the skill
+// (file or directory) at path.
+func SkillDigest(path string) (string, error) {
+	h, err := hashSkillPath(path)
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + h, nil
+}
+
 func hashSkillPath(path string) (string, error) {
 	st, err := os.Stat(path)
 	if err != nil {
@@ -278,7 +325,7 @@ func hashSkillPath(path string) (string, error) {
 	if st.IsDir() {
 		return hashPath(path)
 	}
-	fileHash, err := hashFile(path)
+	fileHash, err := hashFile(path, nil)
 	if err != nil {
 		return "", err
 	}
@@ -289,7 +336,7 @@ func hashSkillPath(path string) (string, error) {
 	if !ok {
 		return fileHash, nil
 	}
-	contractHash, err := hashFile(contractPath)
+	contractHash, err := hashFile(contractPath, nil)
 	if err != nil {
 		return "", err
 	}
@@ -301,17 +348,31 @@ func hashSkillPath(path string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-func hashFile(path string) (string, error) {
+func hashFile(path string, cache HashCache) (string, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return "", err
 	}
 	defer f.Close()
+	var info os.FileInfo
+	if cache != nil {
+		info, err = f.Stat()
+		if err != nil {
+			return "", err
+		}
+		if sum, ok := cache.Lookup(path, info); ok {
+			return sum, nil
+		}
+	}
 	h := sha256.New()
 	if _, err := io.Copy(h, f); err != nil {
 		return "", err
 	}
-	return hex.EncodeToString(h.Sum(nil)), nil
+	sum := hex.EncodeToString(h.Sum(nil))
+	if cache != nil {
+		cache.Store(path, info, sum)
+	}
+	return sum, nil
 }
 
 func relativeIfInside(root string, target string) string {
@@ -62,7 +62,7 @@ func TestBuildSourceLockRejectsSymlinkOutsideSourceRoot(t *testing.T) {
 		t.Skipf("symlink is not supported in this environment: %v", err)
 	}
 
-	_, err := buildSourceLock(root, nil)
+	_, err := buildSourceLock(root, nil, nil)
 	if err == nil {
 		t.Fatal("expected source lock generation to reject symlink outside source root")
 	}
@@ -82,7 +82,7 @@ func TestBuildSourceLockAllowsInternalSymlink(t *testing.T) {
 		t.Skipf("symlink is not supported in this environment: %v", err)
 	}
 
-	lock, err := buildSourceLock(root, nil)
+	lock, err := buildSourceLock(root, nil, nil)
 	if err != nil {
 		t.Fatalf("buildSourceLock() error = %v", err)
 	}
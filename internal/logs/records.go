@@ -0,0 +1,32 @@
+package logs
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// OutputRecord is one line of container output in NDJSON form.
+type OutputRecord struct {
+	RunID  string `json:"run_id"`
+	Stream string `json:"stream"`
+	TS     string `json:"ts"`
+	Line   string `json:"line"`
+}
+
+// EncodeOutputLines wraps every line of text as an OutputRecord and returns
+// the records as newline-delimited JSON. A trailing newline in text does not
+// produce an empty record.
+func EncodeOutputLines(runID, stream, text string, ts time.Time) []byte {
+	if text == "" {
+		return nil
+	}
+	stamp := ts.UTC().Format(time.RFC3339Nano)
+	var out []byte
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		b, _ := json.Marshal(OutputRecord{RunID: runID, Stream: stream, TS: stamp, Line: strings.TrimSuffix(line, "\r")})
+		out = append(out, b...)
+		out = append(out, '\n')
+	}
+	return out
+}
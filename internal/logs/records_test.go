@@ -0,0 +1,27 @@
+package logs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeOutputLines(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	out := EncodeOutputLines("r1", "stdout", "hello\r\nworld\n", ts)
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %d: %q", len(lines), out)
+	}
+	var rec OutputRecord
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("unmarshal record: %v", err)
+	}
+	if rec.RunID != "r1" || rec.Stream != "stdout" || rec.Line != "hello" || rec.TS != "2026-03-01T12:00:00Z" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if EncodeOutputLines("r1", "stderr", "", ts) != nil {
+		t.Fatal("expected no records for empty output")
+	}
+}
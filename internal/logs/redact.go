@@ -0,0 +1,59 @@
+package logs
+
+import (
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces every secret occurrence in persisted output.
+const RedactedValue = "***"
+
+// minRedactLen skips values so short that masking them would mangle ordinary
+// output; real credentials are far longer.
+const minRedactLen = 4
+
+// Redactor masks known secret values before output or events hit disk. A nil
+// Redactor passes text through unchanged.
+type Redactor struct {
+	r *strings.Replacer
+}
+
+// NewRedactor masks each non-trivial value. Longer values are matched first
+// so a secret that contains another is masked as a whole.
+func NewRedactor(values ...string) *Redactor {
+	seen := map[string]struct{}{}
+	uniq := make([]string, 0, len(values))
+	for _, v := range values {
+		if len(strings.TrimSpace(v)) < minRedactLen {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		uniq = append(uniq, v)
+	}
+	if len(uniq) == 0 {
+		return nil
+	}
+	sort.Slice(uniq, func(i, j int) bool { return len(uniq[i]) > len(uniq[j]) })
+	pairs := make([]string, 0, 2*len(uniq))
+	for _, v := range uniq {
+		pairs = append(pairs, v, RedactedValue)
+	}
+	return &Redactor{r: strings.NewReplacer(pairs...)}
+}
+
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	return r.r.Replace(s)
+}
+
+// Event masks the free-text fields of e.
+func (r *Redactor) Event(e Event) Event {
+	e.Message = r.Redact(e.Message)
+	e.Error = r.Redact(e.Error)
+	return e
+}
@@ -0,0 +1,23 @@
+package logs
+
+import "testing"
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor("sk-live-abcdef", "sk-live-abcdef-extended", "ab", "", "sk-live-abcdef")
+	got := r.Redact("key=sk-live-abcdef-extended other=sk-live-abcdef short=ab")
+	if want := "key=*** other=*** short=ab"; got != want {
+		t.Fatalf("Redact() = %q, want %q", got, want)
+	}
+	e := r.Event(Event{Phase: "runtime.exit", Message: "failed", Error: "401 for sk-live-abcdef"})
+	if e.Error != "401 for ***" || e.Message != "failed" {
+		t.Fatalf("Event() = %+v", e)
+	}
+
+	var none *Redactor
+	if none.Redact("sk-live-abcdef") != "sk-live-abcdef" {
+		t.Fatal("nil redactor must pass text through")
+	}
+	if NewRedactor("", "ab") != nil {
+		t.Fatal("expected nil redactor when no value is worth masking")
+	}
+}
@@ -0,0 +1,165 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MaxRotatedFiles bounds how many rolled files (name.1 is the newest) are
+// kept next to a rotated output file.
+const MaxRotatedFiles = 5
+
+// WriteRotated replaces the rotated set at path with content. With maxSize > 0
+// no file in the set grows past maxSize bytes (unless a single line is longer)
+// and only the newest MaxRotatedFiles rolled files survive; with maxSize <= 0
+// content goes to path in one piece.
+func WriteRotated(path string, content []byte, maxSize int64) error {
+	for _, p := range RotatedSet(path) {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if maxSize <= 0 || len(content) == 0 {
+		return os.WriteFile(path, content, 0o644)
+	}
+	return AppendRotated(path, content, maxSize)
+}
+
+// AppendRotated appends data to path, first rolling path to path.1 (and
+// older files one step further) whenever the write would exceed maxSize.
+// Data is split at line breaks so a roll never cuts a line in two.
+func AppendRotated(path string, data []byte, maxSize int64) error {
+	if maxSize <= 0 {
+		return appendFile(path, data)
+	}
+	for len(data) > 0 {
+		cur := int64(0)
+		if info, err := os.Stat(path); err == nil {
+			cur = info.Size()
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		n := int64(len(data))
+		if room := maxSize - cur; n > room {
+			cut := int64(-1)
+			if room > 0 {
+				cut = int64(bytes.LastIndexByte(data[:room], '\n'))
+			}
+			switch {
+			case cut >= 0:
+				n = cut + 1
+			case cur > 0:
+				if err := rotate(path); err != nil {
+					return err
+				}
+				continue
+			default:
+				// One line longer than maxSize: keep it whole in a file of its own.
+				if nl := bytes.IndexByte(data, '\n'); nl >= 0 {
+					n = int64(nl) + 1
+				}
+			}
+		}
+		if err := appendFile(path, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// RotatedSet lists the existing files of the rotated set at path, oldest
+// first, ending with path itself.
+func RotatedSet(path string) []string {
+	var out []string
+	for i := MaxRotatedFiles; i >= 1; i-- {
+		p := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(p); err == nil {
+			out = append(out, p)
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		out = append(out, path)
+	}
+	return out
+}
+
+// OpenRotated returns a reader over the whole rotated set at path in write
+// order. It fails with os.ErrNotExist when path itself is missing.
+func OpenRotated(path string) (io.ReadCloser, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	set := RotatedSet(path)
+	files := make([]*os.File, 0, len(set))
+	readers := make([]io.Reader, 0, len(set))
+	for _, p := range set {
+		f, err := os.Open(p)
+		if err != nil {
+			for _, o := range files {
+				o.Close()
+			}
+			return nil, err
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+	return &multiFile{Reader: io.MultiReader(readers...), files: files}, nil
+}
+
+// ReadRotated reads the whole rotated set at path in write order.
+func ReadRotated(path string) (string, error) {
+	rc, err := OpenRotated(path)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	var b strings.Builder
+	if _, err := io.Copy(&b, rc); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+type multiFile struct {
+	io.Reader
+	files []*os.File
+}
+
+func (m *multiFile) Close() error {
+	var first error
+	for _, f := range m.files {
+		if err := f.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func rotate(path string) error {
+	if err := os.Remove(fmt.Sprintf("%s.%d", path, MaxRotatedFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := MaxRotatedFiles - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
+
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
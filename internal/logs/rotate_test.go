@@ -0,0 +1,63 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteRotatedRollsAtLineBoundaries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+	var b strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	content := b.String()
+	if err := WriteRotated(path, []byte(content), 16); err != nil {
+		t.Fatalf("WriteRotated() error = %v", err)
+	}
+	set := RotatedSet(path)
+	if len(set) != 5 || set[len(set)-1] != path {
+		t.Fatalf("unexpected rotated set: %v", set)
+	}
+	for _, p := range set {
+		data, _ := os.ReadFile(p)
+		if len(data) > 16 || !strings.HasSuffix(string(data), "\n") {
+			t.Fatalf("%s holds %q", p, data)
+		}
+	}
+	got, err := ReadRotated(path)
+	if err != nil || got != content {
+		t.Fatalf("ReadRotated() = %q, %v", got, err)
+	}
+
+	// Rewriting drops the old set; overflowing it keeps only the newest files.
+	if err := WriteRotated(path, []byte(strings.Repeat("0123456789\n", 20)), 11); err != nil {
+		t.Fatalf("WriteRotated() error = %v", err)
+	}
+	if set := RotatedSet(path); len(set) != MaxRotatedFiles+1 {
+		t.Fatalf("expected %d files, got %v", MaxRotatedFiles+1, set)
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.%d", path, MaxRotatedFiles+1)); !os.IsNotExist(err) {
+		t.Fatalf("rotation kept more than %d rolled files", MaxRotatedFiles)
+	}
+	got, _ = ReadRotated(path)
+	if got != strings.Repeat("0123456789\n", MaxRotatedFiles+1) {
+		t.Fatalf("unexpected retained output: %q", got)
+	}
+}
+
+func TestWriteRotatedWithoutLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+	if err := WriteRotated(path, []byte("a\nb\n"), 0); err != nil {
+		t.Fatalf("WriteRotated() error = %v", err)
+	}
+	if set := RotatedSet(path); len(set) != 1 {
+		t.Fatalf("unexpected rotated set: %v", set)
+	}
+	if _, err := ReadRotated(filepath.Join(filepath.Dir(path), "missing.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected not-exist error, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"bytes"
+	"io"
+)
+
+const streamFlushSize = 64 << 10
+
+// OutputStream appends runtime output to a rotated file as it arrives, so a
+// long foreground run can be followed before it exits. Writes are held back
+// until a line is complete: a secret split across two writes is still
+// masked, and a roll never cuts a line. Close flushes a trailing partial line.
+// A line longer than streamFlushSize is written in pieces rather than held.
+type OutputStream struct {
+	path    string
+	maxSize int64
+	redact  *Redactor
+	echo    io.Writer
+	pending []byte
+	written int64
+	err     error
+}
+
+// NewOutputStream starts a fresh rotated set at path. Each completed line is
+// masked by redact (nil keeps it verbatim) and, when echo is set, also copied
+// there.
+func NewOutputStream(path string, maxSize int64, redact *Redactor, echo io.Writer) (*OutputStream, error) {
+	if err := WriteRotated(path, nil, 0); err != nil {
+		return nil, err
+	}
+	return &OutputStream{path: path, maxSize: maxSize, redact: redact, echo: echo}, nil
+}
+
+// Write never fails, so a full disk cannot kill the run it is recording; the
+// first persistence error is reported by Close instead.
+func (s *OutputStream) Write(p []byte) (int, error) {
+	s.pending = append(s.pending, p...)
+	if nl := bytes.LastIndexByte(s.pending, '\n'); nl >= 0 {
+		s.flush(s.pending[:nl+1])
+		s.pending = append(s.pending[:0], s.pending[nl+1:]...)
+	}
+	if len(s.pending) >= streamFlushSize {
+		s.flush(s.pending)
+		s.pending = s.pending[:0]
+	}
+	return len(p), nil
+}
+
+// Written reports how many bytes of output the stream has received.
+func (s *OutputStream) Written() int64 {
+	return s.written + int64(len(s.pending))
+}
+
+func (s *OutputStream) Close() error {
+	if len(s.pending) > 0 {
+		s.flush(s.pending)
+		s.pending = nil
+	}
+	return s.err
+}
+
+func (s *OutputStream) flush(chunk []byte) {
+	s.written += int64(len(chunk))
+	out := []byte(s.redact.Redact(string(chunk)))
+	if s.echo != nil {
+		_, _ = s.echo.Write(out)
+	}
+	if err := AppendRotated(s.path, out, s.maxSize); err != nil && s.err == nil {
+		s.err = err
+	}
+}
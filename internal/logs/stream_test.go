@@ -0,0 +1,53 @@
+package logs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdout.log")
+	if err := os.WriteFile(path+".1", []byte("old run\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var echo bytes.Buffer
+	s, err := NewOutputStream(path, 0, NewRedactor("sk-live-abcdef"), &echo)
+	if err != nil {
+		t.Fatalf("NewOutputStream() error = %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("expected stale rotated file to be removed, stat err = %v", err)
+	}
+	for _, chunk := range []string{"step 1\nkey=sk-li", "ve-abcdef\n", "partial"} {
+		if _, err := s.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	b, _ := os.ReadFile(path)
+	if got := string(b); got != "step 1\nkey=***\n" {
+		t.Fatalf("complete lines should be on disk before Close, got %q", got)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	b, _ = os.ReadFile(path)
+	if want := "step 1\nkey=***\npartial"; string(b) != want || echo.String() != want {
+		t.Fatalf("file = %q, echo = %q, want %q", b, echo.String(), want)
+	}
+	if s.Written() != int64(len("step 1\nkey=sk-live-abcdef\npartial")) {
+		t.Fatalf("Written() = %d", s.Written())
+	}
+
+	long := filepath.Join(t.TempDir(), "long.log")
+	s, err = NewOutputStream(long, 0, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = s.Write([]byte(strings.Repeat("x", streamFlushSize)))
+	if info, err := os.Stat(long); err != nil || info.Size() != streamFlushSize {
+		t.Fatalf("expected an overlong line to be flushed without a newline: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readEnvFile parses a dotenv-style file of KEY=VALUE lines. Blank lines and
+// # comments are skipped, an optional "export " prefix is accepted, and one
+// pair of matching surrounding quotes is stripped. Multi-line values are
+// rejected: every value must fit on its own line.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read env file: %w", err)
+	}
+	defer f.Close()
+
+	out := map[string]string{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(strings.TrimSuffix(sc.Text(), "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if !envNameRe.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: invalid env name %q", path, lineNo, key)
+		}
+		value = strings.TrimSpace(value)
+		if n := len(value); n > 0 && (value[0] == '"' || value[0] == '\'') {
+			if n < 2 || value[n-1] != value[0] {
+				return nil, fmt.Errorf("%s:%d: unterminated quote in %s (values must not contain newlines)", path, lineNo, key)
+			}
+			value = value[1 : n-1]
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("%s:%d: value for %s contains a newline", path, lineNo, key)
+		}
+		out[key] = value
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read env file: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/runtime/spec"
+)
+
+// Runtime defaults used to size the readiness wait when the healthcheck
+// leaves interval or retries unset (docker and podman agree on these).
+const (
+	defaultHealthInterval = 30 * time.Second
+	defaultHealthRetries  = 3
+)
+
+// healthPollInterval is how often awaitHealthy inspects the container.
+var healthPollInterval = time.Second
+
+// errContainerExited means the container stopped before its healthcheck
+// settled, which is reported through the normal exit path instead.
+var errContainerExited = errors.New("container exited before becoming healthy")
+
+// healthWaitBudget covers the start period plus every probe the runtime may
+// run before declaring the container unhealthy, with some slack.
+func healthWaitBudget(hc spec.Healthcheck) time.Duration {
+	interval := hc.Interval
+	if interval <= 0 {
+		interval = defaultHealthInterval
+	}
+	retries := hc.Retries
+	if retries <= 0 {
+		retries = defaultHealthRetries
+	}
+	return hc.StartPeriod + interval*time.Duration(retries+1) + 10*time.Second
+}
+
+// awaitHealthy polls inspect until the runtime reports the container healthy.
+// It fails when the runtime reports unhealthy or the wait budget runs out.
+func awaitHealthy(ctx context.Context, adapter spec.Adapter, containerID string, hc spec.Healthcheck) error {
+	budget := healthWaitBudget(hc)
+	deadline := time.Now().Add(budget)
+	for {
+		if raw, err := adapter.Inspect(ctx, containerID); err == nil {
+			if payload, err := parseInspectPayload(raw); err == nil {
+				if status, exitCode, err := payload.normalize(); err == nil {
+					if _, terminal := mapContainerStatus(status, exitCode); terminal {
+						return errContainerExited
+					}
+				}
+				switch payload.health() {
+				case "healthy":
+					return nil
+				case "unhealthy":
+					return errors.New("container healthcheck reported unhealthy")
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("container did not become healthy within %s", budget.Round(time.Second))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(healthPollInterval):
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/runtime/spec"
+)
+
+// inspectSequence is an adapter whose Inspect replays canned payloads, repeating
+// the last one once they run out.
+type inspectSequence struct {
+	spec.Adapter
+	payloads []string
+	calls    int
+}
+
+func (a *inspectSequence) Inspect(context.Context, string) (string, error) {
+	i := min(a.calls, len(a.payloads)-1)
+	a.calls++
+	return a.payloads[i], nil
+}
+
+func TestAwaitHealthy(t *testing.T) {
+	old := healthPollInterval
+	healthPollInterval = time.Millisecond
+	defer func() { healthPollInterval = old }()
+
+	starting := `[{"State":{"Status":"running","Health":{"Status":"starting"}}}]`
+	cases := []struct {
+		name     string
+		payloads []string
+		hc       spec.Healthcheck
+		wantErr  string
+	}{
+		{name: "healthy", payloads: []string{starting, `[{"State":{"Status":"running","Health":{"Status":"healthy"}}}]`}},
+		{name: "unhealthy", payloads: []string{starting, `[{"State":{"Status":"running","Health":{"Status":"unhealthy"}}}]`}, wantErr: "reported unhealthy"},
+		{name: "exited", payloads: []string{`[{"State":{"Status":"exited","ExitCode":1}}]`}, wantErr: errContainerExited.Error()},
+		{name: "timeout", payloads: []string{starting}, hc: spec.Healthcheck{Interval: time.Nanosecond, Retries: 1, StartPeriod: -10 * time.Second}, wantErr: "did not become healthy"},
+	}
+	for _, tc := range cases {
+		err := awaitHealthy(context.Background(), &inspectSequence{payloads: tc.payloads}, "c1", tc.hc)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Fatalf("%s: awaitHealthy() error = %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("%s: awaitHealthy() error = %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestHealthWaitBudgetUsesRuntimeDefaults(t *testing.T) {
+	if got, want := healthWaitBudget(spec.Healthcheck{}), 130*time.Second; got != want {
+		t.Fatalf("default budget = %s, want %s", got, want)
+	}
+	hc := spec.Healthcheck{Interval: 2 * time.Second, Retries: 4, StartPeriod: 5 * time.Second}
+	if got, want := healthWaitBudget(hc), 25*time.Second; got != want {
+		t.Fatalf("budget = %s, want %s", got, want)
+	}
+}
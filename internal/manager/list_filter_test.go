@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"testing"
+
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+func TestListRunsFiltered(t *testing.T) {
+	m, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer m.Close()
+
+	for _, rec := range []store.RunRecord{
+		{RunID: "r1", CapsuleID: "c1", CapsulePath: "p", Status: "failed", Lifecycle: "ephemeral", RuntimeTarget: "podman", StartedAt: "2026-01-01T00:00:01Z", Labels: map[string]string{"team": "infra", "env": "prod"}},
+		{RunID: "r2", CapsuleID: "c1", CapsulePath: "p", Status: "failed", Lifecycle: "ephemeral", RuntimeTarget: "docker", StartedAt: "2026-01-01T00:00:02Z", Labels: map[string]string{"team": "infra", "env": "dev"}},
+		{RunID: "r3", CapsuleID: "c2", CapsulePath: "p", Status: "succeeded", Lifecycle: "daemon", RuntimeTarget: "podman", StartedAt: "2026-01-01T00:00:03Z"},
+	} {
+		if err := m.store.InsertRun(rec); err != nil {
+			t.Fatalf("InsertRun(%s) error = %v", rec.RunID, err)
+		}
+	}
+
+	cases := []struct {
+		filter store.RunFilter
+		want   []string
+	}{
+		{store.RunFilter{}, []string{"r3", "r2", "r1"}},
+		{store.RunFilter{Status: []string{"failed"}, RuntimeTarget: []string{"podman"}}, []string{"r1"}},
+		{store.RunFilter{RuntimeTarget: []string{"podman", "docker"}, CapsuleID: []string{"c1"}}, []string{"r2", "r1"}},
+		{store.RunFilter{Lifecycle: []string{"debug"}}, nil},
+		{store.RunFilter{Labels: []store.Label{{Key: "team", Value: "infra"}}}, []string{"r2", "r1"}},
+		{store.RunFilter{Labels: []store.Label{{Key: "team", Value: "infra"}, {Key: "env", Value: "prod"}}}, []string{"r1"}},
+	}
+	for _, tc := range cases {
+		got, err := m.ListRunsFiltered(tc.filter, 10)
+		if err != nil {
+			t.Fatalf("ListRunsFiltered(%+v) error = %v", tc.filter, err)
+		}
+		var ids []string
+		for _, r := range got {
+			ids = append(ids, r.RunID)
+		}
+		if len(ids) != len(tc.want) {
+			t.Fatalf("ListRunsFiltered(%+v) = %v, want %v", tc.filter, ids, tc.want)
+		}
+		for i := range ids {
+			if ids[i] != tc.want[i] {
+				t.Fatalf("ListRunsFiltered(%+v) = %v, want %v", tc.filter, ids, tc.want)
+			}
+		}
+	}
+}
+
+func TestRunLabels(t *testing.T) {
+	m, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer m.Close()
+
+	rec := store.RunRecord{RunID: "r1", CapsuleID: "c1", CapsulePath: "p", Status: "succeeded", Lifecycle: "ephemeral", RuntimeTarget: "podman", StartedAt: "2026-01-01T00:00:01Z", Labels: map[string]string{"team": "infra"}}
+	if err := m.store.InsertRun(rec); err != nil {
+		t.Fatalf("InsertRun() error = %v", err)
+	}
+	runs, err := m.RunsByLabel("team", "infra", 10)
+	if err != nil || len(runs) != 1 || runs[0].Labels["team"] != "infra" {
+		t.Fatalf("RunsByLabel() = %+v, %v", runs, err)
+	}
+	got, err := m.GetRun("r1")
+	if err != nil || got.Labels["team"] != "infra" {
+		t.Fatalf("GetRun() labels = %+v, %v", got.Labels, err)
+	}
+	if err := m.store.DeleteRun("r1"); err != nil {
+		t.Fatalf("DeleteRun() error = %v", err)
+	}
+	if runs, _ := m.RunsByLabel("team", "infra", 10); len(runs) != 0 {
+		t.Fatalf("labels outlived their run: %+v", runs)
+	}
+
+	for _, labels := range []map[string]string{
+		{"1team": "infra"},
+		{"team-name": "infra"},
+		{"team": " "},
+	} {
+		if err := validateLabels(labels); err == nil {
+			t.Fatalf("validateLabels(%v) accepted invalid labels", labels)
+		}
+	}
+}
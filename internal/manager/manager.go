@@ -3,7 +3,10 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -35,8 +38,43 @@ type RunOptions struct {
 	LLMAPIKey       string
 	LLMAPIKeyEnv    string
 	SecretEnvs      []string
+	SecretFiles     []string
+	ReadOnlyMounts  bool
+	NoHashCache     bool
+	// AllowSysAdmin lets a clawfile input grant SYS_ADMIN through
+	// habitat.capabilities.add.
+	AllowSysAdmin       bool
+	HealthcheckCmd      string
+	HealthcheckInterval time.Duration
+	PreserveOnFailure   bool
+	LogFormat           string
+	Name                string
+	Labels              map[string]string
+	// MaxLogSize rolls stdout.log/stderr.log to .1, .2, ... once a file would
+	// pass this many bytes; 0 keeps each stream in a single file.
+	MaxLogSize int64
+	// EnvFile is a dotenv-style file of non-secret KEY=VALUE overrides. Its
+	// names must be allowlisted like --secret-env, and LLM and secret envs win
+	// over it.
+	EnvFile string
+	// LLMModel and LLMBaseURL override the clawfile's agent.llm for this run
+	// only; the capsule is not changed. Both need a declared provider.
+	LLMModel   string
+	LLMBaseURL string
+	// NoRedact persists output and events verbatim instead of masking the
+	// run's secret values with ***. Meant for debugging only.
+	NoRedact bool
+	// AttachStdout and AttachStderr, when set, receive a foreground run's
+	// output line by line as it is produced, masked like the stored copy.
+	AttachStdout io.Writer
+	AttachStderr io.Writer
 }
 
+const (
+	LogFormatRaw  = "raw"
+	LogFormatJSON = "json"
+)
+
 type RunOutcome struct {
 	Run   store.RunRecord
 	Error error
@@ -60,8 +98,32 @@ func (m *Manager) Close() error {
 	return m.store.Close()
 }
 
+type RegisteredCapsule struct {
+	CapsuleID   string `json:"capsuleId"`
+	CapsulePath string `json:"capsulePath"`
+}
+
+// RegisterCapsule compiles (or loads) the input and records it in the store
+// without starting a runtime.
+func (m *Manager) RegisterCapsule(opts RunOptions) (RegisteredCapsule, error) {
+	_, _, capPath, capID, err := m.prepareCapsule(opts)
+	if err != nil {
+		return RegisteredCapsule{}, err
+	}
+	if err := m.store.UpsertCapsule(capID, capPath); err != nil {
+		return RegisteredCapsule{}, err
+	}
+	return RegisteredCapsule{CapsuleID: capID, CapsulePath: capPath}, nil
+}
+
 func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, error) {
-	cfg, pol, capPath, capID, err := m.prepareCapsule(opts.InputPath)
+	if err := m.checkRunName(ctx, opts.Name); err != nil {
+		return store.RunRecord{}, err
+	}
+	if err := validateLabels(opts.Labels); err != nil {
+		return store.RunRecord{}, err
+	}
+	cfg, pol, capPath, capID, err := m.prepareCapsule(opts)
 	if err != nil {
 		return store.RunRecord{}, err
 	}
@@ -69,11 +131,28 @@ func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, er
 		return store.RunRecord{}, err
 	}
 
+	if strings.TrimSpace(opts.HealthcheckCmd) != "" && !opts.Detach && cfg.Agent.Lifecycle != v1.LifecycleDaemon {
+		return store.RunRecord{}, fmt.Errorf("healthcheck override requires a detached or daemon run")
+	}
+	switch opts.LogFormat {
+	case "", LogFormatRaw:
+	case LogFormatJSON:
+		if opts.Detach || cfg.Agent.Lifecycle == v1.LifecycleDaemon {
+			return store.RunRecord{}, fmt.Errorf("log format %s is only supported for foreground runs", LogFormatJSON)
+		}
+	default:
+		return store.RunRecord{}, fmt.Errorf("unsupported log format %q (supported: %s, %s)", opts.LogFormat, LogFormatRaw, LogFormatJSON)
+	}
+
 	adapter, target, err := m.resolver.Resolve(ctx, opts.RuntimeOverride, string(cfg.Agent.Runtime.Target))
 	if err != nil {
 		return store.RunRecord{}, err
 	}
-	resolvedLLM, err := llm.Resolve(cfg.Agent.LLM, llm.RuntimeOptions{
+	llmSpec, err := overrideLLM(cfg.Agent.LLM, opts.LLMModel, opts.LLMBaseURL)
+	if err != nil {
+		return store.RunRecord{}, err
+	}
+	resolvedLLM, err := llm.Resolve(llmSpec, llm.RuntimeOptions{
 		APIKey:    opts.LLMAPIKey,
 		APIKeyEnv: opts.LLMAPIKeyEnv,
 	})
@@ -84,14 +163,46 @@ func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, er
 	if err != nil {
 		return store.RunRecord{}, err
 	}
-	env := mergeEnv(cfg.Agent.Habitat.Env, resolvedLLM.Env, resolvedSecrets)
+	fileSecrets, err := resolveSecretFiles(cfg.Agent.Habitat.SecretFiles)
+	if err != nil {
+		return store.RunRecord{}, err
+	}
+	flagFileSecrets, err := resolveSecretFileFlags(opts.SecretFiles)
+	if err != nil {
+		return store.RunRecord{}, err
+	}
+	for k := range flagFileSecrets {
+		if _, ok := resolvedSecrets[k]; ok {
+			return store.RunRecord{}, fmt.Errorf("secret %s is given by both --secret-env and --secret-file", k)
+		}
+		resolvedSecrets[k] = flagFileSecrets[k]
+	}
+	fileEnv := map[string]string{}
+	if opts.EnvFile != "" {
+		if fileEnv, err = readEnvFile(opts.EnvFile); err != nil {
+			return store.RunRecord{}, err
+		}
+	}
+	// Soul env goes last: it describes mounts MetaClaw set up and must not be
+	// pointed elsewhere by an env-file or secret.
+	env := mergeEnv(cfg.Agent.Habitat.Env, fileEnv, resolvedLLM.Env, fileSecrets, resolvedSecrets, policy.SoulEnv(cfg.Agent.Soul))
 	allowed := make(map[string]struct{}, len(pol.EnvAllowlist))
 	for _, k := range pol.EnvAllowlist {
 		allowed[k] = struct{}{}
 	}
+	// A base URL override may add *_BASE_URL keys the compiled policy did not
+	// need; LLM keys are always allowlisted for the effective spec.
+	for _, k := range llm.AllowedEnvKeys(llmSpec) {
+		allowed[k] = struct{}{}
+	}
+	for _, k := range envKeys(fileEnv) {
+		if _, ok := allowed[k]; !ok {
+			return store.RunRecord{}, fmt.Errorf("env-file var %s is not allowlisted by agent policy (declare it in agent.habitat.env to override it at runtime)", k)
+		}
+	}
 	for k := range resolvedSecrets {
 		if _, ok := allowed[k]; !ok {
-			return store.RunRecord{}, fmt.Errorf("secret env %s is not allowlisted by agent policy (declare it in agent.habitat.env to inject at runtime)", k)
+			return store.RunRecord{}, fmt.Errorf("secret %s is not allowlisted by agent policy (declare it in agent.habitat.env to inject at runtime)", k)
 		}
 	}
 	for k := range resolvedLLM.Env {
@@ -101,59 +212,118 @@ func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, er
 		}
 	}
 	env = filterEnvAllowlist(env, allowed)
+	var redact *logs.Redactor
+	if !opts.NoRedact {
+		redact = logs.NewRedactor(secretValues(resolvedLLM.Env[llmSpec.APIKeyEnv], fileSecrets, resolvedSecrets)...)
+	}
 
 	runID := makeRunID()
 	rec := store.RunRecord{
 		RunID:         runID,
+		Name:          opts.Name,
 		CapsuleID:     capID,
 		CapsulePath:   capPath,
 		Status:        "running",
 		Lifecycle:     string(cfg.Agent.Lifecycle),
 		RuntimeTarget: string(target),
 		StartedAt:     time.Now().UTC().Format(time.RFC3339Nano),
+		ImageRef:      cfg.Agent.Runtime.Image,
+		// Names only: the record must prove which bindings a run had
+		// without ever holding a secret value.
+		InjectedEnvKeys: envKeys(env),
+		Labels:          opts.Labels,
+		LLMModel:        llmSpec.Model,
 	}
 	if err := m.store.InsertRun(rec); err != nil {
 		return store.RunRecord{}, err
 	}
-	_ = logs.AppendEvent(m.stateDir, runID, logs.Event{Phase: "runtime.resolve", Runtime: string(target), Message: "runtime selected"})
+	_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.resolve", Runtime: string(target), Message: "runtime selected"}))
+	if llmSpec != cfg.Agent.LLM {
+		_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.llm_override", Runtime: string(target), Message: fmt.Sprintf("llm overridden for this run: model=%s base_url=%s", llmSpec.Model, llmSpec.BaseURL)}))
+	}
+	if opts.ReadOnlyMounts {
+		var downgraded []string
+		pol, downgraded = forceReadOnlyMounts(pol)
+		if len(downgraded) > 0 {
+			_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.policy_override", Runtime: string(target), Message: "mounts forced read-only: " + strings.Join(downgraded, ", ")}))
+		}
+	}
 
 	containerName := "metaclaw_" + runID
-	runRes, runErr := adapter.Run(ctx, spec.RunOptions{
+	detached := opts.Detach || cfg.Agent.Lifecycle == v1.LifecycleDaemon
+	var hc *spec.Healthcheck
+	if detached {
+		hc = runHealthcheck(cfg, opts)
+	}
+	runSpec := spec.RunOptions{
 		ContainerName: containerName,
 		Image:         cfg.Agent.Runtime.Image,
 		Command:       cfg.Agent.Command,
-		Detach:        opts.Detach || cfg.Agent.Lifecycle == v1.LifecycleDaemon,
+		Detach:        detached,
 		Policy:        pol,
 		Env:           env,
 		Workdir:       cfg.Agent.Habitat.Workdir,
 		User:          cfg.Agent.Habitat.User,
 		CPU:           cfg.Agent.Runtime.Resources.CPU,
 		Memory:        cfg.Agent.Runtime.Resources.Memory,
-	})
+		Healthcheck:   hc,
+	}
+	// Foreground output is streamed into the run directory while the
+	// container runs instead of only after it exits.
+	var stdoutStream, stderrStream *logs.OutputStream
+	if !detached {
+		stdoutStream = openOutputStream(m.stateDir, runID, "stdout.log", opts.MaxLogSize, redact, opts.AttachStdout)
+		stderrStream = openOutputStream(m.stateDir, runID, "stderr.log", opts.MaxLogSize, redact, opts.AttachStderr)
+		if stdoutStream != nil {
+			runSpec.Stdout = stdoutStream
+		}
+		if stderrStream != nil {
+			runSpec.Stderr = stderrStream
+		}
+	}
+	runRes, runErr := adapter.Run(ctx, runSpec)
 
 	containerID := runRes.ContainerID
 	if containerID == "" {
 		containerID = containerName
 	}
 	rec.ContainerID = containerID
-	_ = writeRunOutput(m.stateDir, runID, "stdout.log", runRes.Stdout)
-	_ = writeRunOutput(m.stateDir, runID, "stderr.log", runRes.Stderr)
+	persistRunOutput(m.stateDir, runID, "stdout.log", runRes.Stdout, stdoutStream, opts.MaxLogSize, redact, opts.AttachStdout)
+	persistRunOutput(m.stateDir, runID, "stderr.log", runRes.Stderr, stderrStream, opts.MaxLogSize, redact, opts.AttachStderr)
 
-	detached := opts.Detach || cfg.Agent.Lifecycle == v1.LifecycleDaemon
 	if detached {
 		if runErr != nil {
 			errText := runErr.Error()
-			_ = logs.AppendEvent(m.stateDir, runID, logs.Event{Phase: "runtime.start", Runtime: string(target), ContainerID: containerID, Message: "daemon start failed", Error: errText})
+			_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.start", Runtime: string(target), ContainerID: containerID, Message: "daemon start failed", Error: errText}))
 			_ = m.store.UpdateRunCompletion(runID, "failed", containerID, intPtr(runRes.ExitCode), errText)
 			rec.Status = "failed"
 			rec.LastError = errText
 			rec.ExitCode = intPtr(runRes.ExitCode)
 			return rec, runErr
 		}
-		_ = logs.AppendEvent(m.stateDir, runID, logs.Event{Phase: "runtime.start", Runtime: string(target), ContainerID: containerID, Message: "daemon started"})
+		_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.start", Runtime: string(target), ContainerID: containerID, Message: "daemon started"}))
 		_ = m.store.UpdateRunStatus(runID, "running", containerID, "")
 		rec.Status = "running"
 		rec.ContainerID = containerID
+		if hc != nil && target != spec.TargetApple {
+			switch err := awaitHealthy(ctx, adapter, containerID, *hc); {
+			case err == nil:
+				_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.healthcheck", Runtime: string(target), ContainerID: containerID, Message: "container healthy"}))
+			case errors.Is(err, errContainerExited):
+				// refreshRunStatus records the exit (and applies any restart policy).
+			default:
+				errText := err.Error()
+				stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+				_ = adapter.Stop(stopCtx, containerID, 0)
+				cancel()
+				_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.healthcheck", Runtime: string(target), ContainerID: containerID, Message: "container never became healthy; stopped", Error: errText}))
+				_ = m.store.UpdateRunCompletion(runID, "failed", containerID, nil, errText)
+				rec.Status = "failed"
+				rec.LastError = errText
+				rec.EndedAt = time.Now().UTC().Format(time.RFC3339Nano)
+				return rec, err
+			}
+		}
 		refreshed, refreshErr := m.refreshRunStatus(ctx, rec)
 		if refreshErr == nil {
 			rec = refreshed
@@ -170,6 +340,14 @@ func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, er
 		return rec, nil
 	}
 
+	if opts.LogFormat == LogFormatJSON {
+		now := time.Now()
+		// Mask before encoding: JSON escaping could hide a secret from the
+		// redaction pass in writeRunOutput.
+		records := append(logs.EncodeOutputLines(runID, "stdout", redact.Redact(runRes.Stdout), now), logs.EncodeOutputLines(runID, "stderr", redact.Redact(runRes.Stderr), now)...)
+		_ = writeRunOutput(m.stateDir, runID, OutputNDJSONFile, string(records), 0, redact)
+	}
+
 	status := "succeeded"
 	var lastError string
 	exitPtr := intPtr(runRes.ExitCode)
@@ -179,13 +357,27 @@ func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, er
 			lastError = runErr.Error()
 		}
 	}
+	cleanupCtx := ctx
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		status = "timed_out"
+		lastError = "run exceeded timeout"
+		exitPtr = nil
+		// ctx is already expired, but the container still has to be removed.
+		var cancel context.CancelFunc
+		cleanupCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+	}
 
-	if status == "failed" && cfg.Agent.Lifecycle == v1.LifecycleDebug {
+	debugLifecycle := cfg.Agent.Lifecycle == v1.LifecycleDebug
+	switch {
+	case status == "failed" && (debugLifecycle || opts.PreserveOnFailure):
 		status = "failed_paused"
-		_ = logs.AppendEvent(m.stateDir, runID, logs.Event{Phase: "runtime.pause", Runtime: string(target), ContainerID: containerID, Message: "container preserved for debug", Error: lastError})
-	} else {
-		if remErr := adapter.Remove(ctx, containerID); remErr == nil {
-			_ = logs.AppendEvent(m.stateDir, runID, logs.Event{Phase: "runtime.cleanup", Runtime: string(target), ContainerID: containerID, Message: "container removed"})
+		_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.pause", Runtime: string(target), ContainerID: containerID, Message: "container preserved for debug", Error: lastError}))
+	case status == "timed_out" && debugLifecycle:
+		_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.pause", Runtime: string(target), ContainerID: containerID, Message: "container preserved for debug", Error: lastError}))
+	default:
+		if remErr := adapter.Remove(cleanupCtx, containerID); remErr == nil {
+			_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.cleanup", Runtime: string(target), ContainerID: containerID, Message: "container removed"}))
 		}
 	}
 
@@ -195,10 +387,13 @@ func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, er
 	rec.LastError = lastError
 	rec.EndedAt = time.Now().UTC().Format(time.RFC3339Nano)
 	if status == "succeeded" {
-		_ = logs.AppendEvent(m.stateDir, runID, logs.Event{Phase: "runtime.exit", Runtime: string(target), ContainerID: containerID, Message: "completed"})
+		_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.exit", Runtime: string(target), ContainerID: containerID, Message: "completed"}))
 		return rec, nil
 	}
-	_ = logs.AppendEvent(m.stateDir, runID, logs.Event{Phase: "runtime.exit", Runtime: string(target), ContainerID: containerID, Message: "failed", Error: lastError})
+	_ = logs.AppendEvent(m.stateDir, runID, redact.Event(logs.Event{Phase: "runtime.exit", Runtime: string(target), ContainerID: containerID, Message: "failed", Error: lastError}))
+	if status == "timed_out" {
+		return rec, errors.New(lastError)
+	}
 	if runErr != nil {
 		return rec, runErr
 	}
@@ -206,10 +401,29 @@ func (m *Manager) Run(ctx context.Context, opts RunOptions) (store.RunRecord, er
 }
 
 func (m *Manager) ListRuns(limit int) ([]store.RunRecord, error) {
-	recs, err := m.store.ListRuns(limit)
+	return m.ListRunsFiltered(store.RunFilter{}, limit)
+}
+
+// ListRunsFiltered lists runs matching f. Stored "running" records can be
+// stale, so a status filter first reconciles every running run against the
+// runtime before the query runs.
+func (m *Manager) ListRunsFiltered(f store.RunFilter, limit int) ([]store.RunRecord, error) {
+	if len(f.Status) > 0 {
+		live, err := m.store.ListRunsFiltered(store.RunFilter{Status: []string{"running"}}, 1<<20)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range live {
+			_, _ = m.refreshRunStatus(context.Background(), r)
+		}
+	}
+	recs, err := m.store.ListRunsFiltered(f, limit)
 	if err != nil {
 		return nil, err
 	}
+	if len(f.Status) > 0 {
+		return recs, nil
+	}
 	for i := range recs {
 		updated, refreshErr := m.refreshRunStatus(context.Background(), recs[i])
 		if refreshErr == nil {
@@ -219,8 +433,9 @@ func (m *Manager) ListRuns(limit int) ([]store.RunRecord, error) {
 	return recs, nil
 }
 
-func (m *Manager) GetRun(runID string) (store.RunRecord, error) {
-	rec, err := m.store.GetRun(runID)
+// GetRun looks a run up by run ID or by the name given with RunOptions.Name.
+func (m *Manager) GetRun(ref string) (store.RunRecord, error) {
+	rec, err := m.store.GetRunByRef(ref)
 	if err != nil {
 		return store.RunRecord{}, err
 	}
@@ -247,6 +462,12 @@ func (m *Manager) RuntimeLogs(ctx context.Context, r store.RunRecord, follow boo
 	return ad.Logs(ctx, r.ContainerID, follow)
 }
 
+// RunPolicy returns the compiled policy of the capsule a run was started from.
+func (m *Manager) RunPolicy(r store.RunRecord) (policy.Policy, error) {
+	_, pol, _, _, err := loadFromCapsuleDir(r.CapsulePath)
+	return pol, err
+}
+
 func (m *Manager) RuntimeInspect(ctx context.Context, r store.RunRecord) (string, error) {
 	t, err := runtime.ParseTarget(r.RuntimeTarget)
 	if err != nil {
@@ -259,13 +480,13 @@ func (m *Manager) RuntimeInspect(ctx context.Context, r store.RunRecord) (string
 	return ad.Inspect(ctx, r.ContainerID)
 }
 
-func (m *Manager) DebugShell(ctx context.Context, runID string) error {
-	r, err := m.store.GetRun(runID)
+func (m *Manager) DebugShell(ctx context.Context, ref string) error {
+	r, err := m.store.GetRunByRef(ref)
 	if err != nil {
 		return err
 	}
 	if r.Status != "failed_paused" && r.Status != "running" {
-		return fmt.Errorf("run %s is not debuggable (status=%s)", runID, r.Status)
+		return fmt.Errorf("run %s is not debuggable (status=%s)", r.RunID, r.Status)
 	}
 	t, err := runtime.ParseTarget(r.RuntimeTarget)
 	if err != nil {
@@ -278,7 +499,143 @@ func (m *Manager) DebugShell(ctx context.Context, runID string) error {
 	return ad.ExecShell(ctx, r.ContainerID)
 }
 
-func (m *Manager) prepareCapsule(inputPath string) (v1.Clawfile, policy.Policy, string, string, error) {
+// Exec runs one non-interactive command in the container of a running or
+// debug-paused run and returns its captured output and exit code.
+func (m *Manager) Exec(ctx context.Context, ref string, args []string) (spec.ExecResult, error) {
+	if len(args) == 0 {
+		return spec.ExecResult{}, fmt.Errorf("exec requires a command")
+	}
+	r, err := m.store.GetRunByRef(ref)
+	if err != nil {
+		return spec.ExecResult{}, err
+	}
+	if r.Status != "failed_paused" && r.Status != "running" {
+		return spec.ExecResult{}, fmt.Errorf("run %s is not execable (status=%s)", r.RunID, r.Status)
+	}
+	t, err := runtime.ParseTarget(r.RuntimeTarget)
+	if err != nil {
+		return spec.ExecResult{}, err
+	}
+	ad, ok := m.resolver.Adapter(t)
+	if !ok {
+		return spec.ExecResult{}, fmt.Errorf("runtime adapter unavailable: %s", r.RuntimeTarget)
+	}
+	return ad.Exec(ctx, r.ContainerID, args)
+}
+
+// Stop gracefully stops a running detached run and records it as stopped.
+// Runs that already reached a terminal status are returned unchanged, and a
+// container that no longer exists is treated as already stopped.
+func (m *Manager) Stop(ctx context.Context, ref string, timeout time.Duration) (store.RunRecord, error) {
+	r, err := m.store.GetRunByRef(ref)
+	if err != nil {
+		return store.RunRecord{}, err
+	}
+	if r.Status != "running" {
+		return r, nil
+	}
+	t, err := runtime.ParseTarget(r.RuntimeTarget)
+	if err != nil {
+		return r, err
+	}
+	ad, ok := m.resolver.Adapter(t)
+	if !ok {
+		return r, fmt.Errorf("runtime adapter unavailable: %s", r.RuntimeTarget)
+	}
+	message := "container stopped"
+	if stopErr := ad.Stop(ctx, r.ContainerID, timeout); stopErr != nil {
+		if _, inspectErr := ad.Inspect(ctx, r.ContainerID); inspectErr == nil {
+			return r, fmt.Errorf("stop container %s: %w", r.ContainerID, stopErr)
+		}
+		message = "container already gone"
+	}
+	if err := m.store.UpdateRunCompletion(r.RunID, "stopped", r.ContainerID, r.ExitCode, ""); err != nil {
+		return r, err
+	}
+	_ = logs.AppendEvent(m.stateDir, r.RunID, logs.Event{Phase: "runtime.stop", Runtime: r.RuntimeTarget, ContainerID: r.ContainerID, Message: message})
+	r.Status = "stopped"
+	r.EndedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	return r, nil
+}
+
+// RemoveRun deletes a run record and its output directory. A running run is
+// refused unless force is set; any container still attached to the run
+// (running, or preserved for debug after a failure or timeout) is removed on a
+// best-effort basis.
+func (m *Manager) RemoveRun(ctx context.Context, ref string, force bool) error {
+	r, err := m.store.GetRunByRef(ref)
+	if err != nil {
+		return err
+	}
+	if r.Status == "running" && !force {
+		return fmt.Errorf("run %s is still running (stop it first or pass --force)", r.RunID)
+	}
+	if r.ContainerID != "" && (r.Status == "running" || r.Status == "failed_paused" || r.Status == "timed_out") {
+		if t, err := runtime.ParseTarget(r.RuntimeTarget); err == nil {
+			if ad, ok := m.resolver.Adapter(t); ok {
+				_ = ad.Remove(ctx, r.ContainerID)
+			}
+		}
+	}
+	if err := m.store.DeleteRun(r.RunID); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(m.stateDir, "runs", r.RunID))
+}
+
+// RunsByLabel lists runs carrying label key=value, newest first.
+func (m *Manager) RunsByLabel(key, value string, limit int) ([]store.RunRecord, error) {
+	return m.ListRunsFiltered(store.RunFilter{Labels: []store.Label{{Key: key, Value: value}}}, limit)
+}
+
+// validateLabels applies the env-name rules to label keys so labels stay
+// easy to pass through shells and filters.
+func validateLabels(labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !envNameRe.MatchString(k) {
+			return fmt.Errorf("invalid label key %q: must match [A-Za-z_][A-Za-z0-9_]*", k)
+		}
+		if strings.TrimSpace(labels[k]) == "" {
+			return fmt.Errorf("label %s has an empty value", k)
+		}
+	}
+	return nil
+}
+
+var runNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// checkRunName validates a user-supplied run name and rejects one that is
+// already held by a run whose container is still around (running or
+// preserved for debug).
+func (m *Manager) checkRunName(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	if !runNameRe.MatchString(name) {
+		return fmt.Errorf("invalid run name %q: must match [A-Za-z0-9_-]+", name)
+	}
+	runs, err := m.store.ListRunsByName(name)
+	if err != nil {
+		return err
+	}
+	for _, r := range runs {
+		if refreshed, err := m.refreshRunStatus(ctx, r); err == nil {
+			r = refreshed
+		}
+		if r.Status == "running" || r.Status == "failed_paused" {
+			return fmt.Errorf("run name %q is already in use by run %s (status=%s)", name, r.RunID, r.Status)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) prepareCapsule(opts RunOptions) (v1.Clawfile, policy.Policy, string, string, error) {
+	inputPath := opts.InputPath
 	st, err := os.Stat(inputPath)
 	if err != nil {
 		return v1.Clawfile{}, policy.Policy{}, "", "", err
@@ -288,7 +645,11 @@ func (m *Manager) prepareCapsule(inputPath string) (v1.Clawfile, policy.Policy,
 		if err := os.MkdirAll(outDir, 0o755); err != nil {
 			return v1.Clawfile{}, policy.Policy{}, "", "", err
 		}
-		res, err := compiler.Compile(inputPath, outDir)
+		compileOpts := compiler.Options{AllowSysAdmin: opts.AllowSysAdmin}
+		if !opts.NoHashCache {
+			compileOpts.HashCacheDir = filepath.Join(m.stateDir, "hash-cache")
+		}
+		res, err := compiler.CompileWithOptions(inputPath, outDir, compileOpts)
 		if err != nil {
 			return v1.Clawfile{}, policy.Policy{}, "", "", err
 		}
@@ -326,17 +687,72 @@ func loadFromCapsuleDir(capPath string) (v1.Clawfile, policy.Policy, string, str
 	return ir.Clawfile, pol, capPath, m.CapsuleID, nil
 }
 
+// forceReadOnlyMounts returns a copy of p with every mount read-only, plus the
+// source:target pairs of mounts that were previously writable.
+func forceReadOnlyMounts(p policy.Policy) (policy.Policy, []string) {
+	mounts := make([]policy.MountPolicy, len(p.Mounts))
+	var downgraded []string
+	for i, mnt := range p.Mounts {
+		if !mnt.ReadOnly {
+			downgraded = append(downgraded, mnt.Source+":"+mnt.Target)
+			mnt.ReadOnly = true
+		}
+		mounts[i] = mnt
+	}
+	p.Mounts = mounts
+	return p, downgraded
+}
+
 func makeRunID() string {
 	now := time.Now().UTC()
 	return now.Format("20060102t150405") + fmt.Sprintf("%09d", now.Nanosecond())
 }
 
-func writeRunOutput(stateDir, runID, fileName, content string) error {
+// OutputNDJSONFile holds the structured copy of a run's output when LogFormat
+// is json.
+const OutputNDJSONFile = "output.ndjson"
+
+// writeRunOutput persists runtime output with known secret values masked by
+// redact (nil writes content verbatim).
+func writeRunOutput(stateDir, runID, fileName, content string, maxSize int64, redact *logs.Redactor) error {
 	path := filepath.Join(stateDir, "runs", runID, fileName)
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(path, []byte(content), 0o644)
+	return logs.WriteRotated(path, []byte(redact.Redact(content)), maxSize)
+}
+
+// openOutputStream starts streaming one output file of a foreground run. It
+// returns nil when the file cannot be created; persistRunOutput then writes
+// the captured output once the run exits.
+func openOutputStream(stateDir, runID, fileName string, maxSize int64, redact *logs.Redactor, echo io.Writer) *logs.OutputStream {
+	path := filepath.Join(stateDir, "runs", runID, fileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil
+	}
+	s, err := logs.NewOutputStream(path, maxSize, redact, echo)
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// persistRunOutput finishes one output file. Streamed output is kept as is;
+// otherwise (detached runs, an adapter that does not stream, or a stream that
+// failed part way) the captured content is written in one piece, and shown on
+// echo if nothing reached it yet.
+func persistRunOutput(stateDir, runID, fileName, content string, stream *logs.OutputStream, maxSize int64, redact *logs.Redactor, echo io.Writer) {
+	if stream != nil {
+		closeErr := stream.Close()
+		streamed := stream.Written() > 0
+		if closeErr == nil && (streamed || content == "") {
+			return
+		}
+		if !streamed && echo != nil {
+			_, _ = io.WriteString(echo, redact.Redact(content))
+		}
+	}
+	_ = writeRunOutput(stateDir, runID, fileName, content, maxSize, redact)
 }
 
 func intPtr(v int) *int { return &v }
@@ -359,6 +775,16 @@ func mergeEnvMany(maps ...map[string]string) map[string]string {
 	return out
 }
 
+// envKeys returns the sorted names of env.
+func envKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func filterEnvAllowlist(env map[string]string, allow map[string]struct{}) map[string]string {
 	if len(env) == 0 || len(allow) == 0 {
 		return map[string]string{}
@@ -372,6 +798,30 @@ func filterEnvAllowlist(env map[string]string, allow map[string]struct{}) map[st
 	return out
 }
 
+// overrideLLM applies per-run model and base URL overrides to spec. Overrides
+// are refused when the clawfile declares no provider, since there would be no
+// API key env or allowlist to attach them to.
+func overrideLLM(spec v1.LLMSpec, model, baseURL string) (v1.LLMSpec, error) {
+	model, baseURL = strings.TrimSpace(model), strings.TrimSpace(baseURL)
+	if model == "" && baseURL == "" {
+		return spec, nil
+	}
+	if spec.Provider == "" {
+		return spec, fmt.Errorf("--llm-model/--llm-base-url need agent.llm.provider to be declared in the clawfile")
+	}
+	if model != "" {
+		spec.Model = model
+	}
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return spec, fmt.Errorf("invalid --llm-base-url %q: want an http(s) URL", baseURL)
+		}
+		spec.BaseURL = baseURL
+	}
+	return spec, nil
+}
+
 var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
 
 func resolveHostSecretEnvs(names []string) (map[string]string, error) {
@@ -407,6 +857,75 @@ func resolveHostSecretEnvs(names []string) (map[string]string, error) {
 	return out, nil
 }
 
+// resolveSecretFiles reads habitat.secretFiles at run time. Errors name the env
+// and path but never the file contents.
+func resolveSecretFiles(files map[string]string) (map[string]string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make(map[string]string, len(files))
+	for _, name := range names {
+		p := files[name]
+		if !envNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid habitat secretFiles name: %q", name)
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("read secret file for %s: %w", name, err)
+		}
+		value := strings.TrimSpace(string(b))
+		if value == "" {
+			return nil, fmt.Errorf("secret file for %s is empty: %s", name, p)
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
+// secretValues lists the values persisted output must never contain: the LLM
+// API key and every resolved secret, but not plain habitat or env-file values.
+func secretValues(llmKey string, secrets ...map[string]string) []string {
+	out := []string{llmKey}
+	for _, m := range secrets {
+		for _, v := range m {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// resolveSecretFileFlags reads --secret-file NAME=path values. Only the
+// trailing newline is trimmed so secrets with meaningful whitespace survive;
+// errors name the env and path but never the file contents.
+func resolveSecretFileFlags(specs []string) (map[string]string, error) {
+	out := make(map[string]string, len(specs))
+	for _, raw := range specs {
+		name, p, ok := strings.Cut(strings.TrimSpace(raw), "=")
+		name, p = strings.TrimSpace(name), strings.TrimSpace(p)
+		if !ok || name == "" || p == "" {
+			return nil, fmt.Errorf("invalid --secret-file %q (want NAME=path)", raw)
+		}
+		if !envNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid --secret-file name: %q", name)
+		}
+		if _, dup := out[name]; dup {
+			return nil, fmt.Errorf("duplicate --secret-file name: %s", name)
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("read secret file for %s: %w", name, err)
+		}
+		value := strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+		if strings.TrimSpace(value) == "" {
+			return nil, fmt.Errorf("secret file for %s is empty: %s", name, p)
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
 func (m *Manager) refreshRunStatus(ctx context.Context, rec store.RunRecord) (store.RunRecord, error) {
 	if rec.Status != "running" || rec.ContainerID == "" {
 		return rec, nil
@@ -431,22 +950,47 @@ func (m *Manager) refreshRunStatus(ctx context.Context, rec store.RunRecord) (st
 	if err != nil {
 		return rec, err
 	}
-	containerStatus, exitCode, err := parseContainerInspectState(raw)
+	payload, err := parseInspectPayload(raw)
+	if err != nil {
+		return rec, err
+	}
+	containerStatus, exitCode, err := payload.normalize()
 	if err != nil {
 		return rec, err
 	}
 	runStatus, terminal := mapContainerStatus(containerStatus, exitCode)
+	unhealthy := !terminal && payload.health() == "unhealthy"
+	if unhealthy {
+		runStatus, terminal = "failed", true
+	}
 	if !terminal {
 		return rec, nil
 	}
 	lastError := ""
-	if runStatus == "failed" {
+	if unhealthy {
+		lastError = "container healthcheck reported unhealthy"
+	} else if runStatus == "failed" {
 		if exitCode != nil {
 			lastError = fmt.Sprintf("detached container exited with code %d", *exitCode)
 		} else {
 			lastError = "detached container exited"
 		}
 	}
+	if rp := restartPolicyFor(rec); rp != nil && restartWanted(rp.Mode, runStatus) {
+		if rp.MaxRetries == 0 || rec.RestartCount < rp.MaxRetries {
+			if !restartDue(rec, time.Now().UTC()) {
+				return rec, nil
+			}
+			reason := lastError
+			if reason == "" {
+				reason = "detached container exited"
+			}
+			return m.restartRun(ctx, adapter, rec, *rp, exitCode, reason)
+		}
+		if lastError != "" {
+			lastError = fmt.Sprintf("%s (gave up after %d restarts)", lastError, rec.RestartCount)
+		}
+	}
 	if err := m.store.UpdateRunCompletion(rec.RunID, runStatus, rec.ContainerID, exitCode, lastError); err != nil {
 		return rec, err
 	}
@@ -474,32 +1018,78 @@ type inspectPayload struct {
 }
 
 type inspectState struct {
-	Status        string `json:"Status"`
-	StatusLower   string `json:"status"`
-	ExitCode      *int   `json:"ExitCode"`
-	ExitCodeLower *int   `json:"exitCode"`
+	Status        string         `json:"Status"`
+	StatusLower   string         `json:"status"`
+	ExitCode      *int           `json:"ExitCode"`
+	ExitCodeLower *int           `json:"exitCode"`
+	Health        *inspectHealth `json:"Health"`
+	Healthcheck   *inspectHealth `json:"Healthcheck"`
+}
+
+type inspectHealth struct {
+	Status string `json:"Status"`
 }
 
 func parseContainerInspectState(raw string) (string, *int, error) {
+	payload, err := parseInspectPayload(raw)
+	if err != nil {
+		return "", nil, err
+	}
+	return payload.normalize()
+}
+
+func parseInspectPayload(raw string) (inspectPayload, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
-		return "", nil, fmt.Errorf("empty inspect payload")
+		return inspectPayload{}, fmt.Errorf("empty inspect payload")
 	}
 	if strings.HasPrefix(trimmed, "[") {
 		var payload []inspectPayload
 		if err := json.Unmarshal([]byte(trimmed), &payload); err != nil {
-			return "", nil, err
+			return inspectPayload{}, err
 		}
 		if len(payload) == 0 {
-			return "", nil, fmt.Errorf("inspect payload is empty")
+			return inspectPayload{}, fmt.Errorf("inspect payload is empty")
 		}
-		return payload[0].normalize()
+		return payload[0], nil
 	}
 	var payload inspectPayload
 	if err := json.Unmarshal([]byte(trimmed), &payload); err != nil {
-		return "", nil, err
+		return inspectPayload{}, err
 	}
-	return payload.normalize()
+	return payload, nil
+}
+
+// health returns the lower-cased healthcheck status, or "" when the container
+// has no healthcheck. Older podman releases report it under State.Healthcheck.
+func (p inspectPayload) health() string {
+	for _, h := range []*inspectHealth{p.State.Health, p.State.Healthcheck, p.StateLower.Health, p.StateLower.Healthcheck} {
+		if h != nil && h.Status != "" {
+			return strings.ToLower(h.Status)
+		}
+	}
+	return ""
+}
+
+// runHealthcheck merges the clawfile healthcheck with the CLI override, whose
+// command and interval win. Durations were validated at compile time.
+func runHealthcheck(cfg v1.Clawfile, opts RunOptions) *spec.Healthcheck {
+	var hc *spec.Healthcheck
+	if h := cfg.Agent.Runtime.Healthcheck; h != nil && strings.TrimSpace(h.Command) != "" {
+		interval, _ := time.ParseDuration(h.Interval)
+		startPeriod, _ := time.ParseDuration(h.StartPeriod)
+		hc = &spec.Healthcheck{Cmd: h.Command, Interval: interval, Retries: h.Retries, StartPeriod: startPeriod}
+	}
+	if cmd := strings.TrimSpace(opts.HealthcheckCmd); cmd != "" {
+		if hc == nil {
+			hc = &spec.Healthcheck{}
+		}
+		hc.Cmd = cmd
+		if opts.HealthcheckInterval > 0 {
+			hc.Interval = opts.HealthcheckInterval
+		}
+	}
+	return hc
 }
 
 func mapContainerStatus(status string, exitCode *int) (string, bool) {
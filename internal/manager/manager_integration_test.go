@@ -128,6 +128,26 @@ func TestE2ERuntimeDaemonLifecycle(t *testing.T) {
 	if _, err := inspectContainer(runtimeTarget, rec.ContainerID); err != nil {
 		t.Fatalf("expected running container to be inspectable: %v", err)
 	}
+
+	res, err := m.Exec(ctx, rec.RunID, []string{"sh", "-c", "echo E2E_EXEC && exit 4"})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if res.ExitCode != 4 || !strings.Contains(res.Stdout, "E2E_EXEC") {
+		t.Fatalf("unexpected exec result: %+v", res)
+	}
+
+	stopped, err := m.Stop(ctx, rec.RunID, time.Second)
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if stopped.Status != "stopped" {
+		t.Fatalf("expected stopped status, got %q", stopped.Status)
+	}
+	cleanupContainer(t, runtimeTarget, rec.ContainerID)
+	if again, err := m.Stop(ctx, rec.RunID, time.Second); err != nil || again.Status != "stopped" {
+		t.Fatalf("second Stop() should be a no-op, got status=%q err=%v", again.Status, err)
+	}
 }
 
 func TestE2EDaemonStatusReconcilesAfterContainerExit(t *testing.T) {
@@ -231,6 +251,83 @@ func TestE2ERuntimeDebugPauseOnFailure(t *testing.T) {
 	}
 }
 
+func TestE2ERuntimePreserveOnFailureForcesPause(t *testing.T) {
+	runtimeTarget := requireHealthyRuntime(t)
+	ensureImageAvailable(t, runtimeTarget, integrationImage)
+
+	stateDir := t.TempDir()
+	clawPath := writeClawfile(t, stateDir, clawSpec{
+		Name:      "e2e-preserve-fail",
+		Lifecycle: "ephemeral",
+		Runtime:   runtimeTarget,
+		Image:     integrationImage,
+		Command:   "exit 3",
+	})
+
+	m, err := manager.New(stateDir)
+	if err != nil {
+		t.Fatalf("manager.New() error = %v", err)
+	}
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	rec, err := m.Run(ctx, manager.RunOptions{InputPath: clawPath, PreserveOnFailure: true})
+	if err == nil {
+		t.Fatal("expected Run() to return an error for failing command")
+	}
+	if rec.Status != "failed_paused" {
+		t.Fatalf("expected failed_paused status, got %q", rec.Status)
+	}
+	defer cleanupContainer(t, runtimeTarget, rec.ContainerID)
+	if _, err := inspectContainer(runtimeTarget, rec.ContainerID); err != nil {
+		t.Fatalf("expected preserved container to be inspectable: %v", err)
+	}
+}
+
+func TestE2ERuntimeTimeoutMarksTimedOut(t *testing.T) {
+	runtimeTarget := requireHealthyRuntime(t)
+	ensureImageAvailable(t, runtimeTarget, integrationImage)
+
+	stateDir := t.TempDir()
+	clawPath := writeClawfile(t, stateDir, clawSpec{
+		Name:      "e2e-timeout",
+		Lifecycle: "ephemeral",
+		Runtime:   runtimeTarget,
+		Image:     integrationImage,
+		Command:   "sleep 120",
+	})
+
+	m, err := manager.New(stateDir)
+	if err != nil {
+		t.Fatalf("manager.New() error = %v", err)
+	}
+	defer m.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rec, err := m.Run(ctx, manager.RunOptions{InputPath: clawPath})
+	if err == nil {
+		t.Fatal("expected Run() to return an error after the timeout")
+	}
+	if rec.Status != "timed_out" {
+		t.Fatalf("expected timed_out status, got %q", rec.Status)
+	}
+	saved, err := m.GetRun(rec.RunID)
+	if err != nil {
+		t.Fatalf("GetRun() error = %v", err)
+	}
+	if saved.Status != "timed_out" || !strings.Contains(saved.LastError, "exceeded timeout") {
+		t.Fatalf("unexpected saved run: status=%q lastError=%q", saved.Status, saved.LastError)
+	}
+	if err := expectContainerGone(runtimeTarget, rec.ContainerID); err != nil {
+		cleanupContainer(t, runtimeTarget, rec.ContainerID)
+		t.Fatalf("expected container to be removed: %v", err)
+	}
+}
+
 func TestE2ERuntimeOverridePrecedence(t *testing.T) {
 	available := healthyRuntimes()
 	if len(available) == 0 {
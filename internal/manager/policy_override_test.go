@@ -0,0 +1,74 @@
+package manager
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"github.com/fpp-125/metaclaw/internal/policy"
+)
+
+func TestForceReadOnlyMounts(t *testing.T) {
+	in := policy.Policy{
+		Mounts: []policy.MountPolicy{
+			{Source: "/host/vault", Target: "/vault", ReadOnly: false},
+			{Source: "/host/config", Target: "/config", ReadOnly: true},
+		},
+	}
+	out, downgraded := forceReadOnlyMounts(in)
+	for _, m := range out.Mounts {
+		if !m.ReadOnly {
+			t.Fatalf("expected all mounts read-only: %+v", out.Mounts)
+		}
+	}
+	if len(downgraded) != 1 || downgraded[0] != "/host/vault:/vault" {
+		t.Fatalf("unexpected downgraded mounts: %v", downgraded)
+	}
+	if in.Mounts[0].ReadOnly {
+		t.Fatal("input policy should not be mutated")
+	}
+}
+
+func TestForceReadOnlyMountsCoversSoulMemory(t *testing.T) {
+	pol, err := policy.Compile(v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: "none"}},
+			Soul:    v1.SoulSpec{Memory: "/home/me/bot/memory", MemoryWritable: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("policy.Compile() error = %v", err)
+	}
+	out, downgraded := forceReadOnlyMounts(pol)
+	if len(downgraded) != 1 || downgraded[0] != "/home/me/bot/memory:"+v1.SoulDir+"/memory" || !out.Mounts[0].ReadOnly {
+		t.Fatalf("expected --read-only-mounts to cover the soul memory mount: %+v %v", out.Mounts, downgraded)
+	}
+}
+
+func TestOverrideLLM(t *testing.T) {
+	spec := v1.LLMSpec{Provider: v1.LLMProviderOpenAICompatible, Model: "gpt-4.1", APIKeyEnv: "OPENAI_API_KEY"}
+	same, err := overrideLLM(spec, "", "")
+	if err != nil || same != spec {
+		t.Fatalf("expected no-op without overrides, got %+v (%v)", same, err)
+	}
+	out, err := overrideLLM(spec, "gpt-4.1-mini", "https://llm.internal/v1")
+	if err != nil {
+		t.Fatalf("overrideLLM error: %v", err)
+	}
+	if out.Model != "gpt-4.1-mini" || out.BaseURL != "https://llm.internal/v1" || out.Provider != spec.Provider {
+		t.Fatalf("unexpected override: %+v", out)
+	}
+	if spec.Model != "gpt-4.1" {
+		t.Fatalf("override mutated the input spec")
+	}
+	if _, err := overrideLLM(spec, "", "llm.internal/v1"); err == nil || !strings.Contains(err.Error(), "invalid --llm-base-url") {
+		t.Fatalf("expected invalid base url error, got %v", err)
+	}
+	if _, err := overrideLLM(v1.LLMSpec{}, "gpt-4.1-mini", ""); err == nil || !strings.Contains(err.Error(), "agent.llm.provider") {
+		t.Fatalf("expected missing provider error, got %v", err)
+	}
+}
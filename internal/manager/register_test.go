@@ -0,0 +1,34 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegisterCapsuleCompilesWithoutRunning(t *testing.T) {
+	stateDir := t.TempDir()
+	m, err := New(stateDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer m.Close()
+
+	c, err := m.RegisterCapsule(RunOptions{InputPath: filepath.Join("..", "..", "testdata", "hello.claw"), NoHashCache: true})
+	if err != nil {
+		t.Fatalf("RegisterCapsule() error = %v", err)
+	}
+	if c.CapsuleID == "" {
+		t.Fatal("expected capsule id")
+	}
+	if _, err := os.Stat(filepath.Join(c.CapsulePath, "manifest.json")); err != nil {
+		t.Fatalf("expected compiled capsule manifest: %v", err)
+	}
+	runs, err := m.ListRuns(10)
+	if err != nil {
+		t.Fatalf("ListRuns() error = %v", err)
+	}
+	if len(runs) != 0 {
+		t.Fatalf("expected no runs to be recorded, got %d", len(runs))
+	}
+}
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+func TestRemoveRun(t *testing.T) {
+	stateDir := t.TempDir()
+	m, err := New(stateDir)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer m.Close()
+
+	for _, rec := range []store.RunRecord{
+		{RunID: "done", CapsuleID: "c", CapsulePath: "p", Status: "succeeded", Lifecycle: "ephemeral", RuntimeTarget: "docker", StartedAt: "2026-01-01T00:00:00Z"},
+		{RunID: "live", CapsuleID: "c", CapsulePath: "p", Status: "running", Lifecycle: "daemon", RuntimeTarget: "docker", StartedAt: "2026-01-01T00:00:01Z"},
+	} {
+		if err := m.store.InsertRun(rec); err != nil {
+			t.Fatalf("InsertRun(%s) error = %v", rec.RunID, err)
+		}
+	}
+	if err := writeRunOutput(stateDir, "done", "stdout.log", "hi\n", 0, nil); err != nil {
+		t.Fatalf("writeRunOutput() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := m.RemoveRun(ctx, "done", false); err != nil {
+		t.Fatalf("RemoveRun(done) error = %v", err)
+	}
+	if _, err := m.GetRun("done"); err == nil {
+		t.Fatal("expected run record to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, "runs", "done")); !os.IsNotExist(err) {
+		t.Fatalf("expected run dir removed, stat err = %v", err)
+	}
+
+	if err := m.RemoveRun(ctx, "live", false); err == nil || !strings.Contains(err.Error(), "still running") {
+		t.Fatalf("expected running run to be refused, got %v", err)
+	}
+	if err := m.RemoveRun(ctx, "live", true); err != nil {
+		t.Fatalf("RemoveRun(live, force) error = %v", err)
+	}
+	if err := m.RemoveRun(ctx, "missing", false); err == nil || !strings.Contains(err.Error(), "run not found") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
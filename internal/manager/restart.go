@@ -0,0 +1,107 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"github.com/fpp-125/metaclaw/internal/logs"
+	"github.com/fpp-125/metaclaw/internal/runtime/spec"
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+// maxRestartBackoff caps the delay between relaunches of a crash-looping daemon.
+const maxRestartBackoff = 5 * time.Minute
+
+// restartPolicyFor loads the restart policy of a daemon run from its capsule.
+// It returns nil when the run is not supervised.
+func restartPolicyFor(rec store.RunRecord) *v1.RestartPolicy {
+	if rec.Lifecycle != string(v1.LifecycleDaemon) {
+		return nil
+	}
+	cfg, _, _, _, err := loadFromCapsuleDir(rec.CapsulePath)
+	if err != nil {
+		return nil
+	}
+	rp := cfg.Agent.Runtime.RestartPolicy
+	if rp == nil || rp.Mode == "" || rp.Mode == v1.RestartNever {
+		return nil
+	}
+	return rp
+}
+
+// restartWanted reports whether a container that ended with runStatus should
+// be relaunched under mode.
+func restartWanted(mode v1.RestartMode, runStatus string) bool {
+	switch mode {
+	case v1.RestartAlways:
+		return true
+	case v1.RestartOnFailure:
+		return runStatus == "failed"
+	default:
+		return false
+	}
+}
+
+// restartBackoff doubles from one second per restart already made.
+func restartBackoff(restarts int) time.Duration {
+	if restarts >= 9 {
+		return maxRestartBackoff
+	}
+	return min(time.Second<<restarts, maxRestartBackoff)
+}
+
+// restartDue reports whether the backoff since the last (re)start has elapsed.
+// An unparsable timestamp never holds a restart back.
+func restartDue(rec store.RunRecord, now time.Time) bool {
+	last := rec.LastRestartAt
+	if last == "" {
+		last = rec.StartedAt
+	}
+	at, err := time.Parse(time.RFC3339Nano, last)
+	if err != nil {
+		return true
+	}
+	return now.Sub(at) >= restartBackoff(rec.RestartCount)
+}
+
+// restartRun relaunches the run's container in place, so the original env and
+// mounts are kept. A container that is still running (unhealthy) is stopped
+// first. When the relaunch fails the run is recorded as failed.
+func (m *Manager) restartRun(ctx context.Context, adapter spec.Adapter, rec store.RunRecord, rp v1.RestartPolicy, exitCode *int, reason string) (store.RunRecord, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	attempt := rec.RestartCount + 1
+	limit := ""
+	if rp.MaxRetries > 0 {
+		limit = fmt.Sprintf("/%d", rp.MaxRetries)
+	}
+	_ = adapter.Stop(ctx, rec.ContainerID, time.Second)
+	if err := adapter.Start(ctx, rec.ContainerID); err != nil {
+		lastError := fmt.Sprintf("restart %d%s failed: %v", attempt, limit, err)
+		if err := m.store.UpdateRunCompletion(rec.RunID, "failed", rec.ContainerID, exitCode, lastError); err != nil {
+			return rec, err
+		}
+		_ = logs.AppendEvent(m.stateDir, rec.RunID, logs.Event{Phase: "runtime.restart", Runtime: rec.RuntimeTarget, ContainerID: rec.ContainerID, Message: "restart failed", Error: lastError})
+		rec.Status = "failed"
+		rec.ExitCode = exitCode
+		rec.LastError = lastError
+		rec.EndedAt = time.Now().UTC().Format(time.RFC3339Nano)
+		return rec, nil
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := m.store.RecordRunRestart(rec.RunID, attempt, now); err != nil {
+		return rec, err
+	}
+	_ = logs.AppendEvent(m.stateDir, rec.RunID, logs.Event{
+		Phase:       "runtime.restart",
+		Runtime:     rec.RuntimeTarget,
+		ContainerID: rec.ContainerID,
+		Message:     fmt.Sprintf("container restarted (attempt %d%s, policy %s)", attempt, limit, rp.Mode),
+		Error:       reason,
+	})
+	rec.RestartCount = attempt
+	rec.LastRestartAt = now
+	return rec, nil
+}
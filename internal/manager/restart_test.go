@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+func TestRestartWanted(t *testing.T) {
+	cases := []struct {
+		mode   v1.RestartMode
+		status string
+		want   bool
+	}{
+		{v1.RestartAlways, "succeeded", true},
+		{v1.RestartAlways, "failed", true},
+		{v1.RestartOnFailure, "failed", true},
+		{v1.RestartOnFailure, "succeeded", false},
+		{v1.RestartNever, "failed", false},
+	}
+	for _, tc := range cases {
+		if got := restartWanted(tc.mode, tc.status); got != tc.want {
+			t.Fatalf("restartWanted(%s, %s) = %v, want %v", tc.mode, tc.status, got, tc.want)
+		}
+	}
+}
+
+func TestRestartDueHonorsBackoff(t *testing.T) {
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rec := store.RunRecord{StartedAt: started.Format(time.RFC3339Nano)}
+	if restartDue(rec, started.Add(500*time.Millisecond)) {
+		t.Fatal("first restart should wait one second after start")
+	}
+	if !restartDue(rec, started.Add(time.Second)) {
+		t.Fatal("first restart should be due after one second")
+	}
+
+	rec.RestartCount = 3
+	rec.LastRestartAt = started.Add(time.Minute).Format(time.RFC3339Nano)
+	if restartDue(rec, started.Add(time.Minute+7*time.Second)) {
+		t.Fatal("fourth restart should wait 8s after the previous one")
+	}
+	if !restartDue(rec, started.Add(time.Minute+8*time.Second)) {
+		t.Fatal("fourth restart should be due after 8s")
+	}
+	if got := restartBackoff(40); got != maxRestartBackoff {
+		t.Fatalf("restartBackoff(40) = %s, want cap %s", got, maxRestartBackoff)
+	}
+}
+
+func TestRestartPolicyForOnlySupervisesDaemons(t *testing.T) {
+	if rp := restartPolicyFor(store.RunRecord{Lifecycle: "ephemeral", CapsulePath: t.TempDir()}); rp != nil {
+		t.Fatalf("expected no policy for ephemeral run, got %+v", rp)
+	}
+	if rp := restartPolicyFor(store.RunRecord{Lifecycle: "daemon", CapsulePath: t.TempDir()}); rp != nil {
+		t.Fatalf("expected no policy for unreadable capsule, got %+v", rp)
+	}
+}
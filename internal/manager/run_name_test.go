@@ -0,0 +1,46 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	store "github.com/fpp-125/metaclaw/internal/store/sqlite"
+)
+
+func TestRunNameResolutionAndUniqueness(t *testing.T) {
+	m, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer m.Close()
+
+	for _, rec := range []store.RunRecord{
+		{RunID: "run_old", Name: "bot", CapsuleID: "c", CapsulePath: "p", Status: "succeeded", Lifecycle: "ephemeral", RuntimeTarget: "docker", StartedAt: "2026-01-01T00:00:00Z"},
+		{RunID: "run_new", Name: "bot", CapsuleID: "c", CapsulePath: "p", Status: "succeeded", Lifecycle: "ephemeral", RuntimeTarget: "docker", StartedAt: "2026-01-02T00:00:00Z"},
+		{RunID: "run_live", Name: "daemon", CapsuleID: "c", CapsulePath: "p", Status: "running", Lifecycle: "daemon", RuntimeTarget: "docker", StartedAt: "2026-01-03T00:00:00Z"},
+	} {
+		if err := m.store.InsertRun(rec); err != nil {
+			t.Fatalf("InsertRun(%s) error = %v", rec.RunID, err)
+		}
+	}
+
+	r, err := m.GetRun("bot")
+	if err != nil || r.RunID != "run_new" {
+		t.Fatalf("GetRun(bot) = %q, %v; want newest run_new", r.RunID, err)
+	}
+	if r, err := m.GetRun("run_old"); err != nil || r.Name != "bot" {
+		t.Fatalf("GetRun(run_old) = %+v, %v", r, err)
+	}
+
+	ctx := context.Background()
+	if err := m.checkRunName(ctx, "bot"); err != nil {
+		t.Fatalf("terminal runs should not block name reuse: %v", err)
+	}
+	if err := m.checkRunName(ctx, "daemon"); err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Fatalf("expected name conflict with running run, got %v", err)
+	}
+	if err := m.checkRunName(ctx, "bad name!"); err == nil || !strings.Contains(err.Error(), "invalid run name") {
+		t.Fatalf("expected invalid name error, got %v", err)
+	}
+}
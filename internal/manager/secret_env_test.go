@@ -1,8 +1,13 @@
 package manager
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/fpp-125/metaclaw/internal/logs"
 )
 
 func TestResolveHostSecretEnvs(t *testing.T) {
@@ -44,3 +49,153 @@ func TestMergeEnvMany(t *testing.T) {
 		t.Fatalf("unexpected merged env: %+v", out)
 	}
 }
+
+func TestResolveSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte("  ghp_example\n"), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+	got, err := resolveSecretFiles(map[string]string{"GITHUB_TOKEN": tokenPath})
+	if err != nil {
+		t.Fatalf("resolveSecretFiles error: %v", err)
+	}
+	if got["GITHUB_TOKEN"] != "ghp_example" {
+		t.Fatalf("expected trimmed secret, got %q", got["GITHUB_TOKEN"])
+	}
+
+	emptyPath := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyPath, []byte("\n"), 0o600); err != nil {
+		t.Fatalf("write empty: %v", err)
+	}
+	if _, err := resolveSecretFiles(map[string]string{"EMPTY": emptyPath}); err == nil {
+		t.Fatal("expected empty secret file error")
+	}
+	_, err = resolveSecretFiles(map[string]string{"MISSING": filepath.Join(dir, "missing")})
+	if err == nil || !strings.Contains(err.Error(), "MISSING") {
+		t.Fatalf("expected missing file error naming env, got %v", err)
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	content := "# staging overrides\n\nLOG_LEVEL=debug\nexport REGION=\"eu-west-1\"\nGREETING='hello world'\nEMPTY=\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readEnvFile(path)
+	if err != nil {
+		t.Fatalf("readEnvFile error: %v", err)
+	}
+	want := map[string]string{"LOG_LEVEL": "debug", "REGION": "eu-west-1", "GREETING": "hello world", "EMPTY": ""}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected env: %+v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	for name, bad := range map[string]string{
+		"invalid name":   "BAD-NAME=x\n",
+		"missing equals": "JUST_A_WORD\n",
+		"multi-line":     "CERT=\"line one\nline two\"\n",
+	} {
+		p := filepath.Join(dir, strings.ReplaceAll(name, " ", "_"))
+		if err := os.WriteFile(p, []byte(bad), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readEnvFile(p); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEnvKeysSortedNamesOnly(t *testing.T) {
+	got := envKeys(map[string]string{"OPENAI_API_KEY": "sk-secret", "A_VAR": "x"})
+	if strings.Join(got, ",") != "A_VAR,OPENAI_API_KEY" {
+		t.Fatalf("unexpected keys: %v", got)
+	}
+}
+
+func TestResolveSecretFileFlags(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte(" pass phrase \n"), 0o600); err != nil {
+		t.Fatalf("write token: %v", err)
+	}
+	emptyPath := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyPath, []byte("\n"), 0o600); err != nil {
+		t.Fatalf("write empty: %v", err)
+	}
+	got, err := resolveSecretFileFlags([]string{"TAVILY_API_KEY=" + tokenPath})
+	if err != nil {
+		t.Fatalf("resolveSecretFileFlags error: %v", err)
+	}
+	if got["TAVILY_API_KEY"] != " pass phrase " {
+		t.Fatalf("expected only the trailing newline trimmed, got %q", got["TAVILY_API_KEY"])
+	}
+	for _, spec := range []string{
+		"BAD-NAME=" + tokenPath,
+		tokenPath,
+		"TOKEN=",
+		"TOKEN=" + emptyPath,
+		"TOKEN=" + filepath.Join(dir, "missing"),
+	} {
+		if _, err := resolveSecretFileFlags([]string{spec}); err == nil {
+			t.Fatalf("%s: expected error", spec)
+		}
+	}
+	if _, err := resolveSecretFileFlags([]string{"TOKEN=" + tokenPath, "TOKEN=" + tokenPath}); err == nil {
+		t.Fatal("expected duplicate name error")
+	}
+}
+
+func TestWriteRunOutputRedactsSecrets(t *testing.T) {
+	stateDir := t.TempDir()
+	redact := logs.NewRedactor(secretValues("sk-llm-123456", map[string]string{"TAVILY_API_KEY": "tvly-dev-example"})...)
+	if err := writeRunOutput(stateDir, "r1", "stderr.log", "auth sk-llm-123456 and tvly-dev-example failed\n", 0, redact); err != nil {
+		t.Fatalf("writeRunOutput() error = %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(stateDir, "runs", "r1", "stderr.log"))
+	if err != nil {
+		t.Fatalf("read stderr.log: %v", err)
+	}
+	if got := string(b); got != "auth *** and *** failed\n" {
+		t.Fatalf("stderr.log = %q", got)
+	}
+	if err := writeRunOutput(stateDir, "r2", "stderr.log", "sk-llm-123456\n", 0, nil); err != nil {
+		t.Fatalf("writeRunOutput() error = %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(stateDir, "runs", "r2", "stderr.log")); !strings.Contains(string(b), "sk-llm-123456") {
+		t.Fatalf("nil redactor should write verbatim, got %q", b)
+	}
+}
+
+func TestPersistRunOutput(t *testing.T) {
+	stateDir := t.TempDir()
+	redact := logs.NewRedactor("sk-llm-123456")
+	read := func(runID string) string {
+		b, _ := os.ReadFile(filepath.Join(stateDir, "runs", runID, "stdout.log"))
+		return string(b)
+	}
+
+	var echo bytes.Buffer
+	streamed := openOutputStream(stateDir, "streamed", "stdout.log", 0, redact, &echo)
+	_, _ = streamed.Write([]byte("key sk-llm-123456\n"))
+	persistRunOutput(stateDir, "streamed", "stdout.log", "key sk-llm-123456\n", streamed, 0, redact, &echo)
+	if got := read("streamed"); got != "key ***\n" || echo.String() != got {
+		t.Fatalf("streamed stdout.log = %q, echo = %q", got, echo.String())
+	}
+
+	// An adapter that ignores RunOptions.Stdout leaves the stream empty; the
+	// captured output is stored and echoed once the run exits.
+	echo.Reset()
+	silent := openOutputStream(stateDir, "silent", "stdout.log", 0, redact, &echo)
+	persistRunOutput(stateDir, "silent", "stdout.log", "key sk-llm-123456\n", silent, 0, redact, &echo)
+	if got := read("silent"); got != "key ***\n" || echo.String() != got {
+		t.Fatalf("fallback stdout.log = %q, echo = %q", got, echo.String())
+	}
+}
@@ -62,3 +62,20 @@ func TestMapContainerStatus(t *testing.T) {
 		t.Fatalf("expected non-terminal running state, got status=%q terminal=%v", status, terminal)
 	}
 }
+
+func TestInspectPayloadHealth(t *testing.T) {
+	cases := map[string]string{
+		`[{"State":{"Status":"running","Health":{"Status":"unhealthy"}}}]`:  "unhealthy",
+		`{"State":{"Status":"running","Healthcheck":{"Status":"Healthy"}}}`: "healthy",
+		`{"State":{"Status":"running"}}`:                                    "",
+	}
+	for raw, want := range cases {
+		p, err := parseInspectPayload(raw)
+		if err != nil {
+			t.Fatalf("parseInspectPayload(%s) error = %v", raw, err)
+		}
+		if got := p.health(); got != want {
+			t.Fatalf("health(%s) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -9,17 +9,24 @@ import (
 )
 
 type Policy struct {
-	Version      string        `json:"version"`
-	Network      NetworkPolicy `json:"network"`
-	Mounts       []MountPolicy `json:"mounts"`
-	EnvAllowlist []string      `json:"envAllowlist"`
-	Workdir      string        `json:"workdir,omitempty"`
-	User         string        `json:"user,omitempty"`
+	Version        string        `json:"version"`
+	Network        NetworkPolicy `json:"network"`
+	Mounts         []MountPolicy `json:"mounts"`
+	Tmpfs          []TmpfsPolicy `json:"tmpfs,omitempty"`
+	Ports          []PortPolicy  `json:"ports,omitempty"`
+	EnvAllowlist   []string      `json:"envAllowlist"`
+	ExtraHosts     []string      `json:"extraHosts,omitempty"`
+	CapAdd         []string      `json:"capAdd,omitempty"`
+	CapDrop        []string      `json:"capDrop,omitempty"`
+	Workdir        string        `json:"workdir,omitempty"`
+	User           string        `json:"user,omitempty"`
+	ReadOnlyRootfs bool          `json:"readOnlyRootfs,omitempty"`
 }
 
 type NetworkPolicy struct {
-	Mode    string `json:"mode"`
-	Allowed bool   `json:"allowed"`
+	Mode           string   `json:"mode"`
+	Allowed        bool     `json:"allowed"`
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
 }
 
 type MountPolicy struct {
@@ -28,6 +35,19 @@ type MountPolicy struct {
 	ReadOnly bool   `json:"readOnly"`
 }
 
+type TmpfsPolicy struct {
+	Target string `json:"target"`
+	Size   string `json:"size,omitempty"`
+	Mode   string `json:"mode,omitempty"`
+}
+
+type PortPolicy struct {
+	HostIP        string `json:"hostIP"`
+	HostPort      int    `json:"hostPort"`
+	ContainerPort int    `json:"containerPort"`
+	Protocol      string `json:"protocol"`
+}
+
 func Compile(cfg v1.Clawfile) (Policy, error) {
 	p := Policy{Version: "metaclaw.policy/v1"}
 
@@ -37,6 +57,10 @@ func Compile(cfg v1.Clawfile) (Policy, error) {
 		p.Network = NetworkPolicy{Mode: "none", Allowed: false}
 	case "outbound", "all":
 		p.Network = NetworkPolicy{Mode: mode, Allowed: true}
+		if len(cfg.Agent.Habitat.Network.AllowedDomains) > 0 {
+			p.Network.AllowedDomains = append([]string(nil), cfg.Agent.Habitat.Network.AllowedDomains...)
+			sort.Strings(p.Network.AllowedDomains)
+		}
 	default:
 		return Policy{}, fmt.Errorf("unsupported network mode: %s", mode)
 	}
@@ -48,26 +72,77 @@ func Compile(cfg v1.Clawfile) (Policy, error) {
 			ReadOnly: m.ReadOnly,
 		})
 	}
+	soul := soulEntries(cfg.Agent.Soul)
+	for _, e := range soul {
+		if e.mount != nil {
+			p.Mounts = append(p.Mounts, *e.mount)
+		}
+	}
 	sort.Slice(p.Mounts, func(i, j int) bool {
-		if p.Mounts[i].Source == p.Mounts[j].Source {
-			return p.Mounts[i].Target < p.Mounts[j].Target
+		a, b := p.Mounts[i], p.Mounts[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return !a.ReadOnly && b.ReadOnly
+	})
+
+	for _, t := range cfg.Agent.Habitat.Tmpfs {
+		p.Tmpfs = append(p.Tmpfs, TmpfsPolicy{Target: t.Target, Size: t.Size, Mode: t.Mode})
+	}
+	sort.Slice(p.Tmpfs, func(i, j int) bool { return p.Tmpfs[i].Target < p.Tmpfs[j].Target })
+
+	for _, pm := range cfg.Agent.Habitat.Ports {
+		p.Ports = append(p.Ports, PortPolicy{HostIP: pm.HostIP, HostPort: pm.HostPort, ContainerPort: pm.ContainerPort, Protocol: pm.Protocol})
+	}
+	// Every comparator below orders on all fields, so the serialized policy
+	// does not depend on declaration order or on sort stability.
+	sort.Slice(p.Ports, func(i, j int) bool {
+		a, b := p.Ports[i], p.Ports[j]
+		if a.HostPort != b.HostPort {
+			return a.HostPort < b.HostPort
 		}
-		return p.Mounts[i].Source < p.Mounts[j].Source
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.HostIP != b.HostIP {
+			return a.HostIP < b.HostIP
+		}
+		return a.ContainerPort < b.ContainerPort
 	})
 
 	envSet := make(map[string]struct{})
 	for k := range cfg.Agent.Habitat.Env {
 		envSet[k] = struct{}{}
 	}
+	for k := range cfg.Agent.Habitat.SecretFiles {
+		envSet[k] = struct{}{}
+	}
 	for _, k := range llm.AllowedEnvKeys(cfg.Agent.LLM) {
 		envSet[k] = struct{}{}
 	}
+	for _, e := range soul {
+		envSet[e.env] = struct{}{}
+	}
 	for k := range envSet {
 		p.EnvAllowlist = append(p.EnvAllowlist, k)
 	}
 	sort.Strings(p.EnvAllowlist)
 
+	if len(cfg.Agent.Habitat.ExtraHosts) > 0 {
+		p.ExtraHosts = append([]string(nil), cfg.Agent.Habitat.ExtraHosts...)
+		sort.Strings(p.ExtraHosts)
+	}
+
+	p.CapAdd = append([]string(nil), cfg.Agent.Habitat.Capabilities.Add...)
+	sort.Strings(p.CapAdd)
+	p.CapDrop = append([]string(nil), cfg.Agent.Habitat.Capabilities.Drop...)
+	sort.Strings(p.CapDrop)
+
 	p.Workdir = cfg.Agent.Habitat.Workdir
 	p.User = cfg.Agent.Habitat.User
+	p.ReadOnlyRootfs = cfg.Agent.Habitat.ReadOnlyRootfs
 	return p, nil
 }
@@ -1,6 +1,7 @@
 package policy
 
 import (
+	"reflect"
 	"testing"
 
 	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
@@ -56,6 +57,87 @@ func TestCompileIncludesLLMEnvAllowlist(t *testing.T) {
 	assertContains(t, p.EnvAllowlist, "OPENAI_BASE_URL")
 }
 
+func TestCompileAllowlistsSecretFiles(t *testing.T) {
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:      "a",
+			Species:   v1.SpeciesNano,
+			Lifecycle: v1.LifecycleEphemeral,
+			Habitat: v1.HabitatSpec{
+				Network:     v1.NetworkSpec{Mode: "none"},
+				SecretFiles: map[string]string{"GITHUB_TOKEN": "/run/secrets/github"},
+			},
+		},
+	}
+	p, err := Compile(cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	assertContains(t, p.EnvAllowlist, "GITHUB_TOKEN")
+}
+
+func TestCompileSortsExtraHosts(t *testing.T) {
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:      "a",
+			Species:   v1.SpeciesNano,
+			Lifecycle: v1.LifecycleEphemeral,
+			Habitat: v1.HabitatSpec{
+				Network:    v1.NetworkSpec{Mode: "outbound"},
+				ExtraHosts: []string{"web.internal:10.0.0.6", "db.internal:10.0.0.5"},
+			},
+		},
+	}
+	p, err := Compile(cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if len(p.ExtraHosts) != 2 || p.ExtraHosts[0] != "db.internal:10.0.0.5" {
+		t.Fatalf("expected sorted extraHosts, got %v", p.ExtraHosts)
+	}
+}
+
+func TestCompileIgnoresDeclarationOrder(t *testing.T) {
+	build := func(ports []v1.PortMapping, caps []string) Policy {
+		t.Helper()
+		p, err := Compile(v1.Clawfile{
+			APIVersion: "metaclaw/v1",
+			Kind:       "Agent",
+			Agent: v1.AgentSpec{
+				Name:    "a",
+				Species: v1.SpeciesNano,
+				Habitat: v1.HabitatSpec{
+					Network:      v1.NetworkSpec{Mode: "outbound"},
+					Ports:        ports,
+					Capabilities: v1.CapabilitiesSpec{Add: caps},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Compile() error = %v", err)
+		}
+		return p
+	}
+	a := []v1.PortMapping{
+		{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostIP: "0.0.0.0", HostPort: 8080, ContainerPort: 81, Protocol: "tcp"},
+		{HostIP: "127.0.0.1", HostPort: 53, ContainerPort: 53, Protocol: "udp"},
+	}
+	b := []v1.PortMapping{a[2], a[1], a[0]}
+	p1 := build(a, []string{"NET_RAW", "CHOWN"})
+	p2 := build(b, []string{"CHOWN", "NET_RAW"})
+	if !reflect.DeepEqual(p1, p2) {
+		t.Fatalf("policy depends on declaration order:\n%+v\n%+v", p1, p2)
+	}
+	if p1.Ports[1].HostIP != "0.0.0.0" {
+		t.Fatalf("expected host IP to break port ties, got %+v", p1.Ports)
+	}
+}
+
 func assertContains(t *testing.T, list []string, want string) {
 	t.Helper()
 	for _, v := range list {
@@ -65,3 +147,46 @@ func assertContains(t *testing.T, list []string, want string) {
 	}
 	t.Fatalf("expected %q in %v", want, list)
 }
+
+func TestCompileSoul(t *testing.T) {
+	cfg := v1.Clawfile{
+		APIVersion: "metaclaw/v1",
+		Kind:       "Agent",
+		Agent: v1.AgentSpec{
+			Name:    "a",
+			Species: v1.SpeciesNano,
+			Habitat: v1.HabitatSpec{Network: v1.NetworkSpec{Mode: "none"}},
+			Soul:    v1.SoulSpec{Persona: "/home/me/bot/persona.md", Memory: "/home/me/bot/memory"},
+		},
+	}
+	p, err := Compile(cfg)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	wantMounts := []MountPolicy{
+		{Source: "/home/me/bot/memory", Target: "/metaclaw/soul/memory", ReadOnly: true},
+		{Source: "/home/me/bot/persona.md", Target: "/metaclaw/soul/persona.md", ReadOnly: true},
+	}
+	if !reflect.DeepEqual(p.Mounts, wantMounts) {
+		t.Fatalf("unexpected soul mounts: %+v", p.Mounts)
+	}
+	cfg.Agent.Soul.MemoryWritable = true
+	if p, err = Compile(cfg); err != nil || p.Mounts[0].ReadOnly {
+		t.Fatalf("expected memoryWritable to mount memory read-write: %+v (%v)", p.Mounts, err)
+	}
+	if !reflect.DeepEqual(p.EnvAllowlist, []string{EnvSoulMemoryPath, EnvSoulPersonaPath}) {
+		t.Fatalf("unexpected env allowlist: %v", p.EnvAllowlist)
+	}
+	env := SoulEnv(cfg.Agent.Soul)
+	if env[EnvSoulPersonaPath] != "/metaclaw/soul/persona.md" || env[EnvSoulMemoryPath] != "/metaclaw/soul/memory" {
+		t.Fatalf("unexpected soul env: %v", env)
+	}
+
+	inline := SoulEnv(v1.SoulSpec{Persona: "be kind"})
+	if len(inline) != 1 || inline[EnvSoulPersona] != "be kind" {
+		t.Fatalf("unexpected inline soul env: %v", inline)
+	}
+	if SoulEnv(v1.SoulSpec{}) != nil {
+		t.Fatal("expected no env without a soul")
+	}
+}
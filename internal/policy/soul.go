@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"path"
+	"path/filepath"
+
+	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+)
+
+// Soul env names. The plain names carry inline text; the _PATH names point at
+// a file-backed entry mounted under v1.SoulDir.
+const (
+	EnvSoulPersona     = "METACLAW_SOUL_PERSONA"
+	EnvSoulPersonaPath = "METACLAW_SOUL_PERSONA_PATH"
+	EnvSoulMemory      = "METACLAW_SOUL_MEMORY"
+	EnvSoulMemoryPath  = "METACLAW_SOUL_MEMORY_PATH"
+)
+
+type soulEntry struct {
+	env   string
+	value string
+	mount *MountPolicy
+}
+
+// soulEntries maps a normalized soul to env and mounts. Validation has
+// already turned file-backed entries into absolute host paths, so an absolute
+// value is a file and anything else is inline text. Both are mounted
+// read-only unless memoryWritable opts memory into updates.
+func soulEntries(s v1.SoulSpec) []soulEntry {
+	var out []soulEntry
+	for _, f := range []struct {
+		name, value, textEnv, pathEnv string
+		readOnly                      bool
+	}{
+		{"persona", s.Persona, EnvSoulPersona, EnvSoulPersonaPath, true},
+		{"memory", s.Memory, EnvSoulMemory, EnvSoulMemoryPath, !s.MemoryWritable},
+	} {
+		switch {
+		case f.value == "":
+		case filepath.IsAbs(f.value):
+			target := path.Join(v1.SoulDir, f.name+filepath.Ext(f.value))
+			out = append(out, soulEntry{
+				env:   f.pathEnv,
+				value: target,
+				mount: &MountPolicy{Source: f.value, Target: target, ReadOnly: f.readOnly},
+			})
+		default:
+			out = append(out, soulEntry{env: f.textEnv, value: f.value})
+		}
+	}
+	return out
+}
+
+// SoulEnv returns the env a container gets for agent.soul.
+func SoulEnv(s v1.SoulSpec) map[string]string {
+	entries := soulEntries(s)
+	if len(entries) == 0 {
+		return nil
+	}
+	env := make(map[string]string, len(entries))
+	for _, e := range entries {
+		env[e.env] = e.value
+	}
+	return env
+}
@@ -0,0 +1,46 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Managed file contents are kept next to the lock, addressed by the sha256
+// recorded in ProjectLock.ManagedFiles, so upgrades can three-way merge local
+// edits against the exact version that was installed.
+const baseDirName = "template-base"
+
+func baseBlobPath(hostDataDir, sum string) string {
+	return filepath.Join(hostDataDir, baseDirName, sum)
+}
+
+// storeBaseBlob records the content of path under its hash. Existing blobs are
+// left alone since the name already pins their content.
+func storeBaseBlob(hostDataDir, path, sum string) error {
+	dst := baseBlobPath(hostDataDir, sum)
+	if ok, _ := fileExists(dst); ok {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("create template base dir: %w", err)
+	}
+	if err := copyFilePreserveMode(path, dst); err != nil {
+		return fmt.Errorf("store template base: %w", err)
+	}
+	return nil
+}
+
+// loadBaseBlob returns the stored content for sum, verifying it still hashes
+// to sum. Projects installed before blobs were recorded have none.
+func loadBaseBlob(hostDataDir, sum string) ([]byte, error) {
+	path := baseBlobPath(hostDataDir, sum)
+	got, err := sha256File(path)
+	if err != nil {
+		return nil, err
+	}
+	if got != sum {
+		return nil, fmt.Errorf("template base %s is corrupt (hash %s)", path, got)
+	}
+	return os.ReadFile(path)
+}
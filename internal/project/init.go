@@ -17,12 +17,19 @@ type InitOptions struct {
 	HostDataDir string
 	Template    TemplateSource
 	Force       bool
+	DryRun      bool
 }
 
 type InitResult struct {
 	TemplateID     string
 	TemplateCommit string
 	CreatedFiles   int
+	// Created and Updated list template files (slash-separated, relative to the
+	// project) that were, or with DryRun would be, written new or overwritten.
+	Created []string
+	Updated []string
+	// Conflicts lists unexpected top-level entries that block init without Force.
+	Conflicts []string
 }
 
 func Init(opts InitOptions) (InitResult, error) {
@@ -43,12 +50,14 @@ func Init(opts InitOptions) (InitResult, error) {
 		}
 	}
 
-	if err := os.MkdirAll(projectDir, 0o755); err != nil {
-		return InitResult{}, fmt.Errorf("create project dir: %w", err)
+	if !opts.DryRun {
+		if err := os.MkdirAll(projectDir, 0o755); err != nil {
+			return InitResult{}, fmt.Errorf("create project dir: %w", err)
+		}
 	}
 	if !opts.Force {
 		entries, err := os.ReadDir(projectDir)
-		if err != nil {
+		if err != nil && !(opts.DryRun && errors.Is(err, os.ErrNotExist)) {
 			return InitResult{}, fmt.Errorf("read project dir: %w", err)
 		}
 		allowedTop := map[string]struct{}{"": {}}
@@ -65,7 +74,7 @@ func Init(opts InitOptions) (InitResult, error) {
 		}
 		if len(unexpected) > 0 {
 			sort.Strings(unexpected)
-			return InitResult{}, fmt.Errorf("project dir is not empty: %s (unexpected: %s; use --force to continue)", projectDir, strings.Join(unexpected, ", "))
+			return InitResult{Conflicts: unexpected}, fmt.Errorf("project dir is not empty: %s (unexpected: %s; use --force to continue)", projectDir, strings.Join(unexpected, ", "))
 		}
 	}
 
@@ -79,10 +88,21 @@ func Init(opts InitOptions) (InitResult, error) {
 	}
 
 	// Copy the entire template directory into the project (excluding template manifest and .git).
-	created, err := copyTemplateDir(resolved.Dir, projectDir)
+	created, updated, err := copyTemplateDir(resolved.Dir, projectDir, opts.DryRun)
 	if err != nil {
 		return InitResult{}, err
 	}
+	out := InitResult{
+		TemplateID:     manifest.ID,
+		TemplateCommit: strings.TrimSpace(resolved.Commit),
+		CreatedFiles:   len(created) + len(updated),
+		Created:        created,
+		Updated:        updated,
+		Conflicts:      []string{},
+	}
+	if opts.DryRun {
+		return out, nil
+	}
 
 	managed, err := expandManagedFiles(resolved.Dir, manifest.Managed, manifest.User)
 	if err != nil {
@@ -93,6 +113,9 @@ func Init(opts InitOptions) (InitResult, error) {
 		dst := filepath.Join(projectDir, filepath.FromSlash(rel))
 		if sum, err := sha256File(dst); err == nil {
 			managedHashes[rel] = sum
+			if err := storeBaseBlob(hostDataDir, dst, sum); err != nil {
+				return InitResult{}, err
+			}
 		}
 	}
 
@@ -107,15 +130,14 @@ func Init(opts InitOptions) (InitResult, error) {
 	if err := WriteLock(hostDataDir, lock); err != nil {
 		return InitResult{}, err
 	}
-	return InitResult{
-		TemplateID:     manifest.ID,
-		TemplateCommit: strings.TrimSpace(resolved.Commit),
-		CreatedFiles:   created,
-	}, nil
+	return out, nil
 }
 
-func copyTemplateDir(srcDir, dstDir string) (int, error) {
-	created := 0
+// copyTemplateDir copies srcDir into dstDir and reports which files were new
+// and which overwrote an existing file. With dryRun nothing is written.
+func copyTemplateDir(srcDir, dstDir string, dryRun bool) ([]string, []string, error) {
+	created := []string{}
+	updated := []string{}
 	err := filepath.WalkDir(srcDir, func(p string, d fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
@@ -135,6 +157,9 @@ func copyTemplateDir(srcDir, dstDir string) (int, error) {
 			if d.Name() == "__pycache__" {
 				return filepath.SkipDir
 			}
+			if dryRun {
+				return nil
+			}
 			return os.MkdirAll(filepath.Join(dstDir, filepath.FromSlash(rel)), 0o755)
 		}
 		if strings.HasSuffix(d.Name(), ".pyc") {
@@ -154,14 +179,25 @@ func copyTemplateDir(srcDir, dstDir string) (int, error) {
 		if !info.Mode().IsRegular() {
 			return nil
 		}
-		if err := copyFilePreserveMode(p, filepath.Join(dstDir, filepath.FromSlash(rel))); err != nil {
+		dst := filepath.Join(dstDir, filepath.FromSlash(rel))
+		existed, err := fileExists(dst)
+		if err != nil {
 			return err
 		}
-		created++
+		if !dryRun {
+			if err := copyFilePreserveMode(p, dst); err != nil {
+				return err
+			}
+		}
+		if existed {
+			updated = append(updated, rel)
+		} else {
+			created = append(created, rel)
+		}
 		return nil
 	})
 	if err != nil && !errors.Is(err, io.EOF) {
-		return created, err
+		return created, updated, err
 	}
-	return created, nil
+	return created, updated, nil
 }
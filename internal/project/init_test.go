@@ -0,0 +1,132 @@
+package project
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInit_DryRunListsFilesWithoutWriting(t *testing.T) {
+	tmp := t.TempDir()
+	templateDir := filepath.Join(tmp, "template")
+	projectDir := filepath.Join(tmp, "project")
+
+	writeManifest(t, templateDir, []string{"bot/**"}, nil)
+	writeFile(t, filepath.Join(templateDir, "README.md"), "v1\n")
+	writeFile(t, filepath.Join(templateDir, "bot", "chat_once.py"), "print('v1')\n")
+
+	res, err := Init(InitOptions{
+		ProjectDir: projectDir,
+		Template:   TemplateSource{Kind: TemplateSourceKindLocal, Dir: templateDir},
+		DryRun:     true,
+	})
+	if err != nil {
+		t.Fatalf("dry-run init: %v", err)
+	}
+	if strings.Join(res.Created, ",") != "README.md,bot/chat_once.py" || len(res.Updated) != 0 {
+		t.Fatalf("unexpected dry-run result: %+v", res)
+	}
+	if _, err := os.Stat(projectDir); !os.IsNotExist(err) {
+		t.Fatalf("dry-run must not create the project dir, stat err = %v", err)
+	}
+}
+
+func TestInit_ReportsConflictsForNonEmptyDir(t *testing.T) {
+	tmp := t.TempDir()
+	templateDir := filepath.Join(tmp, "template")
+	projectDir := filepath.Join(tmp, "project")
+
+	writeManifest(t, templateDir, []string{"README.md"}, nil)
+	writeFile(t, filepath.Join(templateDir, "README.md"), "v1\n")
+	writeFile(t, filepath.Join(projectDir, "README.md"), "mine\n")
+
+	res, err := Init(InitOptions{
+		ProjectDir: projectDir,
+		Template:   TemplateSource{Kind: TemplateSourceKindLocal, Dir: templateDir},
+	})
+	if err == nil {
+		t.Fatal("expected non-empty project dir error")
+	}
+	if strings.Join(res.Conflicts, ",") != "README.md" {
+		t.Fatalf("expected README.md conflict, got %+v", res)
+	}
+
+	res, err = Init(InitOptions{
+		ProjectDir: projectDir,
+		Template:   TemplateSource{Kind: TemplateSourceKindLocal, Dir: templateDir},
+		Force:      true,
+	})
+	if err != nil {
+		t.Fatalf("forced init: %v", err)
+	}
+	if strings.Join(res.Updated, ",") != "README.md" || len(res.Created) != 0 {
+		t.Fatalf("expected README.md to be reported as updated, got %+v", res)
+	}
+}
+
+func writeTemplateTarball(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create tarball: %v", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("write entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+}
+
+func TestInit_FromTarballTemplate(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmp, "cache"))
+	manifest := `{"schemaVersion":1,"id":"tar-template","managed":["README.md"]}`
+	good := filepath.Join(tmp, "template.tar.gz")
+	writeTemplateTarball(t, good, map[string]string{
+		"tpl/" + ManifestFilename: manifest,
+		"tpl/README.md":           "from tarball\n",
+	})
+
+	projectDir := filepath.Join(tmp, "project")
+	res, err := Init(InitOptions{
+		ProjectDir: projectDir,
+		Template:   TemplateSource{Kind: TemplateSourceKindTarball, Tarball: good},
+	})
+	if err != nil {
+		t.Fatalf("init from tarball: %v", err)
+	}
+	if res.TemplateID != "tar-template" {
+		t.Fatalf("unexpected template id: %+v", res)
+	}
+	b, err := os.ReadFile(filepath.Join(projectDir, "README.md"))
+	if err != nil || string(b) != "from tarball\n" {
+		t.Fatalf("unexpected README: %q (%v)", b, err)
+	}
+
+	for name, entries := range map[string]map[string]string{
+		"escape.tar.gz":      {"../evil": "x", ManifestFilename: manifest},
+		"absolute.tar.gz":    {"/etc/evil": "x", ManifestFilename: manifest},
+		"no-manifest.tar.gz": {"README.md": "x"},
+	} {
+		path := filepath.Join(tmp, name)
+		writeTemplateTarball(t, path, entries)
+		if _, err := ResolveTemplate(TemplateSource{Kind: TemplateSourceKindTarball, Tarball: path}); err == nil {
+			t.Fatalf("%s: expected resolve error", name)
+		}
+	}
+}
@@ -14,8 +14,9 @@ import (
 type TemplateSourceKind string
 
 const (
-	TemplateSourceKindLocal TemplateSourceKind = "local"
-	TemplateSourceKindGit   TemplateSourceKind = "git"
+	TemplateSourceKindLocal   TemplateSourceKind = "local"
+	TemplateSourceKindGit     TemplateSourceKind = "git"
+	TemplateSourceKindTarball TemplateSourceKind = "tarball"
 )
 
 // TemplateSource is persisted in the project lock so `metaclaw project upgrade`
@@ -30,6 +31,9 @@ type TemplateSource struct {
 	Repo string `json:"repo,omitempty"`
 	Ref  string `json:"ref,omitempty"`  // e.g. main
 	Path string `json:"path,omitempty"` // subdir within repo
+
+	// Tarball source (.tar or .tar.gz), for air-gapped distribution.
+	Tarball string `json:"tarball,omitempty"`
 }
 
 // ProjectLock is written into the host data dir.
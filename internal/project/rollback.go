@@ -0,0 +1,154 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const backupDirName = "upgrade-backups"
+
+type RollbackOptions struct {
+	ProjectDir  string
+	HostDataDir string
+	// To names the backup snapshot (its timestamp directory); empty picks the
+	// most recent one.
+	To     string
+	DryRun bool
+}
+
+type RollbackResult struct {
+	Snapshot string
+	Restored []string
+	// LockRestored is true when the snapshot carried the pre-upgrade lock;
+	// older snapshots only get the restored files re-hashed into the lock.
+	LockRestored bool
+}
+
+func backupRootDir(hostDataDir string) string {
+	return filepath.Join(hostDataDir, backupDirName)
+}
+
+// ListBackups returns the upgrade backup snapshots under hostDataDir, newest
+// first. Snapshot names are UTC timestamps, so they sort chronologically.
+func ListBackups(hostDataDir string) ([]string, error) {
+	entries, err := os.ReadDir(backupRootDir(hostDataDir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("read backups: %w", err)
+	}
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			out = append(out, e.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(out)))
+	return out, nil
+}
+
+// Rollback copies the files saved by a forced upgrade back into the project
+// and rewinds the lock so the next upgrade compares against them. Files the
+// upgrade newly added are left in place.
+func Rollback(opts RollbackOptions) (RollbackResult, error) {
+	if strings.TrimSpace(opts.ProjectDir) == "" {
+		return RollbackResult{}, errors.New("project dir is empty")
+	}
+	projectDir, err := filepath.Abs(opts.ProjectDir)
+	if err != nil {
+		return RollbackResult{}, fmt.Errorf("resolve project dir: %w", err)
+	}
+	hostDataDir := strings.TrimSpace(opts.HostDataDir)
+	if hostDataDir == "" {
+		hostDataDir = DefaultHostDataDir(projectDir)
+	} else {
+		hostDataDir, err = filepath.Abs(hostDataDir)
+		if err != nil {
+			return RollbackResult{}, fmt.Errorf("resolve host data dir: %w", err)
+		}
+	}
+
+	snapshots, err := ListBackups(hostDataDir)
+	if err != nil {
+		return RollbackResult{}, err
+	}
+	if len(snapshots) == 0 {
+		return RollbackResult{}, fmt.Errorf("no upgrade backups in %s", backupRootDir(hostDataDir))
+	}
+	name := strings.TrimSpace(opts.To)
+	if name == "" {
+		name = snapshots[0]
+	} else if !containsString(snapshots, name) {
+		return RollbackResult{}, fmt.Errorf("backup %q not found (available: %s)", name, strings.Join(snapshots, ", "))
+	}
+	snapDir := filepath.Join(backupRootDir(hostDataDir), name)
+
+	out := RollbackResult{Snapshot: name, Restored: []string{}}
+	err = filepath.WalkDir(snapDir, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(snapDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == LockFilename {
+			return nil
+		}
+		out.Restored = append(out.Restored, rel)
+		return nil
+	})
+	if err != nil {
+		return out, fmt.Errorf("read backup %s: %w", name, err)
+	}
+	sort.Strings(out.Restored)
+
+	snapLock, snapLockErr := LoadLock(snapDir)
+	out.LockRestored = snapLockErr == nil
+	if opts.DryRun {
+		return out, nil
+	}
+
+	for _, rel := range out.Restored {
+		src := filepath.Join(snapDir, filepath.FromSlash(rel))
+		dst := filepath.Join(projectDir, filepath.FromSlash(rel))
+		if err := copyFilePreserveMode(src, dst); err != nil {
+			return out, fmt.Errorf("restore %s: %w", rel, err)
+		}
+	}
+
+	if out.LockRestored {
+		return out, WriteLock(hostDataDir, snapLock)
+	}
+	lock, err := LoadLock(hostDataDir)
+	if err != nil {
+		return out, fmt.Errorf("load lock: %w", err)
+	}
+	hashes, err := HashManagedFiles(projectDir, out.Restored)
+	if err != nil {
+		return out, err
+	}
+	for rel, sum := range hashes {
+		lock.ManagedFiles[rel] = sum
+	}
+	return out, WriteLock(hostDataDir, lock)
+}
+
+func containsString(items []string, want string) bool {
+	for _, it := range items {
+		if it == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRollbackRestoresForcedUpgrade(t *testing.T) {
+	tmp := t.TempDir()
+	templateDir := filepath.Join(tmp, "template")
+	projectDir := filepath.Join(tmp, "project")
+	src := TemplateSource{Kind: TemplateSourceKindLocal, Dir: templateDir}
+
+	writeManifest(t, templateDir, []string{"README.md"}, nil)
+	writeFile(t, filepath.Join(templateDir, "README.md"), "v1\n")
+	if _, err := Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src}); err != nil {
+		t.Fatalf("upgrade v1: %v", err)
+	}
+	hostDataDir := DefaultHostDataDir(projectDir)
+	before, err := LoadLock(hostDataDir)
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+
+	if _, err := Rollback(RollbackOptions{ProjectDir: projectDir}); err == nil {
+		t.Fatal("expected error without backups")
+	}
+
+	writeFile(t, filepath.Join(projectDir, "README.md"), "local\n")
+	writeFile(t, filepath.Join(templateDir, "README.md"), "v2\n")
+	if _, err := Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src, Force: true}); err != nil {
+		t.Fatalf("forced upgrade: %v", err)
+	}
+	snapshots, err := ListBackups(hostDataDir)
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("expected one backup, got %v (%v)", snapshots, err)
+	}
+
+	res, err := Rollback(RollbackOptions{ProjectDir: projectDir, To: snapshots[0]})
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if !res.LockRestored || len(res.Restored) != 1 || res.Restored[0] != "README.md" {
+		t.Fatalf("unexpected rollback result: %+v", res)
+	}
+	b, _ := os.ReadFile(filepath.Join(projectDir, "README.md"))
+	if string(b) != "local\n" {
+		t.Fatalf("expected local edits restored, got %q", b)
+	}
+	after, err := LoadLock(hostDataDir)
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	if after.ManagedFiles["README.md"] != before.ManagedFiles["README.md"] {
+		t.Fatalf("expected lock rewound to pre-upgrade hashes: %v vs %v", after.ManagedFiles, before.ManagedFiles)
+	}
+
+	if _, err := Rollback(RollbackOptions{ProjectDir: projectDir, To: "19700101T000000Z"}); err == nil {
+		t.Fatal("expected unknown snapshot error")
+	}
+}
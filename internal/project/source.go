@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/fpp-125/metaclaw/internal/capsule"
 )
 
 type ResolvedTemplate struct {
@@ -20,6 +22,16 @@ type ResolvedTemplate struct {
 }
 
 func ResolveTemplate(source TemplateSource) (ResolvedTemplate, error) {
+	return resolveTemplateAt(source, "")
+}
+
+// resolveTemplateAt resolves source like ResolveTemplate, but for git sources
+// a non-empty commit checks out that exact commit instead of the ref tip.
+func resolveTemplateAt(source TemplateSource, commit string) (ResolvedTemplate, error) {
+	commit = strings.TrimSpace(commit)
+	if commit != "" && source.Kind != TemplateSourceKindGit {
+		return ResolvedTemplate{}, fmt.Errorf("pinning a commit requires a git template source (got %q)", source.Kind)
+	}
 	switch source.Kind {
 	case TemplateSourceKindLocal:
 		if strings.TrimSpace(source.Dir) == "" {
@@ -61,13 +73,17 @@ func ResolveTemplate(source TemplateSource) (ResolvedTemplate, error) {
 			return ResolvedTemplate{}, fmt.Errorf("create template cache: %w", err)
 		}
 
-		if _, err := os.Stat(repoDir); err == nil {
-			// Best-effort sync. If offline, we still allow using the cached copy.
-			_ = syncGitRepo(repoDir, ref)
-		} else {
+		if _, err := os.Stat(repoDir); err != nil {
 			if err := gitCloneShallow(cacheRoot, repo, repoDir); err != nil {
 				return ResolvedTemplate{}, err
 			}
+		}
+		if commit != "" {
+			if err := checkoutGitCommit(repoDir, commit); err != nil {
+				return ResolvedTemplate{}, err
+			}
+		} else {
+			// Best-effort sync. If offline, we still allow using the cached copy.
 			_ = syncGitRepo(repoDir, ref)
 		}
 
@@ -80,11 +96,64 @@ func ResolveTemplate(source TemplateSource) (ResolvedTemplate, error) {
 
 		commit, _ := gitRevParse(repoDir, "HEAD")
 		return ResolvedTemplate{Dir: dir, Commit: strings.TrimSpace(commit)}, nil
+	case TemplateSourceKindTarball:
+		return resolveTarballTemplate(source.Tarball)
 	default:
 		return ResolvedTemplate{}, fmt.Errorf("unsupported template source kind %q", source.Kind)
 	}
 }
 
+// resolveTarballTemplate extracts a template tarball into the template cache,
+// keyed by the archive's sha256, and then treats it like a local directory.
+// Extraction rejects absolute and parent-escaping entry names.
+func resolveTarballTemplate(tarball string) (ResolvedTemplate, error) {
+	if strings.TrimSpace(tarball) == "" {
+		return ResolvedTemplate{}, errors.New("template source tarball is empty")
+	}
+	abs, err := filepath.Abs(tarball)
+	if err != nil {
+		return ResolvedTemplate{}, fmt.Errorf("resolve template tarball: %w", err)
+	}
+	sum, err := sha256File(abs)
+	if err != nil {
+		return ResolvedTemplate{}, fmt.Errorf("template tarball not accessible: %w", err)
+	}
+	cacheRoot, err := defaultTemplateCacheRoot()
+	if err != nil {
+		return ResolvedTemplate{}, err
+	}
+	extractDir := filepath.Join(cacheRoot, "tarball", sum[:16])
+	if err := os.MkdirAll(filepath.Dir(extractDir), 0o755); err != nil {
+		return ResolvedTemplate{}, fmt.Errorf("create template cache: %w", err)
+	}
+	// Always extract fresh so a tampered cache entry is never reused.
+	tmp, err := os.MkdirTemp(filepath.Dir(extractDir), "extract-")
+	if err != nil {
+		return ResolvedTemplate{}, fmt.Errorf("create extraction dir: %w", err)
+	}
+	if err := capsule.ExtractTarball(abs, tmp); err != nil {
+		_ = os.RemoveAll(tmp)
+		return ResolvedTemplate{}, fmt.Errorf("extract template tarball: %w", err)
+	}
+	if _, err := capsule.FindArchiveRoot(tmp, ManifestFilename); err != nil {
+		_ = os.RemoveAll(tmp)
+		return ResolvedTemplate{}, fmt.Errorf("template tarball %s: %w", abs, err)
+	}
+	if err := os.RemoveAll(extractDir); err != nil {
+		_ = os.RemoveAll(tmp)
+		return ResolvedTemplate{}, fmt.Errorf("clear template cache: %w", err)
+	}
+	if err := os.Rename(tmp, extractDir); err != nil {
+		_ = os.RemoveAll(tmp)
+		return ResolvedTemplate{}, fmt.Errorf("install extracted template: %w", err)
+	}
+	dir, err := capsule.FindArchiveRoot(extractDir, ManifestFilename)
+	if err != nil {
+		return ResolvedTemplate{}, err
+	}
+	return ResolvedTemplate{Dir: dir}, nil
+}
+
 func defaultTemplateCacheRoot() (string, error) {
 	// Prefer OS cache directory, fallback to temp.
 	if d, err := os.UserCacheDir(); err == nil && strings.TrimSpace(d) != "" {
@@ -122,6 +191,28 @@ func syncGitRepo(repoDir, ref string) error {
 	return nil
 }
 
+// checkoutGitCommit detaches the cached clone at commit, fetching it first if
+// the shallow clone does not have it yet.
+func checkoutGitCommit(repoDir, commit string) error {
+	if _, err := gitRevParse(repoDir, commit+"^{commit}"); err != nil {
+		if err := runGit(repoDir, "fetch", "--depth", "1", "origin", commit); err != nil {
+			return fmt.Errorf("fetch pinned template commit %s: %w", commit, err)
+		}
+	}
+	if err := runGit(repoDir, "checkout", "--force", "--detach", commit); err != nil {
+		return fmt.Errorf("checkout pinned template commit %s: %w", commit, err)
+	}
+	_ = runGit(repoDir, "clean", "-fdx")
+	head, err := gitRevParse(repoDir, "HEAD")
+	if err != nil {
+		return fmt.Errorf("resolve pinned template commit: %w", err)
+	}
+	if !strings.HasPrefix(head, commit) {
+		return fmt.Errorf("template checkout is at %s, expected pinned commit %s", head, commit)
+	}
+	return nil
+}
+
 func runGit(dir string, args ...string) error {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
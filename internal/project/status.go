@@ -0,0 +1,90 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type StatusOptions struct {
+	ProjectDir  string
+	HostDataDir string
+}
+
+// StatusResult compares managed files on disk with the hashes recorded in the
+// project lock. It never resolves the template, so it works offline.
+type StatusResult struct {
+	TemplateID     string
+	TemplateCommit string
+	Template       TemplateSource
+	InstalledAtUTC string
+	Clean          []string
+	Modified       []string
+	Missing        []string
+}
+
+// Drifted reports whether any managed file was modified or removed since the
+// last init/upgrade.
+func (r StatusResult) Drifted() bool {
+	return len(r.Modified) > 0 || len(r.Missing) > 0
+}
+
+func Status(opts StatusOptions) (StatusResult, error) {
+	if strings.TrimSpace(opts.ProjectDir) == "" {
+		return StatusResult{}, errors.New("project dir is empty")
+	}
+	projectDir, err := filepath.Abs(opts.ProjectDir)
+	if err != nil {
+		return StatusResult{}, fmt.Errorf("resolve project dir: %w", err)
+	}
+	hostDataDir := strings.TrimSpace(opts.HostDataDir)
+	if hostDataDir == "" {
+		hostDataDir = DefaultHostDataDir(projectDir)
+	}
+	lock, err := LoadLock(hostDataDir)
+	if err != nil {
+		return StatusResult{}, err
+	}
+
+	out := StatusResult{
+		TemplateID:     lock.TemplateID,
+		TemplateCommit: lock.TemplateCommit,
+		Template:       lock.Template,
+		InstalledAtUTC: lock.InstalledAtUTC,
+		Clean:          []string{},
+		Modified:       []string{},
+		Missing:        []string{},
+	}
+	rels := make([]string, 0, len(lock.ManagedFiles))
+	for rel := range lock.ManagedFiles {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	present := make([]string, 0, len(rels))
+	for _, rel := range rels {
+		ok, err := fileExists(filepath.Join(projectDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return out, err
+		}
+		if !ok {
+			out.Missing = append(out.Missing, rel)
+			continue
+		}
+		present = append(present, rel)
+	}
+	hashes, err := HashManagedFiles(projectDir, present)
+	if err != nil {
+		return out, err
+	}
+	for _, rel := range present {
+		if hashes[rel] == lock.ManagedFiles[rel] {
+			out.Clean = append(out.Clean, rel)
+		} else {
+			out.Modified = append(out.Modified, rel)
+		}
+	}
+	return out, nil
+}
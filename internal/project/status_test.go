@@ -0,0 +1,57 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatusReportsModifiedAndMissingFiles(t *testing.T) {
+	tmp := t.TempDir()
+	templateDir := filepath.Join(tmp, "template")
+	projectDir := filepath.Join(tmp, "project")
+
+	writeManifest(t, templateDir, []string{"bot/**", "README.md"}, nil)
+	writeFile(t, filepath.Join(templateDir, "README.md"), "v1\n")
+	writeFile(t, filepath.Join(templateDir, "bot", "a.py"), "a\n")
+	writeFile(t, filepath.Join(templateDir, "bot", "b.py"), "b\n")
+	if _, err := Upgrade(UpgradeOptions{
+		ProjectDir: projectDir,
+		Template:   TemplateSource{Kind: TemplateSourceKindLocal, Dir: templateDir},
+	}); err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+
+	res, err := Status(StatusOptions{ProjectDir: projectDir})
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if res.Drifted() || len(res.Clean) != 3 || res.TemplateID != "test-template" {
+		t.Fatalf("expected clean status, got %+v", res)
+	}
+
+	writeFile(t, filepath.Join(projectDir, "README.md"), "local\n")
+	if err := os.Remove(filepath.Join(projectDir, "bot", "b.py")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	res, err = Status(StatusOptions{ProjectDir: projectDir})
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !res.Drifted() {
+		t.Fatal("expected drift")
+	}
+	if len(res.Modified) != 1 || res.Modified[0] != "README.md" {
+		t.Fatalf("unexpected modified files: %v", res.Modified)
+	}
+	if len(res.Missing) != 1 || res.Missing[0] != "bot/b.py" {
+		t.Fatalf("unexpected missing files: %v", res.Missing)
+	}
+	if len(res.Clean) != 1 || res.Clean[0] != "bot/a.py" {
+		t.Fatalf("unexpected clean files: %v", res.Clean)
+	}
+
+	if _, err := Status(StatusOptions{ProjectDir: filepath.Join(tmp, "none")}); !os.IsNotExist(err) {
+		t.Fatalf("expected missing lock error, got %v", err)
+	}
+}
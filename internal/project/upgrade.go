@@ -11,6 +11,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/fpp-125/metaclaw/internal/textdiff"
 )
 
 type UpgradeOptions struct {
@@ -19,6 +21,17 @@ type UpgradeOptions struct {
 	Template    TemplateSource
 	Force       bool
 	DryRun      bool
+	// PinnedCommit resolves a git template at this exact commit instead of
+	// the tip of its ref, so upgrades are reproducible across machines.
+	PinnedCommit string
+	// ExpectCommit refuses the upgrade unless the resolved template commit
+	// starts with this value.
+	ExpectCommit string
+	// Merge three-way merges locally modified managed files with the new
+	// template instead of reporting them as conflicts. Overlapping edits are
+	// written with conflict markers and still reported in Conflicts; the lock
+	// records the new template as their baseline.
+	Merge bool
 }
 
 type UpgradeResult struct {
@@ -28,12 +41,17 @@ type UpgradeResult struct {
 	Added          []string
 	Skipped        []string
 	Conflicts      []string
+	// Merged lists locally modified files that merged cleanly with the template.
+	Merged []string
 }
 
 func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 	if strings.TrimSpace(opts.ProjectDir) == "" {
 		return UpgradeResult{}, errors.New("project dir is empty")
 	}
+	if opts.Force && opts.Merge {
+		return UpgradeResult{}, errors.New("force and merge are mutually exclusive")
+	}
 	projectDir, err := filepath.Abs(opts.ProjectDir)
 	if err != nil {
 		return UpgradeResult{}, fmt.Errorf("resolve project dir: %w", err)
@@ -51,10 +69,15 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 	// Load lock (if present) to detect local modifications of managed files.
 	lock, lockErr := LoadLock(hostDataDir)
 
-	resolved, err := ResolveTemplate(opts.Template)
+	resolved, err := resolveTemplateAt(opts.Template, opts.PinnedCommit)
 	if err != nil {
 		return UpgradeResult{}, err
 	}
+	if expect := strings.TrimSpace(opts.ExpectCommit); expect != "" {
+		if got := strings.TrimSpace(resolved.Commit); got == "" || !strings.HasPrefix(got, expect) {
+			return UpgradeResult{TemplateCommit: got}, fmt.Errorf("template resolved to commit %q, expected %s; refusing to upgrade", got, expect)
+		}
+	}
 	manifest, err := LoadManifest(resolved.Dir)
 	if err != nil {
 		return UpgradeResult{}, err
@@ -68,7 +91,8 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 		return UpgradeResult{}, fmt.Errorf("manifest managed patterns matched 0 files")
 	}
 
-	backupRoot := filepath.Join(hostDataDir, "upgrade-backups", time.Now().UTC().Format("20060102T150405Z"))
+	backupRoot := filepath.Join(backupRootDir(hostDataDir), time.Now().UTC().Format("20060102T150405Z"))
+	backedUp := false
 	out := UpgradeResult{
 		TemplateID:     manifest.ID,
 		TemplateCommit: strings.TrimSpace(resolved.Commit),
@@ -76,14 +100,18 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 		Added:          []string{},
 		Skipped:        []string{},
 		Conflicts:      []string{},
+		Merged:         []string{},
 	}
 
 	// Sort for stable output.
 	sort.Strings(managed)
 
+	// Copy so the loaded lock still describes the pre-upgrade state.
 	managedHashes := map[string]string{}
 	if lockErr == nil {
-		managedHashes = lock.ManagedFiles
+		for rel, sum := range lock.ManagedFiles {
+			managedHashes[rel] = sum
+		}
 	}
 
 	for _, rel := range managed {
@@ -124,6 +152,40 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 				// - dst does NOT already match the current template (cur != srcSum).
 				// If dst == srcSum, the user effectively already applied the upgrade and we should not block.
 				if existed && dstSum != "" {
+					if dstSum != prev && dstSum != srcSum && opts.Merge {
+						if prev == srcSum {
+							// Template unchanged since install: keep local edits as-is.
+							out.Skipped = append(out.Skipped, rel)
+							continue
+						}
+						merged, conflict, err := mergeManagedFile(hostDataDir, prev, src, dst, opts.DryRun)
+						switch {
+						case err != nil:
+							// No usable baseline (e.g. a lock written before
+							// baselines were kept): fall back to a plain conflict.
+							out.Conflicts = append(out.Conflicts, rel)
+						case conflict:
+							// Markers were written and the template is applied:
+							// advance the baseline so the resolved file counts as
+							// a local edit on top of the new template.
+							out.Conflicts = append(out.Conflicts, rel)
+							if !opts.DryRun {
+								managedHashes[rel] = srcSum
+								if err := storeBaseBlob(hostDataDir, src, srcSum); err != nil {
+									return out, err
+								}
+							}
+						case merged:
+							out.Merged = append(out.Merged, rel)
+							managedHashes[rel] = srcSum
+							if !opts.DryRun {
+								if err := storeBaseBlob(hostDataDir, src, srcSum); err != nil {
+									return out, err
+								}
+							}
+						}
+						continue
+					}
 					if dstSum != prev && dstSum != srcSum && !opts.Force {
 						out.Conflicts = append(out.Conflicts, rel)
 						continue
@@ -133,6 +195,7 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 							if err := backupFile(dst, filepath.Join(backupRoot, filepath.FromSlash(rel))); err != nil {
 								return out, err
 							}
+							backedUp = true
 						}
 					}
 				}
@@ -147,6 +210,9 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 			} else {
 				out.Skipped = append(out.Skipped, rel)
 				managedHashes[rel] = dstSum
+				if err := storeBaseBlob(hostDataDir, dst, dstSum); err != nil {
+					return out, err
+				}
 			}
 			continue
 		}
@@ -176,6 +242,9 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 		} else {
 			managedHashes[rel] = srcSum
 		}
+		if err := storeBaseBlob(hostDataDir, dst, managedHashes[rel]); err != nil {
+			return out, err
+		}
 	}
 
 	// Write/refresh lock after a successful upgrade. If there were conflicts, do not advance the lock
@@ -183,10 +252,18 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 	if opts.DryRun {
 		return out, nil
 	}
-	if len(out.Conflicts) > 0 && !opts.Force {
+	if len(out.Conflicts) > 0 && !opts.Force && !opts.Merge {
 		return out, fmt.Errorf("upgrade has conflicts (%d files); re-run with --force to overwrite or resolve locally", len(out.Conflicts))
 	}
 
+	// Keep the pre-upgrade lock with the backups so `project rollback` can
+	// restore both the files and the hashes they were installed with.
+	if backedUp {
+		if err := WriteLock(backupRoot, lock); err != nil {
+			return out, fmt.Errorf("back up lock: %w", err)
+		}
+	}
+
 	newLock := ProjectLock{
 		SchemaVersion:  1,
 		Template:       opts.Template,
@@ -205,9 +282,41 @@ func Upgrade(opts UpgradeOptions) (UpgradeResult, error) {
 	if err := WriteLock(hostDataDir, newLock); err != nil {
 		return out, err
 	}
+	if len(out.Conflicts) > 0 {
+		return out, fmt.Errorf("upgrade left %d files with merge conflicts; resolve the conflict markers", len(out.Conflicts))
+	}
 	return out, nil
 }
 
+// mergeManagedFile three-way merges the template file src into the locally
+// modified dst, using the stored baseline for baseSum as the common ancestor.
+// It reports whether dst merged cleanly or was written with conflict markers.
+func mergeManagedFile(hostDataDir, baseSum, src, dst string, dryRun bool) (bool, bool, error) {
+	base, err := loadBaseBlob(hostDataDir, baseSum)
+	if err != nil {
+		return false, false, err
+	}
+	theirs, err := os.ReadFile(src)
+	if err != nil {
+		return false, false, err
+	}
+	ours, err := os.ReadFile(dst)
+	if err != nil {
+		return false, false, err
+	}
+	merged, conflict := textdiff.Merge3(string(base), string(ours), string(theirs), "local", "template")
+	if !dryRun {
+		st, err := os.Stat(dst)
+		if err != nil {
+			return false, false, err
+		}
+		if err := os.WriteFile(dst, []byte(merged), st.Mode().Perm()); err != nil {
+			return false, false, fmt.Errorf("write merged file: %w", err)
+		}
+	}
+	return !conflict, conflict, nil
+}
+
 func fileExists(path string) (bool, error) {
 	st, err := os.Stat(path)
 	if err != nil {
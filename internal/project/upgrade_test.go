@@ -3,7 +3,9 @@ package project
 import (
 	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -140,3 +142,120 @@ func TestUpgrade_NoConflictWhenUserAlreadyAppliedTemplate(t *testing.T) {
 	}
 }
 
+
+func TestUpgrade_MergeKeepsLocalEdits(t *testing.T) {
+	tmp := t.TempDir()
+	templateDir := filepath.Join(tmp, "template")
+	projectDir := filepath.Join(tmp, "project")
+	src := TemplateSource{Kind: TemplateSourceKindLocal, Dir: templateDir}
+
+	writeManifest(t, templateDir, []string{"README.md", "run.sh"}, nil)
+	writeFile(t, filepath.Join(templateDir, "README.md"), "title\nbody\nfooter\n")
+	writeFile(t, filepath.Join(templateDir, "run.sh"), "one\ntwo\nthree\nfour\n")
+	if _, err := Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src}); err != nil {
+		t.Fatalf("upgrade v1: %v", err)
+	}
+
+	writeFile(t, filepath.Join(projectDir, "README.md"), "title\nbody\nlocal footer\n")
+	writeFile(t, filepath.Join(projectDir, "run.sh"), "one\nlocal\nthree\nfour\n")
+	writeFile(t, filepath.Join(templateDir, "README.md"), "new title\nbody\nfooter\n")
+	writeFile(t, filepath.Join(templateDir, "run.sh"), "one\ntemplate\nthree\nfour\n")
+
+	res, err := Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src, Merge: true})
+	if err == nil || !strings.Contains(err.Error(), "merge conflicts") {
+		t.Fatalf("expected merge conflict error, got %v", err)
+	}
+	if len(res.Merged) != 1 || res.Merged[0] != "README.md" || len(res.Conflicts) != 1 || res.Conflicts[0] != "run.sh" {
+		t.Fatalf("unexpected merge result: %+v", res)
+	}
+	b, _ := os.ReadFile(filepath.Join(projectDir, "README.md"))
+	if string(b) != "new title\nbody\nlocal footer\n" {
+		t.Fatalf("unexpected merged README: %q", b)
+	}
+	b, _ = os.ReadFile(filepath.Join(projectDir, "run.sh"))
+	if !strings.Contains(string(b), "<<<<<<< local\nlocal\n=======\ntemplate\n>>>>>>> template\n") {
+		t.Fatalf("expected conflict markers, got %q", b)
+	}
+
+	// The conflicted file's baseline advanced to the template, so once resolved
+	// it is a local edit: the same template leaves it alone.
+	writeFile(t, filepath.Join(projectDir, "run.sh"), "one\ntemplate\nthree\nfour local\n")
+	res, err = Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src, Merge: true})
+	if err != nil {
+		t.Fatalf("upgrade after resolve: %v", err)
+	}
+	if len(res.Conflicts) != 0 || len(res.Merged) != 0 || len(res.Skipped) != 2 {
+		t.Fatalf("unexpected result after resolve: %+v", res)
+	}
+	b, _ = os.ReadFile(filepath.Join(projectDir, "run.sh"))
+	if string(b) != "one\ntemplate\nthree\nfour local\n" {
+		t.Fatalf("resolved run.sh was rewritten: %q", b)
+	}
+
+	// A later template change merges against the new baseline.
+	writeFile(t, filepath.Join(templateDir, "run.sh"), "zero\ntemplate\nthree\nfour\n")
+	res, err = Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src, Merge: true})
+	if err != nil {
+		t.Fatalf("upgrade v3: %v", err)
+	}
+	if len(res.Conflicts) != 0 || len(res.Merged) != 1 || res.Merged[0] != "run.sh" {
+		t.Fatalf("unexpected result for v3: %+v", res)
+	}
+	b, _ = os.ReadFile(filepath.Join(projectDir, "run.sh"))
+	if string(b) != "zero\ntemplate\nthree\nfour local\n" {
+		t.Fatalf("unexpected merged run.sh: %q", b)
+	}
+}
+
+func TestUpgrade_PinnedCommitAndExpectCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	tmp := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmp, "cache"))
+	repoDir := filepath.Join(tmp, "repo")
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@t", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	writeManifest(t, filepath.Join(repoDir, "tpl"), []string{"README.md"}, nil)
+	writeFile(t, filepath.Join(repoDir, "tpl", "README.md"), "v1\n")
+	git("init", "-q", "-b", "main")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	v1 := git("rev-parse", "HEAD")
+	writeFile(t, filepath.Join(repoDir, "tpl", "README.md"), "v2\n")
+	git("commit", "-q", "-am", "v2")
+
+	projectDir := filepath.Join(tmp, "project")
+	src := TemplateSource{Kind: TemplateSourceKindGit, Repo: "file://" + repoDir, Ref: "main", Path: "tpl"}
+	res, err := Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src, PinnedCommit: v1})
+	if err != nil {
+		t.Fatalf("pinned upgrade: %v", err)
+	}
+	if res.TemplateCommit != v1 {
+		t.Fatalf("expected pinned commit %s, got %s", v1, res.TemplateCommit)
+	}
+	b, _ := os.ReadFile(filepath.Join(projectDir, "README.md"))
+	if string(b) != "v1\n" {
+		t.Fatalf("expected pinned content, got %q", b)
+	}
+
+	if _, err := Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: src, ExpectCommit: v1[:12]}); err == nil || !strings.Contains(err.Error(), "refusing to upgrade") {
+		t.Fatalf("expected commit mismatch error, got %v", err)
+	}
+	b, _ = os.ReadFile(filepath.Join(projectDir, "README.md"))
+	if string(b) != "v1\n" {
+		t.Fatalf("mismatched upgrade must not write files, got %q", b)
+	}
+
+	if _, err := Upgrade(UpgradeOptions{ProjectDir: projectDir, Template: TemplateSource{Kind: TemplateSourceKindLocal, Dir: repoDir}, PinnedCommit: v1}); err == nil {
+		t.Fatal("expected pinning a local template to fail")
+	}
+}
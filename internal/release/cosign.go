@@ -0,0 +1,232 @@
+package release
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CoSignatureDir holds co-signatures beside the primary signing/attestation.sig.
+// Each signer contributes <name>.sig over the canonical attestation and the
+// matching <name>.pem public key. The directory is not covered by the
+// attestation, so signatures can be added without touching signed content.
+const CoSignatureDir = "signing/signatures"
+
+type SignOptions struct {
+	ReleaseDir     string
+	PrivateKeyPath string
+	KeyID          string
+	// Passphrase unlocks an encrypted PrivateKeyPath.
+	Passphrase PassphraseFunc
+}
+
+type SignResult struct {
+	ReleaseID     string
+	KeyID         string
+	SignaturePath string
+	PublicKeyPath string
+}
+
+// Sign adds a co-signature to an existing release directory. The attestation
+// is re-checked against the release files and signed as is; it is never
+// rewritten, so earlier signatures stay valid.
+func Sign(opts SignOptions) (SignResult, error) {
+	releaseRoot := strings.TrimSpace(opts.ReleaseDir)
+	if releaseRoot == "" {
+		return SignResult{}, fmt.Errorf("release directory is required")
+	}
+	if strings.TrimSpace(opts.PrivateKeyPath) == "" {
+		return SignResult{}, fmt.Errorf("signing key is required")
+	}
+	sr, err := loadSignedRelease(releaseRoot)
+	if err != nil {
+		return SignResult{}, err
+	}
+	rel := sr.Manifest
+
+	keyBytes, err := os.ReadFile(opts.PrivateKeyPath)
+	if err != nil {
+		return SignResult{}, fmt.Errorf("load signing key: %w", err)
+	}
+	priv, err := parsePrivateKeyPEM(opts.PrivateKeyPath, keyBytes, opts.Passphrase)
+	if err != nil {
+		return SignResult{}, fmt.Errorf("load signing key: %w", err)
+	}
+	id := deriveKeyID(priv.Public().(ed25519.PublicKey))
+	if primary, err := loadPublicKey(filepath.Join(releaseRoot, rel.Signing.PublicKey)); err == nil && deriveKeyID(primary) == id {
+		return SignResult{}, fmt.Errorf("release is already signed by key %s", id)
+	}
+	keyID := strings.TrimSpace(opts.KeyID)
+	if keyID == "" {
+		keyID = id
+	}
+	name := coSignatureName(keyID)
+	if _, err := os.Stat(filepath.Join(releaseRoot, filepath.FromSlash(CoSignatureDir), name+".sig")); err == nil {
+		return SignResult{}, fmt.Errorf("release already has a co-signature named %s", name)
+	}
+	sigPath, err := writeCoSignature(releaseRoot, priv, keyID, sr.AttJSON)
+	if err != nil {
+		return SignResult{}, err
+	}
+	return SignResult{
+		ReleaseID:     rel.ReleaseID,
+		KeyID:         keyID,
+		SignaturePath: sigPath,
+		PublicKeyPath: strings.TrimSuffix(sigPath, ".sig") + ".pem",
+	}, nil
+}
+
+// signedRelease is a release directory loaded for signing: the parsed
+// manifest and attestation plus the exact bytes they were read from.
+type signedRelease struct {
+	Manifest    ReleaseManifest
+	Attestation Attestation
+	AttJSON     []byte
+}
+
+// loadSignedRelease reads release.json and the attestation under releaseRoot
+// and checks that the attestation is canonical, names the same release and
+// still matches the release files. Signatures are left to the caller.
+func loadSignedRelease(releaseRoot string) (signedRelease, error) {
+	releaseJSON, err := os.ReadFile(filepath.Join(releaseRoot, "release.json"))
+	if err != nil {
+		return signedRelease{}, fmt.Errorf("read release manifest: %w", err)
+	}
+	var rel ReleaseManifest
+	if err := json.Unmarshal(releaseJSON, &rel); err != nil {
+		return signedRelease{}, fmt.Errorf("parse release manifest: %w", err)
+	}
+	provJSON, err := os.ReadFile(filepath.Join(releaseRoot, rel.Artifacts.Provenance))
+	if err != nil {
+		return signedRelease{}, fmt.Errorf("read provenance: %w", err)
+	}
+	attJSON, err := os.ReadFile(filepath.Join(releaseRoot, rel.Artifacts.Attestation))
+	if err != nil {
+		return signedRelease{}, fmt.Errorf("read attestation: %w", err)
+	}
+	var att Attestation
+	if err := json.Unmarshal(attJSON, &att); err != nil {
+		return signedRelease{}, fmt.Errorf("parse attestation: %w", err)
+	}
+	attCanonical, err := canonicalJSON(att)
+	if err != nil {
+		return signedRelease{}, fmt.Errorf("canonicalize attestation: %w", err)
+	}
+	if !bytes.Equal(attCanonical, attJSON) {
+		return signedRelease{}, fmt.Errorf("attestation is not in canonical form")
+	}
+	if att.ReleaseID != rel.ReleaseID {
+		return signedRelease{}, fmt.Errorf("attestation release id mismatch: %s != %s", att.ReleaseID, rel.ReleaseID)
+	}
+	if err := checkAttestationDigests(releaseRoot, rel, att, releaseJSON, provJSON); err != nil {
+		return signedRelease{}, fmt.Errorf("release no longer matches its attestation: %w", err)
+	}
+	return signedRelease{Manifest: rel, Attestation: att, AttJSON: attJSON}, nil
+}
+
+func coSignatureName(keyID string) string {
+	r := strings.NewReplacer(":", "_", "/", "_", "\\", "_")
+	return r.Replace(strings.TrimSpace(keyID))
+}
+
+// writeCoSignature signs attJSON with priv and stores the signature and public
+// key under CoSignatureDir, named after keyID. It returns the signature path.
+func writeCoSignature(releaseDir string, priv ed25519.PrivateKey, keyID string, attJSON []byte) (string, error) {
+	pub, ok := priv.Public().(ed25519.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("unexpected public key type")
+	}
+	if keyID == "" {
+		keyID = deriveKeyID(pub)
+	}
+	name := coSignatureName(keyID)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid co-signature key id %q", keyID)
+	}
+	dir := filepath.Join(releaseDir, filepath.FromSlash(CoSignatureDir))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create co-signature dir: %w", err)
+	}
+	if err := writePublicKeyPEM(filepath.Join(dir, name+".pem"), pub); err != nil {
+		return "", fmt.Errorf("write co-signer public key: %w", err)
+	}
+	sigPath := filepath.Join(dir, name+".sig")
+	sig := ed25519.Sign(priv, attJSON)
+	if err := os.WriteFile(sigPath, []byte(base64.StdEncoding.EncodeToString(sig)), 0o644); err != nil {
+		return "", fmt.Errorf("write co-signature: %w", err)
+	}
+	return sigPath, nil
+}
+
+// verifyCoSignatures checks every co-signature in the release against the
+// canonical attestation and returns the derived key ids of valid signers.
+// With a trusted set, signatures from keys outside it are ignored and the
+// trusted copy of the key is used; a signature that fails to verify is an
+// error either way.
+func verifyCoSignatures(releaseRoot string, attCanonical []byte, trusted map[string]ed25519.PublicKey) ([]string, error) {
+	dir := filepath.Join(releaseRoot, filepath.FromSlash(CoSignatureDir))
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read co-signatures: %w", err)
+	}
+	var signers []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sig") {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ".sig")
+		pub, err := loadPublicKey(filepath.Join(dir, name+".pem"))
+		if err != nil {
+			return nil, fmt.Errorf("load co-signer key %s: %w", name, err)
+		}
+		id := deriveKeyID(pub)
+		if trusted != nil {
+			tp, ok := trusted[id]
+			if !ok {
+				continue
+			}
+			pub = tp
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read co-signature %s: %w", name, err)
+		}
+		sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("decode co-signature %s: %w", name, err)
+		}
+		if !ed25519.Verify(pub, attCanonical, sig) {
+			return nil, fmt.Errorf("co-signature %s verification failed", name)
+		}
+		signers = append(signers, id)
+	}
+	sort.Strings(signers)
+	return signers, nil
+}
+
+// distinctSigners merges signer key ids, dropping duplicates.
+func distinctSigners(groups ...[]string) []string {
+	seen := map[string]struct{}{}
+	var out []string
+	for _, g := range groups {
+		for _, id := range g {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,55 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// nextReleaseCounter bumps the monotonic release counter kept in
+// <stateDir>/releases/counter and returns the new value. The bump holds an
+// exclusive lock on counter.lock so concurrent releases never hand out the
+// same value, and the file is replaced by rename so a crash never leaves a
+// truncated counter behind.
+func nextReleaseCounter(stateDir string) (uint64, error) {
+	path := filepath.Join(stateDir, "releases", "counter")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	unlock, err := lockCounter(path + ".lock")
+	if err != nil {
+		return 0, fmt.Errorf("lock release counter: %w", err)
+	}
+	defer unlock()
+	var cur uint64
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		cur, err = strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parse release counter %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return 0, err
+	}
+	next := cur + 1
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".counter-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := fmt.Fprintf(tmp, "%d\n", next); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
@@ -0,0 +1,31 @@
+//go:build !(linux || darwin)
+
+package release
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockCounter creates path exclusively, retrying while another process holds
+// it. Without flock a crashed holder leaves the file behind, so give up after
+// a while and name the file to remove.
+func lockCounter(path string) (func(), error) {
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another release; remove it if no release is running", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package release
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockCounter takes an exclusive flock on path, waiting for other holders.
+// The kernel drops the lock if the process dies.
+func lockCounter(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
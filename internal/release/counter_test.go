@@ -0,0 +1,50 @@
+package release
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNextReleaseCounterConcurrent(t *testing.T) {
+	stateDir := t.TempDir()
+	const workers, bumpsPerWorker = 8, 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[uint64]bool{}
+	errs := make(chan error, workers*bumpsPerWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < bumpsPerWorker; i++ {
+				n, err := nextReleaseCounter(stateDir)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				mu.Lock()
+				if seen[n] {
+					errs <- fmt.Errorf("counter %d handed out twice", n)
+				}
+				seen[n] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent counter bump failed: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(stateDir, "releases", "counter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(b)); got != "80" {
+		t.Fatalf("counter = %s, want 80", got)
+	}
+}
@@ -23,22 +23,52 @@ import (
 
 	"github.com/fpp-125/metaclaw/internal/capsule"
 	v1 "github.com/fpp-125/metaclaw/internal/claw/schema/v1"
+	"github.com/fpp-125/metaclaw/internal/claw/validate"
 	"github.com/fpp-125/metaclaw/internal/compiler"
 	"github.com/fpp-125/metaclaw/internal/locks"
 	"github.com/fpp-125/metaclaw/internal/policy"
+	"github.com/fpp-125/metaclaw/internal/signing"
 )
 
+// PassphraseFunc supplies the passphrase for the encrypted signing key at
+// path. confirm is set when a newly generated key is about to be sealed.
+type PassphraseFunc func(path string, confirm bool) ([]byte, error)
+
 type CreateOptions struct {
 	InputPath      string
 	StateDir       string
 	OutputDir      string
 	Strict         bool
+	RequiredChecks []string
 	PrivateKeyPath string
 	KeyID          string
+	PackageTarball bool
+	// SBOM writes a CycloneDX-style bill of materials next to the provenance
+	// and binds its digest into the signed attestation.
+	SBOM bool
+	// CoSignKeys are extra ed25519 private keys that each add a co-signature
+	// under CoSignatureDir. Unlike PrivateKeyPath they must already exist.
+	CoSignKeys []string
+	// Counter increments <StateDir>/releases/counter and embeds the new value
+	// in the signed attestation so verifiers can reject rolled-back releases.
+	Counter bool
+	// EncryptKey seals an auto-generated signing key under a passphrase.
+	EncryptKey bool
+	// Passphrase unlocks encrypted signing keys and seals a new key when
+	// EncryptKey is set. Without it encrypted keys cannot be used.
+	Passphrase PassphraseFunc
+	// AllowedRegistries restricts the runtime image registry for the
+	// runtime.image_registry_allowed check. It is recorded in release.json so
+	// verify re-checks against the same list.
+	AllowedRegistries []string
+	// AllowSysAdmin lets a clawfile input grant SYS_ADMIN through
+	// habitat.capabilities.add; the runtime.no_sys_admin check still fails.
+	AllowSysAdmin bool
 }
 
 type CreateResult struct {
 	ReleaseDir      string
+	TarballPath     string
 	ReleaseID       string
 	CapsuleID       string
 	CapsulePath     string
@@ -47,6 +77,8 @@ type CreateResult struct {
 	PublicKeyPath   string
 	Checks          []StrictCheck
 	StrictEnforced  bool
+	RequiredChecks  []string
+	Counter         uint64
 	ReleaseManifest ReleaseManifest
 }
 
@@ -54,6 +86,14 @@ type VerifyOptions struct {
 	InputPath      string
 	PublicKeyPath  string
 	RequireRelease bool
+	TrustedKeys    []string
+	// Threshold is the minimum number of distinct keys with a valid signature
+	// over the attestation. Values below 1 mean 1; values above 1 require
+	// TrustedKeys, and only trusted keys count.
+	Threshold int
+	// MinCounter rejects releases whose attested counter is below it. Releases
+	// created without a counter count as 0.
+	MinCounter uint64
 }
 
 type VerifyResult struct {
@@ -65,18 +105,24 @@ type VerifyResult struct {
 	CapsulePath     string
 	SignatureValid  bool
 	StrictSatisfied bool
+	Signers         []string
+	Counter         uint64
 	Checks          []StrictCheck
 }
 
 type ReleaseManifest struct {
-	Version   string           `json:"version"`
-	ReleaseID string           `json:"releaseId"`
-	CreatedAt string           `json:"createdAt"`
-	Strict    bool             `json:"strict"`
-	Capsule   ReleaseCapsule   `json:"capsule"`
-	Artifacts ReleaseArtifacts `json:"artifacts"`
-	Signing   ReleaseSigning   `json:"signing"`
-	Checks    []StrictCheck    `json:"checks"`
+	Version        string   `json:"version"`
+	ReleaseID      string   `json:"releaseId"`
+	CreatedAt      string   `json:"createdAt"`
+	Strict         bool     `json:"strict"`
+	RequiredChecks []string `json:"requiredChecks,omitempty"`
+	// AllowedRegistries is the image registry allowlist the release was
+	// checked against; empty means any registry.
+	AllowedRegistries []string         `json:"allowedRegistries,omitempty"`
+	Capsule           ReleaseCapsule   `json:"capsule"`
+	Artifacts         ReleaseArtifacts `json:"artifacts"`
+	Signing           ReleaseSigning   `json:"signing"`
+	Checks            []StrictCheck    `json:"checks"`
 }
 
 type ReleaseCapsule struct {
@@ -89,6 +135,7 @@ type ReleaseArtifacts struct {
 	Provenance  string `json:"provenance"`
 	Attestation string `json:"attestation"`
 	Signature   string `json:"signature"`
+	SBOM        string `json:"sbom,omitempty"`
 }
 
 type ReleaseSigning struct {
@@ -104,6 +151,7 @@ type Attestation struct {
 	CapsuleID string            `json:"capsuleId"`
 	Strict    bool              `json:"strict"`
 	KeyID     string            `json:"keyId"`
+	Counter   uint64            `json:"counter,omitempty"`
 	Digests   map[string]string `json:"digests"`
 }
 
@@ -121,10 +169,13 @@ type Provenance struct {
 	SourceFiles    int    `json:"sourceFiles"`
 }
 
+// StrictCheck is one release check. Advisory checks are recorded but only
+// gate a release when named in RequiredChecks.
 type StrictCheck struct {
-	Name    string `json:"name"`
-	Passed  bool   `json:"passed"`
-	Details string `json:"details"`
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Advisory bool   `json:"advisory,omitempty"`
+	Details  string `json:"details"`
 }
 
 type irDoc struct {
@@ -147,7 +198,7 @@ func Create(opts CreateOptions) (CreateResult, error) {
 		outputDir = filepath.Join(stateDir, "releases")
 	}
 
-	capsulePath, capID, createdCapsule, err := prepareCapsule(opts.InputPath, stateDir)
+	capsulePath, capID, createdCapsule, err := prepareCapsule(opts.InputPath, stateDir, compiler.Options{AllowSysAdmin: opts.AllowSysAdmin})
 	if err != nil {
 		return CreateResult{}, err
 	}
@@ -165,12 +216,23 @@ func Create(opts CreateOptions) (CreateResult, error) {
 		return CreateResult{}, err
 	}
 
-	checks := strictChecks(ir, pol, srcLock)
+	allowedRegistries, err := validate.NormalizeAllowedRegistries(opts.AllowedRegistries)
+	if err != nil {
+		return CreateResult{}, err
+	}
+	checks := strictChecks(ir, pol, srcLock, allowedRegistries)
+	required, err := normalizeRequiredChecks(checks, opts.RequiredChecks)
+	if err != nil {
+		return CreateResult{}, err
+	}
 	if opts.Strict {
 		if failed := failedChecks(checks); len(failed) > 0 {
 			return CreateResult{}, fmt.Errorf("strict checks failed: %s", strings.Join(failed, "; "))
 		}
 	}
+	if failed := failedRequiredChecks(checks, required); len(failed) > 0 {
+		return CreateResult{}, fmt.Errorf("required strict checks failed: %s", strings.Join(failed, "; "))
+	}
 
 	if err := os.MkdirAll(outputDir, 0o755); err != nil {
 		return CreateResult{}, fmt.Errorf("create output dir: %w", err)
@@ -191,7 +253,7 @@ func Create(opts CreateOptions) (CreateResult, error) {
 	if privateKeyPath == "" {
 		privateKeyPath = filepath.Join(stateDir, "keys", "release_ed25519.pem")
 	}
-	priv, pub, createdKey, err := loadOrCreatePrivateKey(privateKeyPath)
+	priv, pub, createdKey, err := loadOrCreatePrivateKey(privateKeyPath, opts.EncryptKey, opts.Passphrase)
 	if err != nil {
 		return CreateResult{}, fmt.Errorf("load signing key: %w", err)
 	}
@@ -217,10 +279,12 @@ func Create(opts CreateOptions) (CreateResult, error) {
 
 	createdAt := time.Now().UTC().Format(time.RFC3339Nano)
 	releaseManifest := ReleaseManifest{
-		Version:   "metaclaw.release/v1",
-		ReleaseID: releaseID,
-		CreatedAt: createdAt,
-		Strict:    opts.Strict,
+		Version:           "metaclaw.release/v1",
+		ReleaseID:         releaseID,
+		CreatedAt:         createdAt,
+		Strict:            opts.Strict,
+		RequiredChecks:    required,
+		AllowedRegistries: allowedRegistries,
 		Capsule: ReleaseCapsule{
 			ID:             manifest.CapsuleID,
 			Path:           "capsule",
@@ -238,6 +302,9 @@ func Create(opts CreateOptions) (CreateResult, error) {
 		},
 		Checks: checks,
 	}
+	if opts.SBOM {
+		releaseManifest.Artifacts.SBOM = SBOMFile
+	}
 
 	releaseJSON, err := canonicalJSON(releaseManifest)
 	if err != nil {
@@ -258,6 +325,21 @@ func Create(opts CreateOptions) (CreateResult, error) {
 		return CreateResult{}, fmt.Errorf("write provenance: %w", err)
 	}
 
+	var sbomJSON []byte
+	if opts.SBOM {
+		bom, err := buildSBOM(createdAt, manifest.CapsuleID, releaseCapsulePath)
+		if err != nil {
+			return CreateResult{}, fmt.Errorf("build sbom: %w", err)
+		}
+		sbomJSON, err = canonicalJSON(bom)
+		if err != nil {
+			return CreateResult{}, fmt.Errorf("marshal sbom: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(releaseDir, releaseManifest.Artifacts.SBOM), sbomJSON, 0o644); err != nil {
+			return CreateResult{}, fmt.Errorf("write sbom: %w", err)
+		}
+	}
+
 	capsuleManifestPath := filepath.Join(releaseCapsulePath, "manifest.json")
 	capsuleManifestJSON, err := os.ReadFile(capsuleManifestPath)
 	if err != nil {
@@ -277,6 +359,15 @@ func Create(opts CreateOptions) (CreateResult, error) {
 			"capsule_manifest": digest(capsuleManifestJSON),
 		},
 	}
+	if sbomJSON != nil {
+		att.Digests["sbom"] = digest(sbomJSON)
+	}
+	if opts.Counter {
+		att.Counter, err = nextReleaseCounter(stateDir)
+		if err != nil {
+			return CreateResult{}, fmt.Errorf("bump release counter: %w", err)
+		}
+	}
 	attJSON, err := canonicalJSON(att)
 	if err != nil {
 		return CreateResult{}, fmt.Errorf("marshal attestation: %w", err)
@@ -294,9 +385,37 @@ func Create(opts CreateOptions) (CreateResult, error) {
 	if err := os.WriteFile(sigPath, []byte(base64.StdEncoding.EncodeToString(sig)), 0o644); err != nil {
 		return CreateResult{}, fmt.Errorf("write signature: %w", err)
 	}
+	signers := map[string]struct{}{deriveKeyID(pub): {}}
+	for _, p := range opts.CoSignKeys {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return CreateResult{}, fmt.Errorf("load co-signing key: %w", err)
+		}
+		coPriv, err := parsePrivateKeyPEM(p, b, opts.Passphrase)
+		if err != nil {
+			return CreateResult{}, fmt.Errorf("load co-signing key %s: %w", p, err)
+		}
+		coID := deriveKeyID(coPriv.Public().(ed25519.PublicKey))
+		if _, dup := signers[coID]; dup {
+			return CreateResult{}, fmt.Errorf("signing key %s is given more than once", coID)
+		}
+		signers[coID] = struct{}{}
+		if _, err := writeCoSignature(releaseDir, coPriv, "", attJSON); err != nil {
+			return CreateResult{}, err
+		}
+	}
+
+	tarballPath := ""
+	if opts.PackageTarball {
+		tarballPath = releaseDir + ".tar.gz"
+		if err := writeReleaseTarball(releaseDir, tarballPath); err != nil {
+			return CreateResult{}, fmt.Errorf("write release tarball: %w", err)
+		}
+	}
 
 	return CreateResult{
 		ReleaseDir:      releaseDir,
+		TarballPath:     tarballPath,
 		ReleaseID:       releaseID,
 		CapsuleID:       manifest.CapsuleID,
 		CapsulePath:     releaseCapsulePath,
@@ -305,6 +424,8 @@ func Create(opts CreateOptions) (CreateResult, error) {
 		PublicKeyPath:   publicKeyPath,
 		Checks:          checks,
 		StrictEnforced:  opts.Strict,
+		RequiredChecks:  required,
+		Counter:         att.Counter,
 		ReleaseManifest: releaseManifest,
 	}, nil
 }
@@ -317,15 +438,18 @@ func Verify(opts VerifyOptions) (VerifyResult, error) {
 	if err != nil {
 		return VerifyResult{}, err
 	}
+	if st.Mode().IsRegular() {
+		return VerifyTarball(opts)
+	}
 	if !st.IsDir() {
-		return VerifyResult{}, fmt.Errorf("input must be a directory")
+		return VerifyResult{}, fmt.Errorf("input must be a directory or release tarball")
 	}
 
 	releasePath := filepath.Join(opts.InputPath, "release.json")
 	if _, err := os.Stat(releasePath); err == nil {
 		return verifyReleaseDir(opts)
 	}
-	if opts.RequireRelease {
+	if opts.RequireRelease || opts.Threshold > 1 || opts.MinCounter > 0 {
 		return VerifyResult{}, fmt.Errorf("release manifest not found: %s", releasePath)
 	}
 
@@ -347,8 +471,42 @@ func Verify(opts VerifyOptions) (VerifyResult, error) {
 	}, nil
 }
 
+// VerifyTarball extracts a release tarball produced by Create with
+// PackageTarball into a temporary directory and verifies it like a release
+// directory. Paths in the result refer to the tarball and its members.
+func VerifyTarball(opts VerifyOptions) (VerifyResult, error) {
+	tmp, err := os.MkdirTemp("", "metaclaw-release-verify-*")
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := capsule.ExtractTarball(opts.InputPath, tmp); err != nil {
+		return VerifyResult{}, fmt.Errorf("extract release tarball: %w", err)
+	}
+	root, err := capsule.FindArchiveRoot(tmp, "release.json")
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	inner := opts
+	inner.InputPath = root
+	res, err := verifyReleaseDir(inner)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	res.ReleasePath = opts.InputPath
+	if member, err := filepath.Rel(tmp, res.CapsulePath); err == nil {
+		res.CapsulePath = filepath.ToSlash(member)
+	}
+	return res, nil
+}
+
 func verifyReleaseDir(opts VerifyOptions) (VerifyResult, error) {
 	releaseRoot := opts.InputPath
+	if opts.Threshold > 1 && len(opts.TrustedKeys) == 0 {
+		// Anyone can drop a self-made key and signature next to the release,
+		// so co-signers only count when they come from a trusted set.
+		return VerifyResult{}, fmt.Errorf("signature threshold %d requires trusted signer keys", opts.Threshold)
+	}
 	releaseJSON, err := os.ReadFile(filepath.Join(releaseRoot, "release.json"))
 	if err != nil {
 		return VerifyResult{}, fmt.Errorf("read release manifest: %w", err)
@@ -400,19 +558,8 @@ func verifyReleaseDir(opts VerifyOptions) (VerifyResult, error) {
 	if rel.Signing.KeyID != "" && att.KeyID != rel.Signing.KeyID {
 		return VerifyResult{}, fmt.Errorf("attestation key id mismatch: release=%s attestation=%s", rel.Signing.KeyID, att.KeyID)
 	}
-	if got := att.Digests["release"]; got != digest(releaseJSON) {
-		return VerifyResult{}, fmt.Errorf("release digest mismatch")
-	}
-	if got := att.Digests["provenance"]; got != digest(provJSON) {
-		return VerifyResult{}, fmt.Errorf("provenance digest mismatch")
-	}
-	capManifestPath := filepath.Join(capsulePath, "manifest.json")
-	capManifestJSON, err := os.ReadFile(capManifestPath)
-	if err != nil {
-		return VerifyResult{}, fmt.Errorf("read capsule manifest: %w", err)
-	}
-	if got := att.Digests["capsule_manifest"]; got != digest(capManifestJSON) {
-		return VerifyResult{}, fmt.Errorf("capsule manifest digest mismatch")
+	if err := checkAttestationDigests(releaseRoot, rel, att, releaseJSON, provJSON); err != nil {
+		return VerifyResult{}, err
 	}
 
 	sigData, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigRaw)))
@@ -420,13 +567,26 @@ func verifyReleaseDir(opts VerifyOptions) (VerifyResult, error) {
 		return VerifyResult{}, fmt.Errorf("decode signature: %w", err)
 	}
 
-	publicKeyPath := strings.TrimSpace(opts.PublicKeyPath)
-	if publicKeyPath == "" {
-		publicKeyPath = filepath.Join(releaseRoot, rel.Signing.PublicKey)
-	}
-	pub, err := loadPublicKey(publicKeyPath)
-	if err != nil {
-		return VerifyResult{}, fmt.Errorf("load public key: %w", err)
+	var pub ed25519.PublicKey
+	var trusted map[string]ed25519.PublicKey
+	if len(opts.TrustedKeys) > 0 {
+		trusted, err = loadTrustedKeys(opts.TrustedKeys)
+		if err != nil {
+			return VerifyResult{}, err
+		}
+		pub, err = trustedSigningKey(trusted, att.KeyID, filepath.Join(releaseRoot, rel.Signing.PublicKey))
+		if err != nil {
+			return VerifyResult{}, err
+		}
+	} else {
+		publicKeyPath := strings.TrimSpace(opts.PublicKeyPath)
+		if publicKeyPath == "" {
+			publicKeyPath = filepath.Join(releaseRoot, rel.Signing.PublicKey)
+		}
+		pub, err = loadPublicKey(publicKeyPath)
+		if err != nil {
+			return VerifyResult{}, fmt.Errorf("load public key: %w", err)
+		}
 	}
 
 	attCanonical, err := canonicalJSON(att)
@@ -436,17 +596,35 @@ func verifyReleaseDir(opts VerifyOptions) (VerifyResult, error) {
 	if !ed25519.Verify(pub, attCanonical, sigData) {
 		return VerifyResult{}, fmt.Errorf("signature verification failed")
 	}
+	coSigners, err := verifyCoSignatures(releaseRoot, attCanonical, trusted)
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	signers := distinctSigners([]string{deriveKeyID(pub)}, coSigners)
+	threshold := opts.Threshold
+	if threshold < 1 {
+		threshold = 1
+	}
+	if len(signers) < threshold {
+		return VerifyResult{}, fmt.Errorf("signature threshold not met: %d valid signer(s), need %d", len(signers), threshold)
+	}
+	if att.Counter < opts.MinCounter {
+		return VerifyResult{}, fmt.Errorf("release counter %d is below minimum %d (possible rollback)", att.Counter, opts.MinCounter)
+	}
 
 	ir, pol, srcLock, err := loadCapsuleDocs(capsulePath)
 	if err != nil {
 		return VerifyResult{}, err
 	}
-	checks := strictChecks(ir, pol, srcLock)
+	checks := signedChecks(strictChecks(ir, pol, srcLock, rel.AllowedRegistries), rel.Checks)
 	if rel.Strict {
 		if failed := failedChecks(checks); len(failed) > 0 {
 			return VerifyResult{}, fmt.Errorf("strict checks no longer satisfied: %s", strings.Join(failed, "; "))
 		}
 	}
+	if failed := failedRequiredChecks(checks, rel.RequiredChecks); len(failed) > 0 {
+		return VerifyResult{}, fmt.Errorf("required strict checks no longer satisfied: %s", strings.Join(failed, "; "))
+	}
 
 	return VerifyResult{
 		Kind:            "release",
@@ -457,11 +635,63 @@ func verifyReleaseDir(opts VerifyOptions) (VerifyResult, error) {
 		CapsulePath:     capsulePath,
 		SignatureValid:  true,
 		StrictSatisfied: !rel.Strict || len(failedChecks(checks)) == 0,
+		Signers:         signers,
+		Counter:         att.Counter,
 		Checks:          checks,
 	}, nil
 }
 
-func prepareCapsule(inputPath, stateDir string) (capsulePath string, capsuleID string, created bool, err error) {
+// checkAttestationDigests confirms the release files still hash to the
+// digests recorded in the attestation.
+func checkAttestationDigests(releaseRoot string, rel ReleaseManifest, att Attestation, releaseJSON, provJSON []byte) error {
+	if got := att.Digests["release"]; got != digest(releaseJSON) {
+		return fmt.Errorf("release digest mismatch")
+	}
+	if got := att.Digests["provenance"]; got != digest(provJSON) {
+		return fmt.Errorf("provenance digest mismatch")
+	}
+	capManifestPath := filepath.Join(releaseRoot, rel.Capsule.Path, "manifest.json")
+	capManifestJSON, err := os.ReadFile(capManifestPath)
+	if err != nil {
+		return fmt.Errorf("read capsule manifest: %w", err)
+	}
+	if got := att.Digests["capsule_manifest"]; got != digest(capManifestJSON) {
+		return fmt.Errorf("capsule manifest digest mismatch")
+	}
+	if rel.Artifacts.SBOM != "" || att.Digests["sbom"] != "" {
+		if rel.Artifacts.SBOM == "" {
+			return fmt.Errorf("attestation records an sbom digest but the release lists no sbom")
+		}
+		sbomJSON, err := os.ReadFile(filepath.Join(releaseRoot, rel.Artifacts.SBOM))
+		if err != nil {
+			return fmt.Errorf("read sbom: %w", err)
+		}
+		if got := att.Digests["sbom"]; got != digest(sbomJSON) {
+			return fmt.Errorf("sbom digest mismatch")
+		}
+	}
+	return nil
+}
+
+// writeReleaseTarball packs releaseDir under its own base name and renames the
+// result into place so a partial tarball is never left at path.
+func writeReleaseTarball(releaseDir, path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".rel-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := capsule.WriteTarball(releaseDir, filepath.Base(releaseDir), tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func prepareCapsule(inputPath, stateDir string, compileOpts compiler.Options) (capsulePath string, capsuleID string, created bool, err error) {
 	st, err := os.Stat(inputPath)
 	if err != nil {
 		return "", "", false, err
@@ -478,7 +708,7 @@ func prepareCapsule(inputPath, stateDir string) (capsulePath string, capsuleID s
 		if err := os.MkdirAll(capsuleRoot, 0o755); err != nil {
 			return "", "", false, err
 		}
-		res, err := compiler.Compile(inputPath, capsuleRoot)
+		res, err := compiler.CompileWithOptions(inputPath, capsuleRoot, compileOpts)
 		if err != nil {
 			return "", "", false, err
 		}
@@ -517,7 +747,7 @@ func loadCapsuleDocs(capsulePath string) (irDoc, policy.Policy, locks.SourceLock
 	return ir, pol, srcLock, nil
 }
 
-func strictChecks(ir irDoc, pol policy.Policy, src locks.SourceLock) []StrictCheck {
+func strictChecks(ir irDoc, pol policy.Policy, src locks.SourceLock, allowedRegistries []string) []StrictCheck {
 	checks := make([]StrictCheck, 0, 8)
 
 	image := strings.TrimSpace(ir.Clawfile.Agent.Runtime.Image)
@@ -530,12 +760,54 @@ func strictChecks(ir irDoc, pol policy.Policy, src locks.SourceLock) []StrictChe
 		Details: "runtime.image must be digest-pinned",
 	})
 
+	registryDetails := "no registry allowlist configured"
+	if len(allowedRegistries) > 0 {
+		registryDetails = fmt.Sprintf("runtime.image registry %s must be one of: %s", validate.ImageRegistry(image), strings.Join(allowedRegistries, ","))
+	}
+	checks = append(checks, StrictCheck{
+		Name:    "runtime.image_registry_allowed",
+		Passed:  validate.RegistryAllowed(image, allowedRegistries),
+		Details: registryDetails,
+	})
+
 	checks = append(checks, StrictCheck{
 		Name:    "habitat.network_not_all",
 		Passed:  strings.TrimSpace(pol.Network.Mode) != "all",
 		Details: "strict mode forbids network=all",
 	})
 
+	domainDetails := "network is not outbound"
+	if strings.TrimSpace(pol.Network.Mode) == "outbound" {
+		domainDetails = "outbound network has no habitat.network.allowedDomains"
+		if len(pol.Network.AllowedDomains) > 0 {
+			domainDetails = "outbound DNS-pinned to: " + strings.Join(pol.Network.AllowedDomains, ",") + " (not enforced for direct IPs)"
+		}
+	}
+	checks = append(checks, StrictCheck{
+		Name:     "habitat.network_domain_allowlist",
+		Passed:   strings.TrimSpace(pol.Network.Mode) != "outbound" || len(pol.Network.AllowedDomains) > 0,
+		Advisory: true,
+		Details:  domainDetails,
+	})
+
+	checks = append(checks, StrictCheck{
+		Name:     "habitat.readonly_rootfs_enabled",
+		Passed:   pol.ReadOnlyRootfs,
+		Advisory: true,
+		Details:  "habitat.readOnlyRootfs keeps the container root filesystem immutable",
+	})
+
+	checks = append(checks, StrictCheck{
+		Name:    "runtime.cap_drop_all",
+		Passed:  containsString(pol.CapDrop, "ALL"),
+		Details: "strict mode expects habitat.capabilities.drop to include ALL",
+	})
+	checks = append(checks, StrictCheck{
+		Name:    "runtime.no_sys_admin",
+		Passed:  !containsString(pol.CapAdd, "SYS_ADMIN"),
+		Details: "strict mode forbids habitat.capabilities.add SYS_ADMIN",
+	})
+
 	mountSourceOK := true
 	mountTargetOK := true
 	mountTargetClean := true
@@ -567,6 +839,28 @@ func strictChecks(ir irDoc, pol policy.Policy, src locks.SourceLock) []StrictChe
 		Details: "mount targets must be normalized paths",
 	})
 
+	tmpfsTargetOK := true
+	tmpfsTargetClean := true
+	for _, t := range pol.Tmpfs {
+		target := strings.TrimSpace(t.Target)
+		if !strings.HasPrefix(target, "/") {
+			tmpfsTargetOK = false
+		}
+		if path.Clean(target) != target {
+			tmpfsTargetClean = false
+		}
+	}
+	checks = append(checks, StrictCheck{
+		Name:    "habitat.tmpfs_targets_absolute",
+		Passed:  tmpfsTargetOK,
+		Details: "all tmpfs targets must be absolute container paths",
+	})
+	checks = append(checks, StrictCheck{
+		Name:    "habitat.tmpfs_targets_clean",
+		Passed:  tmpfsTargetClean,
+		Details: "tmpfs targets must be normalized paths",
+	})
+
 	sourceNonEmpty := len(src.Files) > 0
 	sourceRelOK := true
 	for _, f := range src.Files {
@@ -616,10 +910,85 @@ func llmNoInlineSecret(cfg v1.Clawfile) bool {
 	return !exists
 }
 
+// signedChecks downgrades recomputed checks that are missing from the check
+// list recorded (and signed) in release.json to advisory, so a release made
+// before a check existed keeps verifying.
+func signedChecks(checks, recorded []StrictCheck) []StrictCheck {
+	known := make(map[string]struct{}, len(recorded))
+	for _, c := range recorded {
+		known[c.Name] = struct{}{}
+	}
+	out := make([]StrictCheck, 0, len(checks))
+	for _, c := range checks {
+		if _, ok := known[c.Name]; !ok && !c.Advisory {
+			c.Advisory = true
+			c.Details += " (not recorded when the release was signed)"
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
 func failedChecks(checks []StrictCheck) []string {
 	out := make([]string, 0)
 	for _, c := range checks {
-		if !c.Passed {
+		if !c.Passed && !c.Advisory {
+			out = append(out, c.Name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// normalizeRequiredChecks trims, dedupes, and sorts the requested check names,
+// rejecting names that strictChecks does not produce.
+func normalizeRequiredChecks(checks []StrictCheck, names []string) ([]string, error) {
+	known := make(map[string]struct{}, len(checks))
+	for _, c := range checks {
+		known[c.Name] = struct{}{}
+	}
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(names))
+	var unknown []string
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		if _, ok := seen[n]; ok {
+			continue
+		}
+		seen[n] = struct{}{}
+		if _, ok := known[n]; !ok {
+			unknown = append(unknown, n)
+			continue
+		}
+		out = append(out, n)
+	}
+	if len(unknown) > 0 {
+		all := make([]string, 0, len(known))
+		for k := range known {
+			all = append(all, k)
+		}
+		sort.Strings(all)
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown strict check(s): %s (known: %s)", strings.Join(unknown, ", "), strings.Join(all, ", "))
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func failedRequiredChecks(checks []StrictCheck, required []string) []string {
+	if len(required) == 0 {
+		return nil
+	}
+	want := make(map[string]struct{}, len(required))
+	for _, n := range required {
+		want[n] = struct{}{}
+	}
+	out := make([]string, 0)
+	for _, c := range checks {
+		if _, ok := want[c.Name]; ok && !c.Passed {
 			out = append(out, c.Name)
 		}
 	}
@@ -735,9 +1104,11 @@ func copyDir(src, dst string) error {
 	})
 }
 
-func loadOrCreatePrivateKey(path string) (ed25519.PrivateKey, ed25519.PublicKey, bool, error) {
+// loadOrCreatePrivateKey loads the key at path, generating one if it does not
+// exist. With encrypt a generated key is sealed under a passphrase from pass.
+func loadOrCreatePrivateKey(path string, encrypt bool, pass PassphraseFunc) (ed25519.PrivateKey, ed25519.PublicKey, bool, error) {
 	if b, err := os.ReadFile(path); err == nil {
-		priv, err := parsePrivateKeyPEM(b)
+		priv, err := parsePrivateKeyPEM(path, b, pass)
 		if err != nil {
 			return nil, nil, false, err
 		}
@@ -757,6 +1128,19 @@ func loadOrCreatePrivateKey(path string) (ed25519.PrivateKey, ed25519.PublicKey,
 	if err != nil {
 		return nil, nil, false, err
 	}
+	if encrypt {
+		if pass == nil {
+			return nil, nil, false, fmt.Errorf("encrypting a new signing key requires a passphrase")
+		}
+		secret, err := pass(path, true)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if err := signing.WriteEncryptedPrivateKeyPEM(path, priv, secret); err != nil {
+			return nil, nil, false, err
+		}
+		return priv, pub, true, nil
+	}
 	if err := writePrivateKeyPEM(path, priv); err != nil {
 		return nil, nil, false, err
 	}
@@ -772,20 +1156,14 @@ func writePrivateKeyPEM(path string, key ed25519.PrivateKey) error {
 	return os.WriteFile(path, pem.EncodeToMemory(block), 0o600)
 }
 
-func parsePrivateKeyPEM(pemBytes []byte) (ed25519.PrivateKey, error) {
-	block, _ := pem.Decode(pemBytes)
-	if block == nil {
-		return nil, fmt.Errorf("invalid private key PEM")
+// parsePrivateKeyPEM accepts plain and passphrase-encrypted keys; pass is
+// only consulted for encrypted ones.
+func parsePrivateKeyPEM(path string, pemBytes []byte, pass PassphraseFunc) (ed25519.PrivateKey, error) {
+	var unlock func() ([]byte, error)
+	if pass != nil {
+		unlock = func() ([]byte, error) { return pass(path, false) }
 	}
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if err != nil {
-		return nil, err
-	}
-	priv, ok := key.(ed25519.PrivateKey)
-	if !ok {
-		return nil, fmt.Errorf("private key is not ed25519")
-	}
-	return priv, nil
+	return signing.ParsePrivateKeyPEM(pemBytes, unlock)
 }
 
 func writePublicKeyPEM(path string, key ed25519.PublicKey) error {
@@ -817,7 +1195,47 @@ func loadPublicKey(path string) (ed25519.PublicKey, error) {
 	return pub, nil
 }
 
+// trustedSigningKey returns the trusted key whose derived id matches the
+// attestation key id. The public key embedded in the release must itself be
+// trusted, so a release signed by an unknown key cannot vouch for itself.
+func trustedSigningKey(trusted map[string]ed25519.PublicKey, keyID, embeddedPath string) (ed25519.PublicKey, error) {
+	embedded, err := loadPublicKey(embeddedPath)
+	if err != nil {
+		return nil, fmt.Errorf("load embedded public key: %w", err)
+	}
+	if _, ok := trusted[deriveKeyID(embedded)]; !ok {
+		return nil, fmt.Errorf("embedded public key %s is not trusted", deriveKeyID(embedded))
+	}
+	pub, ok := trusted[keyID]
+	if !ok {
+		return nil, fmt.Errorf("attestation key id %s does not match any trusted key", keyID)
+	}
+	return pub, nil
+}
+
+// loadTrustedKeys reads the trusted public keys, indexed by derived key id.
+func loadTrustedKeys(paths []string) (map[string]ed25519.PublicKey, error) {
+	trusted := map[string]ed25519.PublicKey{}
+	for _, p := range paths {
+		pub, err := loadPublicKey(p)
+		if err != nil {
+			return nil, fmt.Errorf("load trusted key %s: %w", p, err)
+		}
+		trusted[deriveKeyID(pub)] = pub
+	}
+	return trusted, nil
+}
+
 func deriveKeyID(key ed25519.PublicKey) string {
 	sum := sha256.Sum256(key)
 	return "ed25519:" + hex.EncodeToString(sum[:8])
 }
+
+func containsString(list []string, want string) bool {
+	for _, v := range list {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,14 @@
 package release
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/fpp-125/metaclaw/internal/capsule"
+	"github.com/fpp-125/metaclaw/internal/signing"
 )
 
 func TestCreateAndVerifyReleaseStrict(t *testing.T) {
@@ -48,6 +52,25 @@ func TestCreateAndVerifyReleaseStrict(t *testing.T) {
 	}
 }
 
+// testdata/rel_baseline_strict is a strict release built by an engine that
+// predates the capability checks; its capsule policy has no capDrop.
+func TestVerifyStrictReleaseFromOlderEngine(t *testing.T) {
+	t.Parallel()
+
+	res, err := Verify(VerifyOptions{InputPath: filepath.Join("testdata", "rel_baseline_strict"), RequireRelease: true})
+	if err != nil {
+		t.Fatalf("verify baseline release: %v", err)
+	}
+	if !res.StrictSatisfied {
+		t.Fatal("expected strict checks recorded at signing to stay satisfied")
+	}
+	for _, c := range res.Checks {
+		if c.Name == "runtime.cap_drop_all" && (c.Passed || !c.Advisory) {
+			t.Fatalf("expected unrecorded cap_drop_all to be reported as a failed advisory check, got %+v", c)
+		}
+	}
+}
+
 func TestVerifyReleaseFailsAfterSignatureTamper(t *testing.T) {
 	t.Parallel()
 
@@ -78,6 +101,376 @@ func TestVerifyReleaseFailsAfterSignatureTamper(t *testing.T) {
 	}
 }
 
+func TestCreatePackagesVerifiableTarball(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	res, err := Create(CreateOptions{
+		InputPath:      clawPath,
+		StateDir:       filepath.Join(root, "state"),
+		PackageTarball: true,
+	})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	if want := res.ReleaseDir + ".tar.gz"; res.TarballPath != want {
+		t.Fatalf("tarball path = %q, want %q", res.TarballPath, want)
+	}
+
+	verifyRes, err := Verify(VerifyOptions{InputPath: res.TarballPath, RequireRelease: true})
+	if err != nil {
+		t.Fatalf("verify tarball: %v", err)
+	}
+	if !verifyRes.SignatureValid || verifyRes.ReleaseID != res.ReleaseID {
+		t.Fatalf("unexpected verify result: %+v", verifyRes)
+	}
+	if verifyRes.ReleasePath != res.TarballPath {
+		t.Fatalf("release path = %q, want tarball path", verifyRes.ReleasePath)
+	}
+
+	sigPath := filepath.Join(res.ReleaseDir, "signing", "attestation.sig")
+	if err := os.WriteFile(sigPath, []byte("ZmFrZV9zaWduYXR1cmU="), 0o644); err != nil {
+		t.Fatalf("tamper signature: %v", err)
+	}
+	tampered := filepath.Join(root, "tampered.tar.gz")
+	f, err := os.Create(tampered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := capsule.WriteTarball(res.ReleaseDir, filepath.Base(res.ReleaseDir), f); err != nil {
+		t.Fatalf("repack tarball: %v", err)
+	}
+	f.Close()
+	if _, err := VerifyTarball(VerifyOptions{InputPath: tampered}); err == nil || !strings.Contains(err.Error(), "signature verification failed") {
+		t.Fatalf("expected signature failure for tampered tarball, got %v", err)
+	}
+}
+
+func TestVerifyTrustedKeysPinsSigner(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	res, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state")})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	_, other, _, err := loadOrCreatePrivateKey(filepath.Join(root, "other.pem"), false, nil)
+	if err != nil {
+		t.Fatalf("create other key: %v", err)
+	}
+	otherPub := filepath.Join(root, "other.pub.pem")
+	if err := writePublicKeyPEM(otherPub, other); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: []string{otherPub, res.PublicKeyPath}}); err != nil {
+		t.Fatalf("verify with signer trusted: %v", err)
+	}
+	_, err = Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: []string{otherPub}})
+	if err == nil || !strings.Contains(err.Error(), "is not trusted") {
+		t.Fatalf("expected untrusted signer to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyThresholdCountsDistinctCoSigners(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	coKey := filepath.Join(root, "bob.pem")
+	_, bob, _, err := loadOrCreatePrivateKey(coKey, false, nil)
+	if err != nil {
+		t.Fatalf("create co-signing key: %v", err)
+	}
+	res, err := Create(CreateOptions{
+		InputPath:      clawPath,
+		StateDir:       filepath.Join(root, "state"),
+		PrivateKeyPath: filepath.Join(root, "alice.pem"),
+		CoSignKeys:     []string{coKey},
+	})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	coSig := filepath.Join(res.ReleaseDir, filepath.FromSlash(CoSignatureDir), coSignatureName(deriveKeyID(bob))+".sig")
+	if _, err := os.Stat(coSig); err != nil {
+		t.Fatalf("co-signature missing: %v", err)
+	}
+
+	bobPub := filepath.Join(root, "bob.pub.pem")
+	if err := writePublicKeyPEM(bobPub, bob); err != nil {
+		t.Fatal(err)
+	}
+	trusted := []string{res.PublicKeyPath, bobPub}
+	out, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: trusted, Threshold: 2})
+	if err != nil {
+		t.Fatalf("verify threshold 2: %v", err)
+	}
+	if len(out.Signers) != 2 {
+		t.Fatalf("signers = %v, want 2", out.Signers)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: trusted, Threshold: 3}); err == nil || !strings.Contains(err.Error(), "signature threshold not met") {
+		t.Fatalf("expected threshold failure, got %v", err)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, Threshold: 2}); err == nil || !strings.Contains(err.Error(), "requires trusted signer keys") {
+		t.Fatalf("expected threshold without trusted keys to be refused, got %v", err)
+	}
+
+	// Only trusted co-signers count towards the threshold.
+	_, err = Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: []string{res.PublicKeyPath}, Threshold: 2})
+	if err == nil || !strings.Contains(err.Error(), "signature threshold not met") {
+		t.Fatalf("expected untrusted co-signer to be ignored, got %v", err)
+	}
+
+	if err := os.WriteFile(coSig, []byte("ZmFrZV9zaWduYXR1cmU="), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir}); err == nil || !strings.Contains(err.Error(), "verification failed") {
+		t.Fatalf("expected tampered co-signature to fail, got %v", err)
+	}
+}
+
+func TestVerifyThresholdIgnoresSelfMadeCoSignatures(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	res, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state")})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	// Anyone with write access to the release can mint keys and co-sign it.
+	for _, name := range []string{"mallory.pem", "trudy.pem"} {
+		key := filepath.Join(root, name)
+		if _, _, _, err := loadOrCreatePrivateKey(key, false, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Sign(SignOptions{ReleaseDir: res.ReleaseDir, PrivateKeyPath: key}); err != nil {
+			t.Fatalf("co-sign with %s: %v", name, err)
+		}
+	}
+
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, Threshold: 2}); err == nil || !strings.Contains(err.Error(), "requires trusted signer keys") {
+		t.Fatalf("expected threshold without trusted keys to be refused, got %v", err)
+	}
+	_, err = Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: []string{res.PublicKeyPath}, Threshold: 2})
+	if err == nil || !strings.Contains(err.Error(), "1 valid signer(s), need 2") {
+		t.Fatalf("expected self-made co-signatures not to count, got %v", err)
+	}
+}
+
+func TestSignAddsCoSignatureWithoutTouchingAttestation(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	res, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state")})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	attPath := filepath.Join(res.ReleaseDir, "attestation.json")
+	before, err := os.ReadFile(attPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sign(SignOptions{ReleaseDir: res.ReleaseDir, PrivateKeyPath: res.PrivateKeyPath}); err == nil || !strings.Contains(err.Error(), "already signed") {
+		t.Fatalf("expected primary key to be rejected, got %v", err)
+	}
+
+	coKey := filepath.Join(root, "bob.pem")
+	_, bob, _, err := loadOrCreatePrivateKey(coKey, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobPub := filepath.Join(root, "bob.pub.pem")
+	if err := writePublicKeyPEM(bobPub, bob); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := Sign(SignOptions{ReleaseDir: res.ReleaseDir, PrivateKeyPath: coKey})
+	if err != nil {
+		t.Fatalf("sign release: %v", err)
+	}
+	if _, err := os.Stat(signed.SignaturePath); err != nil {
+		t.Fatalf("co-signature missing: %v", err)
+	}
+	after, err := os.ReadFile(attPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("attestation was rewritten by sign")
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: []string{res.PublicKeyPath, bobPub}, Threshold: 2}); err != nil {
+		t.Fatalf("verify after sign: %v", err)
+	}
+	if _, err := Sign(SignOptions{ReleaseDir: res.ReleaseDir, PrivateKeyPath: coKey}); err == nil || !strings.Contains(err.Error(), "already has a co-signature") {
+		t.Fatalf("expected duplicate co-signature to be rejected, got %v", err)
+	}
+
+	provPath := filepath.Join(res.ReleaseDir, "provenance.json")
+	if err := os.WriteFile(provPath, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(root, "carol.pem")
+	if _, _, _, err := loadOrCreatePrivateKey(other, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sign(SignOptions{ReleaseDir: res.ReleaseDir, PrivateKeyPath: other}); err == nil || !strings.Contains(err.Error(), "provenance digest mismatch") {
+		t.Fatalf("expected tampered release to be refused, got %v", err)
+	}
+}
+
+func TestReleaseCounterRejectsRollback(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+	stateDir := filepath.Join(root, "state")
+
+	first, err := Create(CreateOptions{InputPath: clawPath, StateDir: stateDir, Counter: true})
+	if err != nil {
+		t.Fatalf("create first release: %v", err)
+	}
+	second, err := Create(CreateOptions{InputPath: clawPath, StateDir: stateDir, Counter: true})
+	if err != nil {
+		t.Fatalf("create second release: %v", err)
+	}
+	if first.Counter != 1 || second.Counter != 2 {
+		t.Fatalf("counters = %d, %d; want 1, 2", first.Counter, second.Counter)
+	}
+
+	out, err := Verify(VerifyOptions{InputPath: second.ReleaseDir, MinCounter: 2})
+	if err != nil {
+		t.Fatalf("verify latest release: %v", err)
+	}
+	if out.Counter != 2 {
+		t.Fatalf("verified counter = %d, want 2", out.Counter)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: first.ReleaseDir, MinCounter: 2}); err == nil || !strings.Contains(err.Error(), "below minimum") {
+		t.Fatalf("expected rollback rejection, got %v", err)
+	}
+}
+
+func TestCreateWithEncryptedSigningKey(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+	passphrase := func(secret string) PassphraseFunc {
+		return func(string, bool) ([]byte, error) { return []byte(secret), nil }
+	}
+
+	keyPath := filepath.Join(root, "release.pem")
+	first, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state"), PrivateKeyPath: keyPath, EncryptKey: true, Passphrase: passphrase("s3cret")})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	b, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), signing.EncryptedPrivateKeyPEMType) {
+		t.Fatalf("expected generated key to be encrypted")
+	}
+
+	second, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state"), PrivateKeyPath: keyPath, Passphrase: passphrase("s3cret")})
+	if err != nil {
+		t.Fatalf("create release with existing encrypted key: %v", err)
+	}
+	if first.ReleaseManifest.Signing.KeyID != second.ReleaseManifest.Signing.KeyID {
+		t.Fatalf("expected the encrypted key to be reused")
+	}
+
+	if _, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state"), PrivateKeyPath: keyPath, Passphrase: passphrase("wrong")}); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("expected wrong passphrase error, got %v", err)
+	}
+	if _, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state"), PrivateKeyPath: keyPath}); err == nil || !strings.Contains(err.Error(), "private key is encrypted") {
+		t.Fatalf("expected encrypted key without a passphrase to be refused, got %v", err)
+	}
+}
+
+func TestResignRotatesSigningKey(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	res, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state")})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	oldPub := filepath.Join(root, "old.pub.pem")
+	if err := copyFile(res.PublicKeyPath, oldPub); err != nil {
+		t.Fatal(err)
+	}
+	coKey := filepath.Join(root, "bob.pem")
+	if _, _, _, err := loadOrCreatePrivateKey(coKey, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sign(SignOptions{ReleaseDir: res.ReleaseDir, PrivateKeyPath: coKey}); err != nil {
+		t.Fatalf("co-sign: %v", err)
+	}
+	newKey := filepath.Join(root, "new.pem")
+	_, newPub, _, err := loadOrCreatePrivateKey(newKey, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A key that did not produce the current signature cannot rotate it.
+	bobPub := filepath.Join(root, "bob.pub.pem")
+	_, bob, _, err := loadOrCreatePrivateKey(coKey, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writePublicKeyPEM(bobPub, bob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resign(ResignOptions{ReleaseDir: res.ReleaseDir, OldPublicKeyPath: bobPub, NewPrivateKeyPath: newKey}); err == nil || !strings.Contains(err.Error(), "refusing to rotate") {
+		t.Fatalf("expected refusal with the wrong old key, got %v", err)
+	}
+
+	out, err := Resign(ResignOptions{ReleaseDir: res.ReleaseDir, OldPublicKeyPath: oldPub, NewPrivateKeyPath: newKey})
+	if err != nil {
+		t.Fatalf("resign: %v", err)
+	}
+	if out.NewKeyID != deriveKeyID(newPub) || out.DroppedCoSignatures != 1 {
+		t.Fatalf("unexpected resign result: %+v", out)
+	}
+	newPubPath := filepath.Join(root, "new.pub.pem")
+	if err := writePublicKeyPEM(newPubPath, newPub); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: []string{newPubPath}}); err != nil {
+		t.Fatalf("verify with new key: %v", err)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, TrustedKeys: []string{oldPub}}); err == nil {
+		t.Fatalf("expected old key to no longer verify the release")
+	}
+}
+
+func copyFile(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, 0o644)
+}
+
 func TestCreateStrictRejectsNetworkAll(t *testing.T) {
 	t.Parallel()
 
@@ -127,6 +520,152 @@ func TestVerifyCapsuleDirectory(t *testing.T) {
 	}
 }
 
+func TestCreateRequireStrictPassGatesOnlyNamedChecks(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "all")
+
+	res, err := Create(CreateOptions{
+		InputPath:      clawPath,
+		StateDir:       filepath.Join(root, "state"),
+		RequiredChecks: []string{"runtime.image_digest_pinned"},
+	})
+	if err != nil {
+		t.Fatalf("expected release to pass when only unrelated checks fail: %v", err)
+	}
+	if len(res.ReleaseManifest.RequiredChecks) != 1 || res.ReleaseManifest.RequiredChecks[0] != "runtime.image_digest_pinned" {
+		t.Fatalf("expected required checks recorded in manifest: %+v", res.ReleaseManifest.RequiredChecks)
+	}
+	recordedFailure := false
+	for _, c := range res.Checks {
+		if c.Name == "habitat.network_not_all" && !c.Passed {
+			recordedFailure = true
+		}
+	}
+	if !recordedFailure {
+		t.Fatalf("expected failing non-required check to be recorded: %+v", res.Checks)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, RequireRelease: true}); err != nil {
+		t.Fatalf("verify release: %v", err)
+	}
+
+	_, err = Create(CreateOptions{
+		InputPath:      clawPath,
+		StateDir:       filepath.Join(root, "state"),
+		RequiredChecks: []string{"runtime.image_digest_pinned", " habitat.network_not_all"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "required strict checks failed: habitat.network_not_all") {
+		t.Fatalf("expected required check failure, got %v", err)
+	}
+}
+
+func TestDomainAllowlistCheckIsAdvisory(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "outbound")
+
+	res, err := Create(CreateOptions{InputPath: clawPath, StateDir: filepath.Join(root, "state"), Strict: true})
+	if err != nil {
+		t.Fatalf("strict release of unrestricted outbound agent should pass: %v", err)
+	}
+	found := false
+	for _, c := range res.Checks {
+		if c.Name == "habitat.network_domain_allowlist" {
+			found = true
+			if c.Passed || !c.Advisory {
+				t.Fatalf("expected failing advisory allowlist check, got %+v", c)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("domain allowlist check not recorded: %+v", res.Checks)
+	}
+
+	_, err = Create(CreateOptions{
+		InputPath:      clawPath,
+		StateDir:       filepath.Join(root, "state"),
+		RequiredChecks: []string{"habitat.network_domain_allowlist"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "habitat.network_domain_allowlist") {
+		t.Fatalf("expected required allowlist check to gate the release, got %v", err)
+	}
+}
+
+func TestCreateRequireStrictPassRejectsUnknownCheck(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	_, err := Create(CreateOptions{
+		InputPath:      clawPath,
+		StateDir:       filepath.Join(root, "state"),
+		RequiredChecks: []string{"no.such_check"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "unknown strict check(s): no.such_check") {
+		t.Fatalf("expected unknown check error, got %v", err)
+	}
+}
+
+func TestCreateSBOMIsSignedAndVerified(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	res, err := Create(CreateOptions{
+		InputPath: clawPath,
+		StateDir:  filepath.Join(root, "state"),
+		SBOM:      true,
+	})
+	if err != nil {
+		t.Fatalf("create release: %v", err)
+	}
+	if res.ReleaseManifest.Artifacts.SBOM != SBOMFile {
+		t.Fatalf("sbom artifact = %q, want %q", res.ReleaseManifest.Artifacts.SBOM, SBOMFile)
+	}
+	sbomPath := filepath.Join(res.ReleaseDir, SBOMFile)
+	b, err := os.ReadFile(sbomPath)
+	if err != nil {
+		t.Fatalf("read sbom: %v", err)
+	}
+	var bom SBOM
+	if err := json.Unmarshal(b, &bom); err != nil {
+		t.Fatalf("parse sbom: %v", err)
+	}
+	if bom.BOMFormat != "CycloneDX" || bom.Metadata.Component.Name != res.CapsuleID {
+		t.Fatalf("unexpected sbom header: %+v", bom)
+	}
+	var sawImage, sawSource bool
+	for _, c := range bom.Components {
+		switch {
+		case c.Type == "container" && len(c.Hashes) == 1:
+			sawImage = true
+		case c.Type == "file" && c.Name == "agent.claw" && len(c.Hashes) == 1:
+			sawSource = true
+		}
+	}
+	if !sawImage || !sawSource {
+		t.Fatalf("expected image and agent.claw components, got %+v", bom.Components)
+	}
+
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, RequireRelease: true}); err != nil {
+		t.Fatalf("verify release: %v", err)
+	}
+	if err := os.WriteFile(sbomPath, append(b, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, RequireRelease: true}); err == nil || !strings.Contains(err.Error(), "sbom digest mismatch") {
+		t.Fatalf("expected sbom digest mismatch, got %v", err)
+	}
+}
+
 func writeTestClaw(t *testing.T, outPath string, networkMode string) {
 	t.Helper()
 	content := "apiVersion: metaclaw/v1\n" +
@@ -149,3 +688,37 @@ func writeTestClaw(t *testing.T, outPath string, networkMode string) {
 		t.Fatalf("write claw: %v", err)
 	}
 }
+
+func TestImageRegistryAllowedCheck(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	clawPath := filepath.Join(root, "agent.claw")
+	writeTestClaw(t, clawPath, "none")
+
+	_, err := Create(CreateOptions{
+		InputPath:         clawPath,
+		StateDir:          filepath.Join(root, "state"),
+		RequiredChecks:    []string{"runtime.image_registry_allowed"},
+		AllowedRegistries: []string{"registry.corp.internal"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "runtime.image_registry_allowed") {
+		t.Fatalf("expected docker.io image to fail the registry check, got %v", err)
+	}
+
+	res, err := Create(CreateOptions{
+		InputPath:         clawPath,
+		StateDir:          filepath.Join(root, "state"),
+		RequiredChecks:    []string{"runtime.image_registry_allowed"},
+		AllowedRegistries: []string{"docker.io"},
+	})
+	if err != nil {
+		t.Fatalf("create with docker.io allowed: %v", err)
+	}
+	if got := res.ReleaseManifest.AllowedRegistries; len(got) != 1 || got[0] != "docker.io" {
+		t.Fatalf("expected allowlist recorded in release.json, got %v", got)
+	}
+	if _, err := Verify(VerifyOptions{InputPath: res.ReleaseDir, RequireRelease: true}); err != nil {
+		t.Fatalf("verify release: %v", err)
+	}
+}
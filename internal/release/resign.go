@@ -0,0 +1,126 @@
+package release
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ResignOptions struct {
+	ReleaseDir        string
+	OldPublicKeyPath  string
+	NewPrivateKeyPath string
+	KeyID             string
+	// Passphrase unlocks an encrypted NewPrivateKeyPath.
+	Passphrase PassphraseFunc
+}
+
+type ResignResult struct {
+	ReleaseID     string
+	OldKeyID      string
+	NewKeyID      string
+	PublicKeyPath string
+	// DroppedCoSignatures counts co-signatures removed because they covered
+	// the old attestation; co-signers have to sign the release again.
+	DroppedCoSignatures int
+}
+
+// Resign rotates the signing key of an existing release. The current
+// signature must verify under the old public key and the release files must
+// still match the attestation; only then are release.json and the attestation
+// rewritten for the new key and signed with it. The capsule is untouched.
+func Resign(opts ResignOptions) (ResignResult, error) {
+	releaseRoot := strings.TrimSpace(opts.ReleaseDir)
+	if releaseRoot == "" {
+		return ResignResult{}, fmt.Errorf("release directory is required")
+	}
+	if strings.TrimSpace(opts.OldPublicKeyPath) == "" || strings.TrimSpace(opts.NewPrivateKeyPath) == "" {
+		return ResignResult{}, fmt.Errorf("old public key and new signing key are required")
+	}
+	sr, err := loadSignedRelease(releaseRoot)
+	if err != nil {
+		return ResignResult{}, err
+	}
+	rel, att := sr.Manifest, sr.Attestation
+	sigRaw, err := os.ReadFile(filepath.Join(releaseRoot, rel.Artifacts.Signature))
+	if err != nil {
+		return ResignResult{}, fmt.Errorf("read signature: %w", err)
+	}
+	oldPub, err := loadPublicKey(opts.OldPublicKeyPath)
+	if err != nil {
+		return ResignResult{}, fmt.Errorf("load old public key: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigRaw)))
+	if err != nil || !ed25519.Verify(oldPub, sr.AttJSON, sig) {
+		return ResignResult{}, fmt.Errorf("current signature does not verify with the old public key; refusing to rotate")
+	}
+
+	keyBytes, err := os.ReadFile(opts.NewPrivateKeyPath)
+	if err != nil {
+		return ResignResult{}, fmt.Errorf("load new signing key: %w", err)
+	}
+	newPriv, err := parsePrivateKeyPEM(opts.NewPrivateKeyPath, keyBytes, opts.Passphrase)
+	if err != nil {
+		return ResignResult{}, fmt.Errorf("load new signing key: %w", err)
+	}
+	newPub := newPriv.Public().(ed25519.PublicKey)
+	if deriveKeyID(newPub) == deriveKeyID(oldPub) {
+		return ResignResult{}, fmt.Errorf("new signing key is the same as the old key")
+	}
+	newKeyID := strings.TrimSpace(opts.KeyID)
+	if newKeyID == "" {
+		newKeyID = deriveKeyID(newPub)
+	}
+
+	publicKeyPath := filepath.Join(releaseRoot, rel.Signing.PublicKey)
+	if err := writePublicKeyPEM(publicKeyPath, newPub); err != nil {
+		return ResignResult{}, fmt.Errorf("write public key: %w", err)
+	}
+	rel.Signing.KeyID = newKeyID
+	newReleaseJSON, err := canonicalJSON(rel)
+	if err != nil {
+		return ResignResult{}, fmt.Errorf("marshal release manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(releaseRoot, "release.json"), newReleaseJSON, 0o644); err != nil {
+		return ResignResult{}, fmt.Errorf("write release manifest: %w", err)
+	}
+
+	oldKeyID := att.KeyID
+	att.KeyID = newKeyID
+	att.Digests["release"] = digest(newReleaseJSON)
+	newAttJSON, err := canonicalJSON(att)
+	if err != nil {
+		return ResignResult{}, fmt.Errorf("marshal attestation: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(releaseRoot, rel.Artifacts.Attestation), newAttJSON, 0o644); err != nil {
+		return ResignResult{}, fmt.Errorf("write attestation: %w", err)
+	}
+	newSig := ed25519.Sign(newPriv, newAttJSON)
+	if err := os.WriteFile(filepath.Join(releaseRoot, rel.Artifacts.Signature), []byte(base64.StdEncoding.EncodeToString(newSig)), 0o644); err != nil {
+		return ResignResult{}, fmt.Errorf("write signature: %w", err)
+	}
+
+	dropped := 0
+	coDir := filepath.Join(releaseRoot, filepath.FromSlash(CoSignatureDir))
+	if entries, err := os.ReadDir(coDir); err == nil {
+		for _, e := range entries {
+			if strings.HasSuffix(e.Name(), ".sig") {
+				dropped++
+			}
+		}
+		if err := os.RemoveAll(coDir); err != nil {
+			return ResignResult{}, fmt.Errorf("remove stale co-signatures: %w", err)
+		}
+	}
+
+	return ResignResult{
+		ReleaseID:           rel.ReleaseID,
+		OldKeyID:            oldKeyID,
+		NewKeyID:            newKeyID,
+		PublicKeyPath:       publicKeyPath,
+		DroppedCoSignatures: dropped,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package release
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fpp-125/metaclaw/internal/locks"
+)
+
+// SBOMFile is the release-relative path of the optional software bill of
+// materials written by Create with SBOM set.
+const SBOMFile = "sbom.cdx.json"
+
+// SBOM is a minimal CycloneDX-style bill of materials: the runtime image,
+// the declared skills from the deps lock, and the source files from the
+// source lock.
+type SBOM struct {
+	BOMFormat   string          `json:"bomFormat"`
+	SpecVersion string          `json:"specVersion"`
+	Version     int             `json:"version"`
+	Metadata    SBOMMetadata    `json:"metadata"`
+	Components  []SBOMComponent `json:"components"`
+}
+
+type SBOMMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Component SBOMComponent `json:"component"`
+}
+
+type SBOMComponent struct {
+	Type    string     `json:"type"`
+	Name    string     `json:"name"`
+	Version string     `json:"version,omitempty"`
+	Hashes  []SBOMHash `json:"hashes,omitempty"`
+}
+
+type SBOMHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+func buildSBOM(createdAt, capsuleID, capsulePath string) (SBOM, error) {
+	var deps locks.DepsLock
+	if err := readLockFile(capsulePath, locks.DepsLockFile, &deps); err != nil {
+		return SBOM{}, err
+	}
+	var img locks.ImageLock
+	if err := readLockFile(capsulePath, locks.ImageLockFile, &img); err != nil {
+		return SBOM{}, err
+	}
+	var src locks.SourceLock
+	if err := readLockFile(capsulePath, locks.SourceLockFile, &src); err != nil {
+		return SBOM{}, err
+	}
+
+	components := make([]SBOMComponent, 0, 1+len(deps.Skills)+len(src.Files))
+	imageDigest := img.Digest
+	if _, pinned, ok := strings.Cut(img.Image, "@"); ok && strings.HasPrefix(pinned, "sha256:") {
+		imageDigest = pinned
+	}
+	components = append(components, SBOMComponent{
+		Type:   "container",
+		Name:   img.Image,
+		Hashes: sbomHashes(imageDigest),
+	})
+	for _, s := range deps.Skills {
+		name := s.ID
+		if name == "" {
+			name = s.Path
+		}
+		components = append(components, SBOMComponent{
+			Type:    "library",
+			Name:    name,
+			Version: s.Version,
+			Hashes:  sbomHashes(s.Digest),
+		})
+	}
+	for _, f := range src.Files {
+		components = append(components, SBOMComponent{
+			Type:   "file",
+			Name:   f.Path,
+			Hashes: sbomHashes(f.SHA256),
+		})
+	}
+
+	return SBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Metadata: SBOMMetadata{
+			Timestamp: createdAt,
+			Component: SBOMComponent{Type: "application", Name: capsuleID},
+		},
+		Components: components,
+	}, nil
+}
+
+func sbomHashes(d string) []SBOMHash {
+	d = strings.TrimPrefix(strings.TrimSpace(d), "sha256:")
+	if d == "" {
+		return nil
+	}
+	return []SBOMHash{{Alg: "SHA-256", Content: d}}
+}
+
+func readLockFile(capsulePath, name string, v any) error {
+	b, err := os.ReadFile(filepath.Join(capsulePath, "locks", name))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
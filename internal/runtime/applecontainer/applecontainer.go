@@ -3,11 +3,15 @@ package applecontainer
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fpp-125/metaclaw/internal/policy"
 	"github.com/fpp-125/metaclaw/internal/runtime/spec"
@@ -33,6 +37,25 @@ func (a *Adapter) Available(context.Context) bool {
 }
 
 func (a *Adapter) Run(ctx context.Context, opts spec.RunOptions) (spec.RunResult, error) {
+	if len(opts.Policy.Network.AllowedDomains) > 0 {
+		// Unlike extraHosts this narrows name resolution, so refuse rather than
+		// silently run with unrestricted DNS.
+		return spec.RunResult{}, fmt.Errorf("apple_container cannot DNS-pin habitat.network.allowedDomains; use podman, docker or nerdctl")
+	}
+	if len(opts.Policy.ExtraHosts) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: apple_container does not support extraHosts; ignoring %d host entr%s\n", len(opts.Policy.ExtraHosts), pluralY(len(opts.Policy.ExtraHosts)))
+	}
+	if len(opts.Policy.CapAdd) > 0 || len(opts.Policy.CapDrop) > 0 {
+		fmt.Fprintln(os.Stderr, "warning: apple_container does not support habitat.capabilities; capabilities are left at runtime defaults")
+	}
+	for _, t := range opts.Policy.Tmpfs {
+		if t.Size != "" || t.Mode != "" {
+			fmt.Fprintf(os.Stderr, "warning: apple_container ignores tmpfs size/mode for %s\n", t.Target)
+		}
+	}
+	if opts.Healthcheck != nil {
+		fmt.Fprintln(os.Stderr, "warning: apple_container does not support healthchecks; run status will not reflect health")
+	}
 	args := []string{"run", "--name", opts.ContainerName}
 	if opts.Detach {
 		args = append(args, "-d")
@@ -40,7 +63,7 @@ func (a *Adapter) Run(ctx context.Context, opts spec.RunOptions) (spec.RunResult
 	args = append(args, policyFlags(opts.Policy, opts.Env, opts.Workdir, opts.User, opts.CPU, opts.Memory)...)
 	args = append(args, opts.Image)
 	args = append(args, opts.Command...)
-	stdout, stderr, code, err := run(ctx, a.bin, args, opts.Env)
+	stdout, stderr, code, err := runStreaming(ctx, a.bin, args, opts.Env, opts.Stdout, opts.Stderr)
 	if opts.Detach {
 		return spec.RunResult{ContainerID: strings.TrimSpace(stdout), ExitCode: code, Stdout: stdout, Stderr: stderr}, err
 	}
@@ -82,11 +105,42 @@ func (a *Adapter) ExecShell(ctx context.Context, containerID string) error {
 	return nil
 }
 
+func (a *Adapter) Exec(ctx context.Context, containerID string, args []string) (spec.ExecResult, error) {
+	stdout, stderr, code, err := run(ctx, a.bin, append([]string{"exec", containerID}, args...), nil)
+	return execResult(stdout, stderr, code, err)
+}
+
+func (a *Adapter) Stop(ctx context.Context, containerID string, timeout time.Duration) error {
+	_, stderr, _, err := run(ctx, a.bin, stopArgs(containerID, timeout), nil)
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return err
+}
+
+func (a *Adapter) Start(ctx context.Context, containerID string) error {
+	_, stderr, _, err := run(ctx, a.bin, []string{"start", containerID}, nil)
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return err
+}
+
 func (a *Adapter) Remove(ctx context.Context, containerID string) error {
 	_, _, _, err := run(ctx, a.bin, []string{"rm", "-f", containerID}, nil)
 	return err
 }
 
+// stopArgs asks the runtime for a graceful stop; a zero timeout keeps the
+// runtime's default grace period.
+func stopArgs(containerID string, timeout time.Duration) []string {
+	args := []string{"stop"}
+	if timeout > 0 {
+		args = append(args, "--time", strconv.Itoa(int(timeout.Round(time.Second)/time.Second)))
+	}
+	return append(args, containerID)
+}
+
 func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, memory string) []string {
 	args := make([]string, 0)
 	switch p.Network.Mode {
@@ -97,6 +151,9 @@ func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, mem
 	case "all":
 		args = append(args, "--network=host")
 	}
+	if p.ReadOnlyRootfs {
+		args = append(args, "--read-only")
+	}
 	for _, m := range p.Mounts {
 		v := fmt.Sprintf("%s:%s", m.Source, m.Target)
 		if m.ReadOnly {
@@ -104,6 +161,12 @@ func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, mem
 		}
 		args = append(args, "-v", v)
 	}
+	for _, t := range p.Tmpfs {
+		args = append(args, "--tmpfs", t.Target)
+	}
+	for _, pm := range p.Ports {
+		args = append(args, "--publish", spec.PublishArg(pm))
+	}
 	allow := make(map[string]struct{}, len(p.EnvAllowlist))
 	for _, k := range p.EnvAllowlist {
 		allow[k] = struct{}{}
@@ -134,12 +197,18 @@ func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, mem
 }
 
 func run(ctx context.Context, bin string, args []string, extraEnv map[string]string) (string, string, int, error) {
+	return runStreaming(ctx, bin, args, extraEnv, nil, nil)
+}
+
+// runStreaming is run with stdout and stderr, when non-nil, also receiving
+// the output as it is produced. The returned strings still hold all of it.
+func runStreaming(ctx context.Context, bin string, args []string, extraEnv map[string]string, stdout, stderr io.Writer) (string, string, int, error) {
 	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Env = mergeEnv(extraEnv)
 	var out bytes.Buffer
 	var errBuf bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &errBuf
+	cmd.Stdout = tee(&out, stdout)
+	cmd.Stderr = tee(&errBuf, stderr)
 	err := cmd.Run()
 	exit := 0
 	if err != nil {
@@ -152,6 +221,13 @@ func run(ctx context.Context, bin string, args []string, extraEnv map[string]str
 	return out.String(), errBuf.String(), exit, err
 }
 
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
+
 func mergeEnv(extra map[string]string) []string {
 	if len(extra) == 0 {
 		return os.Environ()
@@ -172,3 +248,21 @@ func mergeEnv(extra map[string]string) []string {
 	sort.Strings(out)
 	return out
 }
+
+func pluralY(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
+
+// execResult reports a command that ran and exited non-zero as a result, not
+// an error; only failures to start the runtime CLI are returned as errors.
+func execResult(stdout, stderr string, code int, err error) (spec.ExecResult, error) {
+	res := spec.ExecResult{ExitCode: code, Stdout: stdout, Stderr: stderr}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return res, err
+	}
+	return res, nil
+}
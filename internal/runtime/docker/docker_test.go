@@ -1,9 +1,13 @@
 package docker
 
 import (
+	"os/exec"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fpp-125/metaclaw/internal/policy"
+	"github.com/fpp-125/metaclaw/internal/runtime/spec"
 )
 
 func TestPolicyFlagsUseEnvKeysWithoutInliningSecrets(t *testing.T) {
@@ -34,6 +38,110 @@ func TestPolicyFlagsUseEnvKeysWithoutInliningSecrets(t *testing.T) {
 	}
 }
 
+func TestPolicyFlagsExtraHosts(t *testing.T) {
+	p := policy.Policy{
+		Network:    policy.NetworkPolicy{Mode: "outbound", Allowed: true},
+		ExtraHosts: []string{"db.internal:10.0.0.5"},
+	}
+	args := policyFlags(p, nil, "", "", "", "")
+	if !containsPair(args, "--add-host", "db.internal:10.0.0.5") {
+		t.Fatalf("missing --add-host in args: %v", args)
+	}
+}
+
+func TestPolicyFlagsCapabilities(t *testing.T) {
+	p := policy.Policy{
+		Network: policy.NetworkPolicy{Mode: "none"},
+		CapAdd:  []string{"NET_BIND_SERVICE"},
+		CapDrop: []string{"ALL"},
+	}
+	args := policyFlags(p, nil, "", "", "", "")
+	if !containsPair(args, "--cap-drop", "ALL") || !containsPair(args, "--cap-add", "NET_BIND_SERVICE") {
+		t.Fatalf("missing capability flags in args: %v", args)
+	}
+}
+
+func TestPolicyFlagsReadOnlyRootfs(t *testing.T) {
+	p := policy.Policy{Network: policy.NetworkPolicy{Mode: "none"}}
+	if args := policyFlags(p, nil, "", "", "", ""); contains(args, "--read-only") {
+		t.Fatalf("unexpected --read-only without readOnlyRootfs: %v", args)
+	}
+	p.ReadOnlyRootfs = true
+	if args := policyFlags(p, nil, "", "", "", ""); !contains(args, "--read-only") {
+		t.Fatalf("missing --read-only for readOnlyRootfs: %v", args)
+	}
+}
+
+func TestPolicyFlagsTmpfs(t *testing.T) {
+	p := policy.Policy{
+		Network: policy.NetworkPolicy{Mode: "none"},
+		Tmpfs:   []policy.TmpfsPolicy{{Target: "/tmp", Size: "64m", Mode: "1777"}, {Target: "/run"}},
+	}
+	args := policyFlags(p, nil, "", "", "", "")
+	if !containsPair(args, "--tmpfs", "/tmp:size=64m,mode=1777") || !containsPair(args, "--tmpfs", "/run") {
+		t.Fatalf("missing --tmpfs flags in args: %v", args)
+	}
+}
+
+func TestPolicyFlagsPublishPorts(t *testing.T) {
+	p := policy.Policy{
+		Network: policy.NetworkPolicy{Mode: "outbound", Allowed: true},
+		Ports: []policy.PortPolicy{
+			{HostIP: "127.0.0.1", HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+			{HostIP: "::1", HostPort: 5353, ContainerPort: 53, Protocol: "udp"},
+		},
+	}
+	args := policyFlags(p, nil, "", "", "", "")
+	if !containsPair(args, "-p", "127.0.0.1:8080:80/tcp") || !containsPair(args, "-p", "[::1]:5353:53/udp") {
+		t.Fatalf("missing -p flags in args: %v", args)
+	}
+}
+
+func TestRunArgsHealthcheck(t *testing.T) {
+	args := runArgs(spec.RunOptions{
+		ContainerName: "metaclaw-test",
+		Image:         "alpine@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Detach:        true,
+		Policy:        policy.Policy{Network: policy.NetworkPolicy{Mode: "outbound"}},
+		Healthcheck:   &spec.Healthcheck{Cmd: "curl -f localhost:8080/health", Interval: 5 * time.Second, Retries: 2, StartPeriod: 10 * time.Second},
+	})
+	if !containsPair(args, "--health-cmd", "curl -f localhost:8080/health") || !containsPair(args, "--health-interval", "5s") ||
+		!containsPair(args, "--health-retries", "2") || !containsPair(args, "--health-start-period", "10s") {
+		t.Fatalf("missing healthcheck flags in args: %v", args)
+	}
+	imageIdx := -1
+	for i, a := range args {
+		if strings.HasPrefix(a, "alpine@") {
+			imageIdx = i
+		}
+	}
+	for i, a := range args {
+		if a == "--health-cmd" && i > imageIdx {
+			t.Fatalf("healthcheck flags must precede the image: %v", args)
+		}
+	}
+}
+
+func TestStopArgs(t *testing.T) {
+	if got := strings.Join(stopArgs("c1", 0), " "); got != "stop c1" {
+		t.Fatalf("stopArgs(no timeout) = %q", got)
+	}
+	if got := strings.Join(stopArgs("c1", 1500*time.Millisecond), " "); got != "stop -t 2 c1" {
+		t.Fatalf("stopArgs(1.5s) = %q", got)
+	}
+}
+
+func TestExecResultKeepsNonZeroExitAsResult(t *testing.T) {
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	res, gotErr := execResult("out", "err", 3, err)
+	if gotErr != nil || res.ExitCode != 3 || res.Stdout != "out" {
+		t.Fatalf("execResult(exit 3) = %+v, %v", res, gotErr)
+	}
+	if _, gotErr := execResult("", "", -1, exec.ErrNotFound); gotErr == nil {
+		t.Fatal("expected start failure to be returned as error")
+	}
+}
+
 func contains(args []string, want string) bool {
 	for _, a := range args {
 		if a == want {
@@ -51,3 +159,14 @@ func containsPair(args []string, left, right string) bool {
 	}
 	return false
 }
+
+func TestPolicyFlagsDomainAllowlistBlackholesDNS(t *testing.T) {
+	p := policy.Policy{Network: policy.NetworkPolicy{Mode: "outbound", Allowed: true, AllowedDomains: []string{"api.example.com"}}}
+	if args := policyFlags(p, nil, "", "", "", ""); !containsPair(args, "--dns", spec.BlackholeDNS) {
+		t.Fatalf("expected blackhole dns for domain allowlist: %v", args)
+	}
+	p.Network.AllowedDomains = nil
+	if args := policyFlags(p, nil, "", "", "", ""); contains(args, "--dns") {
+		t.Fatalf("unexpected --dns without allowlist: %v", args)
+	}
+}
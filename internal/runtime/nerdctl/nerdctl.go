@@ -0,0 +1,274 @@
+package nerdctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/policy"
+	"github.com/fpp-125/metaclaw/internal/runtime/spec"
+)
+
+// Adapter drives containerd through nerdctl, whose CLI mirrors docker's.
+type Adapter struct{}
+
+func New() *Adapter { return &Adapter{} }
+
+func (a *Adapter) Name() spec.Target { return spec.TargetNerdctl }
+
+func (a *Adapter) Available(context.Context) bool {
+	_, err := exec.LookPath("nerdctl")
+	return err == nil
+}
+
+func (a *Adapter) Run(ctx context.Context, opts spec.RunOptions) (spec.RunResult, error) {
+	pinned, err := spec.PinAllowedDomains(ctx, opts.Policy, net.DefaultResolver.LookupHost)
+	if err != nil {
+		return spec.RunResult{}, err
+	}
+	opts.Policy = pinned
+	args := runArgs(opts)
+	stdout, stderr, code, err := runStreaming(ctx, "nerdctl", args, opts.Env, opts.Stdout, opts.Stderr)
+	if opts.Detach {
+		return spec.RunResult{ContainerID: strings.TrimSpace(stdout), ExitCode: code, Stdout: stdout, Stderr: stderr}, err
+	}
+	return spec.RunResult{ContainerID: opts.ContainerName, ExitCode: code, Stdout: stdout, Stderr: stderr}, err
+}
+
+func (a *Adapter) Logs(ctx context.Context, containerID string, follow bool) (string, error) {
+	args := []string{"logs"}
+	if follow {
+		args = append(args, "--follow")
+	}
+	args = append(args, containerID)
+	stdout, stderr, _, err := run(ctx, "nerdctl", args, nil)
+	if err != nil {
+		return stdout + stderr, err
+	}
+	return stdout + stderr, nil
+}
+
+func (a *Adapter) Inspect(ctx context.Context, containerID string) (string, error) {
+	stdout, stderr, _, err := run(ctx, "nerdctl", []string{"inspect", containerID}, nil)
+	if err != nil {
+		return stdout + stderr, err
+	}
+	return stdout, nil
+}
+
+func (a *Adapter) ExecShell(ctx context.Context, containerID string) error {
+	return interactive(ctx, "nerdctl", []string{"exec", "-it", containerID, "sh"})
+}
+
+func (a *Adapter) Exec(ctx context.Context, containerID string, args []string) (spec.ExecResult, error) {
+	stdout, stderr, code, err := run(ctx, "nerdctl", append([]string{"exec", containerID}, args...), nil)
+	return execResult(stdout, stderr, code, err)
+}
+
+func (a *Adapter) Stop(ctx context.Context, containerID string, timeout time.Duration) error {
+	_, stderr, _, err := run(ctx, "nerdctl", stopArgs(containerID, timeout), nil)
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return err
+}
+
+func (a *Adapter) Start(ctx context.Context, containerID string) error {
+	_, stderr, _, err := run(ctx, "nerdctl", []string{"start", containerID}, nil)
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return err
+}
+
+func (a *Adapter) Remove(ctx context.Context, containerID string) error {
+	_, _, _, err := run(ctx, "nerdctl", []string{"rm", "-f", containerID}, nil)
+	return err
+}
+
+// stopArgs asks the runtime for a graceful stop; a zero timeout keeps the
+// runtime's default grace period.
+func stopArgs(containerID string, timeout time.Duration) []string {
+	args := []string{"stop"}
+	if timeout > 0 {
+		args = append(args, "-t", strconv.Itoa(int(timeout.Round(time.Second)/time.Second)))
+	}
+	return append(args, containerID)
+}
+
+func runArgs(opts spec.RunOptions) []string {
+	args := []string{"run", "--name", opts.ContainerName}
+	if opts.Detach {
+		args = append(args, "-d")
+	}
+	args = append(args, policyFlags(opts.Policy, opts.Env, opts.Workdir, opts.User, opts.CPU, opts.Memory)...)
+	if hc := opts.Healthcheck; hc != nil && hc.Cmd != "" {
+		args = append(args, "--health-cmd", hc.Cmd)
+		if hc.Interval > 0 {
+			args = append(args, "--health-interval", hc.Interval.String())
+		}
+		if hc.Retries > 0 {
+			args = append(args, "--health-retries", strconv.Itoa(hc.Retries))
+		}
+		if hc.StartPeriod > 0 {
+			args = append(args, "--health-start-period", hc.StartPeriod.String())
+		}
+	}
+	args = append(args, opts.Image)
+	args = append(args, opts.Command...)
+	return args
+}
+
+func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, memory string) []string {
+	args := make([]string, 0)
+	switch p.Network.Mode {
+	case "none":
+		args = append(args, "--network=none")
+	case "outbound":
+		args = append(args, "--network=bridge")
+		if len(p.Network.AllowedDomains) > 0 {
+			args = append(args, "--dns", spec.BlackholeDNS)
+		}
+	case "all":
+		args = append(args, "--network=host")
+	}
+	if p.ReadOnlyRootfs {
+		args = append(args, "--read-only")
+	}
+	for _, c := range p.CapDrop {
+		args = append(args, "--cap-drop", c)
+	}
+	for _, c := range p.CapAdd {
+		args = append(args, "--cap-add", c)
+	}
+	for _, h := range p.ExtraHosts {
+		args = append(args, "--add-host", h)
+	}
+	for _, m := range p.Mounts {
+		v := fmt.Sprintf("%s:%s", m.Source, m.Target)
+		if m.ReadOnly {
+			v += ":ro"
+		}
+		args = append(args, "-v", v)
+	}
+	for _, t := range p.Tmpfs {
+		args = append(args, "--tmpfs", spec.TmpfsArg(t))
+	}
+	for _, pm := range p.Ports {
+		args = append(args, "-p", spec.PublishArg(pm))
+	}
+	allow := make(map[string]struct{}, len(p.EnvAllowlist))
+	for _, k := range p.EnvAllowlist {
+		allow[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		if _, ok := allow[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "-e", k)
+	}
+	if workdir != "" {
+		args = append(args, "-w", workdir)
+	}
+	if user != "" {
+		args = append(args, "-u", user)
+	}
+	if cpu != "" {
+		args = append(args, "--cpus", cpu)
+	}
+	if memory != "" {
+		args = append(args, "--memory", memory)
+	}
+	return args
+}
+
+func run(ctx context.Context, bin string, args []string, extraEnv map[string]string) (string, string, int, error) {
+	return runStreaming(ctx, bin, args, extraEnv, nil, nil)
+}
+
+// runStreaming is run with stdout and stderr, when non-nil, also receiving
+// the output as it is produced. The returned strings still hold all of it.
+func runStreaming(ctx context.Context, bin string, args []string, extraEnv map[string]string, stdout, stderr io.Writer) (string, string, int, error) {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Env = mergeEnv(extraEnv)
+	var out bytes.Buffer
+	var errBuf bytes.Buffer
+	cmd.Stdout = tee(&out, stdout)
+	cmd.Stderr = tee(&errBuf, stderr)
+	err := cmd.Run()
+	exit := 0
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			exit = ee.ExitCode()
+		} else {
+			exit = -1
+		}
+	}
+	return out.String(), errBuf.String(), exit, err
+}
+
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
+
+func interactive(ctx context.Context, bin string, args []string) error {
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return fmt.Errorf("shell session ended with non-zero exit: %w", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func mergeEnv(extra map[string]string) []string {
+	if len(extra) == 0 {
+		return os.Environ()
+	}
+	env := make(map[string]string, len(extra)+8)
+	for _, item := range os.Environ() {
+		if i := strings.IndexByte(item, '='); i > 0 {
+			env[item[:i]] = item[i+1:]
+		}
+	}
+	for k, v := range extra {
+		env[k] = v
+	}
+	out := make([]string, 0, len(env))
+	for k, v := range env {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// execResult reports a command that ran and exited non-zero as a result, not
+// an error; only failures to start the runtime CLI are returned as errors.
+func execResult(stdout, stderr string, code int, err error) (spec.ExecResult, error) {
+	res := spec.ExecResult{ExitCode: code, Stdout: stdout, Stderr: stderr}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return res, err
+	}
+	return res, nil
+}
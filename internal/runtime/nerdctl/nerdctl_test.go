@@ -0,0 +1,48 @@
+package nerdctl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fpp-125/metaclaw/internal/policy"
+	"github.com/fpp-125/metaclaw/internal/runtime/spec"
+)
+
+func TestRunArgsUseDockerCompatibleFlags(t *testing.T) {
+	args := runArgs(spec.RunOptions{
+		ContainerName: "metaclaw-test",
+		Image:         "alpine@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Command:       []string{"sh", "-lc", "echo hi"},
+		Detach:        true,
+		Policy: policy.Policy{
+			Network:      policy.NetworkPolicy{Mode: "none"},
+			Mounts:       []policy.MountPolicy{{Source: "/host", Target: "/ctr", ReadOnly: true}},
+			EnvAllowlist: []string{"FOO"},
+			CapDrop:      []string{"ALL"},
+		},
+		Env:         map[string]string{"FOO": "secret"},
+		Healthcheck: &spec.Healthcheck{Cmd: "true", Interval: 5 * time.Second},
+	})
+	got := strings.Join(args, " ")
+	for _, want := range []string{"run --name metaclaw-test -d", "--network=none", "-v /host:/ctr:ro", "-e FOO", "--cap-drop ALL", "--health-cmd true"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("args missing %q: %v", want, args)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Fatalf("env value leaked into args: %v", args)
+	}
+	if !strings.HasSuffix(got, "alpine@sha256:0000000000000000000000000000000000000000000000000000000000000000 sh -lc echo hi") {
+		t.Fatalf("image and command must come last: %v", args)
+	}
+}
+
+func TestStopArgs(t *testing.T) {
+	if got := strings.Join(stopArgs("c1", 3*time.Second), " "); got != "stop -t 3 c1" {
+		t.Fatalf("stopArgs() = %q", got)
+	}
+	if got := strings.Join(stopArgs("c1", 0), " "); got != "stop c1" {
+		t.Fatalf("stopArgs(0) = %q", got)
+	}
+}
@@ -3,11 +3,16 @@ package podman
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/exec"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fpp-125/metaclaw/internal/policy"
 	"github.com/fpp-125/metaclaw/internal/runtime/spec"
@@ -25,14 +30,13 @@ func (a *Adapter) Available(context.Context) bool {
 }
 
 func (a *Adapter) Run(ctx context.Context, opts spec.RunOptions) (spec.RunResult, error) {
-	args := []string{"run", "--name", opts.ContainerName}
-	if opts.Detach {
-		args = append(args, "-d")
+	pinned, err := spec.PinAllowedDomains(ctx, opts.Policy, net.DefaultResolver.LookupHost)
+	if err != nil {
+		return spec.RunResult{}, err
 	}
-	args = append(args, policyFlags(opts.Policy, opts.Env, opts.Workdir, opts.User, opts.CPU, opts.Memory)...)
-	args = append(args, opts.Image)
-	args = append(args, opts.Command...)
-	stdout, stderr, code, err := run(ctx, "podman", args, false, opts.Env)
+	opts.Policy = pinned
+	args := runArgs(opts)
+	stdout, stderr, code, err := runStreaming(ctx, "podman", args, false, opts.Env, opts.Stdout, opts.Stderr)
 	if opts.Detach {
 		return spec.RunResult{ContainerID: strings.TrimSpace(stdout), ExitCode: code, Stdout: stdout, Stderr: stderr}, err
 	}
@@ -64,11 +68,65 @@ func (a *Adapter) ExecShell(ctx context.Context, containerID string) error {
 	return interactive(ctx, "podman", []string{"exec", "-it", containerID, "sh"})
 }
 
+func (a *Adapter) Exec(ctx context.Context, containerID string, args []string) (spec.ExecResult, error) {
+	stdout, stderr, code, err := run(ctx, "podman", append([]string{"exec", containerID}, args...), false, nil)
+	return execResult(stdout, stderr, code, err)
+}
+
+func (a *Adapter) Stop(ctx context.Context, containerID string, timeout time.Duration) error {
+	_, stderr, _, err := run(ctx, "podman", stopArgs(containerID, timeout), false, nil)
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return err
+}
+
+func (a *Adapter) Start(ctx context.Context, containerID string) error {
+	_, stderr, _, err := run(ctx, "podman", []string{"start", containerID}, false, nil)
+	if err != nil && strings.TrimSpace(stderr) != "" {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr))
+	}
+	return err
+}
+
 func (a *Adapter) Remove(ctx context.Context, containerID string) error {
 	_, _, _, err := run(ctx, "podman", []string{"rm", "-f", containerID}, false, nil)
 	return err
 }
 
+// stopArgs asks the runtime for a graceful stop; a zero timeout keeps the
+// runtime's default grace period.
+func stopArgs(containerID string, timeout time.Duration) []string {
+	args := []string{"stop"}
+	if timeout > 0 {
+		args = append(args, "-t", strconv.Itoa(int(timeout.Round(time.Second)/time.Second)))
+	}
+	return append(args, containerID)
+}
+
+func runArgs(opts spec.RunOptions) []string {
+	args := []string{"run", "--name", opts.ContainerName}
+	if opts.Detach {
+		args = append(args, "-d")
+	}
+	args = append(args, policyFlags(opts.Policy, opts.Env, opts.Workdir, opts.User, opts.CPU, opts.Memory)...)
+	if hc := opts.Healthcheck; hc != nil && hc.Cmd != "" {
+		args = append(args, "--health-cmd", hc.Cmd)
+		if hc.Interval > 0 {
+			args = append(args, "--health-interval", hc.Interval.String())
+		}
+		if hc.Retries > 0 {
+			args = append(args, "--health-retries", strconv.Itoa(hc.Retries))
+		}
+		if hc.StartPeriod > 0 {
+			args = append(args, "--health-start-period", hc.StartPeriod.String())
+		}
+	}
+	args = append(args, opts.Image)
+	args = append(args, opts.Command...)
+	return args
+}
+
 func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, memory string) []string {
 	args := make([]string, 0)
 	switch p.Network.Mode {
@@ -76,9 +134,24 @@ func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, mem
 		args = append(args, "--network=none")
 	case "outbound":
 		args = append(args, "--network=bridge")
+		if len(p.Network.AllowedDomains) > 0 {
+			args = append(args, "--dns", spec.BlackholeDNS)
+		}
 	case "all":
 		args = append(args, "--network=host")
 	}
+	if p.ReadOnlyRootfs {
+		args = append(args, "--read-only")
+	}
+	for _, c := range p.CapDrop {
+		args = append(args, "--cap-drop", c)
+	}
+	for _, c := range p.CapAdd {
+		args = append(args, "--cap-add", c)
+	}
+	for _, h := range p.ExtraHosts {
+		args = append(args, "--add-host", h)
+	}
 	for _, m := range p.Mounts {
 		v := fmt.Sprintf("%s:%s", m.Source, m.Target)
 		if m.ReadOnly {
@@ -86,6 +159,12 @@ func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, mem
 		}
 		args = append(args, "-v", v)
 	}
+	for _, t := range p.Tmpfs {
+		args = append(args, "--tmpfs", spec.TmpfsArg(t))
+	}
+	for _, pm := range p.Ports {
+		args = append(args, "-p", spec.PublishArg(pm))
+	}
 	allow := make(map[string]struct{}, len(p.EnvAllowlist))
 	for _, k := range p.EnvAllowlist {
 		allow[k] = struct{}{}
@@ -116,6 +195,12 @@ func policyFlags(p policy.Policy, env map[string]string, workdir, user, cpu, mem
 }
 
 func run(ctx context.Context, bin string, args []string, stdin bool, extraEnv map[string]string) (string, string, int, error) {
+	return runStreaming(ctx, bin, args, stdin, extraEnv, nil, nil)
+}
+
+// runStreaming is run with stdout and stderr, when non-nil, also receiving
+// the output as it is produced. The returned strings still hold all of it.
+func runStreaming(ctx context.Context, bin string, args []string, stdin bool, extraEnv map[string]string, stdout, stderr io.Writer) (string, string, int, error) {
 	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Env = mergeEnv(extraEnv)
 	if stdin {
@@ -123,8 +208,8 @@ func run(ctx context.Context, bin string, args []string, stdin bool, extraEnv ma
 	}
 	var out bytes.Buffer
 	var errBuf bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &errBuf
+	cmd.Stdout = tee(&out, stdout)
+	cmd.Stderr = tee(&errBuf, stderr)
 	err := cmd.Run()
 	exit := 0
 	if err != nil {
@@ -137,6 +222,13 @@ func run(ctx context.Context, bin string, args []string, stdin bool, extraEnv ma
 	return out.String(), errBuf.String(), exit, err
 }
 
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
+
 func interactive(ctx context.Context, bin string, args []string) error {
 	cmd := exec.CommandContext(ctx, bin, args...)
 	cmd.Stdin = os.Stdin
@@ -171,3 +263,14 @@ func mergeEnv(extra map[string]string) []string {
 	sort.Strings(out)
 	return out
 }
+
+// execResult reports a command that ran and exited non-zero as a result, not
+// an error; only failures to start the runtime CLI are returned as errors.
+func execResult(stdout, stderr string, code int, err error) (spec.ExecResult, error) {
+	res := spec.ExecResult{ExitCode: code, Stdout: stdout, Stderr: stderr}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return res, err
+	}
+	return res, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/fpp-125/metaclaw/internal/runtime/applecontainer"
 	"github.com/fpp-125/metaclaw/internal/runtime/docker"
+	"github.com/fpp-125/metaclaw/internal/runtime/nerdctl"
 	"github.com/fpp-125/metaclaw/internal/runtime/podman"
 	"github.com/fpp-125/metaclaw/internal/runtime/spec"
 )
@@ -17,15 +18,16 @@ type Resolver struct {
 
 func NewResolver() *Resolver {
 	return &Resolver{adapters: map[spec.Target]spec.Adapter{
-		spec.TargetPodman: podman.New(),
-		spec.TargetApple:  applecontainer.New(),
-		spec.TargetDocker: docker.New(),
+		spec.TargetPodman:  podman.New(),
+		spec.TargetApple:   applecontainer.New(),
+		spec.TargetDocker:  docker.New(),
+		spec.TargetNerdctl: nerdctl.New(),
 	}}
 }
 
 func ParseTarget(v string) (spec.Target, error) {
 	switch spec.Target(v) {
-	case "", spec.TargetPodman, spec.TargetApple, spec.TargetDocker:
+	case "", spec.TargetPodman, spec.TargetApple, spec.TargetDocker, spec.TargetNerdctl:
 		return spec.Target(v), nil
 	default:
 		return "", fmt.Errorf("invalid runtime target: %s", v)
@@ -63,14 +65,14 @@ func (r *Resolver) Resolve(ctx context.Context, cliOverride string, clawfileTarg
 			return ad, t, nil
 		}
 	}
-	return nil, "", fmt.Errorf("no supported runtime available; install podman, docker, or apple container")
+	return nil, "", fmt.Errorf("no supported runtime available; install podman, docker, nerdctl, or apple container")
 }
 
 func hostDefaultOrder() []spec.Target {
 	if goruntime.GOOS == "darwin" {
 		return []spec.Target{spec.TargetApple, spec.TargetDocker, spec.TargetPodman}
 	}
-	return []spec.Target{spec.TargetPodman, spec.TargetDocker, spec.TargetApple}
+	return []spec.Target{spec.TargetPodman, spec.TargetDocker, spec.TargetNerdctl, spec.TargetApple}
 }
 
 func (r *Resolver) Adapter(target spec.Target) (spec.Adapter, bool) {
@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/fpp-125/metaclaw/internal/policy"
+	"github.com/fpp-125/metaclaw/internal/runtime/spec"
+)
+
+// TestAdaptersPassEnvKeysInSortedOrder runs every adapter against a fake
+// runtime binary that records its argv, so map iteration order in the env
+// cannot leak into the command line.
+func TestAdaptersPassEnvKeysInSortedOrder(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("fake runtime binaries are shell scripts")
+	}
+	binDir := t.TempDir()
+	argvLog := filepath.Join(binDir, "argv.log")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > \"$METACLAW_TEST_ARGV_LOG\"\n"
+	for _, name := range []string{"podman", "docker", "nerdctl", "container"} {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("METACLAW_APPLE_CONTAINER_BIN", "")
+	t.Setenv("METACLAW_TEST_ARGV_LOG", argvLog)
+
+	keys := []string{"ZETA", "ALPHA", "OPENAI_API_KEY", "MIDDLE", "BETA", "TAVILY_API_KEY"}
+	reversed := make([]string, len(keys))
+	for i, k := range keys {
+		reversed[len(keys)-1-i] = k
+	}
+	r := NewResolver()
+	for _, target := range []spec.Target{spec.TargetPodman, spec.TargetDocker, spec.TargetNerdctl, spec.TargetApple} {
+		t.Run(string(target), func(t *testing.T) {
+			ad := r.adapters[target]
+			runArgv := func(order []string) []string {
+				env := make(map[string]string, len(order))
+				for _, k := range order {
+					env[k] = "v-" + k
+				}
+				if _, err := ad.Run(context.Background(), spec.RunOptions{
+					ContainerName: "metaclaw-test",
+					Image:         "alpine@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+					Command:       []string{"sh", "-lc", "true"},
+					Policy:        policy.Policy{Network: policy.NetworkPolicy{Mode: "none"}, EnvAllowlist: keys},
+					Env:           env,
+				}); err != nil {
+					t.Fatalf("run: %v", err)
+				}
+				b, err := os.ReadFile(argvLog)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return strings.Split(strings.TrimSpace(string(b)), "\n")
+			}
+
+			want := runArgv(keys)
+			if got := runArgv(reversed); strings.Join(got, "\x00") != strings.Join(want, "\x00") {
+				t.Fatalf("argv differs between identical env inputs:\nwant: %v\ngot:  %v", want, got)
+			}
+			var envKeys []string
+			for i := 0; i < len(want)-1; i++ {
+				if want[i] == "-e" {
+					envKeys = append(envKeys, want[i+1])
+				}
+			}
+			if !sort.StringsAreSorted(envKeys) || len(envKeys) != len(keys) {
+				t.Fatalf("expected sorted -e keys, got %v", envKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/fpp-125/metaclaw/internal/policy"
+)
+
+// BlackholeDNS is the resolver given to containers with a domain allowlist.
+// Nothing answers there, so only names pinned in /etc/hosts resolve. This
+// pins DNS only: connections to direct IP addresses are not blocked.
+const BlackholeDNS = "127.0.0.1"
+
+// PinAllowedDomains resolves every allowlisted domain on the host and returns
+// a copy of p whose ExtraHosts pin those names to the addresses found. IPv4
+// addresses are listed first. A policy without an allowlist is returned as is.
+func PinAllowedDomains(ctx context.Context, p policy.Policy, lookup func(context.Context, string) ([]string, error)) (policy.Policy, error) {
+	if len(p.Network.AllowedDomains) == 0 {
+		return p, nil
+	}
+	hosts := append([]string(nil), p.ExtraHosts...)
+	for _, domain := range p.Network.AllowedDomains {
+		addrs, err := lookup(ctx, domain)
+		if err != nil {
+			return p, fmt.Errorf("resolve allowed domain %s: %w", domain, err)
+		}
+		ips := make([]net.IP, 0, len(addrs))
+		for _, a := range addrs {
+			if ip := net.ParseIP(a); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+		if len(ips) == 0 {
+			return p, fmt.Errorf("resolve allowed domain %s: no addresses", domain)
+		}
+		sort.Slice(ips, func(i, j int) bool {
+			iv4, jv4 := ips[i].To4() != nil, ips[j].To4() != nil
+			if iv4 != jv4 {
+				return iv4
+			}
+			return ips[i].String() < ips[j].String()
+		})
+		for _, ip := range ips {
+			hosts = append(hosts, domain+":"+ip.String())
+		}
+	}
+	p.ExtraHosts = hosts
+	return p, nil
+}
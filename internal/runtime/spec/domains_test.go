@@ -0,0 +1,42 @@
+package spec
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fpp-125/metaclaw/internal/policy"
+)
+
+func TestPinAllowedDomains(t *testing.T) {
+	lookup := func(_ context.Context, host string) ([]string, error) {
+		switch host {
+		case "api.example.com":
+			return []string{"2001:db8::1", "203.0.113.9", "203.0.113.2"}, nil
+		case "down.example.com":
+			return nil, errors.New("no such host")
+		}
+		return nil, nil
+	}
+	p := policy.Policy{
+		Network:    policy.NetworkPolicy{Mode: "outbound", Allowed: true, AllowedDomains: []string{"api.example.com"}},
+		ExtraHosts: []string{"db.internal:10.0.0.5"},
+	}
+	got, err := PinAllowedDomains(context.Background(), p, lookup)
+	if err != nil {
+		t.Fatalf("PinAllowedDomains() error = %v", err)
+	}
+	want := []string{"db.internal:10.0.0.5", "api.example.com:203.0.113.2", "api.example.com:203.0.113.9", "api.example.com:2001:db8::1"}
+	if !reflect.DeepEqual(got.ExtraHosts, want) {
+		t.Fatalf("ExtraHosts = %v, want %v", got.ExtraHosts, want)
+	}
+	if len(p.ExtraHosts) != 1 {
+		t.Fatalf("input policy was modified: %v", p.ExtraHosts)
+	}
+
+	p.Network.AllowedDomains = []string{"down.example.com"}
+	if _, err := PinAllowedDomains(context.Background(), p, lookup); err == nil {
+		t.Fatal("expected an unresolvable allowed domain to fail the run")
+	}
+}
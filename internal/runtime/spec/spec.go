@@ -2,6 +2,10 @@ package spec
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/fpp-125/metaclaw/internal/policy"
 )
@@ -9,9 +13,10 @@ import (
 type Target string
 
 const (
-	TargetPodman Target = "podman"
-	TargetApple  Target = "apple_container"
-	TargetDocker Target = "docker"
+	TargetPodman  Target = "podman"
+	TargetApple   Target = "apple_container"
+	TargetDocker  Target = "docker"
+	TargetNerdctl Target = "nerdctl"
 )
 
 type RunOptions struct {
@@ -25,6 +30,19 @@ type RunOptions struct {
 	User          string
 	CPU           string
 	Memory        string
+	Healthcheck   *Healthcheck
+	// Stdout and Stderr, when set, receive a foreground run's output as the
+	// container produces it. RunResult still carries the full output.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Healthcheck is a container health probe run by the runtime via a shell.
+type Healthcheck struct {
+	Cmd         string
+	Interval    time.Duration
+	Retries     int
+	StartPeriod time.Duration
 }
 
 type RunResult struct {
@@ -34,6 +52,12 @@ type RunResult struct {
 	Stderr      string
 }
 
+type ExecResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
 type Adapter interface {
 	Name() Target
 	Available(ctx context.Context) bool
@@ -41,5 +65,33 @@ type Adapter interface {
 	Logs(ctx context.Context, containerID string, follow bool) (string, error)
 	Inspect(ctx context.Context, containerID string) (string, error)
 	ExecShell(ctx context.Context, containerID string) error
+	Exec(ctx context.Context, containerID string, args []string) (ExecResult, error)
+	Stop(ctx context.Context, containerID string, timeout time.Duration) error
+	Start(ctx context.Context, containerID string) error
 	Remove(ctx context.Context, containerID string) error
 }
+
+// TmpfsArg renders a tmpfs mount as the value of --tmpfs (target[:opts]).
+func TmpfsArg(t policy.TmpfsPolicy) string {
+	opts := make([]string, 0, 2)
+	if t.Size != "" {
+		opts = append(opts, "size="+t.Size)
+	}
+	if t.Mode != "" {
+		opts = append(opts, "mode="+t.Mode)
+	}
+	if len(opts) == 0 {
+		return t.Target
+	}
+	return t.Target + ":" + strings.Join(opts, ",")
+}
+
+// PublishArg renders a port mapping as the value of -p/--publish
+// (hostIP:hostPort:containerPort/protocol); IPv6 host IPs are bracketed.
+func PublishArg(p policy.PortPolicy) string {
+	host := p.HostIP
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return fmt.Sprintf("%s:%d:%d/%s", host, p.HostPort, p.ContainerPort, p.Protocol)
+}
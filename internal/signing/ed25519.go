@@ -42,39 +42,40 @@ func WritePrivateKeyPEM(path string, key ed25519.PrivateKey) error {
 }
 
 func WritePublicKeyPEM(path string, key ed25519.PublicKey) error {
-	if len(key) != ed25519.PublicKeySize {
-		return fmt.Errorf("invalid ed25519 public key size: %d", len(key))
-	}
-	spki, err := x509.MarshalPKIXPublicKey(key)
+	b, err := EncodePublicKeyPEM(key)
 	if err != nil {
-		return fmt.Errorf("marshal public key: %w", err)
+		return err
 	}
-	block := &pem.Block{Type: publicKeyPEMType, Bytes: spki}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(path, pem.EncodeToMemory(block), 0o644)
+	return os.WriteFile(path, b, 0o644)
+}
+
+// EncodePublicKeyPEM returns key as a PEM PKIX block, the format written by WritePublicKeyPEM.
+func EncodePublicKeyPEM(key ed25519.PublicKey) ([]byte, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key size: %d", len(key))
+	}
+	spki, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: publicKeyPEMType, Bytes: spki}), nil
 }
 
+// LoadPrivateKeyPEM reads a plain or encrypted private key; the passphrase of
+// an encrypted key comes from ReadPassphrase.
 func LoadPrivateKeyPEM(path string) (ed25519.PrivateKey, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	block, _ := pem.Decode(b)
-	if block == nil {
-		return nil, fmt.Errorf("invalid PEM: %s", path)
-	}
-	pk, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	priv, err := ParsePrivateKeyPEM(b, func() ([]byte, error) {
+		return ReadPassphrase(fmt.Sprintf("Passphrase for %s: ", path), false)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("parse private key: %w", err)
-	}
-	priv, ok := pk.(ed25519.PrivateKey)
-	if !ok {
-		return nil, fmt.Errorf("private key is not ed25519")
-	}
-	if len(priv) != ed25519.PrivateKeySize {
-		return nil, fmt.Errorf("invalid ed25519 private key size: %d", len(priv))
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 	return priv, nil
 }
@@ -1,8 +1,10 @@
 package signing
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -53,3 +55,55 @@ func TestPEMReadWrite(t *testing.T) {
 		t.Fatalf("verify: %v", err)
 	}
 }
+
+func TestEncryptedPrivateKeyRoundTrip(t *testing.T) {
+	priv, _, err := GenerateEd25519KeyPair()
+	if err != nil {
+		t.Fatalf("generate key pair: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "k.priv.pem")
+	if err := WriteEncryptedPrivateKeyPEM(path, priv, []byte("correct horse")); err != nil {
+		t.Fatalf("write encrypted key: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), EncryptedPrivateKeyPEMType) {
+		t.Fatalf("expected encrypted PEM block, got:\n%s", b)
+	}
+
+	if _, err := ParsePrivateKeyPEM(b, func() ([]byte, error) { return []byte("wrong"), nil }); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("expected wrong passphrase error, got %v", err)
+	}
+	got, err := ParsePrivateKeyPEM(b, func() ([]byte, error) { return []byte("correct horse"), nil })
+	if err != nil {
+		t.Fatalf("decrypt key: %v", err)
+	}
+	if !got.Equal(priv) {
+		t.Fatalf("decrypted key does not match")
+	}
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	if _, err := LoadPrivateKeyPEM(path); err != nil {
+		t.Fatalf("load with env passphrase: %v", err)
+	}
+}
+
+func TestParseScryptHeaderRejectsForeignParameters(t *testing.T) {
+	if _, _, _, err := parseScryptHeader(fmt.Sprintf("scrypt N=%d,r=%d,p=%d", scryptN, scryptR, scryptP)); err != nil {
+		t.Fatalf("parse written parameters: %v", err)
+	}
+	for _, kdf := range []string{
+		"scrypt N=1048576,r=8,p=1",
+		"scrypt N=32768,r=1024,p=1",
+		"scrypt N=32768,r=8,p=64",
+		"scrypt N=32768,r=8",
+		"scrypt N=32768,r=8,p=1,q=2",
+		"pbkdf2 N=32768,r=8,p=1",
+	} {
+		if _, _, _, err := parseScryptHeader(kdf); err == nil {
+			t.Fatalf("expected %q to be rejected", kdf)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package signing
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv names the environment variable consulted for the passphrase
+// of an encrypted private key before falling back to a terminal prompt.
+const PassphraseEnv = "METACLAW_KEY_PASSPHRASE"
+
+// EncryptedPrivateKeyPEMType marks a PKCS8 key sealed with AES-256-GCM under a
+// scrypt-derived key. KDF parameters, salt and nonce travel as PEM headers.
+const EncryptedPrivateKeyPEMType = "METACLAW ENCRYPTED PRIVATE KEY"
+
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptSaltSz = 16
+)
+
+// EncryptPrivateKeyPEM seals key under passphrase and returns the PEM block.
+func EncryptPrivateKeyPEM(key ed25519.PrivateKey, passphrase []byte) ([]byte, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key size: %d", len(key))
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("marshal private key: %w", err)
+	}
+	salt := make([]byte, scryptSaltSz)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := keyAEAD(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	block := &pem.Block{
+		Type: EncryptedPrivateKeyPEMType,
+		Headers: map[string]string{
+			"Cipher": "AES-256-GCM",
+			"KDF":    fmt.Sprintf("scrypt N=%d,r=%d,p=%d", scryptN, scryptR, scryptP),
+			"Salt":   hex.EncodeToString(salt),
+			"Nonce":  hex.EncodeToString(nonce),
+		},
+		Bytes: aead.Seal(nil, nonce, pkcs8, nil),
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// WriteEncryptedPrivateKeyPEM is WritePrivateKeyPEM with the key sealed under
+// passphrase.
+func WriteEncryptedPrivateKeyPEM(path string, key ed25519.PrivateKey, passphrase []byte) error {
+	b, err := EncryptPrivateKeyPEM(key, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
+
+// ParsePrivateKeyPEM decodes a plain PKCS8 or encrypted ed25519 private key.
+// passphrase is only called for encrypted keys.
+func ParsePrivateKeyPEM(b []byte, passphrase func() ([]byte, error)) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key PEM")
+	}
+	der := block.Bytes
+	if block.Type == EncryptedPrivateKeyPEMType {
+		if passphrase == nil {
+			return nil, fmt.Errorf("private key is encrypted")
+		}
+		pass, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		der, err = decryptKeyBlock(block, pass)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pk, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	priv, ok := pk.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not ed25519")
+	}
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid ed25519 private key size: %d", len(priv))
+	}
+	return priv, nil
+}
+
+// ReadPassphrase returns the key passphrase from PassphraseEnv or, when stdin
+// is a terminal, prompts for it on stderr. confirm asks twice, for new keys.
+func ReadPassphrase(prompt string, confirm bool) ([]byte, error) {
+	if v := os.Getenv(PassphraseEnv); v != "" {
+		return []byte(v), nil
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("private key passphrase required: set %s or run in a terminal", PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(pass) == 0 {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if string(again) != string(pass) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	return pass, nil
+}
+
+func decryptKeyBlock(block *pem.Block, passphrase []byte) ([]byte, error) {
+	if c := block.Headers["Cipher"]; c != "AES-256-GCM" {
+		return nil, fmt.Errorf("unsupported private key cipher %q", c)
+	}
+	n, r, p, err := parseScryptHeader(block.Headers["KDF"])
+	if err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(block.Headers["Salt"])
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("invalid private key salt")
+	}
+	nonce, err := hex.DecodeString(block.Headers["Nonce"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key nonce")
+	}
+	aead, err := keyAEAD(passphrase, salt, n, r, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid private key nonce")
+	}
+	der, err := aead.Open(nil, nonce, block.Bytes, nil)
+	if err != nil {
+		return nil, errors.New("decrypt private key: wrong passphrase or corrupted key")
+	}
+	return der, nil
+}
+
+// parseScryptHeader reads the KDF header and only accepts the parameters
+// EncryptPrivateKeyPEM writes, so a crafted key file cannot make decryption
+// allocate or spin without bound.
+func parseScryptHeader(v string) (n, r, p int, err error) {
+	params, ok := strings.CutPrefix(strings.TrimSpace(v), "scrypt ")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("unsupported private key kdf %q", v)
+	}
+	for _, kv := range strings.Split(params, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(kv), "=")
+		x, convErr := strconv.Atoi(val)
+		if convErr != nil {
+			return 0, 0, 0, fmt.Errorf("invalid private key kdf %q", v)
+		}
+		switch k {
+		case "N":
+			n = x
+		case "r":
+			r = x
+		case "p":
+			p = x
+		default:
+			return 0, 0, 0, fmt.Errorf("invalid private key kdf %q", v)
+		}
+	}
+	if n != scryptN || r != scryptR || p != scryptP {
+		return 0, 0, 0, fmt.Errorf("unsupported private key kdf parameters %q (want N=%d,r=%d,p=%d)", v, scryptN, scryptR, scryptP)
+	}
+	return n, r, p, nil
+}
+
+func keyAEAD(passphrase, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(c)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -17,6 +18,7 @@ type Store struct {
 
 type RunRecord struct {
 	RunID         string `json:"runId"`
+	Name          string `json:"name,omitempty"`
 	CapsuleID     string `json:"capsuleId"`
 	CapsulePath   string `json:"capsulePath"`
 	Status        string `json:"status"`
@@ -27,8 +29,22 @@ type RunRecord struct {
 	StartedAt     string `json:"startedAt"`
 	EndedAt       string `json:"endedAt,omitempty"`
 	LastError     string `json:"lastError,omitempty"`
+	RestartCount  int    `json:"restartCount,omitempty"`
+	LastRestartAt string `json:"lastRestartAt,omitempty"`
+	ImageRef      string `json:"imageRef,omitempty"`
+	// InjectedEnvKeys lists the env names passed into the container, sorted.
+	// Values are never stored.
+	InjectedEnvKeys []string          `json:"injectedEnvKeys,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	// LLMModel is the model the run was started with, after any per-run
+	// override.
+	LLMModel string `json:"llmModel,omitempty"`
 }
 
+// busyTimeout bounds how long a statement waits for another process's lock
+// on state.db.
+const busyTimeout = 10 * time.Second
+
 func Open(stateDir string) (*Store, error) {
 	if stateDir == "" {
 		stateDir = ".metaclaw"
@@ -37,10 +53,18 @@ func Open(stateDir string) (*Store, error) {
 		return nil, err
 	}
 	dbPath := filepath.Join(stateDir, "state.db")
-	db, err := sql.Open("sqlite", dbPath)
+	// Parallel metaclaw processes (e.g. CI jobs sharing a state dir) contend
+	// for the database file: wait for a lock instead of failing with
+	// "database is locked", and take the write lock when a transaction
+	// begins so two writers cannot deadlock upgrading from a read.
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_txlock=immediate", dbPath, busyTimeout.Milliseconds())
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, err
 	}
+	// One connection per process serializes this process's own statements,
+	// so only other processes can ever hold the lock.
+	db.SetMaxOpenConns(1)
 	s := &Store{db: db}
 	if err := s.initSchema(); err != nil {
 		_ = db.Close()
@@ -56,36 +80,174 @@ func (s *Store) Close() error {
 	return s.db.Close()
 }
 
+// migration is one schema step. Steps run in version order inside their own
+// transaction and must tolerate databases created before versioning existed,
+// where some of their effects may already be present.
+type migration struct {
+	version int
+	name    string
+	apply   func(tx *sql.Tx) error
+}
+
+// migrations is append-only: never edit or reorder a released step, add a new
+// one with the next version instead.
+var migrations = []migration{
+	{version: 1, name: "create capsules and runs", apply: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`CREATE TABLE IF NOT EXISTS capsules (
+				capsule_id TEXT PRIMARY KEY,
+				capsule_path TEXT NOT NULL,
+				created_at TEXT NOT NULL
+			);`,
+			`CREATE TABLE IF NOT EXISTS runs (
+				run_id TEXT PRIMARY KEY,
+				capsule_id TEXT NOT NULL,
+				capsule_path TEXT NOT NULL,
+				status TEXT NOT NULL,
+				lifecycle TEXT NOT NULL,
+				runtime_target TEXT NOT NULL,
+				container_id TEXT,
+				exit_code INTEGER,
+				started_at TEXT NOT NULL,
+				ended_at TEXT,
+				last_error TEXT,
+				FOREIGN KEY(capsule_id) REFERENCES capsules(capsule_id)
+			);`,
+		)
+	}},
+	{version: 2, name: "add run name and restart tracking", apply: func(tx *sql.Tx) error {
+		for _, col := range [][2]string{
+			{"name", "TEXT"},
+			{"restart_count", "INTEGER NOT NULL DEFAULT 0"},
+			{"last_restart_at", "TEXT"},
+		} {
+			if err := ensureColumn(tx, "runs", col[0], col[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{version: 3, name: "record run image and injected env keys", apply: func(tx *sql.Tx) error {
+		if err := ensureColumn(tx, "runs", "image_ref", "TEXT"); err != nil {
+			return err
+		}
+		return ensureColumn(tx, "runs", "injected_env_keys", "TEXT")
+	}},
+	{version: 4, name: "add run labels", apply: func(tx *sql.Tx) error {
+		return execAll(tx,
+			`CREATE TABLE IF NOT EXISTS run_labels (
+				run_id TEXT NOT NULL,
+				key TEXT NOT NULL,
+				value TEXT NOT NULL,
+				PRIMARY KEY(run_id, key),
+				FOREIGN KEY(run_id) REFERENCES runs(run_id)
+			);`,
+			`CREATE INDEX IF NOT EXISTS run_labels_key_value ON run_labels(key, value);`,
+		)
+	}},
+	{version: 5, name: "record run llm model", apply: func(tx *sql.Tx) error {
+		return ensureColumn(tx, "runs", "llm_model", "TEXT")
+	}},
+}
+
 func (s *Store) initSchema() error {
-	stmts := []string{
-		`CREATE TABLE IF NOT EXISTS capsules (
-			capsule_id TEXT PRIMARY KEY,
-			capsule_path TEXT NOT NULL,
-			created_at TEXT NOT NULL
-		);`,
-		`CREATE TABLE IF NOT EXISTS runs (
-			run_id TEXT PRIMARY KEY,
-			capsule_id TEXT NOT NULL,
-			capsule_path TEXT NOT NULL,
-			status TEXT NOT NULL,
-			lifecycle TEXT NOT NULL,
-			runtime_target TEXT NOT NULL,
-			container_id TEXT,
-			exit_code INTEGER,
-			started_at TEXT NOT NULL,
-			ended_at TEXT,
-			last_error TEXT,
-			FOREIGN KEY(capsule_id) REFERENCES capsules(capsule_id)
-		);`,
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	);`); err != nil {
+		return err
+	}
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.applyMigration(m); err != nil {
+			return fmt.Errorf("migrate state db to version %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) applyMigration(m migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	// Another process may have applied m while this one waited for the lock.
+	var current sql.NullInt64
+	if err := tx.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	if int(current.Int64) >= m.version {
+		return nil
+	}
+	if err := m.apply(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now().UTC().Format(time.RFC3339Nano),
+	); err != nil {
+		return err
 	}
+	return tx.Commit()
+}
+
+// SchemaVersion returns the highest applied migration version, or 0 for a
+// database that has not been migrated yet.
+func (s *Store) SchemaVersion() (int, error) {
+	var v sql.NullInt64
+	if err := s.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, err
+	}
+	return int(v.Int64), nil
+}
+
+func execAll(tx *sql.Tx, stmts ...string) error {
 	for _, stmt := range stmts {
-		if _, err := s.db.Exec(stmt); err != nil {
+		if _, err := tx.Exec(stmt); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// ensureColumn adds a column to a table created by an older schema.
+func ensureColumn(tx *sql.Tx, table, column, decl string) error {
+	rows, err := tx.Query(`PRAGMA table_info(` + table + `)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl)
+	return err
+}
+
 func (s *Store) UpsertCapsule(capsuleID, capsulePath string) error {
 	_, err := s.db.Exec(
 		`INSERT INTO capsules (capsule_id, capsule_path, created_at)
@@ -96,14 +258,28 @@ func (s *Store) UpsertCapsule(capsuleID, capsulePath string) error {
 	return err
 }
 
+// InsertRun records a new run together with its labels.
 func (s *Store) InsertRun(r RunRecord) error {
-	_, err := s.db.Exec(
-		`INSERT INTO runs (run_id, capsule_id, capsule_path, status, lifecycle, runtime_target, container_id, exit_code, started_at, ended_at, last_error)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		r.RunID, r.CapsuleID, r.CapsulePath, r.Status, r.Lifecycle, r.RuntimeTarget, nullableString(r.ContainerID), nullableInt(r.ExitCode),
-		r.StartedAt, nullableString(r.EndedAt), nullableString(r.LastError),
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	_, err = tx.Exec(
+		`INSERT INTO runs (run_id, name, capsule_id, capsule_path, status, lifecycle, runtime_target, container_id, exit_code, started_at, ended_at, last_error, image_ref, injected_env_keys, llm_model)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.RunID, nullableString(r.Name), r.CapsuleID, r.CapsulePath, r.Status, r.Lifecycle, r.RuntimeTarget, nullableString(r.ContainerID), nullableInt(r.ExitCode),
+		r.StartedAt, nullableString(r.EndedAt), nullableString(r.LastError), nullableString(r.ImageRef), nullableString(strings.Join(r.InjectedEnvKeys, ",")), nullableString(r.LLMModel),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	for k, v := range r.Labels {
+		if _, err := tx.Exec(`INSERT INTO run_labels (run_id, key, value) VALUES (?, ?, ?)`, r.RunID, k, v); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (s *Store) UpdateRunStatus(runID, status, containerID, lastError string) error {
@@ -122,16 +298,51 @@ func (s *Store) UpdateRunCompletion(runID, status, containerID string, exitCode
 	return err
 }
 
-func (s *Store) GetRun(runID string) (RunRecord, error) {
-	row := s.db.QueryRow(`SELECT run_id, capsule_id, capsule_path, status, lifecycle, runtime_target, COALESCE(container_id,''), exit_code, started_at, COALESCE(ended_at,''), COALESCE(last_error,'') FROM runs WHERE run_id = ?`, runID)
+// RecordRunRestart bumps the restart counter of a supervised run that was
+// relaunched at the given time.
+func (s *Store) RecordRunRestart(runID string, restartCount int, at string) error {
+	_, err := s.db.Exec(
+		`UPDATE runs SET restart_count = ?, last_restart_at = ? WHERE run_id = ?`,
+		restartCount, nullableString(at), runID,
+	)
+	return err
+}
+
+func (s *Store) DeleteRun(runID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.Exec(`DELETE FROM run_labels WHERE run_id = ?`, runID); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`DELETE FROM runs WHERE run_id = ?`, runID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("run not found: %s", runID)
+	}
+	return tx.Commit()
+}
+
+const runColumns = `run_id, COALESCE(name,''), capsule_id, capsule_path, status, lifecycle, runtime_target, COALESCE(container_id,''), exit_code, started_at, COALESCE(ended_at,''), COALESCE(last_error,''), restart_count, COALESCE(last_restart_at,''), COALESCE(image_ref,''), COALESCE(injected_env_keys,''), COALESCE(llm_model,'')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRun(row rowScanner) (RunRecord, error) {
 	var r RunRecord
 	var exit sql.NullInt64
-	if err := row.Scan(&r.RunID, &r.CapsuleID, &r.CapsulePath, &r.Status, &r.Lifecycle, &r.RuntimeTarget, &r.ContainerID, &exit, &r.StartedAt, &r.EndedAt, &r.LastError); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return RunRecord{}, fmt.Errorf("run not found: %s", runID)
-		}
+	var envKeys string
+	if err := row.Scan(&r.RunID, &r.Name, &r.CapsuleID, &r.CapsulePath, &r.Status, &r.Lifecycle, &r.RuntimeTarget, &r.ContainerID, &exit, &r.StartedAt, &r.EndedAt, &r.LastError, &r.RestartCount, &r.LastRestartAt, &r.ImageRef, &envKeys, &r.LLMModel); err != nil {
 		return RunRecord{}, err
 	}
+	if envKeys != "" {
+		r.InjectedEnvKeys = strings.Split(envKeys, ",")
+	}
 	if exit.Valid {
 		v := int(exit.Int64)
 		r.ExitCode = &v
@@ -139,31 +350,152 @@ func (s *Store) GetRun(runID string) (RunRecord, error) {
 	return r, nil
 }
 
+func (s *Store) GetRun(runID string) (RunRecord, error) {
+	r, err := scanRun(s.db.QueryRow(`SELECT `+runColumns+` FROM runs WHERE run_id = ?`, runID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RunRecord{}, fmt.Errorf("run not found: %s", runID)
+		}
+		return RunRecord{}, err
+	}
+	return s.withLabels(r)
+}
+
+// GetRunByRef resolves ref as a run ID first and then as a run name; when
+// several runs share a name the most recently started one wins.
+func (s *Store) GetRunByRef(ref string) (RunRecord, error) {
+	r, err := scanRun(s.db.QueryRow(`SELECT `+runColumns+` FROM runs WHERE run_id = ? OR name = ? ORDER BY run_id = ? DESC, started_at DESC LIMIT 1`, ref, ref, ref))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RunRecord{}, fmt.Errorf("run not found: %s", ref)
+		}
+		return RunRecord{}, err
+	}
+	return s.withLabels(r)
+}
+
+func (s *Store) withLabels(r RunRecord) (RunRecord, error) {
+	runs := []RunRecord{r}
+	if err := s.attachLabels(runs); err != nil {
+		return RunRecord{}, err
+	}
+	return runs[0], nil
+}
+
+// attachLabels fills in Labels for each run in place.
+func (s *Store) attachLabels(runs []RunRecord) error {
+	if len(runs) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(runs))
+	args := make([]any, 0, len(runs))
+	for i, r := range runs {
+		index[r.RunID] = i
+		args = append(args, r.RunID)
+	}
+	rows, err := s.db.Query(`SELECT run_id, key, value FROM run_labels WHERE run_id IN (?`+strings.Repeat(`, ?`, len(args)-1)+`)`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var runID, key, value string
+		if err := rows.Scan(&runID, &key, &value); err != nil {
+			return err
+		}
+		i := index[runID]
+		if runs[i].Labels == nil {
+			runs[i].Labels = map[string]string{}
+		}
+		runs[i].Labels[key] = value
+	}
+	return rows.Err()
+}
+
+// ListRunsByName returns every run carrying name, newest first.
+func (s *Store) ListRunsByName(name string) ([]RunRecord, error) {
+	rows, err := s.db.Query(`SELECT `+runColumns+` FROM runs WHERE name = ? ORDER BY started_at DESC`, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.collectRuns(rows)
+}
+
 func (s *Store) ListRuns(limit int) ([]RunRecord, error) {
+	return s.ListRunsFiltered(RunFilter{}, limit)
+}
+
+// RunFilter restricts ListRunsFiltered. Values within one field are ORed;
+// non-empty fields are ANDed together. Every entry in Labels must match.
+type RunFilter struct {
+	Status        []string
+	RuntimeTarget []string
+	Lifecycle     []string
+	CapsuleID     []string
+	Labels        []Label
+}
+
+type Label struct {
+	Key   string
+	Value string
+}
+
+func (s *Store) ListRunsFiltered(f RunFilter, limit int) ([]RunRecord, error) {
 	if limit <= 0 {
 		limit = 100
 	}
-	rows, err := s.db.Query(`SELECT run_id, capsule_id, capsule_path, status, lifecycle, runtime_target, COALESCE(container_id,''), exit_code, started_at, COALESCE(ended_at,''), COALESCE(last_error,'')
-		FROM runs ORDER BY started_at DESC LIMIT ?`, limit)
+	var where []string
+	var args []any
+	for _, c := range []struct {
+		column string
+		values []string
+	}{
+		{"status", f.Status},
+		{"runtime_target", f.RuntimeTarget},
+		{"lifecycle", f.Lifecycle},
+		{"capsule_id", f.CapsuleID},
+	} {
+		if len(c.values) == 0 {
+			continue
+		}
+		where = append(where, c.column+` IN (?`+strings.Repeat(`, ?`, len(c.values)-1)+`)`)
+		for _, v := range c.values {
+			args = append(args, v)
+		}
+	}
+	for _, l := range f.Labels {
+		where = append(where, `run_id IN (SELECT run_id FROM run_labels WHERE key = ? AND value = ?)`)
+		args = append(args, l.Key, l.Value)
+	}
+	query := `SELECT ` + runColumns + ` FROM runs`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY started_at DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return s.collectRuns(rows)
+}
 
+func (s *Store) collectRuns(rows *sql.Rows) ([]RunRecord, error) {
 	out := make([]RunRecord, 0)
 	for rows.Next() {
-		var r RunRecord
-		var exit sql.NullInt64
-		if err := rows.Scan(&r.RunID, &r.CapsuleID, &r.CapsulePath, &r.Status, &r.Lifecycle, &r.RuntimeTarget, &r.ContainerID, &exit, &r.StartedAt, &r.EndedAt, &r.LastError); err != nil {
+		r, err := scanRun(rows)
+		if err != nil {
+			rows.Close()
 			return nil, err
 		}
-		if exit.Valid {
-			v := int(exit.Int64)
-			r.ExitCode = &v
-		}
 		out = append(out, r)
 	}
-	if err := rows.Err(); err != nil {
+	err := rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachLabels(out); err != nil {
 		return nil, err
 	}
 	return out, nil
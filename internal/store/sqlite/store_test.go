@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOpenMigratesLegacyDatabase(t *testing.T) {
+	stateDir := t.TempDir()
+	legacy, err := sql.Open("sqlite", filepath.Join(stateDir, "state.db"))
+	if err != nil {
+		t.Fatalf("open legacy db: %v", err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE capsules (capsule_id TEXT PRIMARY KEY, capsule_path TEXT NOT NULL, created_at TEXT NOT NULL);`,
+		`CREATE TABLE runs (run_id TEXT PRIMARY KEY, capsule_id TEXT NOT NULL, capsule_path TEXT NOT NULL, status TEXT NOT NULL, lifecycle TEXT NOT NULL, runtime_target TEXT NOT NULL, container_id TEXT, exit_code INTEGER, started_at TEXT NOT NULL, ended_at TEXT, last_error TEXT);`,
+		`INSERT INTO runs (run_id, capsule_id, capsule_path, status, lifecycle, runtime_target, started_at) VALUES ('run_old', 'cap', '/cap', 'succeeded', 'ephemeral', 'docker', '2026-01-01T00:00:00Z');`,
+	} {
+		if _, err := legacy.Exec(stmt); err != nil {
+			t.Fatalf("seed legacy db: %v", err)
+		}
+	}
+	_ = legacy.Close()
+
+	s, err := Open(stateDir)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	v, err := s.SchemaVersion()
+	if err != nil || v != migrations[len(migrations)-1].version {
+		t.Fatalf("SchemaVersion() = %d, %v", v, err)
+	}
+	old, err := s.GetRun("run_old")
+	if err != nil || old.Status != "succeeded" {
+		t.Fatalf("legacy run lost: %+v (%v)", old, err)
+	}
+	if err := s.InsertRun(RunRecord{RunID: "run_new", Name: "named", CapsuleID: "cap", CapsulePath: "/cap", Status: "running", Lifecycle: "daemon", RuntimeTarget: "docker", StartedAt: "2026-01-02T00:00:00Z", ImageRef: "alpine@sha256:abc", InjectedEnvKeys: []string{"A", "B"}, LLMModel: "gpt-4.1-mini"}); err != nil {
+		t.Fatalf("InsertRun() on migrated db: %v", err)
+	}
+	_ = s.Close()
+
+	// Reopening must not re-run applied migrations.
+	s, err = Open(stateDir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&n); err != nil || n != len(migrations) {
+		t.Fatalf("expected %d migration rows, got %d (%v)", len(migrations), n, err)
+	}
+	r, err := s.GetRunByRef("named")
+	if err != nil || r.RunID != "run_new" {
+		t.Fatalf("GetRunByRef() = %+v, %v", r, err)
+	}
+	if r.ImageRef != "alpine@sha256:abc" || len(r.InjectedEnvKeys) != 2 || r.InjectedEnvKeys[1] != "B" {
+		t.Fatalf("image/env keys not round-tripped: %+v", r)
+	}
+	if r.LLMModel != "gpt-4.1-mini" {
+		t.Fatalf("llm model not round-tripped: %+v", r)
+	}
+}
+
+func TestConcurrentStoresDoNotLock(t *testing.T) {
+	stateDir := t.TempDir()
+	const workers, runsPerWorker = 8, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*runsPerWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// A Store per goroutine stands in for separate metaclaw processes,
+			// including racing each other through the first migration.
+			s, err := Open(stateDir)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer s.Close()
+			for i := 0; i < runsPerWorker; i++ {
+				id := fmt.Sprintf("run_%d_%d", w, i)
+				code := 0
+				for _, op := range []func() error{
+					func() error { return s.UpsertCapsule("cap", "/cap") },
+					func() error {
+						return s.InsertRun(RunRecord{RunID: id, CapsuleID: "cap", CapsulePath: "/cap", Status: "running", Lifecycle: "ephemeral", RuntimeTarget: "docker", StartedAt: "2026-01-01T00:00:00Z", Labels: map[string]string{"worker": fmt.Sprint(w)}})
+					},
+					func() error { return s.UpdateRunStatus(id, "running", "c_"+id, "") },
+					func() error { return s.UpdateRunCompletion(id, "succeeded", "c_"+id, &code, "") },
+				} {
+					if err := op(); err != nil {
+						errs <- err
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("concurrent store access failed: %v", err)
+	}
+	s, err := Open(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	runs, err := s.ListRunsFiltered(RunFilter{Status: []string{"succeeded"}}, workers*runsPerWorker)
+	if err != nil || len(runs) != workers*runsPerWorker {
+		t.Fatalf("expected %d succeeded runs, got %d (%v)", workers*runsPerWorker, len(runs), err)
+	}
+}
@@ -0,0 +1,146 @@
+package textdiff
+
+import (
+	"sort"
+	"strings"
+)
+
+// hunk replaces base lines [start, end) with lines.
+type hunk struct {
+	start, end int
+	lines      []string
+	ours       bool
+}
+
+// Merge3 performs a line-based three-way merge of ours and theirs against
+// their common ancestor base. Regions changed identically on both sides, or
+// on only one side, merge cleanly; overlapping divergent edits are written
+// between conflict markers labelled with oursName and theirsName. It reports
+// whether any conflict markers were emitted.
+func Merge3(base, ours, theirs, oursName, theirsName string) (string, bool) {
+	if ours == theirs {
+		return ours, false
+	}
+	if base == ours {
+		return theirs, false
+	}
+	if base == theirs {
+		return ours, false
+	}
+	b := splitLines(base)
+	hunks := append(changeHunks(b, splitLines(ours), true), changeHunks(b, splitLines(theirs), false)...)
+	sort.SliceStable(hunks, func(i, j int) bool {
+		if hunks[i].start != hunks[j].start {
+			return hunks[i].start < hunks[j].start
+		}
+		return hunks[i].end < hunks[j].end
+	})
+
+	var out []string
+	conflict := false
+	cursor := 0
+	for i := 0; i < len(hunks); {
+		lo, hi := hunks[i].start, hunks[i].end
+		j := i + 1
+		// Touching hunks are grouped too, so edits on adjacent lines are
+		// treated as one region rather than silently interleaved.
+		for j < len(hunks) && hunks[j].start <= hi {
+			if hunks[j].end > hi {
+				hi = hunks[j].end
+			}
+			j++
+		}
+		region := hunks[i:j]
+		i = j
+
+		out = append(out, b[cursor:lo]...)
+		cursor = hi
+		var oursHunks, theirsHunks []hunk
+		for _, h := range region {
+			if h.ours {
+				oursHunks = append(oursHunks, h)
+			} else {
+				theirsHunks = append(theirsHunks, h)
+			}
+		}
+		switch {
+		case len(theirsHunks) == 0:
+			out = append(out, applyHunks(b, lo, hi, oursHunks)...)
+		case len(oursHunks) == 0:
+			out = append(out, applyHunks(b, lo, hi, theirsHunks)...)
+		default:
+			o := applyHunks(b, lo, hi, oursHunks)
+			t := applyHunks(b, lo, hi, theirsHunks)
+			if equalLines(o, t) {
+				out = append(out, o...)
+				continue
+			}
+			conflict = true
+			out = append(out, "<<<<<<< "+oursName)
+			out = append(out, o...)
+			out = append(out, "=======")
+			out = append(out, t...)
+			out = append(out, ">>>>>>> "+theirsName)
+		}
+	}
+	out = append(out, b[cursor:]...)
+	if len(out) == 0 {
+		return "", conflict
+	}
+	return strings.Join(out, "\n") + "\n", conflict
+}
+
+// changeHunks groups the edit script from base to other into hunks expressed
+// in base line offsets.
+func changeHunks(base, other []string, ours bool) []hunk {
+	var hunks []hunk
+	pos := 0
+	var cur *hunk
+	for _, o := range diffLines(base, other) {
+		if o.kind == opEqual {
+			if cur != nil {
+				hunks = append(hunks, *cur)
+				cur = nil
+			}
+			pos++
+			continue
+		}
+		if cur == nil {
+			cur = &hunk{start: pos, end: pos, ours: ours}
+		}
+		if o.kind == opDelete {
+			pos++
+			cur.end = pos
+		} else {
+			cur.lines = append(cur.lines, o.line)
+		}
+	}
+	if cur != nil {
+		hunks = append(hunks, *cur)
+	}
+	return hunks
+}
+
+// applyHunks renders base[lo:hi] with one side's hunks applied.
+func applyHunks(base []string, lo, hi int, hunks []hunk) []string {
+	out := []string{}
+	pos := lo
+	for _, h := range hunks {
+		out = append(out, base[pos:h.start]...)
+		out = append(out, h.lines...)
+		pos = h.end
+	}
+	return append(out, base[pos:hi]...)
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+// Package textdiff renders line-based unified diffs and three-way merges.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns a unified diff of a and b with the given number of context
+// lines, or "" if the inputs are identical.
+func Unified(aName, bName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	if context < 0 {
+		context = 0
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+
+	// aPos/bPos[i] are the 0-based line offsets in a and b before ops[i].
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, o := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if o.kind != opInsert {
+			aPos[i+1]++
+		}
+		if o.kind != opDelete {
+			bPos[i+1]++
+		}
+	}
+
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1
+		for {
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual {
+				next++
+			}
+			if next >= len(ops) || next-end > 2*context {
+				break
+			}
+			end = next + 1
+		}
+		stop := end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		aCount := aPos[stop] - aPos[start]
+		bCount := bPos[stop] - bPos[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aPos[start], aCount), hunkRange(bPos[start], bCount))
+		for _, o := range ops[start:stop] {
+			sb.WriteByte(byte(o.kind))
+			sb.WriteString(o.line)
+			sb.WriteByte('\n')
+		}
+		i = stop
+	}
+	return sb.String()
+}
+
+func hunkRange(offset, count int) string {
+	start := offset + 1
+	if count == 0 {
+		start = offset
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines computes a shortest edit script with the linear-space variant of
+// Myers' O(ND) algorithm: find the middle snake of the edit graph and recurse
+// on either side of it, so memory stays O(N+M) however far apart a and b are.
+func diffLines(a, b []string) []op {
+	size := len(a) + len(b) + 2
+	vf := make([]int, 2*size)
+	vb := make([]int, 2*size)
+	return diffRange(make([]op, 0, len(a)+len(b)), a, b, vf, vb)
+}
+
+func diffRange(ops []op, a, b []string, vf, vb []int) []op {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		ops = append(ops, op{kind: opEqual, line: a[0]})
+		a, b = a[1:], b[1:]
+	}
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	tail := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			ops = append(ops, op{kind: opInsert, line: line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			ops = append(ops, op{kind: opDelete, line: line})
+		}
+	default:
+		x, y, u, v := middleSnake(a, b, vf, vb)
+		ops = diffRange(ops, a[:x], b[:y], vf, vb)
+		for _, line := range a[x:u] {
+			ops = append(ops, op{kind: opEqual, line: line})
+		}
+		ops = diffRange(ops, a[u:], b[v:], vf, vb)
+	}
+	for _, line := range tail {
+		ops = append(ops, op{kind: opEqual, line: line})
+	}
+	return ops
+}
+
+// middleSnake runs the forward and reverse searches until their furthest
+// reaching paths overlap and returns the snake where they meet, from (x, y)
+// to (u, v). vf and vb are scratch diagonals indexed around len/2; vb holds
+// reverse progress measured from the end of a.
+func middleSnake(a, b []string, vf, vb []int) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta&1 != 0
+	off := len(vf) / 2
+	vf[off+1], vb[off+1] = 0, 0
+	for d := 0; d <= (n+m+1)/2; d++ {
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && vf[off+k-1] < vf[off+k+1]) {
+				px = vf[off+k+1]
+			} else {
+				px = vf[off+k-1] + 1
+			}
+			py := px - k
+			ex, ey := px, py
+			for ex < n && ey < m && a[ex] == b[ey] {
+				ex++
+				ey++
+			}
+			vf[off+k] = ex
+			if kr := delta - k; odd && kr >= -(d-1) && kr <= d-1 && ex+vb[off+kr] >= n {
+				return px, py, ex, ey
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && vb[off+k-1] < vb[off+k+1]) {
+				px = vb[off+k+1]
+			} else {
+				px = vb[off+k-1] + 1
+			}
+			py := px - k
+			ex, ey := px, py
+			for ex < n && ey < m && a[n-1-ex] == b[m-1-ey] {
+				ex++
+				ey++
+			}
+			vb[off+k] = ex
+			if kf := delta - k; !odd && kf >= -d && kf <= d && vf[off+kf]+ex >= n {
+				return n - ex, m - ey, n - px, m - py
+			}
+		}
+	}
+	// The searches always meet by d = ceil((n+m)/2).
+	panic("textdiff: middle snake not found")
+}
@@ -0,0 +1,153 @@
+package textdiff
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestUnifiedIdentical(t *testing.T) {
+	if got := Unified("a", "b", "x\ny\n", "x\ny\n", 3); got != "" {
+		t.Fatalf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedSingleHunk(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\n"
+	b := "one\n2\nthree\nfour\nfive\n"
+	want := "--- a\n+++ b\n" +
+		"@@ -1,4 +1,5 @@\n" +
+		" one\n" +
+		"-two\n" +
+		"+2\n" +
+		" three\n" +
+		" four\n" +
+		"+five\n"
+	if got := Unified("a", "b", a, b, 3); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedSplitsDistantHunks(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	b := "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n"
+	want := "--- a\n+++ b\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		"-1\n" +
+		"+x\n" +
+		" 2\n" +
+		"@@ -9,2 +9,2 @@\n" +
+		" 9\n" +
+		"-10\n" +
+		"+y\n"
+	if got := Unified("a", "b", a, b, 1); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedFromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("a", "b", "", "x\ny\n", 3); got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+	cases := []struct {
+		name         string
+		ours, theirs string
+		want         string
+		conflict     bool
+	}{
+		{name: "disjoint edits", ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n", want: "A\nb\nc\nd\nE\n"},
+		{name: "same edit both sides", ours: "a\nB\nc\nd\ne\n", theirs: "a\nB\nc\nd\nE\n", want: "a\nB\nc\nd\nE\n"},
+		{name: "only theirs", ours: base, theirs: "a\nc\nd\ne\nf\n", want: "a\nc\nd\ne\nf\n"},
+		{
+			name:     "divergent edit",
+			ours:     "a\nb\nmine\nd\ne\n",
+			theirs:   "a\nb\nyours\nd\ne\n",
+			want:     "a\nb\n<<<<<<< local\nmine\n=======\nyours\n>>>>>>> template\nd\ne\n",
+			conflict: true,
+		},
+	}
+	for _, tc := range cases {
+		got, conflict := Merge3(base, tc.ours, tc.theirs, "local", "template")
+		if got != tc.want || conflict != tc.conflict {
+			t.Fatalf("%s: got (%q, %v), want (%q, %v)", tc.name, got, conflict, tc.want, tc.conflict)
+		}
+	}
+}
+
+func TestDiffLinesIsShortestEditScript(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		out := make([]string, rng.Intn(12))
+		for i := range out {
+			out[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return out
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := gen(), gen()
+		ops := diffLines(a, b)
+		var gotA, gotB []string
+		edits := 0
+		for _, o := range ops {
+			if o.kind != opInsert {
+				gotA = append(gotA, o.line)
+			}
+			if o.kind != opDelete {
+				gotB = append(gotB, o.line)
+			}
+			if o.kind != opEqual {
+				edits++
+			}
+		}
+		if fmt.Sprint(gotA) != fmt.Sprint(a) || fmt.Sprint(gotB) != fmt.Sprint(b) {
+			t.Fatalf("ops do not rebuild inputs %v -> %v: %v", a, b, ops)
+		}
+		if want := len(a) + len(b) - 2*lcsLen(a, b); edits != want {
+			t.Fatalf("diff %v -> %v uses %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesMemoryIsLinear(t *testing.T) {
+	const n = 4000
+	a := make([]string, n)
+	b := make([]string, n)
+	for i := range a {
+		a[i] = fmt.Sprintf("a%d", i)
+		b[i] = fmt.Sprintf("b%d", i)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ops := diffLines(a, b)
+	runtime.ReadMemStats(&after)
+	if len(ops) != 2*n {
+		t.Fatalf("got %d ops, want %d", len(ops), 2*n)
+	}
+	// Keeping a diagonal snapshot per edit distance would need ~n*n words.
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 8<<20 {
+		t.Fatalf("diffLines allocated %d bytes for %d lines", alloc, 2*n)
+	}
+}
+
+func lcsLen(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}